@@ -0,0 +1,51 @@
+package access_test
+
+import (
+	"encoding/pem"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/access"
+	"github.com/s7techlab/cckit/convert"
+	"github.com/s7techlab/cckit/extensions/owner"
+	idtestdata "github.com/s7techlab/cckit/identity/testdata"
+	"github.com/s7techlab/cckit/router"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+func TestAccess(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Access suite")
+}
+
+var _ = Describe(`Grant`, func() {
+
+	It("Allow to create grant from PEM identical to stored by owner extension", func() {
+		cc := testcc.NewMockStub(`owner`, router.NewChaincode(router.New(`owner`).Init(owner.InvokeSetFromCreator)))
+
+		certPEM := idtestdata.Certificates[0].MustCertBytes()
+		expectcc.ResponseOk(cc.From(idtestdata.DefaultMSP, certPEM).Init())
+
+		grant, err := access.GrantFromPEM(idtestdata.DefaultMSP, certPEM)
+		Expect(err).NotTo(HaveOccurred())
+
+		grantBytes, err := convert.ToBytes(grant)
+		Expect(err).NotTo(HaveOccurred())
+		ownerKey, err := shim.CreateCompositeKey(owner.StateKey()[0], owner.StateKey()[1:])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(grantBytes).To(Equal(cc.State[ownerKey]))
+	})
+
+	It("Disallow to create grant from invalid PEM or certificate", func() {
+		_, err := access.GrantFromPEM(idtestdata.DefaultMSP, []byte(`not a pem`))
+		Expect(err).To(MatchError(ContainSubstring(access.ErrPEMInvalid.Error())))
+
+		_, err = access.GrantFromPEM(idtestdata.DefaultMSP,
+			pem.EncodeToMemory(&pem.Block{Type: `CERTIFICATE`, Bytes: []byte(`not a cert`)}))
+		Expect(err).To(MatchError(ContainSubstring(access.ErrCertificateInvalid.Error())))
+	})
+})
@@ -0,0 +1,81 @@
+// Package access contains helpers for building access grants, stored in chaincode state
+package access
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/pkg/errors"
+	"github.com/s7techlab/cckit/identity"
+)
+
+var (
+	// ErrPEMInvalid occurs when PEM block with certificate not found
+	ErrPEMInvalid = errors.New(`invalid certificate PEM`)
+
+	// ErrCertificateInvalid occurs when PEM block contains invalid x509 certificate
+	ErrCertificateInvalid = errors.New(`invalid x509 certificate`)
+
+	// ErrGrantExpired occurs when grant certificate is expired at tx timestamp
+	ErrGrantExpired = errors.New(`grant expired`)
+)
+
+// Grant access grant of identity, stored in chaincode state (i.e. by owner extension)
+type Grant = identity.Entry
+
+// GrantFromIdentity creates grant from identity, i.e. resolved from tx creator
+func GrantFromIdentity(id identity.Identity) (*Grant, error) {
+	return identity.CreateEntry(id)
+}
+
+// GrantFromPEM creates grant from MSP ID and certificate PEM without stub,
+// serialized grant is identical to grant stored for same tx creator
+func GrantFromPEM(mspID string, certPEM []byte) (*Grant, error) {
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return nil, fmt.Errorf(`%w: %s`, ErrPEMInvalid, identity.ErrPemEncodedExpected)
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf(`%w: %s`, ErrCertificateInvalid, err)
+	}
+
+	return GrantFromIdentity(&identity.CertIdentity{MspID: mspID, Cert: cert})
+}
+
+// GrantExpiresAt returns expiration time of grant certificate
+func GrantExpiresAt(grant *Grant) (time.Time, error) {
+	cert, err := identity.Certificate(grant.PEM)
+	if err != nil {
+		return time.Time{}, fmt.Errorf(`%w: %s`, ErrCertificateInvalid, err)
+	}
+	return cert.NotAfter, nil
+}
+
+// CheckGrantNotExpired checks grant certificate is not expired at tx timestamp.
+// Tx timestamp is used instead of wall clock, so check result is the same on all endorsers
+func CheckGrantNotExpired(stub shim.ChaincodeStubInterface, grant *Grant) error {
+	expiresAt, err := GrantExpiresAt(grant)
+	if err != nil {
+		return err
+	}
+
+	txTimestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return errors.Wrap(err, `get tx timestamp`)
+	}
+	txTime, err := ptypes.Timestamp(txTimestamp)
+	if err != nil {
+		return errors.Wrap(err, `convert tx timestamp`)
+	}
+
+	if txTime.After(expiresAt) {
+		return fmt.Errorf(`%w: %s at %s`, ErrGrantExpired, grant.GetID(), expiresAt.Format(time.RFC3339))
+	}
+	return nil
+}
@@ -1,8 +1,11 @@
 package convert_test
 
 import (
+	"errors"
 	"testing"
 
+	"github.com/golang/protobuf/ptypes/timestamp"
+
 	"github.com/s7techlab/cckit/convert"
 
 	. "github.com/onsi/ginkgo"
@@ -51,4 +54,66 @@ var _ = Describe(`Convert`, func() {
 		Expect(bNil).To(Equal([]byte{}))
 	})
 
+	Describe(`ArgsToBytes`, func() {
+
+		type (
+			entity struct {
+				Name string
+			}
+			nameString string
+		)
+
+		var (
+			nilEntity  *entity
+			nilMessage *timestamp.Timestamp
+			entry      = &entity{Name: `name`}
+		)
+
+		supported := []struct {
+			arg      interface{}
+			expected []byte
+		}{
+			{nil, []byte(convert.NilArg)},
+			{`string`, []byte(`string`)},
+			{nameString(`typed`), []byte(`typed`)},
+			{[]byte(`bytes`), []byte(`bytes`)},
+			{true, []byte(`true`)},
+			{1, []byte(`1`)},
+			{int32(-2), []byte(`-2`)},
+			{uint(3), []byte(`3`)},
+			{entity{Name: `name`}, []byte(`{"Name":"name"}`)},
+			{entry, []byte(`{"Name":"name"}`)},
+			{[]string{`a`, `b`}, []byte(`["a","b"]`)},
+			{map[string]int{`a`: 1}, []byte(`{"a":1}`)},
+		}
+
+		unsupported := []struct {
+			arg      interface{}
+			typeName string
+		}{
+			{nilEntity, `*convert_test.entity`},
+			{nilMessage, `Timestamp`},
+			{func() {}, `func()`},
+			{make(chan int), `chan int`},
+			{complex(1, 2), `complex128`},
+			{map[string]interface{}{`fn`: func() {}}, `map[string]interface {}`},
+		}
+
+		It(`Allow to convert supported args`, func() {
+			for _, s := range supported {
+				args, err := convert.ArgsToBytes(`fn`, s.arg)
+				Expect(err).NotTo(HaveOccurred(), `%T`, s.arg)
+				Expect(args).To(Equal([][]byte{[]byte(`fn`), s.expected}), `%T`, s.arg)
+			}
+		})
+
+		It(`Disallow to convert nil pointers and unsupported types`, func() {
+			for _, u := range unsupported {
+				_, err := convert.ArgsToBytes(`fn`, u.arg)
+				Expect(errors.Is(err, convert.ErrUnsupportedArg)).To(BeTrue(), u.typeName)
+				Expect(err.Error()).To(ContainSubstring(`arg[1]`))
+				Expect(err.Error()).To(ContainSubstring(u.typeName))
+			}
+		})
+	})
 })
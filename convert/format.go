@@ -0,0 +1,65 @@
+package convert
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+)
+
+// Payload formats of proto messages
+const (
+	FormatJSON  = `json`
+	FormatProto = `proto`
+)
+
+// ErrFormatUnsupported occurs when payload format is not json or proto
+var ErrFormatUnsupported = errors.New(`unsupported payload format`)
+
+// CheckFormat returns error if format is not empty, json or proto
+func CheckFormat(format string) error {
+	switch format {
+	case ``, FormatJSON, FormatProto:
+		return nil
+	}
+	return fmt.Errorf(`%w: %s`, ErrFormatUnsupported, format)
+}
+
+// ToBytesFormat converts value to bytes in format: proto messages are marshalled to JSON with jsonpb
+// or to proto binary. Other values and empty format are converted with ToBytes
+func ToBytesFormat(value interface{}, format string) ([]byte, error) {
+	if err := CheckFormat(format); err != nil {
+		return nil, err
+	}
+
+	msg, ok := value.(proto.Message)
+	if !ok || format != FormatJSON {
+		return ToBytes(value)
+	}
+
+	var buf bytes.Buffer
+	if err := (&jsonpb.Marshaler{}).Marshal(&buf, msg); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// FromBytesFormat converts bytes in format to target, see ToBytesFormat
+func FromBytesFormat(bb []byte, target interface{}, format string) (interface{}, error) {
+	if err := CheckFormat(format); err != nil {
+		return nil, err
+	}
+
+	msg, ok := target.(proto.Message)
+	if !ok || format != FormatJSON {
+		return FromBytes(bb, target)
+	}
+
+	result := proto.Clone(msg)
+	if err := jsonpb.Unmarshal(bytes.NewReader(bb), result); err != nil {
+		return nil, errors.Wrap(err, ErrUnableToConvertValueToStruct.Error())
+	}
+	return result, nil
+}
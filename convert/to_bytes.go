@@ -10,14 +10,30 @@ import (
 	"github.com/pkg/errors"
 )
 
-// ArgsToBytes converts func arguments to bytes
+// NilArg value of nil invoke argument, nil interface{} args are converted to empty bytes
+const NilArg = ``
+
+// ErrUnsupportedArg occurs when invoke argument can't be converted to bytes
+var ErrUnsupportedArg = errors.New(`unsupported invoke arg`)
+
+// ArgsToBytes converts func arguments to bytes. Nil args are converted to NilArg,
+// nil pointers and unsupported types are rejected with error, naming arg position and type
 func ArgsToBytes(iargs ...interface{}) (aa [][]byte, err error) {
 	args := make([][]byte, len(iargs))
 
 	for i, arg := range iargs {
+		if arg == nil {
+			args[i] = []byte(NilArg)
+			continue
+		}
+
+		if v := reflect.ValueOf(arg); v.Kind() == reflect.Ptr && v.IsNil() {
+			return nil, fmt.Errorf(`%w: arg[%d] is nil pointer %T`, ErrUnsupportedArg, i, arg)
+		}
+
 		val, err := ToBytes(arg)
 		if err != nil {
-			return nil, errors.Wrap(err, fmt.Sprintf(`unable to convert invoke arg[%d]`, i))
+			return nil, fmt.Errorf(`%w: arg[%d] of type %T: %s`, ErrUnsupportedArg, i, arg, err)
 		}
 		args[i] = val
 	}
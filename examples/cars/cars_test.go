@@ -2,6 +2,7 @@ package cars_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/s7techlab/cckit/examples/cars"
 	"github.com/s7techlab/cckit/extensions/owner"
@@ -88,3 +89,26 @@ var _ = Describe(`Cars`, func() {
 		})
 	})
 })
+
+var _ = Describe(`Cars equivalence`, func() {
+
+	stub := func(opts ...testcc.MockStubOpt) testcc.StubFactory {
+		return func() *testcc.MockStub {
+			cc := testcc.NewMockStub(`cars`, cars.New(), append([]testcc.MockStubOpt{
+				testcc.WithClock(testcc.NewMockClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))),
+				testcc.WithSequentialTxIDs(`tx-`),
+			}, opts...)...)
+			expectcc.ResponseOk(cc.From(Authority).Init())
+			return cc
+		}
+	}
+
+	It("Allow to run scenario against stubs with and without defensive copies", func() {
+		testcc.AssertEquivalence(GinkgoT(), []*testcc.SeedInvoke{
+			{Fn: `carRegister`, Args: []interface{}{cars.Payloads[0]}, From: Authority},
+			{Fn: `carRegister`, Args: []interface{}{cars.Payloads[1]}, From: Someone},
+			{Fn: `carRegister`, Args: []interface{}{cars.Payloads[1]}, From: Authority},
+			{Fn: `carList`},
+		}, stub(), stub(testcc.WithDefensiveCopies(false)))
+	})
+})
@@ -0,0 +1,247 @@
+// Package escrow contains two-phase offer/accept chaincode: offer terms are passed via transient map
+// and stored in org implicit private data collections, only terms hashes are compared across orgs
+package escrow
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"github.com/s7techlab/cckit/identity"
+	"github.com/s7techlab/cckit/router"
+	p "github.com/s7techlab/cckit/router/param"
+)
+
+const (
+	OfferEntity = `OFFER`
+
+	// TransientTermsKey transient map key with offer terms
+	TransientTermsKey = `terms`
+
+	// ImplicitCollectionPrefix prefix of org implicit private data collection
+	ImplicitCollectionPrefix = `_implicit_org_`
+
+	OfferedEvent  = `OFFERED`
+	AcceptedEvent = `ACCEPTED`
+	SettledEvent  = `SETTLED`
+)
+
+// Offer statuses
+const (
+	StatusOffered  = `offered`
+	StatusAccepted = `accepted`
+	StatusSettled  = `settled`
+)
+
+var (
+	ErrTransientTermsMissing = errors.New(`offer terms not found in transient map`)
+	ErrTermsMismatch         = errors.New(`offer terms hash mismatch`)
+	ErrInvokerNotSeller      = errors.New(`invoker is not offer seller`)
+	ErrInvokerNotBuyer       = errors.New(`invoker is not offer buyer`)
+	ErrOfferStatusInvalid    = errors.New(`invalid offer status`)
+)
+
+type (
+	// Terms private offer terms, passed via transient map
+	Terms struct {
+		Item  string
+		Price int
+	}
+
+	// Offer public offer state, terms are known only to buyer and seller orgs
+	Offer struct {
+		Id        string
+		BuyerMSP  string
+		SellerMSP string
+		Status    string
+		TermsHash []byte // set on accept
+	}
+)
+
+// Key for offer entry in chaincode state
+func (o Offer) Key() ([]string, error) {
+	return []string{OfferEntity, o.Id}, nil
+}
+
+// ImplicitCollection returns name of org implicit private data collection
+func ImplicitCollection(mspID string) string {
+	return ImplicitCollectionPrefix + mspID
+}
+
+func New() *router.Chaincode {
+	r := router.New(`escrow`)
+
+	r.Init(router.EmptyContextHandler).
+
+		// Get public offer state
+		Query(`offerGet`, queryOffer, p.String(`id`)).
+
+		// Get offer terms from org implicit collection, allowed only for this org
+		Query(`offerTerms`, queryOfferTerms, p.String(`id`), p.String(`mspId`)).
+
+		// Buyer submits offer terms via transient map to seller org
+		Invoke(`offer`, invokeOffer, p.String(`id`), p.String(`sellerMspId`)).
+
+		// Seller accepts offer with same terms via transient map
+		Invoke(`accept`, invokeAccept, p.String(`id`)).
+
+		// Buyer settles accepted offer
+		Invoke(`settle`, invokeSettle, p.String(`id`))
+
+	return router.NewChaincode(r)
+}
+
+func queryOffer(c router.Context) (interface{}, error) {
+	return c.State().Get(Offer{Id: c.ParamString(`id`)}, &Offer{})
+}
+
+func queryOfferTerms(c router.Context) (interface{}, error) {
+	termsBytes, err := c.Stub().GetPrivateData(ImplicitCollection(c.ParamString(`mspId`)), c.ParamString(`id`))
+	if err != nil {
+		return nil, err
+	}
+
+	terms := &Terms{}
+	if err = json.Unmarshal(termsBytes, terms); err != nil {
+		return nil, errors.Wrap(err, `unmarshal terms`)
+	}
+	return terms, nil
+}
+
+func invokeOffer(c router.Context) (interface{}, error) {
+	invoker, err := identity.FromStub(c.Stub())
+	if err != nil {
+		return nil, err
+	}
+
+	terms, err := transientTerms(c)
+	if err != nil {
+		return nil, err
+	}
+
+	offer := &Offer{
+		Id:        c.ParamString(`id`),
+		BuyerMSP:  invoker.GetMSPID(),
+		SellerMSP: c.ParamString(`sellerMspId`),
+		Status:    StatusOffered,
+	}
+
+	if err = c.State().Insert(offer); err != nil {
+		return nil, err
+	}
+
+	if err = c.Stub().PutPrivateData(ImplicitCollection(offer.BuyerMSP), offer.Id, terms); err != nil {
+		return nil, err
+	}
+
+	return offer, c.Event().Set(OfferedEvent, offer)
+}
+
+func invokeAccept(c router.Context) (interface{}, error) {
+	offer, err := offerWithStatus(c, StatusOffered)
+	if err != nil {
+		return nil, err
+	}
+
+	invoker, err := identity.FromStub(c.Stub())
+	if err != nil {
+		return nil, err
+	}
+
+	if invoker.GetMSPID() != offer.SellerMSP {
+		return nil, ErrInvokerNotSeller
+	}
+
+	terms, err := transientTerms(c)
+	if err != nil {
+		return nil, err
+	}
+
+	// seller can't read buyer collection, only terms hash
+	buyerTermsHash, err := c.Stub().GetPrivateDataHash(ImplicitCollection(offer.BuyerMSP), offer.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	termsHash := sha256.Sum256(terms)
+	if !bytes.Equal(buyerTermsHash, termsHash[:]) {
+		return nil, ErrTermsMismatch
+	}
+
+	if err = c.Stub().PutPrivateData(ImplicitCollection(offer.SellerMSP), offer.Id, terms); err != nil {
+		return nil, err
+	}
+
+	offer.Status = StatusAccepted
+	offer.TermsHash = termsHash[:]
+	if err = c.State().Put(offer); err != nil {
+		return nil, err
+	}
+
+	return offer, c.Event().Set(AcceptedEvent, offer)
+}
+
+func invokeSettle(c router.Context) (interface{}, error) {
+	offer, err := offerWithStatus(c, StatusAccepted)
+	if err != nil {
+		return nil, err
+	}
+
+	invoker, err := identity.FromStub(c.Stub())
+	if err != nil {
+		return nil, err
+	}
+
+	if invoker.GetMSPID() != offer.BuyerMSP {
+		return nil, ErrInvokerNotBuyer
+	}
+
+	// both orgs must hold accepted terms
+	for _, mspID := range []string{offer.BuyerMSP, offer.SellerMSP} {
+		termsHash, err := c.Stub().GetPrivateDataHash(ImplicitCollection(mspID), offer.Id)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(termsHash, offer.TermsHash) {
+			return nil, ErrTermsMismatch
+		}
+	}
+
+	offer.Status = StatusSettled
+	if err = c.State().Put(offer); err != nil {
+		return nil, err
+	}
+
+	return offer, c.Event().Set(SettledEvent, offer)
+}
+
+func offerWithStatus(c router.Context, status string) (*Offer, error) {
+	offer, err := c.State().Get(Offer{Id: c.ParamString(`id`)}, &Offer{})
+	if err != nil {
+		return nil, err
+	}
+
+	o := offer.(Offer)
+	if o.Status != status {
+		return nil, errors.Wrapf(ErrOfferStatusInvalid, `expected %s, actual %s`, status, o.Status)
+	}
+	return &o, nil
+}
+
+func transientTerms(c router.Context) ([]byte, error) {
+	transient, err := c.Stub().GetTransient()
+	if err != nil {
+		return nil, err
+	}
+
+	terms, ok := transient[TransientTermsKey]
+	if !ok {
+		return nil, ErrTransientTermsMissing
+	}
+
+	if err = json.Unmarshal(terms, &Terms{}); err != nil {
+		return nil, errors.Wrap(err, `unmarshal terms`)
+	}
+	return terms, nil
+}
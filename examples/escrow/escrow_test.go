@@ -0,0 +1,117 @@
+package escrow_test
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/examples/escrow"
+	idtestdata "github.com/s7techlab/cckit/identity/testdata"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+func TestEscrow(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Escrow Suite")
+}
+
+const (
+	BuyerMSP  = `BuyerMSP`
+	SellerMSP = `SellerMSP`
+	OfferId   = `offer1`
+)
+
+var (
+	Buyer  = idtestdata.Certificates[0].MustIdentity(BuyerMSP)
+	Seller = idtestdata.Certificates[1].MustIdentity(SellerMSP)
+
+	Terms = &escrow.Terms{Item: `car`, Price: 1000}
+)
+
+func termsTransient(terms *escrow.Terms) map[string][]byte {
+	termsBytes, err := json.Marshal(terms)
+	Expect(err).NotTo(HaveOccurred())
+	return map[string][]byte{escrow.TransientTermsKey: termsBytes}
+}
+
+func offer(cc *testcc.MockStub) escrow.Offer {
+	return expectcc.PayloadIs(cc.Query(`offerGet`, OfferId), &escrow.Offer{}).(escrow.Offer)
+}
+
+var _ = Describe(`Escrow`, func() {
+
+	var cc *testcc.MockStub
+
+	BeforeEach(func() {
+		cc = testcc.NewMockStub(`escrow`, escrow.New())
+		expectcc.ResponseOk(cc.Init())
+	})
+
+	It("Allow buyer and seller to offer, accept and settle with same terms", func() {
+		expectcc.ResponseOk(cc.From(Buyer).WithTransient(termsTransient(Terms)).Invoke(`offer`, OfferId, SellerMSP))
+		Expect(offer(cc).Status).To(Equal(escrow.StatusOffered))
+
+		// terms are not in public state
+		for _, value := range cc.State {
+			Expect(string(value)).NotTo(ContainSubstring(Terms.Item))
+		}
+
+		expectcc.ResponseOk(cc.From(Seller).WithTransient(termsTransient(Terms)).Invoke(`accept`, OfferId))
+		expectcc.ResponseOk(cc.From(Buyer).Invoke(`settle`, OfferId))
+
+		settled := offer(cc)
+		Expect(settled.Status).To(Equal(escrow.StatusSettled))
+
+		termsBytes, _ := json.Marshal(Terms)
+		termsHash := sha256.Sum256(termsBytes)
+		Expect(settled.TermsHash).To(Equal(termsHash[:]))
+
+		for _, mspID := range []string{BuyerMSP, SellerMSP} {
+			hash, err := cc.GetPrivateDataHash(escrow.ImplicitCollection(mspID), OfferId)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hash).To(Equal(termsHash[:]), mspID)
+		}
+
+		Expect(expectcc.PayloadIs(cc.From(Seller).Query(`offerTerms`, OfferId, SellerMSP), &escrow.Terms{})).
+			To(Equal(*Terms))
+	})
+
+	It("Disallow seller to read buyer collection", func() {
+		expectcc.ResponseOk(cc.From(Buyer).WithTransient(termsTransient(Terms)).Invoke(`offer`, OfferId, SellerMSP))
+
+		Expect(expectcc.PayloadIs(cc.From(Buyer).Query(`offerTerms`, OfferId, BuyerMSP), &escrow.Terms{})).
+			To(Equal(*Terms))
+		expectcc.ResponseError(cc.From(Seller).Query(`offerTerms`, OfferId, BuyerMSP),
+			testcc.ErrCollectionReadDenied)
+	})
+
+	It("Disallow to offer and accept without terms in transient map", func() {
+		expectcc.ResponseError(cc.From(Buyer).Invoke(`offer`, OfferId, SellerMSP),
+			escrow.ErrTransientTermsMissing)
+
+		expectcc.ResponseOk(cc.From(Buyer).WithTransient(termsTransient(Terms)).Invoke(`offer`, OfferId, SellerMSP))
+		expectcc.ResponseError(cc.From(Seller).Invoke(`accept`, OfferId),
+			escrow.ErrTransientTermsMissing)
+	})
+
+	It("Disallow seller to accept other terms", func() {
+		expectcc.ResponseOk(cc.From(Buyer).WithTransient(termsTransient(Terms)).Invoke(`offer`, OfferId, SellerMSP))
+
+		expectcc.ResponseError(cc.From(Seller).WithTransient(termsTransient(&escrow.Terms{Item: `car`, Price: 1})).
+			Invoke(`accept`, OfferId), escrow.ErrTermsMismatch)
+		Expect(offer(cc).Status).To(Equal(escrow.StatusOffered))
+	})
+
+	It("Disallow buyer to accept and seller to settle", func() {
+		expectcc.ResponseOk(cc.From(Buyer).WithTransient(termsTransient(Terms)).Invoke(`offer`, OfferId, SellerMSP))
+		expectcc.ResponseError(cc.From(Buyer).WithTransient(termsTransient(Terms)).Invoke(`accept`, OfferId),
+			escrow.ErrInvokerNotSeller)
+
+		expectcc.ResponseOk(cc.From(Seller).WithTransient(termsTransient(Terms)).Invoke(`accept`, OfferId))
+		expectcc.ResponseError(cc.From(Seller).Invoke(`settle`, OfferId), escrow.ErrInvokerNotBuyer)
+	})
+})
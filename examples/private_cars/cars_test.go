@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/s7techlab/cckit/extensions/owner"
+	"github.com/s7techlab/cckit/identity"
 	"github.com/s7techlab/cckit/identity/testdata"
 	"github.com/s7techlab/cckit/state"
 	testcc "github.com/s7techlab/cckit/testing"
@@ -88,3 +89,60 @@ var _ = Describe(`Cars`, func() {
 		})
 	})
 })
+
+var _ = Describe(`Cars environment`, func() {
+
+	var (
+		network *testcc.Network
+		cc      *testcc.MockStub
+		proxy   *testcc.MockStub
+	)
+
+	BeforeEach(func() {
+		var err error
+		// identities, collections, seeded state and chaincode links are described in one file
+		network, err = testcc.LoadEnvironment(`testdata/environment.json`,
+			testcc.WithEnvironmentChaincode(`cars`, New()),
+			testcc.WithEnvironmentChaincode(`cars_proxy`, NewProxy(`my_channel`, `cars`)))
+		Expect(err).NotTo(HaveOccurred())
+
+		cc, err = network.Chaincode(`my_channel`, `cars`)
+		Expect(err).NotTo(HaveOccurred())
+		proxy, err = network.Chaincode(`my_channel`, `cars_proxy`)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	identity := func(name string) *identity.CertIdentity {
+		id, err := network.Identity(name)
+		Expect(err).NotTo(HaveOccurred())
+		return id
+	}
+
+	It("Allow to read car from seeded private state", func() {
+		car := expectcc.PayloadIs(cc.From(identity(`someone`)).Invoke(`carGet`, `A777MP77`),
+			&Car{}).(Car)
+		Expect(car.Title).To(Equal(`VAZ-2101`))
+	})
+
+	It("Allow to read car, registered by environment invoke, from owner", func() {
+		cars := expectcc.PayloadIs(cc.From(identity(`authority`)).Invoke(`carList`),
+			&[]Car{}).([]Car)
+		Expect(cars).To(HaveLen(2))
+	})
+
+	It("Disallow non authority to add information about car", func() {
+		expectcc.ResponseError(
+			cc.From(identity(`someone`)).Invoke(`carRegister`, Payloads[2]),
+			owner.ErrOwnerOnly)
+	})
+
+	It("Disallow non collection member to read car", func() {
+		expectcc.ResponseForbidden(cc.From(identity(`auditor`)).Invoke(`carGet`, `A777MP77`))
+	})
+
+	It("Allow to read car through linked proxy chaincode", func() {
+		car := expectcc.PayloadIs(proxy.From(identity(`someone`)).Invoke(`carGet`, `B555EE55`),
+			&Car{}).(Car)
+		Expect(car.Title).To(Equal(`GAZ-24`))
+	})
+})
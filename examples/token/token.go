@@ -0,0 +1,202 @@
+// Package token contains simple mintable token chaincode with paginated holders listing
+package token
+
+import (
+	"github.com/pkg/errors"
+	"github.com/s7techlab/cckit/convert"
+	"github.com/s7techlab/cckit/extensions/owner"
+	"github.com/s7techlab/cckit/identity"
+	"github.com/s7techlab/cckit/router"
+	p "github.com/s7techlab/cckit/router/param"
+)
+
+const (
+	BalancePrefix  = `BALANCE`
+	TotalSupplyKey = `totalSupply`
+
+	TransferEvent = `transfer`
+	MintEvent     = `mint`
+)
+
+var (
+	ErrAmountMustBePositive             = errors.New(`amount must be positive`)
+	ErrNotEnoughFunds                   = errors.New(`not enough funds`)
+	ErrForbiddenToTransferToSameAccount = errors.New(`forbidden to transfer to same account`)
+)
+
+type (
+	// Transfer event payload, emitted on transfer and mint (with empty From)
+	Transfer struct {
+		From   identity.Id
+		To     identity.Id
+		Amount int
+	}
+
+	// Holder balance of account
+	Holder struct {
+		Account identity.Id
+		Balance int
+	}
+
+	// HoldersPage page of holders, ordered by msp id and cert id. Bookmark is empty for last page
+	HoldersPage struct {
+		Holders  []Holder
+		Bookmark string
+	}
+)
+
+func New() *router.Chaincode {
+	r := router.New(`token`).Use(p.StrictKnown)
+
+	r.Init(owner.InvokeSetFromCreator).
+
+		// Get the total token supply
+		Query(`totalSupply`, queryTotalSupply).
+
+		// Get account balance
+		Query(`balanceOf`, queryBalanceOf, p.String(`mspId`), p.String(`certId`)).
+
+		// List holders page by page, empty bookmark for first page
+		Query(`holders`, queryHolders, p.Int(`pageSize`), p.String(`bookmark`)).
+
+		// Issue new tokens to account, allowed only for chaincode owner
+		Invoke(`mint`, invokeMint, p.String(`toMspId`), p.String(`toCertId`), p.Int(`amount`), owner.Only).
+
+		// Send amount of tokens from invoker account
+		Invoke(`transfer`, invokeTransfer, p.String(`toMspId`), p.String(`toCertId`), p.Int(`amount`))
+
+	return router.NewChaincode(r)
+}
+
+func queryTotalSupply(c router.Context) (interface{}, error) {
+	return c.State().GetInt(TotalSupplyKey, 0)
+}
+
+func queryBalanceOf(c router.Context) (interface{}, error) {
+	return getBalance(c, c.ParamString(`mspId`), c.ParamString(`certId`))
+}
+
+func queryHolders(c router.Context) (interface{}, error) {
+	iter, metadata, err := c.Stub().GetStateByPartialCompositeKeyWithPagination(
+		BalancePrefix, nil, int32(c.ParamInt(`pageSize`)), c.ParamString(`bookmark`))
+	if err != nil {
+		return nil, errors.Wrap(err, `get holders page`)
+	}
+	defer func() { _ = iter.Close() }()
+
+	page := HoldersPage{Holders: []Holder{}, Bookmark: metadata.Bookmark}
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		_, attrs, err := c.Stub().SplitCompositeKey(kv.Key)
+		if err != nil {
+			return nil, err
+		}
+
+		balance, err := convert.FromBytes(kv.Value, convert.TypeInt)
+		if err != nil {
+			return nil, err
+		}
+
+		page.Holders = append(page.Holders, Holder{
+			Account: identity.Id{MSP: attrs[0], Cert: attrs[1]},
+			Balance: balance.(int),
+		})
+	}
+
+	return page, nil
+}
+
+func invokeMint(c router.Context) (interface{}, error) {
+	to := identity.Id{MSP: c.ParamString(`toMspId`), Cert: c.ParamString(`toCertId`)}
+	amount := c.ParamInt(`amount`)
+	if amount <= 0 {
+		return nil, ErrAmountMustBePositive
+	}
+
+	totalSupply, err := c.State().GetInt(TotalSupplyKey, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	balance, err := getBalance(c, to.MSP, to.Cert)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = c.State().Put(TotalSupplyKey, totalSupply+amount); err != nil {
+		return nil, err
+	}
+
+	if err = setBalance(c, to.MSP, to.Cert, balance+amount); err != nil {
+		return nil, err
+	}
+
+	if err = c.Event().Set(MintEvent, &Transfer{To: to, Amount: amount}); err != nil {
+		return nil, err
+	}
+
+	return balance + amount, nil
+}
+
+func invokeTransfer(c router.Context) (interface{}, error) {
+	to := identity.Id{MSP: c.ParamString(`toMspId`), Cert: c.ParamString(`toCertId`)}
+	amount := c.ParamInt(`amount`)
+	if amount <= 0 {
+		return nil, ErrAmountMustBePositive
+	}
+
+	invoker, err := identity.FromStub(c.Stub())
+	if err != nil {
+		return nil, err
+	}
+	from := identity.Id{MSP: invoker.GetMSPID(), Cert: invoker.GetID()}
+
+	if from == to {
+		return nil, ErrForbiddenToTransferToSameAccount
+	}
+
+	fromBalance, err := getBalance(c, from.MSP, from.Cert)
+	if err != nil {
+		return nil, err
+	}
+
+	if fromBalance < amount {
+		return nil, ErrNotEnoughFunds
+	}
+
+	toBalance, err := getBalance(c, to.MSP, to.Cert)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = setBalance(c, from.MSP, from.Cert, fromBalance-amount); err != nil {
+		return nil, err
+	}
+
+	if err = setBalance(c, to.MSP, to.Cert, toBalance+amount); err != nil {
+		return nil, err
+	}
+
+	if err = c.Event().Set(TransferEvent, &Transfer{From: from, To: to, Amount: amount}); err != nil {
+		return nil, err
+	}
+
+	// return current invoker balance
+	return fromBalance - amount, nil
+}
+
+func balanceKey(mspId, certId string) []string {
+	return []string{BalancePrefix, mspId, certId}
+}
+
+func getBalance(c router.Context, mspId, certId string) (int, error) {
+	return c.State().GetInt(balanceKey(mspId, certId), 0)
+}
+
+func setBalance(c router.Context, mspId, certId string, balance int) error {
+	return c.State().Put(balanceKey(mspId, certId), balance)
+}
@@ -0,0 +1,181 @@
+package token_test
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/s7techlab/cckit/examples/token"
+	"github.com/s7techlab/cckit/extensions/owner"
+	"github.com/s7techlab/cckit/identity"
+	idtestdata "github.com/s7techlab/cckit/identity/testdata"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+func TestToken(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Token Suite")
+}
+
+var (
+	ids = idtestdata.MustIdentities(idtestdata.Certificates, idtestdata.DefaultMSP)
+
+	TokenOwner = ids[0]
+	Holder1    = ids[1]
+	Holder2    = ids[2]
+)
+
+func balanceKey(id identity.Identity) string {
+	key, err := shim.CreateCompositeKey(token.BalancePrefix, []string{id.GetMSPID(), id.GetID()})
+	Expect(err).NotTo(HaveOccurred())
+	return key
+}
+
+func holders(cc *testcc.MockStub, pageSize int, bookmark string) token.HoldersPage {
+	return expectcc.PayloadIs(cc.Query(`holders`, pageSize, bookmark), &token.HoldersPage{}).(token.HoldersPage)
+}
+
+func totalBalance(cc *testcc.MockStub) int {
+	var (
+		total    int
+		bookmark string
+	)
+	for {
+		page := holders(cc, 2, bookmark)
+		for _, h := range page.Holders {
+			total += h.Balance
+		}
+		if bookmark = page.Bookmark; bookmark == `` {
+			return total
+		}
+	}
+}
+
+var _ = Describe(`Token`, func() {
+
+	var cc *testcc.MockStub
+
+	BeforeEach(func() {
+		cc = testcc.NewMockStub(`token`, token.New())
+		expectcc.ResponseOk(cc.From(TokenOwner).Init())
+	})
+
+	Describe(`Mint`, func() {
+
+		It("Allow owner to mint tokens", func() {
+			events, _ := cc.EventSubscription()
+
+			expectcc.PayloadInt(cc.From(TokenOwner).Invoke(`mint`, Holder1.MspID, Holder1.GetID(), 100), 100)
+			expectcc.PayloadInt(cc.Query(`totalSupply`), 100)
+			expectcc.PayloadInt(cc.Query(`balanceOf`, Holder1.MspID, Holder1.GetID()), 100)
+
+			expectcc.EventIs(<-events, token.MintEvent, &token.Transfer{
+				To:     identity.Id{MSP: Holder1.MspID, Cert: Holder1.GetID()},
+				Amount: 100,
+			})
+		})
+
+		It("Disallow non owner to mint tokens", func() {
+			expectcc.ResponseError(cc.From(Holder1).Invoke(`mint`, Holder1.MspID, Holder1.GetID(), 100),
+				owner.ErrOwnerOnly)
+		})
+
+		It("Disallow to mint non positive amount", func() {
+			expectcc.ResponseError(cc.From(TokenOwner).Invoke(`mint`, Holder1.MspID, Holder1.GetID(), 0),
+				token.ErrAmountMustBePositive)
+		})
+	})
+
+	Describe(`Transfer`, func() {
+
+		BeforeEach(func() {
+			expectcc.ResponseOk(cc.From(TokenOwner).Invoke(`mint`, Holder1.MspID, Holder1.GetID(), 100))
+		})
+
+		It("Allow holder to transfer tokens", func() {
+			events, _ := cc.EventSubscription()
+
+			// transfer reads and writes only balances of participants
+			balances := []string{balanceKey(Holder1), balanceKey(Holder2)}
+			cc.DeclareAccess(balances, balances)
+			expectcc.PayloadInt(cc.From(Holder1).Invoke(`transfer`, Holder2.MspID, Holder2.GetID(), 30), 70)
+			Expect(cc.LastAccessError).NotTo(HaveOccurred())
+
+			expectcc.PayloadInt(cc.Query(`balanceOf`, Holder2.MspID, Holder2.GetID()), 30)
+			expectcc.EventIs(<-events, token.TransferEvent, &token.Transfer{
+				From:   identity.Id{MSP: Holder1.MspID, Cert: Holder1.GetID()},
+				To:     identity.Id{MSP: Holder2.MspID, Cert: Holder2.GetID()},
+				Amount: 30,
+			})
+		})
+
+		It("Disallow to transfer more than balance", func() {
+			expectcc.ResponseError(cc.From(Holder1).Invoke(`transfer`, Holder2.MspID, Holder2.GetID(), 101),
+				token.ErrNotEnoughFunds)
+		})
+
+		It("Disallow to transfer to same account", func() {
+			expectcc.ResponseError(cc.From(Holder1).Invoke(`transfer`, Holder1.MspID, Holder1.GetID(), 1),
+				token.ErrForbiddenToTransferToSameAccount)
+		})
+	})
+
+	Describe(`Holders`, func() {
+
+		It("Allow to list holders page by page", func() {
+			for i := 0; i < 7; i++ {
+				expectcc.ResponseOk(cc.From(TokenOwner).Invoke(`mint`, `SomeMSP`, fmt.Sprintf(`cert%d`, i), i+1))
+			}
+
+			var (
+				listed   []token.Holder
+				bookmark string
+				pages    int
+			)
+			for {
+				page := holders(cc, 3, bookmark)
+				Expect(len(page.Holders)).To(BeNumerically(`<=`, 3))
+				listed = append(listed, page.Holders...)
+				pages++
+
+				if bookmark = page.Bookmark; bookmark == `` {
+					break
+				}
+			}
+
+			Expect(pages).To(Equal(3))
+			Expect(listed).To(HaveLen(7))
+			for i, h := range listed {
+				Expect(h).To(Equal(token.Holder{
+					Account: identity.Id{MSP: `SomeMSP`, Cert: fmt.Sprintf(`cert%d`, i)},
+					Balance: i + 1,
+				}))
+			}
+
+			Expect(holders(cc, 0, ``).Holders).To(HaveLen(7))
+		})
+
+		It("Allow to keep total supply with random transfers", func() {
+			rnd := testcc.NewRandTB(GinkgoT())
+			accounts := testcc.Identities{`owner`: TokenOwner, `holder1`: Holder1, `holder2`: Holder2}
+
+			for _, id := range accounts {
+				expectcc.ResponseOk(cc.From(TokenOwner).Invoke(`mint`, id.GetMSPID(), id.GetID(), 50))
+			}
+
+			for i := 0; i < 50; i++ {
+				_, from := rnd.PickIdentity(accounts)
+				_, to := rnd.PickIdentity(accounts)
+				// transfers to same account and over balance are rejected, state is not changed
+				cc.From(from).Invoke(`transfer`, to.GetMSPID(), to.GetID(), rnd.Intn(40)+1)
+			}
+
+			expectcc.PayloadInt(cc.Query(`totalSupply`), 150)
+			Expect(totalBalance(cc)).To(Equal(150), rnd.String())
+		})
+	})
+})
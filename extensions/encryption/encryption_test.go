@@ -228,7 +228,7 @@ var _ = Describe(`Router`, func() {
 		})
 		//
 		It("Allow to create payment providing key in encryptPaymentCC ", func(done Done) {
-			events := encryptPaymentCCWithEncStateContext.EventSubscription()
+			events, _ := encryptPaymentCCWithEncStateContext.EventSubscription()
 
 			responsePayment := expectcc.PayloadIs(
 				// encCCInvoker encrypts args before passing to cc invoke and pass key in transient map
@@ -0,0 +1,120 @@
+package owner
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/pkg/errors"
+
+	"github.com/s7techlab/cckit/identity"
+	"github.com/s7techlab/cckit/router"
+	"github.com/s7techlab/cckit/state"
+)
+
+// ErrRegistryInvokeFailed occurs when registry chaincode responds with error
+var ErrRegistryInvokeFailed = errors.New(`registry chaincode invoke failed`)
+
+type (
+	// Authorizer decides, if invoker is allowed to call owner only methods
+	Authorizer interface {
+		IsAuthorized(stub shim.ChaincodeStubInterface, invoker identity.Identity) (bool, error)
+	}
+
+	// AuthorizerFunc function adapter to Authorizer
+	AuthorizerFunc func(stub shim.ChaincodeStubInterface, invoker identity.Identity) (bool, error)
+
+	// StateAuthorizer default authorizer, invoker must be owner, stored in chaincode state
+	StateAuthorizer struct {
+		// State for reading owner, state over stub is used if not set
+		State state.State
+	}
+
+	// Opts options of owner checks
+	Opts struct {
+		Authorizer Authorizer
+	}
+
+	// Opt option of owner checks
+	Opt func(*Opts)
+)
+
+// WithAuthorizer sets authorizer, used instead of owner, stored in chaincode state
+func WithAuthorizer(authorizer Authorizer) Opt {
+	return func(opts *Opts) {
+		opts.Authorizer = authorizer
+	}
+}
+
+// IsAuthorized calls f(stub, invoker)
+func (f AuthorizerFunc) IsAuthorized(stub shim.ChaincodeStubInterface, invoker identity.Identity) (bool, error) {
+	return f(stub, invoker)
+}
+
+// IsAuthorized checks invoker is owner, stored in chaincode state
+func (a StateAuthorizer) IsAuthorized(stub shim.ChaincodeStubInterface, invoker identity.Identity) (bool, error) {
+	st := a.State
+	if st == nil {
+		st = stubState(stub)
+	}
+	return isOwner(stub, st, invoker)
+}
+
+// AttributeAuthorizer authorizes invoker with attribute name of certificate, equal to value
+func AttributeAuthorizer(name, value string) Authorizer {
+	return AuthorizerFunc(func(stub shim.ChaincodeStubInterface, _ identity.Identity) (bool, error) {
+		clientIdentity, err := cid.New(stub)
+		if err != nil {
+			return false, errors.Wrap(err, `client identity from stub`)
+		}
+
+		attrValue, found, err := clientIdentity.GetAttributeValue(name)
+		if err != nil {
+			return false, err
+		}
+		return found && attrValue == value, nil
+	})
+}
+
+// RegistryAuthorizer authorizes invoker with registry chaincode, invoked with fn, invoker MSP ID
+// and certificate PEM args. Registry must respond with `true` payload to authorize invoker
+func RegistryAuthorizer(chaincode, channel, fn string) Authorizer {
+	return AuthorizerFunc(func(stub shim.ChaincodeStubInterface, invoker identity.Identity) (bool, error) {
+		res := stub.InvokeChaincode(chaincode,
+			[][]byte{[]byte(fn), []byte(invoker.GetMSPIdentifier()), invoker.GetPEM()}, channel)
+		if res.Status >= shim.ERRORTHRESHOLD {
+			return false, fmt.Errorf(`%w: %s: %s`, ErrRegistryInvokeFailed, chaincode, res.Message)
+		}
+		return string(res.Payload) == `true`, nil
+	})
+}
+
+// OnlyWith allows access to authorized invokers, by default - to chaincode owner
+func OnlyWith(opts ...Opt) router.MiddlewareFunc {
+	return func(next router.HandlerFunc, pos ...int) router.HandlerFunc {
+		return func(c router.Context) (interface{}, error) {
+			authorized, err := IsInvoker(c, opts...)
+			if authorized && err == nil {
+				return next(c)
+			}
+			return nil, ErrOwnerOnly
+		}
+	}
+}
+
+// authorize checks tx creator with authorizer from opts, by default - with owner, stored in st
+func authorize(stub shim.ChaincodeStubInterface, st state.State, opts ...Opt) (bool, error) {
+	o := &Opts{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.Authorizer == nil {
+		o.Authorizer = StateAuthorizer{State: st}
+	}
+
+	invoker, err := identity.InvokerFromStub(stub)
+	if err != nil {
+		return false, err
+	}
+	return o.Authorizer.IsAuthorized(stub, invoker)
+}
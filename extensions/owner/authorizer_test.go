@@ -0,0 +1,105 @@
+package owner
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"math/big"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/identity"
+	"github.com/s7techlab/cckit/router"
+	p "github.com/s7techlab/cckit/router/param"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+// certWithAttrs returns identity with Fabric CA attributes certificate extension
+func certWithAttrs(mspID, attrs string) *identity.CertIdentity {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: `attributed`},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtraExtensions: []pkix.Extension{{
+			Id:    asn1.ObjectIdentifier{1, 2, 3, 4, 5, 6, 7, 8, 1},
+			Value: []byte(`{"attrs":` + attrs + `}`),
+		}},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).NotTo(HaveOccurred())
+
+	id, err := identity.New(mspID, pem.EncodeToMemory(&pem.Block{Type: `CERTIFICATE`, Bytes: der}))
+	Expect(err).NotTo(HaveOccurred())
+	return id
+}
+
+func NewProtected(opts ...Opt) *router.Chaincode {
+	return router.NewChaincode(router.
+		New(`protected`).
+		Init(InvokeSetFromCreator).
+		Invoke(`admin`, func(c router.Context) (interface{}, error) {
+			return `admin`, nil
+		}, OnlyWith(opts...)))
+}
+
+// NewRegistry responds if identity with MSP ID and certificate is admin
+func NewRegistry(admin identity.Identity) *router.Chaincode {
+	return router.NewChaincode(router.
+		New(`registry`).
+		Query(`isAdmin`, func(c router.Context) (interface{}, error) {
+			return c.ParamString(`mspId`) == admin.GetMSPIdentifier() &&
+				string(c.ParamBytes(`cert`)) == string(admin.GetPEM()), nil
+		}, p.String(`mspId`), p.Bytes(`cert`)))
+}
+
+var _ = Describe(`Authorizers`, func() {
+
+	It("Allow owner, stored in state, by default", func() {
+		cc := testcc.NewMockStub(`protected`, NewProtected())
+		expectcc.ResponseOk(cc.From(Owner).Init())
+
+		expectcc.PayloadString(cc.From(Owner).Invoke(`admin`), `admin`)
+		expectcc.ResponseError(cc.From(Someone).Invoke(`admin`), ErrOwnerOnly)
+	})
+
+	It("Allow invoker with certificate attribute", func() {
+		cc := testcc.NewMockStub(`protected`, NewProtected(WithAuthorizer(AttributeAuthorizer(`admin`, `true`))))
+		expectcc.ResponseOk(cc.From(Owner).Init())
+
+		expectcc.PayloadString(cc.From(certWithAttrs(`SOME_MSP`, `{"admin":"true"}`)).Invoke(`admin`), `admin`)
+		expectcc.ResponseError(cc.From(certWithAttrs(`SOME_MSP`, `{"admin":"false"}`)).Invoke(`admin`), ErrOwnerOnly)
+		// owner is not admin without attribute
+		expectcc.ResponseError(cc.From(Owner).Invoke(`admin`), ErrOwnerOnly)
+	})
+
+	It("Allow invoker, authorized by registry chaincode", func() {
+		cc := testcc.NewMockStub(`protected`, NewProtected(
+			WithAuthorizer(RegistryAuthorizer(`registry`, ``, `isAdmin`))))
+		Expect(cc.MockPeerChaincode(`registry`, testcc.NewMockStub(`registry`, NewRegistry(Someone)))).To(Succeed())
+		expectcc.ResponseOk(cc.From(Owner).Init())
+
+		expectcc.PayloadString(cc.From(Someone).Invoke(`admin`), `admin`)
+		expectcc.ResponseError(cc.From(Owner).Invoke(`admin`), ErrOwnerOnly)
+	})
+
+	It("Disallow invoker, if registry chaincode is not available", func() {
+		cc := testcc.NewMockStub(`protected`, &PlainOwnable{})
+		expectcc.ResponseOk(cc.From(Owner).Init())
+
+		cc.From(Owner).MockTransactionStart(`tx`)
+		_, err := IsInvokerStub(cc, WithAuthorizer(RegistryAuthorizer(`registry`, ``, `isAdmin`)))
+		cc.MockTransactionEnd(`tx`)
+		Expect(err).To(MatchError(ContainSubstring(ErrRegistryInvokeFailed.Error())))
+	})
+})
@@ -0,0 +1,55 @@
+package owner
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"github.com/s7techlab/cckit/identity"
+)
+
+// JSON field names of serialized owner grant. Grant is read by chaincodes in other languages,
+// so names must not be changed. Grant has wire format of identity.Entry
+const (
+	GrantFieldMSPID   = identity.EntryFieldMSPID
+	GrantFieldSubject = identity.EntryFieldSubject
+	GrantFieldIssuer  = identity.EntryFieldIssuer
+	GrantFieldPEM     = identity.EntryFieldPEM
+)
+
+// grantJSON wire format of owner grant, tags must match GrantField* constants
+type grantJSON struct {
+	MSPId   string `json:"MSPId"`
+	Subject string `json:"Subject"`
+	Issuer  string `json:"Issuer"`
+	PEM     []byte `json:"PEM"`
+}
+
+// MarshalGrant serializes owner grant of identity. Certificate PEM is encoded as base64 string
+func MarshalGrant(id identity.Identity) ([]byte, error) {
+	entry, err := identity.CreateEntry(id)
+	if err != nil {
+		return nil, errors.Wrap(err, `create owner entry`)
+	}
+	return marshalGrantEntry(entry)
+}
+
+// UnmarshalGrant deserializes owner grant, serialized with MarshalGrant by current or previous versions,
+// decoding rules are of identity.Entry
+func UnmarshalGrant(bb []byte) (*identity.Entry, error) {
+	entry := &identity.Entry{}
+	if err := json.Unmarshal(bb, entry); err != nil {
+		return nil, errors.Wrap(err, `unmarshal owner grant`)
+	}
+	return entry, nil
+}
+
+func marshalGrantEntry(entry *identity.Entry) ([]byte, error) {
+	bb, err := json.Marshal(&grantJSON{
+		MSPId:   entry.MSPId,
+		Subject: entry.Subject,
+		Issuer:  entry.Issuer,
+		PEM:     entry.PEM,
+	})
+	return bb, errors.Wrap(err, `marshal owner grant`)
+}
@@ -8,7 +8,7 @@ const QueryMethod = `owner`
 
 // FromState returns raw data ( serialized Grant ) of current chain code owner
 func Query(c router.Context) (interface{}, error) {
-	return c.State().Get(OwnerStateKey)
+	return getRaw(c.Stub(), c.State())
 }
 
 // InvokeSetFromCreator sets tx creator as chaincode owner, if owner not previously setted
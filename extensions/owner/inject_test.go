@@ -0,0 +1,39 @@
+package owner
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/identity"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+var _ = Describe(`Injected invoker`, func() {
+
+	var (
+		admin = identity.Entry{MSPId: `SOME_MSP`, Subject: `CN=admin`, Issuer: `CN=ca`}
+		user  = identity.Entry{MSPId: `SOME_MSP`, Subject: `CN=user`, Issuer: `CN=ca`}
+		cc    *testcc.MockStub
+	)
+
+	BeforeEach(func() {
+		cc = testcc.NewMockStub(`protected`, NewProtected())
+		expectcc.ResponseOk(cc.WithInvoker(admin).Init())
+	})
+
+	It("Allow owner, injected without certificate, to invoke owner only method", func() {
+		Expect(expectcc.PayloadIs(cc.WithInvoker(admin).Invoke(`admin`), ``)).To(Equal(`admin`))
+	})
+
+	It("Disallow not owner, injected without certificate, to invoke owner only method", func() {
+		expectcc.ResponseError(cc.WithInvoker(user).Invoke(`admin`), ErrOwnerOnly)
+	})
+
+	It("Disallow to inject invoker and set tx creator for one invoke", func() {
+		expectcc.ResponseError(cc.WithInvoker(admin).From(Owner).Invoke(`admin`), testcc.ErrInvokerAndCreatorSet)
+
+		// invoker and creator are cleared after rejected invoke
+		expectcc.ResponseOk(cc.WithInvoker(admin).Invoke(`admin`))
+	})
+})
@@ -10,13 +10,7 @@ var (
 	ErrOwnerOnly = errors.New(`owner only`)
 )
 
-// Only allow access from chain code owner
+// Only allow access from chain code owner, see OnlyWith for using other authorizers
 func Only(next router.HandlerFunc, pos ...int) router.HandlerFunc {
-	return func(c router.Context) (interface{}, error) {
-		invokerIsOwner, err := IsInvoker(c)
-		if invokerIsOwner && err == nil {
-			return next(c)
-		}
-		return nil, ErrOwnerOnly
-	}
+	return OnlyWith()(next, pos...)
 }
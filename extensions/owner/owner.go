@@ -2,13 +2,25 @@
 package owner
 
 import (
+	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/pkg/errors"
 	"github.com/s7techlab/cckit/identity"
 	r "github.com/s7techlab/cckit/router"
+	"github.com/s7techlab/cckit/state"
 )
 
-// OwnerStateKey key used to store owner grant struct in chain code state
-const OwnerStateKey = `OWNER`
+const (
+	// StateNamespace namespace of owner extension in reserved extensions state
+	StateNamespace = `OWNER`
+
+	// OwnerStateKey legacy plain key of owner grant struct, read as fallback if owner is not stored under StateKey
+	OwnerStateKey = `OWNER`
+)
+
+// StateKey returns key used to store owner grant struct in chain code state
+func StateKey() state.Key {
+	return state.ExtensionKey(StateNamespace)
+}
 
 var (
 	// ErrOwnerNotProvided occurs when providing owner identity in init arguments
@@ -19,37 +31,21 @@ var (
 )
 
 func IsSetted(c r.Context) (bool, error) {
-	return c.State().Exists(OwnerStateKey)
+	return isSetted(c.Stub(), c.State())
 }
 
 func Get(c r.Context) (*identity.Entry, error) {
-	ownerEntry, err := c.State().Get(OwnerStateKey, &identity.Entry{})
-	if err != nil {
-		return nil, err
-	}
-
-	o := ownerEntry.(identity.Entry)
-	return &o, nil
+	return get(c.Stub(), c.State())
 }
 
 // SetFromCreator sets chain code owner from stub creator
 func SetFromCreator(c r.Context) (*identity.Entry, error) {
-	if ownerSetted, err := IsSetted(c); err != nil {
-		return nil, err
-	} else if ownerSetted {
-		return Get(c)
-	}
-
-	creator, err := identity.FromStub(c.Stub())
-	if err != nil {
-		return nil, err
-	}
+	return setFromCreator(c.Stub(), c.State())
+}
 
-	identityEntry, err := identity.CreateEntry(creator)
-	if err != nil {
-		return nil, err
-	}
-	return identityEntry, c.State().Insert(OwnerStateKey, identityEntry)
+// Transfer sets new chaincode owner, tx creator must be current owner
+func Transfer(c r.Context, newOwner identity.Identity) (*identity.Entry, error) {
+	return transfer(c.Stub(), c.State(), newOwner)
 }
 
 // SetFromArgs set owner fron first args
@@ -87,18 +83,149 @@ func Insert(c r.Context, mspID string, cert []byte) (*identity.Entry, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, `create owner entry`)
 	}
-	return identityEntry, c.State().Insert(OwnerStateKey, identityEntry)
+	return identityEntry, insert(c.Stub(), c.State(), identityEntry)
 }
 
 // IsInvokerOr checks tx creator and compares with owner of another identity
 func IsInvokerOr(c r.Context, allowedTo ...identity.Identity) (bool, error) {
-	if isOwner, err := IsInvoker(c); isOwner || err != nil {
+	return isInvokerOr(c.Stub(), c.State(), allowedTo...)
+}
+
+// IdentityFromState
+func IdentityEntryFromState(c r.Context) (identity.Entry, error) {
+	return identityEntryFromState(c.Stub(), c.State())
+}
+
+// IsInvoker checks  than tx creator is chain code owner or is authorized with authorizer from opts
+func IsInvoker(c r.Context, opts ...Opt) (bool, error) {
+	return authorize(c.Stub(), c.State(), opts...)
+}
+
+// stateKey returns key of stored owner grant: StateKey or legacy OwnerStateKey, if owner is stored only there
+func stateKey(stub shim.ChaincodeStubInterface, st state.State) (interface{}, error) {
+	var key interface{} = StateKey()
+	err := state.WithReservedKeys(stub, func() error {
+		if exists, err := st.Exists(StateKey()); err != nil || exists {
+			return err
+		}
+
+		legacyExists, err := st.Exists(OwnerStateKey)
+		if legacyExists {
+			key = OwnerStateKey
+		}
+		return err
+	})
+	return key, err
+}
+
+// getRaw returns serialized owner grant
+func getRaw(stub shim.ChaincodeStubInterface, st state.State) (interface{}, error) {
+	key, err := stateKey(stub, st)
+	if err != nil {
+		return nil, err
+	}
+
+	var res interface{}
+	err = state.WithReservedKeys(stub, func() error {
+		res, err = st.Get(key)
+		return err
+	})
+	return res, err
+}
+
+func isSetted(stub shim.ChaincodeStubInterface, st state.State) (bool, error) {
+	var exists bool
+	err := state.WithReservedKeys(stub, func() (err error) {
+		for _, key := range []interface{}{StateKey(), OwnerStateKey} {
+			if exists, err = st.Exists(key); err != nil || exists {
+				return err
+			}
+		}
+		return nil
+	})
+	return exists, err
+}
+
+func get(stub shim.ChaincodeStubInterface, st state.State) (*identity.Entry, error) {
+	ownerEntry, err := identityEntryFromState(stub, st)
+	if err != nil {
+		return nil, err
+	}
+	return &ownerEntry, nil
+}
+
+func setFromCreator(stub shim.ChaincodeStubInterface, st state.State) (*identity.Entry, error) {
+	if ownerSetted, err := isSetted(stub, st); err != nil {
+		return nil, err
+	} else if ownerSetted {
+		return get(stub, st)
+	}
+
+	creator, err := identity.InvokerFromStub(stub)
+	if err != nil {
+		return nil, err
+	}
+
+	identityEntry, err := identity.CreateEntry(creator)
+	if err != nil {
+		return nil, err
+	}
+	return identityEntry, insert(stub, st, identityEntry)
+}
+
+func transfer(stub shim.ChaincodeStubInterface, st state.State, newOwner identity.Identity) (*identity.Entry, error) {
+	if newOwner == nil {
+		return nil, ErrOwnerNotProvided
+	}
+
+	if isOwner, err := isInvoker(stub, st); err != nil {
+		return nil, err
+	} else if !isOwner {
+		return nil, ErrOwnerOnly
+	}
+
+	identityEntry, err := identity.CreateEntry(newOwner)
+	if err != nil {
+		return nil, errors.Wrap(err, `create owner entry`)
+	}
+
+	grant, err := marshalGrantEntry(identityEntry)
+	if err != nil {
+		return nil, err
+	}
+
+	// owner stored under legacy key is migrated to StateKey
+	return identityEntry, state.WithReservedKeys(stub, func() error {
+		if err := st.Put(StateKey(), grant); err != nil {
+			return err
+		}
+		if legacyExists, err := st.Exists(OwnerStateKey); err != nil || !legacyExists {
+			return err
+		}
+		return st.Delete(OwnerStateKey)
+	})
+}
+
+// insert puts serialized owner grant to reserved key
+func insert(stub shim.ChaincodeStubInterface, st state.State, identityEntry *identity.Entry) error {
+	grant, err := marshalGrantEntry(identityEntry)
+	if err != nil {
+		return err
+	}
+
+	return state.WithReservedKeys(stub, func() error {
+		return st.Insert(StateKey(), grant)
+	})
+}
+
+func isInvokerOr(stub shim.ChaincodeStubInterface, st state.State, allowedTo ...identity.Identity) (bool, error) {
+	if isOwner, err := isInvoker(stub, st); isOwner || err != nil {
 		return isOwner, err
 	}
 	if len(allowedTo) == 0 {
 		return false, nil
 	}
-	invoker, err := identity.FromStub(c.Stub())
+	invoker, err := identity.InvokerFromStub(stub)
 	if err != nil {
 		return false, err
 	}
@@ -111,26 +238,40 @@ func IsInvokerOr(c r.Context, allowedTo ...identity.Identity) (bool, error) {
 	return false, nil
 }
 
-// IdentityFromState
-func IdentityEntryFromState(c r.Context) (identity.Entry, error) {
-	res, err := c.State().Get(OwnerStateKey, &identity.Entry{})
+func identityEntryFromState(stub shim.ChaincodeStubInterface, st state.State) (identity.Entry, error) {
+	key, err := stateKey(stub, st)
 	if err != nil {
 		return identity.Entry{}, err
 	}
 
-	return res.(identity.Entry), nil
+	var res interface{}
+	if err = state.WithReservedKeys(stub, func() error {
+		res, err = st.Get(key, []byte{})
+		return err
+	}); err != nil {
+		return identity.Entry{}, err
+	}
+
+	entry, err := UnmarshalGrant(res.([]byte))
+	if err != nil {
+		return identity.Entry{}, err
+	}
+	return *entry, nil
 }
 
-// IsInvoker checks  than tx creator is chain code owner
-func IsInvoker(c r.Context) (bool, error) {
-	invoker, err := identity.FromStub(c.Stub())
+func isInvoker(stub shim.ChaincodeStubInterface, st state.State) (bool, error) {
+	invoker, err := identity.InvokerFromStub(stub)
 	if err != nil {
 		return false, err
 	}
-	ownerEntry, err := IdentityEntryFromState(c)
+	return isOwner(stub, st, invoker)
+}
+
+func isOwner(stub shim.ChaincodeStubInterface, st state.State, invoker identity.Identity) (bool, error) {
+	ownerEntry, err := identityEntryFromState(stub, st)
 	if err != nil {
 		return false, err
 	}
 
-	return ownerEntry.MSPId == invoker.MspID && ownerEntry.Subject == invoker.GetSubject(), nil
+	return ownerEntry.MSPId == invoker.GetMSPIdentifier() && ownerEntry.Subject == invoker.GetSubject(), nil
 }
@@ -1,8 +1,15 @@
 package owner
 
 import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"strconv"
 	"testing"
 
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/peer"
+
 	"github.com/s7techlab/cckit/identity"
 	"github.com/s7techlab/cckit/identity/testdata"
 	"github.com/s7techlab/cckit/router"
@@ -13,6 +20,11 @@ import (
 	. "github.com/onsi/gomega"
 )
 
+// GrantGoldenFile serialized grant of Owner, update only on deliberate wire format change with -update-golden
+const GrantGoldenFile = `testdata/grant.golden.json`
+
+var updateGolden = flag.Bool(`update-golden`, false, `update owner grant golden file`)
+
 var (
 	Owner   = testdata.Certificates[0].MustIdentity(`SOME_MSP`)
 	Someone = testdata.Certificates[1].MustIdentity(`SOME_MSP`)
@@ -38,6 +50,41 @@ func NewOwnableFromArgs() *router.Chaincode {
 		Invoke(QueryMethod, Query))
 }
 
+// PlainOwnable chaincode uses owner extension without router
+type PlainOwnable struct{}
+
+func (cc *PlainOwnable) Init(stub shim.ChaincodeStubInterface) peer.Response {
+	ownerEntry, err := SetFromCreatorStub(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success(ownerEntry)
+}
+
+func (cc *PlainOwnable) Invoke(stub shim.ChaincodeStubInterface) peer.Response {
+	fn, args := stub.GetFunctionAndParameters()
+	switch fn {
+	case `isOwner`:
+		isOwner, err := IsInvokerStub(stub)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		return shim.Success([]byte(strconv.FormatBool(isOwner)))
+
+	case `transfer`:
+		newOwner, err := identity.New(args[0], []byte(args[1]))
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		if err = TransferStub(stub, newOwner); err != nil {
+			return shim.Error(err.Error())
+		}
+		return shim.Success(nil)
+	}
+
+	return shim.Error(`unknown method`)
+}
+
 var _ = Describe(`Ownable`, func() {
 
 	//Create chaincode mock
@@ -94,4 +141,115 @@ var _ = Describe(`Ownable`, func() {
 		})
 
 	})
+	Describe("Owner stub functions", func() {
+		cc3 := testcc.NewMockStub(`plainOwnable`, &PlainOwnable{})
+
+		It("Allow to set owner during chaincode init", func() {
+			ownerEntry := expectcc.PayloadIs(cc3.From(Owner).Init(), &identity.Entry{}).(identity.Entry)
+			Expect(ownerEntry.GetSubject()).To(Equal(Owner.GetSubject()))
+		})
+
+		It("Allow to check invoker is owner", func() {
+			Expect(expectcc.PayloadIs(cc3.From(Owner).Invoke(`isOwner`), true)).To(BeTrue())
+			Expect(expectcc.PayloadIs(cc3.From(Someone).Invoke(`isOwner`), true)).To(BeFalse())
+		})
+
+		It("Disallow to transfer ownership by non owner", func() {
+			expectcc.ResponseError(
+				cc3.From(Someone).Invoke(`transfer`, Someone.MspID, Someone.GetPEM()), ErrOwnerOnly)
+		})
+
+		It("Allow to transfer ownership by owner", func() {
+			expectcc.ResponseOk(cc3.From(Owner).Invoke(`transfer`, Someone.MspID, Someone.GetPEM()))
+
+			Expect(expectcc.PayloadIs(cc3.From(Someone).Invoke(`isOwner`), true)).To(BeTrue())
+			Expect(expectcc.PayloadIs(cc3.From(Owner).Invoke(`isOwner`), true)).To(BeFalse())
+		})
+	})
+
+	Describe("Owner stored under legacy key", func() {
+		var cc *testcc.MockStub
+
+		BeforeEach(func() {
+			cc = testcc.NewMockStub(`plainOwnable`, &PlainOwnable{})
+			ownerEntry, err := identity.CreateEntry(Owner)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cc.SeedState(map[string][]byte{OwnerStateKey: testcc.MustJSONMarshal(ownerEntry)})).To(Succeed())
+		})
+
+		It("Allow to read owner from legacy key", func() {
+			Expect(expectcc.PayloadIs(cc.From(Owner).Invoke(`isOwner`), true)).To(BeTrue())
+			Expect(expectcc.PayloadIs(cc.From(Someone).Invoke(`isOwner`), true)).To(BeFalse())
+		})
+
+		It("Owner not changed during chaincode upgrade", func() {
+			ownerEntry := expectcc.PayloadIs(cc.From(Someone).Init(), &identity.Entry{}).(identity.Entry)
+			Expect(ownerEntry.GetSubject()).To(Equal(Owner.GetSubject()))
+			Expect(cc.State).To(HaveKey(OwnerStateKey))
+		})
+
+		It("Allow to migrate owner to state key on transfer", func() {
+			expectcc.ResponseOk(cc.From(Owner).Invoke(`transfer`, Someone.MspID, Someone.GetPEM()))
+
+			key, err := shim.CreateCompositeKey(StateKey()[0], StateKey()[1:])
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cc.State).To(HaveKey(key))
+			Expect(cc.State).NotTo(HaveKey(OwnerStateKey))
+			Expect(expectcc.PayloadIs(cc.From(Someone).Invoke(`isOwner`), true)).To(BeTrue())
+		})
+	})
+})
+
+var _ = Describe(`Owner grant serialization`, func() {
+
+	It("Serialized grant matches golden bytes", func() {
+		grant, err := MarshalGrant(Owner)
+		Expect(err).NotTo(HaveOccurred())
+
+		if *updateGolden {
+			Expect(ioutil.WriteFile(GrantGoldenFile, grant, 0644)).To(Succeed())
+		}
+
+		golden, err := ioutil.ReadFile(GrantGoldenFile)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(grant)).To(Equal(string(golden)))
+	})
+
+	It("Serialized grant contains documented field names only", func() {
+		grant, err := MarshalGrant(Owner)
+		Expect(err).NotTo(HaveOccurred())
+
+		fields := make(map[string]interface{})
+		Expect(json.Unmarshal(grant, &fields)).To(Succeed())
+		Expect(fields).To(HaveLen(4))
+		for _, field := range []string{GrantFieldMSPID, GrantFieldSubject, GrantFieldIssuer, GrantFieldPEM} {
+			Expect(fields).To(HaveKey(field))
+		}
+	})
+
+	It("Allow to unmarshal serialized grant", func() {
+		grant, err := MarshalGrant(Owner)
+		Expect(err).NotTo(HaveOccurred())
+
+		entry, err := UnmarshalGrant(grant)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entry.Is(Owner)).To(BeTrue())
+		Expect(entry.GetPEM()).To(Equal(Owner.GetPEM()))
+	})
+
+	It("Owner set from creator and transferred is stored as serialized grant", func() {
+		cc := testcc.NewMockStub(`plainOwnable`, &PlainOwnable{})
+		expectcc.ResponseOk(cc.From(Owner).Init())
+
+		key, err := shim.CreateCompositeKey(StateKey()[0], StateKey()[1:])
+		Expect(err).NotTo(HaveOccurred())
+		ownerGrant, err := MarshalGrant(Owner)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cc.State[key]).To(Equal(ownerGrant))
+
+		expectcc.ResponseOk(cc.From(Owner).Invoke(`transfer`, Someone.MspID, Someone.GetPEM()))
+		someoneGrant, err := MarshalGrant(Someone)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cc.State[key]).To(Equal(someoneGrant))
+	})
 })
@@ -0,0 +1,41 @@
+package owner
+
+import (
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"go.uber.org/zap"
+
+	"github.com/s7techlab/cckit/identity"
+	"github.com/s7techlab/cckit/state"
+)
+
+// Stub level equivalents of owner functions, for chaincodes not using router
+
+func stubState(stub shim.ChaincodeStubInterface) state.State {
+	return state.NewState(stub, zap.NewNop())
+}
+
+// SetFromCreatorStub sets tx creator as chaincode owner, if owner not previously setted.
+// Returns serialized owner identity entry
+func SetFromCreatorStub(stub shim.ChaincodeStubInterface) ([]byte, error) {
+	ownerEntry, err := setFromCreator(stub, stubState(stub))
+	if err != nil {
+		return nil, err
+	}
+	return marshalGrantEntry(ownerEntry)
+}
+
+// TransferStub sets new chaincode owner, tx creator must be current owner
+func TransferStub(stub shim.ChaincodeStubInterface, newOwner identity.Identity) error {
+	_, err := transfer(stub, stubState(stub), newOwner)
+	return err
+}
+
+// IsInvokerStub checks than tx creator is chaincode owner or is authorized with authorizer from opts
+func IsInvokerStub(stub shim.ChaincodeStubInterface, opts ...Opt) (bool, error) {
+	return authorize(stub, stubState(stub), opts...)
+}
+
+// IsInvokerOrStub checks tx creator and compares with owner of another identity
+func IsInvokerOrStub(stub shim.ChaincodeStubInterface, allowedTo ...identity.Identity) (bool, error) {
+	return isInvokerOr(stub, stubState(stub), allowedTo...)
+}
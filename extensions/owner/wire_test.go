@@ -0,0 +1,67 @@
+package owner
+
+import (
+	"encoding/json"
+	"flag"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/identity/testdata"
+)
+
+const (
+	// GrantWireCorpus dir of serialized grants of all versions, fixtures are appended with -update-wire-corpus
+	GrantWireCorpus = `testdata/wire`
+	// GrantWireVersion version of grant wire format, increment when grant fields are added
+	GrantWireVersion = `v1`
+)
+
+var updateWireCorpus = flag.Bool(`update-wire-corpus`, false, `append current version fixtures to wire corpus`)
+
+var _ = Describe(`Owner grant wire compatibility`, func() {
+
+	var fixtures []*testdata.WireFixture
+
+	BeforeEach(func() {
+		if *updateWireCorpus {
+			grant, err := MarshalGrant(Owner)
+			Expect(err).NotTo(HaveOccurred())
+			entry, err := UnmarshalGrant(grant)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = testdata.AppendWireFixture(GrantWireCorpus, `grant`, &testdata.WireFixture{
+				Version: GrantWireVersion, Expected: testdata.NewWireEntry(entry), Data: grant})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		var err error
+		fixtures, err = testdata.ReadWireCorpus(GrantWireCorpus, `grant`)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("Allow to unmarshal grants, serialized by all versions", func() {
+		versions := make(map[string]bool)
+		for _, fixture := range fixtures {
+			entry, err := UnmarshalGrant(fixture.Data)
+			Expect(err).NotTo(HaveOccurred(), fixture.File)
+			Expect(fixture.Expected.Verify(entry)).To(Succeed(), fixture.File)
+			versions[fixture.Version] = true
+		}
+		Expect(versions).To(HaveKey(GrantWireVersion), `run with -update-wire-corpus to add current version`)
+	})
+
+	It("Allow previous versions to read fields of current grant", func() {
+		grant, err := MarshalGrant(Owner)
+		Expect(err).NotTo(HaveOccurred())
+		current := make(map[string]interface{})
+		Expect(json.Unmarshal(grant, &current)).To(Succeed())
+
+		for _, fixture := range fixtures {
+			previous := make(map[string]interface{})
+			Expect(json.Unmarshal(fixture.Data, &previous)).To(Succeed())
+			for field, value := range previous {
+				Expect(current).To(HaveKeyWithValue(field, value), fixture.File)
+			}
+		}
+	})
+})
@@ -7,6 +7,7 @@ import (
 	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
 	"github.com/s7techlab/cckit/identity"
 	r "github.com/s7techlab/cckit/router"
+	"github.com/s7techlab/cckit/state"
 )
 
 const (
@@ -44,7 +45,9 @@ func Ping(c r.Context) (interface{}, error) {
 	if err := c.Event().Set(PingEvent, pingInfo); err != nil {
 		return nil, err
 	}
-	return pingInfo, c.State().Put(pingInfo, pingInfo)
+	return pingInfo, state.WithReservedKeys(c.Stub(), func() error {
+		return c.State().Put(pingInfo, pingInfo)
+	})
 }
 
 // FromContext create PingInfo struct with tx creator Id and certificate in PEM format
@@ -121,7 +121,9 @@ func (cs *ChaincodeService) Events(in *service.ChaincodeLocator, stream service.
 	if mockStub, err = cs.Peer.Chaincode(in.Channel, in.Chaincode); err != nil {
 		return
 	}
-	events := mockStub.EventSubscription()
+	events, closer := mockStub.EventSubscription()
+	defer func() { _ = closer() }()
+
 	ctx := stream.Context()
 	for {
 		select {
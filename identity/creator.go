@@ -0,0 +1,43 @@
+package identity
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	protomsp "github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/pkg/errors"
+)
+
+// CreatorParser extracts serialized identity from tx creator bytes,
+// allows to use custom MSP envelopes, wrapping serialized identity
+type CreatorParser func(creator []byte) (*protomsp.SerializedIdentity, error)
+
+// ParseSerializedIdentity default creator parser, tx creator is marshaled msp.SerializedIdentity
+func ParseSerializedIdentity(creator []byte) (*protomsp.SerializedIdentity, error) {
+	serialized := &protomsp.SerializedIdentity{}
+	if err := proto.Unmarshal(creator, serialized); err != nil {
+		return nil, errors.Wrap(err, `unmarshal serialized identity`)
+	}
+	return serialized, nil
+}
+
+// FromCreator creates Identity from tx creator bytes, parsed with creator parser
+func FromCreator(creator []byte, parse CreatorParser) (*CertIdentity, error) {
+	if parse == nil {
+		parse = ParseSerializedIdentity
+	}
+
+	serialized, err := parse(creator)
+	if err != nil {
+		return nil, errors.Wrap(err, `parse creator`)
+	}
+	return FromSerialized(*serialized)
+}
+
+// FromStubWithParser creates Identity from tx creator (stub.GetCreator), wrapped in custom envelope
+func FromStubWithParser(stub shim.ChaincodeStubInterface, parse CreatorParser) (*CertIdentity, error) {
+	creator, err := stub.GetCreator()
+	if err != nil {
+		return nil, errors.Wrap(err, `get creator`)
+	}
+	return FromCreator(creator, parse)
+}
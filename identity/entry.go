@@ -3,18 +3,33 @@ package identity
 
 import (
 	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	protomsp "github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/hyperledger/fabric/msp"
+)
+
+// JSON field names of serialized Entry. Entries are stored on ledger, so names must not be changed,
+// new fields must be optional for decoding, see Entry.UnmarshalJSON
+const (
+	EntryFieldMSPID   = `MSPId`
+	EntryFieldSubject = `Subject`
+	EntryFieldIssuer  = `Issuer`
+	EntryFieldPEM     = `PEM`
 )
 
 // Entry structure for storing identity information
 // string representation certificate Subject and Issuer can be used for reach query searching
 type Entry struct {
-	MSPId   string
-	Subject string
-	Issuer  string
-	PEM     []byte
+	MSPId   string            `json:"MSPId"`
+	Subject string            `json:"Subject"`
+	Issuer  string            `json:"Issuer"`
+	PEM     []byte            `json:"PEM"`
 	Cert    *x509.Certificate `json:"-"` // temporary cert
 }
 
@@ -71,11 +86,99 @@ func (e Entry) GetPublicKey() interface{} {
 	return e.Cert.PublicKey
 }
 
+// ========  msp.Identity interface, Entry without certificate can be used as invoker Identity in tests ===
+
+func (e Entry) ExpiresAt() time.Time {
+	if e.Cert != nil {
+		return e.Cert.NotAfter
+	}
+	return time.Time{}
+}
+
+func (e Entry) GetMSPIdentifier() string {
+	return e.MSPId
+}
+
+func (e Entry) GetIdentifier() *msp.IdentityIdentifier {
+	return &msp.IdentityIdentifier{
+		Mspid: e.MSPId,
+		Id:    e.GetID(),
+	}
+}
+
+func (e Entry) Validate() error {
+	return nil
+}
+
+func (e Entry) Verify(msg []byte, sig []byte) error {
+	return nil
+}
+
+func (e Entry) Anonymous() bool {
+	return false
+}
+
+func (e Entry) GetOrganizationalUnits() []*msp.OUIdentifier {
+	return nil
+}
+
+func (e Entry) Serialize() ([]byte, error) {
+	return proto.Marshal(&protomsp.SerializedIdentity{Mspid: e.MSPId, IdBytes: e.PEM})
+}
+
+func (e Entry) SatisfiesPrincipal(principal *protomsp.MSPPrincipal) error {
+	return nil
+}
+
 // Is checks IdentityEntry is equal to an other Identity
 func (e Entry) Is(id Identity) bool {
 	return e.MSPId == id.GetMSPID() && e.Subject == id.GetSubject()
 }
 
+// UnmarshalJSON decodes Entry, serialized by current or previous versions.
+// Unknown fields, added by later versions, are ignored. Missing and null fields are left empty.
+// Field names are matched exactly, then case-insensitively, like encoding/json does
+func (e *Entry) UnmarshalJSON(bb []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(bb, &fields); err != nil {
+		return err
+	}
+	if fields == nil {
+		return nil
+	}
+
+	entry := Entry{}
+	for name, target := range map[string]interface{}{
+		EntryFieldMSPID:   &entry.MSPId,
+		EntryFieldSubject: &entry.Subject,
+		EntryFieldIssuer:  &entry.Issuer,
+		EntryFieldPEM:     &entry.PEM,
+	} {
+		raw, ok := entryField(fields, name)
+		if !ok {
+			continue
+		}
+		if err := json.Unmarshal(raw, target); err != nil {
+			return fmt.Errorf(`unmarshal identity entry field %s: %w`, name, err)
+		}
+	}
+
+	*e = entry
+	return nil
+}
+
+func entryField(fields map[string]json.RawMessage, name string) (json.RawMessage, bool) {
+	if raw, ok := fields[name]; ok {
+		return raw, true
+	}
+	for field, raw := range fields {
+		if strings.EqualFold(field, name) {
+			return raw, true
+		}
+	}
+	return nil, false
+}
+
 //func (e Entry) FromBytes(bb []byte) (interface{}, error) {
 //	entry := new(Entry)
 //	err := json.Unmarshal(bb, entry)
@@ -0,0 +1,28 @@
+package identity
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// FingerprintLength length of identity fingerprint in hex chars (64 bits)
+const FingerprintLength = 16
+
+// Fingerprint returns short stable identity hash: first 16 hex chars of SHA-256 over MSP id and cert subject.
+// Can be used in logs and event payloads instead of identity subject
+func Fingerprint(id Identity) string {
+	return fingerprint(id.GetMSPID(), id.GetSubject())
+}
+
+// Fingerprint returns short stable hash of identity entry, see Fingerprint
+func (e Entry) Fingerprint() string {
+	return fingerprint(e.MSPId, e.Subject)
+}
+
+func fingerprint(mspID, subject string) string {
+	hash := sha256.New()
+	_, _ = hash.Write([]byte(mspID))
+	_, _ = hash.Write([]byte{0})
+	_, _ = hash.Write([]byte(subject))
+	return hex.EncodeToString(hash.Sum(nil))[:FingerprintLength]
+}
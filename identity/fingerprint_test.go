@@ -0,0 +1,44 @@
+package identity_test
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/identity"
+	"github.com/s7techlab/cckit/identity/testdata"
+)
+
+var _ = Describe(`Fingerprint`, func() {
+
+	It("Allow to get stable fingerprint without subject", func() {
+		id := testdata.Certificates[0].MustIdentity(testdata.DefaultMSP)
+		entry, err := identity.CreateEntry(id)
+		Expect(err).NotTo(HaveOccurred())
+
+		fingerprint := identity.Fingerprint(id)
+		Expect(fingerprint).To(HaveLen(identity.FingerprintLength))
+		Expect(fingerprint).To(Equal(entry.Fingerprint()))
+		Expect(id.GetSubject()).NotTo(ContainSubstring(fingerprint))
+
+		Expect(identity.Fingerprint(testdata.Certificates[0].MustIdentity(`OtherMSP`))).NotTo(Equal(fingerprint))
+		Expect(identity.Fingerprint(testdata.Certificates[1].MustIdentity(testdata.DefaultMSP))).NotTo(Equal(fingerprint))
+	})
+
+	It("Allow to get fingerprints without collisions over generated identities", func() {
+		const count = 200000
+		fingerprints := make(map[string]struct{}, count)
+
+		for i := 0; i < count; i++ {
+			entry := identity.Entry{
+				MSPId:   fmt.Sprintf(`Org%dMSP`, i%10),
+				Subject: fmt.Sprintf(`CN=user%d,OU=client,O=org%d`, i/10, i%10),
+			}
+			fingerprints[entry.Fingerprint()] = struct{}{}
+		}
+
+		// collision probability for 64 bit hash over 2*10^5 identities is ~10^-9
+		Expect(fingerprints).To(HaveLen(count))
+	})
+})
@@ -0,0 +1,23 @@
+package identity
+
+import (
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// InvokerInjector stub, providing invoker identity directly, without tx creator serialization.
+// Implemented by test doubles only, peer stub provides invoker as tx creator
+type InvokerInjector interface {
+	// InjectedInvoker returns injected invoker identity, if it's set for current tx
+	InjectedInvoker() (Identity, bool)
+}
+
+// InvokerFromStub returns injected invoker identity, if stub is InvokerInjector,
+// otherwise identity is created from tx creator, see FromStub
+func InvokerFromStub(stub shim.ChaincodeStubInterface) (Identity, error) {
+	if injector, ok := stub.(InvokerInjector); ok {
+		if invoker, ok := injector.InjectedInvoker(); ok {
+			return invoker, nil
+		}
+	}
+	return FromStub(stub)
+}
@@ -0,0 +1,115 @@
+package testdata
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/s7techlab/cckit/identity"
+)
+
+// ErrWireMismatch occurs when value, decoded from wire fixture, differs from expected one
+var ErrWireMismatch = errors.New(`wire fixture mismatch`)
+
+type (
+	// WireFixture value, serialized by one version of package, with expected decoded values.
+	// Fixtures are stored in wire compatibility corpus as <kind>.<version>.json and never changed
+	WireFixture struct {
+		Version  string          `json:"version"`
+		Expected WireEntry       `json:"expected"`
+		Data     json.RawMessage `json:"data"`
+		// File fixture is read from
+		File string `json:"-"`
+	}
+
+	// WireEntry expected values of identity entry in format, independent of entry serialization
+	WireEntry struct {
+		MSPID     string `json:"mspId"`
+		Subject   string `json:"subject"`
+		Issuer    string `json:"issuer"`
+		PEMSHA256 string `json:"pemSha256"`
+	}
+)
+
+// NewWireEntry creates expected values of identity entry
+func NewWireEntry(entry *identity.Entry) WireEntry {
+	return WireEntry{
+		MSPID:     entry.MSPId,
+		Subject:   entry.Subject,
+		Issuer:    entry.Issuer,
+		PEMSHA256: pemHash(entry.PEM),
+	}
+}
+
+// Verify returns ErrWireMismatch, naming first mismatched field, if entry differs from expected values
+func (w WireEntry) Verify(entry *identity.Entry) error {
+	actual := NewWireEntry(entry)
+	for _, field := range []struct{ name, expected, actual string }{
+		{identity.EntryFieldMSPID, w.MSPID, actual.MSPID},
+		{identity.EntryFieldSubject, w.Subject, actual.Subject},
+		{identity.EntryFieldIssuer, w.Issuer, actual.Issuer},
+		{identity.EntryFieldPEM, w.PEMSHA256, actual.PEMSHA256},
+	} {
+		if field.expected != field.actual {
+			return fmt.Errorf(`%w: field %s: expected %q, actual %q`,
+				ErrWireMismatch, field.name, field.expected, field.actual)
+		}
+	}
+	return nil
+}
+
+// ReadWireCorpus reads all fixtures of kind from corpus dir, ordered by file name
+func ReadWireCorpus(dir, kind string) ([]*WireFixture, error) {
+	files, err := filepath.Glob(filepath.Join(dir, kind+`.*.json`))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var fixtures []*WireFixture
+	for _, file := range files {
+		bb, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		fixture := &WireFixture{File: file}
+		if err = json.Unmarshal(bb, fixture); err != nil {
+			return nil, errors.Wrapf(err, `read wire fixture %s`, file)
+		}
+		fixtures = append(fixtures, fixture)
+	}
+	return fixtures, nil
+}
+
+// AppendWireFixture writes fixture of kind and version to corpus dir, existing fixture is never overwritten.
+// Returns false if fixture of version already exists
+func AppendWireFixture(dir, kind string, fixture *WireFixture) (bool, error) {
+	file := filepath.Join(dir, fmt.Sprintf(`%s.%s.json`, kind, fixture.Version))
+	if _, err := os.Stat(file); err == nil {
+		return false, nil
+	}
+
+	bb, err := json.MarshalIndent(fixture, ``, `  `)
+	if err != nil {
+		return false, err
+	}
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return false, err
+	}
+	return true, ioutil.WriteFile(file, append(bb, '\n'), 0644)
+}
+
+func pemHash(pem []byte) string {
+	if len(pem) == 0 {
+		return ``
+	}
+	hash := sha256.Sum256(pem)
+	return hex.EncodeToString(hash[:])
+}
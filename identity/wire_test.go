@@ -0,0 +1,114 @@
+package identity_test
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"reflect"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/identity"
+	"github.com/s7techlab/cckit/identity/testdata"
+)
+
+const (
+	// EntryWireCorpus dir of serialized entries of all versions, fixtures are appended with -update-wire-corpus
+	EntryWireCorpus = `testdata/wire`
+	// EntryWireVersion version of entry wire format, increment when Entry fields are added
+	EntryWireVersion = `v1`
+)
+
+var updateWireCorpus = flag.Bool(`update-wire-corpus`, false, `append current version fixtures to wire corpus`)
+
+func wireEntry() *identity.Entry {
+	entry, err := identity.CreateEntry(testdata.Certificates[0].MustIdentity(testdata.DefaultMSP))
+	Expect(err).NotTo(HaveOccurred())
+	return entry
+}
+
+func jsonFields(bb []byte) map[string]interface{} {
+	fields := make(map[string]interface{})
+	Expect(json.Unmarshal(bb, &fields)).To(Succeed())
+	return fields
+}
+
+var _ = Describe(`Entry wire compatibility`, func() {
+
+	var fixtures []*testdata.WireFixture
+
+	BeforeEach(func() {
+		if *updateWireCorpus {
+			data, err := json.Marshal(wireEntry())
+			Expect(err).NotTo(HaveOccurred())
+			_, err = testdata.AppendWireFixture(EntryWireCorpus, `entry`, &testdata.WireFixture{
+				Version: EntryWireVersion, Expected: testdata.NewWireEntry(wireEntry()), Data: data})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		var err error
+		fixtures, err = testdata.ReadWireCorpus(EntryWireCorpus, `entry`)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("Allow to decode entries, serialized by all versions", func() {
+		versions := make(map[string]bool)
+		for _, fixture := range fixtures {
+			entry := &identity.Entry{}
+			Expect(json.Unmarshal(fixture.Data, entry)).To(Succeed(), fixture.File)
+			Expect(fixture.Expected.Verify(entry)).To(Succeed(), fixture.File)
+			versions[fixture.Version] = true
+		}
+		Expect(versions).To(HaveKey(EntryWireVersion), `run with -update-wire-corpus to add current version`)
+	})
+
+	It("Allow previous versions to read fields of current serialization", func() {
+		current, err := json.Marshal(wireEntry())
+		Expect(err).NotTo(HaveOccurred())
+		currentFields := jsonFields(current)
+
+		for _, fixture := range fixtures {
+			for field, value := range jsonFields(fixture.Data) {
+				Expect(currentFields).To(HaveKey(field), fixture.File)
+				Expect(reflect.TypeOf(currentFields[field])).To(Equal(reflect.TypeOf(value)), fixture.File)
+			}
+		}
+	})
+
+	It("Allow to decode entry with unknown, missing and null fields", func() {
+		fields := jsonFields(fixtures[0].Data)
+		fields[`NotAfter`] = `2030-01-01T00:00:00Z`
+		fields[`Fingerprints`] = map[string]interface{}{`sha256`: `abc`}
+		fields[identity.EntryFieldIssuer] = nil
+		delete(fields, identity.EntryFieldPEM)
+		bb, err := json.Marshal(fields)
+		Expect(err).NotTo(HaveOccurred())
+
+		entry := &identity.Entry{}
+		Expect(json.Unmarshal(bb, entry)).To(Succeed())
+		Expect(entry.MSPId).To(Equal(testdata.DefaultMSP))
+		Expect(entry.Subject).To(Equal(fixtures[0].Expected.Subject))
+		Expect(entry.Issuer).To(BeEmpty())
+		Expect(entry.PEM).To(BeNil())
+	})
+
+	It("Disallow renamed field to pass corpus check", func() {
+		fields := jsonFields(fixtures[0].Data)
+		fields[`SubjectName`] = fields[identity.EntryFieldSubject]
+		delete(fields, identity.EntryFieldSubject)
+		bb, err := json.Marshal(fields)
+		Expect(err).NotTo(HaveOccurred())
+
+		entry := &identity.Entry{}
+		Expect(json.Unmarshal(bb, entry)).To(Succeed())
+		err = fixtures[0].Expected.Verify(entry)
+		Expect(errors.Is(err, testdata.ErrWireMismatch)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring(identity.EntryFieldSubject))
+	})
+
+	It("Disallow to decode field of wrong type", func() {
+		err := json.Unmarshal([]byte(`{"MSPId":1}`), &identity.Entry{})
+		Expect(err).To(MatchError(ContainSubstring(identity.EntryFieldMSPID)))
+	})
+})
@@ -0,0 +1,49 @@
+package response
+
+import (
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/peer"
+
+	"github.com/s7techlab/cckit/convert"
+)
+
+// FormatMessagePrefix prefix of success response message, echoing payload format of proto message
+const FormatMessagePrefix = `format:`
+
+// SuccessFormat returns shim.Success with data, serialized in format, see convert.ToBytesFormat.
+// If data is proto message and format is set, format is echoed in response message
+func SuccessFormat(data interface{}, format string) peer.Response {
+	if format == `` {
+		return Success(data)
+	}
+
+	bb, err := convert.ToBytesFormat(data, format)
+	if err != nil {
+		return Error(err)
+	}
+
+	res := Success(bb)
+	if _, ok := data.(proto.Message); ok {
+		res.Message = FormatMessagePrefix + format
+	}
+	return res
+}
+
+// CreateFormat returns peer.Response (SuccessFormat or Error) depending on value of err, see Create
+func CreateFormat(data interface{}, err interface{}, format string) peer.Response {
+	if res := Create(nil, err); res.Status != shim.OK {
+		return res
+	}
+	return SuccessFormat(data, format)
+}
+
+// Format returns payload format, echoed in response message, empty if format is not echoed
+func Format(res peer.Response) string {
+	if !strings.HasPrefix(res.Message, FormatMessagePrefix) {
+		return ``
+	}
+	return strings.TrimPrefix(res.Message, FormatMessagePrefix)
+}
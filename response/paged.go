@@ -0,0 +1,27 @@
+package response
+
+import (
+	"reflect"
+
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// PagedResponse page of list with bookmark of next page, bookmark is empty for last page
+type PagedResponse struct {
+	Items    interface{} `json:"items"`
+	Bookmark string      `json:"bookmark"`
+	Count    int32       `json:"count"`
+}
+
+// NewPagedResponse creates page from items slice and query response metadata
+func NewPagedResponse(items interface{}, metadata *peer.QueryResponseMetadata) *PagedResponse {
+	if v := reflect.ValueOf(items); items == nil || (v.Kind() == reflect.Slice && v.IsNil()) {
+		items = []interface{}{}
+	}
+
+	return &PagedResponse{
+		Items:    items,
+		Bookmark: metadata.GetBookmark(),
+		Count:    metadata.GetFetchedRecordsCount(),
+	}
+}
@@ -10,9 +10,34 @@ import (
 	"github.com/hyperledger/fabric-protos-go/peer"
 )
 
-// Error returns shim.Error
+// StatusError error with response status, set instead of generic shim.ERROR, i.e. http.StatusNotFound
+type StatusError struct {
+	Status int32
+	Err    error
+}
+
+func (e *StatusError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *StatusError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorWithStatus returns err with response status, status below shim.ERRORTHRESHOLD is ignored
+func ErrorWithStatus(status int32, err error) error {
+	return &StatusError{Status: status, Err: err}
+}
+
+// Error returns shim.Error, status of wrapped StatusError is used if set
 func Error(err interface{}) peer.Response {
-	return shim.Error(fmt.Sprintf("%s", err))
+	res := shim.Error(fmt.Sprintf("%s", err))
+
+	var statusErr *StatusError
+	if e, ok := err.(error); ok && errors.As(e, &statusErr) && statusErr.Status >= shim.ERRORTHRESHOLD {
+		res.Status = statusErr.Status
+	}
+	return res
 }
 
 // Success returns shim.Success with serialized json if necessary
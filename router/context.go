@@ -3,10 +3,12 @@ package router
 import (
 	"time"
 
+	"github.com/golang/protobuf/proto"
 	"go.uber.org/zap"
 
 	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
 	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/peer"
 	"github.com/s7techlab/cckit/state"
 )
 
@@ -74,6 +76,12 @@ type (
 		// SetParam sets parameter value.
 		SetParam(name string, value interface{})
 
+		// Transient returns transient map value, prefetched with SetTransient or read from stub transient map.
+		Transient(key string) []byte
+
+		// SetTransient sets prefetched transient map value.
+		SetTransient(key string, value []byte)
+
 		// Get retrieves data from the context.
 		Get(key string) interface{}
 		// Set saves data in the context.
@@ -87,14 +95,15 @@ type (
 	}
 
 	context struct {
-		stub    shim.ChaincodeStubInterface
-		handler *HandlerMeta
-		logger  *zap.Logger
-		state   state.State
-		event   state.Event
-		args    [][]byte
-		params  InterfaceMap
-		store   InterfaceMap
+		stub      shim.ChaincodeStubInterface
+		handler   *HandlerMeta
+		logger    *zap.Logger
+		state     state.State
+		event     state.Event
+		args      [][]byte
+		params    InterfaceMap
+		store     InterfaceMap
+		transient map[string][]byte
 	}
 )
 
@@ -174,6 +183,38 @@ func (c *context) Time() (time.Time, error) {
 	return time.Unix(txTimestamp.GetSeconds(), int64(txTimestamp.GetNanos())), nil
 }
 
+// ChaincodeID extracts name and version of invoked chaincode from signed proposal chaincode spec,
+// i.e. ChaincodeID(c.Stub())
+func ChaincodeID(stub shim.ChaincodeStubInterface) (*peer.ChaincodeID, error) {
+	signedProposal, err := stub.GetSignedProposal()
+	if err != nil {
+		return nil, err
+	}
+	if signedProposal == nil {
+		return nil, ErrChaincodeIDNotFound
+	}
+
+	proposal := &peer.Proposal{}
+	if err = proto.Unmarshal(signedProposal.ProposalBytes, proposal); err != nil {
+		return nil, err
+	}
+
+	payload := &peer.ChaincodeProposalPayload{}
+	if err = proto.Unmarshal(proposal.Payload, payload); err != nil {
+		return nil, err
+	}
+
+	invocationSpec := &peer.ChaincodeInvocationSpec{}
+	if err = proto.Unmarshal(payload.Input, invocationSpec); err != nil {
+		return nil, err
+	}
+
+	if invocationSpec.GetChaincodeSpec().GetChaincodeId() == nil {
+		return nil, ErrChaincodeIDNotFound
+	}
+	return invocationSpec.ChaincodeSpec.ChaincodeId, nil
+}
+
 // ReplaceArgs replace args, for usage in preMiddleware
 func (c *context) ReplaceArgs(args [][]byte) Context {
 	c.args = args
@@ -251,6 +292,25 @@ func (c *context) ParamInt(name string) int {
 	return out
 }
 
+func (c *context) Transient(key string) []byte {
+	if value, ok := c.transient[key]; ok {
+		return value
+	}
+
+	transient, err := c.stub.GetTransient()
+	if err != nil {
+		return nil
+	}
+	return transient[key]
+}
+
+func (c *context) SetTransient(key string, value []byte) {
+	if c.transient == nil {
+		c.transient = make(map[string][]byte)
+	}
+	c.transient[key] = value
+}
+
 func (c *context) Set(key string, val interface{}) {
 	if c.store == nil {
 		c.store = make(InterfaceMap)
@@ -0,0 +1,121 @@
+package router
+
+import (
+	"encoding/json"
+	"reflect"
+	"runtime"
+	"sort"
+)
+
+// describePos position, middleware is built with for collecting method description
+const describePos = -1
+
+type (
+	// Description of router, used by tooling for client code generation
+	Description struct {
+		Name    string               `json:"name"`
+		Methods []*MethodDescription `json:"methods"`
+		// Middleware names of router level middleware, applied to all methods
+		Middleware []string `json:"middleware,omitempty"`
+	}
+
+	// MethodDescription of registered chaincode method
+	MethodDescription struct {
+		Name string     `json:"name"`
+		Type MethodType `json:"type"`
+		// Group prefix, method registered with
+		Group      string              `json:"group,omitempty"`
+		Params     []*ParamDescription `json:"params,omitempty"`
+		Middleware []string            `json:"middleware,omitempty"`
+	}
+
+	// ParamDescription of chaincode method parameter, declared with param middleware
+	ParamDescription struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+		// Pos of arg, -1 - next after previous param
+		Pos int `json:"pos"`
+	}
+
+	// MiddlewareDescription describes middleware for router introspection
+	MiddlewareDescription struct {
+		Name  string
+		Param *ParamDescription
+	}
+
+	// describeContext carries middleware description to router while method is registered
+	describeContext struct {
+		Context
+		description MiddlewareDescription
+	}
+)
+
+// DescribedMiddleware attaches description to middleware, so it's exported with router description
+func DescribedMiddleware(description MiddlewareDescription, middleware MiddlewareFunc) MiddlewareFunc {
+	return func(next HandlerFunc, pos ...int) HandlerFunc {
+		if len(pos) == 1 && pos[0] == describePos {
+			_, _ = next(&describeContext{description: description})
+		}
+		return middleware(next, pos...)
+	}
+}
+
+// Describe returns description of methods, registered in router and its groups, ordered by method name
+func (g *Group) Describe() *Description {
+	description := &Description{Name: g.name, Methods: make([]*MethodDescription, 0, len(g.descriptions))}
+	for _, m := range g.descriptions {
+		description.Methods = append(description.Methods, m)
+	}
+	sort.Slice(description.Methods, func(i, j int) bool {
+		return description.Methods[i].Name < description.Methods[j].Name
+	})
+
+	for _, m := range g.middleware {
+		description.Middleware = append(description.Middleware, describeMiddleware(m).Name)
+	}
+	return description
+}
+
+// DescribeJSON returns router description, serialized to JSON
+func (g *Group) DescribeJSON() ([]byte, error) {
+	return json.MarshalIndent(g.Describe(), ``, `  `)
+}
+
+// DescribeQuery adds query method, returning router description
+func (g *Group) DescribeQuery(path string) *Group {
+	return g.Query(path, func(Context) (interface{}, error) {
+		return g.DescribeJSON()
+	})
+}
+
+func (g *Group) describeMethod(t MethodType, path string, middleware []MiddlewareFunc) {
+	method := &MethodDescription{Name: g.prefix + path, Type: t, Group: g.prefix}
+	for _, m := range middleware {
+		d := describeMiddleware(m)
+		if d.Param != nil {
+			method.Params = append(method.Params, d.Param)
+		} else {
+			method.Middleware = append(method.Middleware, d.Name)
+		}
+	}
+	g.descriptions[method.Name] = method
+}
+
+// describeMiddleware returns attached description or name of middleware function
+func describeMiddleware(middleware MiddlewareFunc) MiddlewareDescription {
+	var (
+		description MiddlewareDescription
+		described   bool
+	)
+	middleware(func(c Context) (interface{}, error) {
+		if d, ok := c.(*describeContext); ok {
+			description, described = d.description, true
+		}
+		return nil, nil
+	}, describePos)
+
+	if !described {
+		description.Name = runtime.FuncForPC(reflect.ValueOf(middleware).Pointer()).Name()
+	}
+	return description
+}
@@ -0,0 +1,84 @@
+package router_test
+
+import (
+	"flag"
+	"io/ioutil"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/router"
+	p "github.com/s7techlab/cckit/router/param"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+// DescriptionGoldenFile description of router, update only on deliberate format change with -update-golden
+const DescriptionGoldenFile = `testdata/description.golden.json`
+
+var updateGolden = flag.Bool(`update-golden`, false, `update router description golden file`)
+
+type Payment struct {
+	From   string
+	To     string
+	Amount int
+}
+
+func Audit(next router.HandlerFunc, pos ...int) router.HandlerFunc {
+	return next
+}
+
+func NewDescribedRouter() *router.Group {
+	r := router.New(`payments`).Use(Audit)
+
+	r.Invoke(`paymentCreate`, router.EmptyContextHandler,
+		p.Struct(`payment`, &Payment{}), p.TransientRequired(`secret`), Audit).
+		Query(`paymentGet`, router.EmptyContextHandler, p.String(`id`)).
+		DescribeQuery(`describe`)
+
+	r.Group(`admin`).
+		Invoke(`Reset`, router.EmptyContextHandler, p.Bool(`force`, 0))
+
+	return r
+}
+
+var _ = Describe(`Router description`, func() {
+
+	It("Exported description matches golden description", func() {
+		description, err := NewDescribedRouter().DescribeJSON()
+		Expect(err).NotTo(HaveOccurred())
+
+		if *updateGolden {
+			Expect(ioutil.WriteFile(DescriptionGoldenFile, description, 0644)).To(Succeed())
+		}
+
+		golden, err := ioutil.ReadFile(DescriptionGoldenFile)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(description)).To(MatchJSON(golden))
+	})
+
+	It("Description is updated, when method is registered", func() {
+		r := router.New(`payments`)
+		Expect(r.Describe().Methods).To(BeEmpty())
+
+		r.Query(`paymentList`, router.EmptyContextHandler)
+		Expect(r.Describe().Methods).To(Equal([]*router.MethodDescription{{
+			Name: `paymentList`, Type: router.MethodQuery}}))
+	})
+
+	It("Allow to query description on-chain", func() {
+		r := NewDescribedRouter()
+		expected, err := r.DescribeJSON()
+		Expect(err).NotTo(HaveOccurred())
+
+		cc := testcc.NewMockStub(`payments`, router.NewChaincode(r))
+		res := cc.Query(`describe`)
+		expectcc.ResponseOk(res)
+		Expect(res.Payload).To(MatchJSON(expected))
+	})
+
+	It("Described middleware is applied on invoke", func() {
+		cc := testcc.NewMockStub(`payments`, router.NewChaincode(NewDescribedRouter()))
+		expectcc.ResponseError(cc.Query(`paymentGet`), `method "paymentGet", param "id" not exists`)
+	})
+})
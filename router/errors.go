@@ -14,4 +14,13 @@ var (
 
 	// ErrHandlerError error in handler
 	ErrHandlerError = errors.New(`router handler error`)
+
+	// ErrChaincodeIDNotFound occurs when signed proposal doesn't contain chaincode spec
+	ErrChaincodeIDNotFound = errors.New(`chaincode id not found in signed proposal`)
+
+	// ErrResourceNotFound occurs when resource entry, addressed by key params, doesn't exist
+	ErrResourceNotFound = errors.New(`resource not found`)
+
+	// ErrResourceExists occurs when trying to create resource entry with key of existing entry
+	ErrResourceExists = errors.New(`resource already exists`)
 )
@@ -0,0 +1,32 @@
+package router
+
+import (
+	"github.com/s7techlab/cckit/convert"
+)
+
+const (
+	// FormatKey reserved transient key with requested format of proto message payload: json or proto
+	FormatKey = `~format`
+
+	// defaultFormatKey context store key of router default format
+	defaultFormatKey = `~defaultFormat`
+)
+
+// WithFormat sets default format of proto message payloads, used if format is not requested with FormatKey
+func (g *Group) WithFormat(format string) *Group {
+	g.format = format
+	return g
+}
+
+// ResponseFormat returns payload format, requested with FormatKey transient key or router default format.
+// Empty format means proto messages are serialized with convert.ToBytes
+func ResponseFormat(c Context) (string, error) {
+	format := string(c.Transient(FormatKey))
+	if format == `` {
+		format, _ = c.Get(defaultFormatKey).(string)
+	}
+	if err := convert.CheckFormat(format); err != nil {
+		return ``, err
+	}
+	return format, nil
+}
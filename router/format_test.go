@@ -0,0 +1,84 @@
+package router_test
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/convert"
+	"github.com/s7techlab/cckit/response"
+	"github.com/s7techlab/cckit/router"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+func NewFormatCC(format string) *router.Chaincode {
+	event := &peer.ChaincodeEvent{ChaincodeId: `format`, TxId: `tx1`, EventName: `Formatted`}
+	r := router.New(`format`).WithFormat(format).
+		Query(`event`, func(c router.Context) (interface{}, error) {
+			return event, nil
+		}).
+		Query(`eventResponse`, func(c router.Context) (interface{}, error) {
+			res := c.Response().Create(event, nil)
+			return res.Payload, nil
+		}).
+		Query(`string`, func(c router.Context) (interface{}, error) {
+			return `value`, nil
+		})
+
+	return router.NewChaincode(r)
+}
+
+var _ = Describe(`Payload format negotiation`, func() {
+
+	expected := &peer.ChaincodeEvent{ChaincodeId: `format`, TxId: `tx1`, EventName: `Formatted`}
+
+	It("Allow to request json and proto payloads of the same handler", func() {
+		cc := testcc.NewMockStub(`format`, NewFormatCC(``))
+
+		jsonRes := cc.WithFormat(convert.FormatJSON).Query(`event`)
+		protoRes := cc.WithFormat(convert.FormatProto).Query(`event`)
+
+		Expect(response.Format(jsonRes)).To(Equal(convert.FormatJSON))
+		Expect(response.Format(protoRes)).To(Equal(convert.FormatProto))
+		Expect(jsonRes.Payload).NotTo(Equal(protoRes.Payload))
+
+		fromJSON := expectcc.PayloadIs(jsonRes, &peer.ChaincodeEvent{}).(proto.Message)
+		fromProto := expectcc.PayloadIs(protoRes, &peer.ChaincodeEvent{}).(proto.Message)
+		Expect(proto.Equal(fromJSON, expected)).To(BeTrue())
+		Expect(proto.Equal(fromProto, expected)).To(BeTrue())
+	})
+
+	It("Allow to set default format per router", func() {
+		cc := testcc.NewMockStub(`format`, NewFormatCC(convert.FormatJSON))
+
+		res := cc.Query(`event`)
+		Expect(response.Format(res)).To(Equal(convert.FormatJSON))
+		Expect(string(res.Payload)).To(ContainSubstring(`"eventName":"Formatted"`))
+
+		res = cc.WithFormat(convert.FormatProto).Query(`event`)
+		Expect(response.Format(res)).To(Equal(convert.FormatProto))
+	})
+
+	It("Allow to serialize proto message in requested format with context response", func() {
+		cc := testcc.NewMockStub(`format`, NewFormatCC(``))
+
+		res := cc.WithFormat(convert.FormatJSON).Query(`eventResponse`)
+		Expect(string(res.Payload)).To(ContainSubstring(`"eventName":"Formatted"`))
+	})
+
+	It("Disallow to request unsupported format", func() {
+		cc := testcc.NewMockStub(`format`, NewFormatCC(``))
+
+		expectcc.ResponseError(cc.WithFormat(`xml`).Query(`event`), convert.ErrFormatUnsupported)
+	})
+
+	It("Allow non proto payloads regardless of format", func() {
+		cc := testcc.NewMockStub(`format`, NewFormatCC(convert.FormatJSON))
+
+		res := cc.Query(`string`)
+		Expect(response.Format(res)).To(BeEmpty())
+		expectcc.PayloadString(res, `value`)
+	})
+})
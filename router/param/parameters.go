@@ -83,8 +83,10 @@ func Param(name string, paramType interface{}, argPoss ...int) router.Middleware
 	}
 
 	parameter := Parameter{name, paramType, argPos}
+	description := router.MiddlewareDescription{Name: `param`, Param: &router.ParamDescription{
+		Name: name, Type: fmt.Sprintf(`%T`, paramType), Pos: argPos}}
 
-	return func(next router.HandlerFunc, pos ...int) router.HandlerFunc {
+	return router.DescribedMiddleware(description, func(next router.HandlerFunc, pos ...int) router.HandlerFunc {
 		return func(c router.Context) (interface{}, error) {
 
 			arg, err := parameter.ValueFromContext(c)
@@ -94,5 +96,5 @@ func Param(name string, paramType interface{}, argPoss ...int) router.Middleware
 			c.SetParam(name, arg)
 			return next(c)
 		}
-	}
+	})
 }
@@ -0,0 +1,48 @@
+package param
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/s7techlab/cckit/router"
+)
+
+// ErrTransientKeyRequired occurs when required key is absent in transient map
+var ErrTransientKeyRequired = errors.New(`transient key required`)
+
+// TransientRequired creates middleware, rejecting invoke without key in transient map.
+// Value is prefetched into context, see router.Context Transient
+func TransientRequired(key string) router.MiddlewareFunc {
+	return transient(key, true)
+}
+
+// TransientOptional creates middleware, prefetching value of key from transient map into context, if exists
+func TransientOptional(key string) router.MiddlewareFunc {
+	return transient(key, false)
+}
+
+func transient(key string, required bool) router.MiddlewareFunc {
+	name := `transientOptional`
+	if required {
+		name = `transientRequired`
+	}
+	description := router.MiddlewareDescription{Name: name + `(` + key + `)`}
+
+	return router.DescribedMiddleware(description, func(next router.HandlerFunc, pos ...int) router.HandlerFunc {
+		return func(c router.Context) (interface{}, error) {
+			transientMap, err := c.Stub().GetTransient()
+			if err != nil {
+				return nil, err
+			}
+
+			value, ok := transientMap[key]
+			switch {
+			case ok:
+				c.SetTransient(key, value)
+			case required:
+				return nil, fmt.Errorf(`%w: method "%s", key "%s"`, ErrTransientKeyRequired, c.Path(), key)
+			}
+			return next(c)
+		}
+	})
+}
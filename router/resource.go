@@ -0,0 +1,151 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/hyperledger/fabric-protos-go/peer"
+
+	"github.com/s7techlab/cckit/convert"
+	"github.com/s7techlab/cckit/response"
+)
+
+const (
+	// ResourcePageSizeParam name of page size param of resource List method
+	ResourcePageSizeParam = `pageSize`
+	// ResourceBookmarkParam name of bookmark param of resource List method, optional
+	ResourceBookmarkParam = `bookmark`
+)
+
+type (
+	// ResourceKeyFunc returns state key of resource entry, addressed by key params
+	ResourceKeyFunc func(Context) (interface{}, error)
+
+	// ResourceListFunc returns page of resource entries and metadata with bookmark of next page,
+	// like state.ListPaginated does
+	ResourceListFunc func(c Context, pageSize int32, bookmark string) (
+		items interface{}, metadata *peer.QueryResponseMetadata, err error)
+
+	// ResourceHandlers handlers and shared params of resource methods, nil handlers are not registered
+	ResourceHandlers struct {
+		// Key returns state key of entry, checked to exist before Get, Update and Delete
+		// and to not exist before Create. Existence is not checked if Key is nil
+		Key ResourceKeyFunc
+		// KeyParams params, entry is addressed with, shared by Get, Create, Update and Delete
+		KeyParams []MiddlewareFunc
+		// PayloadParams params of Create and Update, following key params
+		PayloadParams []MiddlewareFunc
+
+		Get    HandlerFunc
+		Create HandlerFunc
+		Update HandlerFunc
+		Delete HandlerFunc
+		// List is called with page size and bookmark params, page is returned as response.PagedResponse
+		List ResourceListFunc
+	}
+)
+
+// Resource registers methods of resource handlers with canonical names: <name>Get, <name>Create,
+// <name>Update, <name>Delete queries and invokes and <name>List query.
+// Missing entry is reported with ErrResourceNotFound and http.StatusNotFound status,
+// existing entry on create with ErrResourceExists and http.StatusConflict status
+func (g *Group) Resource(name string, handlers ResourceHandlers) *Group {
+	keyParams := handlers.KeyParams
+	payloadParams := concatMiddleware(keyParams, handlers.PayloadParams...)
+
+	if handlers.Get != nil {
+		g.Query(name+`Get`, handlers.Get, concatMiddleware(keyParams, resourceExists(handlers.Key, true))...)
+	}
+	if handlers.Create != nil {
+		g.Invoke(name+`Create`, handlers.Create,
+			concatMiddleware(payloadParams, resourceExists(handlers.Key, false))...)
+	}
+	if handlers.Update != nil {
+		g.Invoke(name+`Update`, handlers.Update,
+			concatMiddleware(payloadParams, resourceExists(handlers.Key, true))...)
+	}
+	if handlers.Delete != nil {
+		g.Invoke(name+`Delete`, handlers.Delete, concatMiddleware(keyParams, resourceExists(handlers.Key, true))...)
+	}
+	if handlers.List != nil {
+		g.Query(name+`List`, resourceList(handlers.List),
+			resourcePageParam(ResourcePageSizeParam, convert.TypeInt, 0),
+			resourcePageParam(ResourceBookmarkParam, convert.TypeString, 1))
+	}
+	return g
+}
+
+// concatMiddleware returns new slice, so shared params slice is not modified by append
+func concatMiddleware(middleware []MiddlewareFunc, more ...MiddlewareFunc) []MiddlewareFunc {
+	return append(append(make([]MiddlewareFunc, 0, len(middleware)+len(more)), middleware...), more...)
+}
+
+// resourceExists checks entry, addressed by key, exists or not
+func resourceExists(key ResourceKeyFunc, mustExist bool) MiddlewareFunc {
+	return func(next HandlerFunc, pos ...int) HandlerFunc {
+		return func(c Context) (interface{}, error) {
+			if key == nil {
+				return next(c)
+			}
+
+			k, err := key(c)
+			if err != nil {
+				return nil, err
+			}
+			exists, err := c.State().Exists(k)
+			if err != nil {
+				return nil, err
+			}
+
+			switch {
+			case mustExist && !exists:
+				return nil, response.ErrorWithStatus(http.StatusNotFound,
+					fmt.Errorf(`%w: %s, key %v`, ErrResourceNotFound, c.Path(), k))
+			case !mustExist && exists:
+				return nil, response.ErrorWithStatus(http.StatusConflict,
+					fmt.Errorf(`%w: %s, key %v`, ErrResourceExists, c.Path(), k))
+			}
+			return next(c)
+		}
+	}
+}
+
+// resourcePageParam sets page param from arg at pos, bookmark can be omitted
+func resourcePageParam(name string, paramType interface{}, argPos int) MiddlewareFunc {
+	description := MiddlewareDescription{Name: `param`, Param: &ParamDescription{
+		Name: name, Type: fmt.Sprintf(`%T`, paramType), Pos: argPos}}
+
+	return DescribedMiddleware(description, func(next HandlerFunc, pos ...int) HandlerFunc {
+		return func(c Context) (interface{}, error) {
+			// first arg is chaincode function name
+			args := c.GetArgs()[1:]
+			if argPos >= len(args) {
+				if name != ResourceBookmarkParam {
+					return nil, response.ErrorWithStatus(http.StatusBadRequest, fmt.Errorf(
+						`method "%s", param "%s" not exists, param expected at pos : %d, stub args length: %d`,
+						c.Path(), name, argPos, len(args)))
+				}
+				c.SetParam(name, ``)
+				return next(c)
+			}
+
+			value, err := convert.FromBytes(args[argPos], paramType)
+			if err != nil {
+				return nil, response.ErrorWithStatus(http.StatusBadRequest,
+					fmt.Errorf(`method "%s", param "%s": %w`, c.Path(), name, err))
+			}
+			c.SetParam(name, value)
+			return next(c)
+		}
+	})
+}
+
+func resourceList(list ResourceListFunc) HandlerFunc {
+	return func(c Context) (interface{}, error) {
+		items, metadata, err := list(c, int32(c.ParamInt(ResourcePageSizeParam)), c.ParamString(ResourceBookmarkParam))
+		if err != nil {
+			return nil, err
+		}
+		return response.NewPagedResponse(items, metadata), nil
+	}
+}
@@ -0,0 +1,140 @@
+package router_test
+
+import (
+	"errors"
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/hyperledger/fabric-protos-go/peer"
+
+	"github.com/s7techlab/cckit/response"
+	"github.com/s7techlab/cckit/router"
+	p "github.com/s7techlab/cckit/router/param"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+const AssetEntity = `ASSET`
+
+type Asset struct {
+	Name  string
+	Value int
+}
+
+func assetKey(c router.Context) (interface{}, error) {
+	return []string{AssetEntity, c.ParamString(`id`)}, nil
+}
+
+func assetPayload(c router.Context) (Asset, error) {
+	asset := c.Param(`asset`).(Asset)
+	if asset.Name == `` {
+		return asset, p.PayloadValidationError(errors.New(`name is empty`))
+	}
+	return asset, nil
+}
+
+func NewResourceCC() *router.Chaincode {
+	r := router.New(`assets`)
+
+	r.Resource(`asset`, router.ResourceHandlers{
+		Key:           assetKey,
+		KeyParams:     []router.MiddlewareFunc{p.String(`id`)},
+		PayloadParams: []router.MiddlewareFunc{p.Struct(`asset`, &Asset{})},
+
+		Get: func(c router.Context) (interface{}, error) {
+			key, _ := assetKey(c)
+			return c.State().Get(key, &Asset{})
+		},
+		Create: func(c router.Context) (interface{}, error) {
+			asset, err := assetPayload(c)
+			if err != nil {
+				return nil, err
+			}
+			key, _ := assetKey(c)
+			return asset, c.State().Put(key, asset)
+		},
+		Update: func(c router.Context) (interface{}, error) {
+			asset, err := assetPayload(c)
+			if err != nil {
+				return nil, err
+			}
+			key, _ := assetKey(c)
+			return asset, c.State().Put(key, asset)
+		},
+		Delete: func(c router.Context) (interface{}, error) {
+			key, _ := assetKey(c)
+			return nil, c.State().Delete(key)
+		},
+		List: func(c router.Context, pageSize int32, bookmark string) (
+			interface{}, *peer.QueryResponseMetadata, error) {
+			return c.State().ListPaginated(AssetEntity, pageSize, bookmark, &Asset{})
+		},
+	})
+
+	return router.NewChaincode(r)
+}
+
+var _ = Describe(`Resource`, func() {
+
+	var cc *testcc.MockStub
+
+	BeforeEach(func() {
+		cc = testcc.NewMockStub(`assets`, NewResourceCC())
+	})
+
+	It("Allow to create, get, update and delete resource entry", func() {
+		expectcc.ResponseOk(cc.Invoke(`assetCreate`, `a1`, &Asset{Name: `first`, Value: 1}))
+		Expect(expectcc.PayloadIs(cc.Query(`assetGet`, `a1`), &Asset{})).To(Equal(Asset{Name: `first`, Value: 1}))
+
+		expectcc.ResponseOk(cc.Invoke(`assetUpdate`, `a1`, &Asset{Name: `first`, Value: 2}))
+		Expect(expectcc.PayloadIs(cc.Query(`assetGet`, `a1`), &Asset{})).To(Equal(Asset{Name: `first`, Value: 2}))
+
+		expectcc.ResponseOk(cc.Invoke(`assetDelete`, `a1`))
+		expectcc.ResponseNotFound(cc.Query(`assetGet`, `a1`))
+	})
+
+	It("Allow to list resource entries with pagination", func() {
+		for _, id := range []string{`a1`, `a2`, `a3`} {
+			expectcc.ResponseOk(cc.Invoke(`assetCreate`, id, &Asset{Name: id}))
+		}
+
+		page := expectcc.PayloadIs(cc.Query(`assetList`, 2), &response.PagedResponse{}).(response.PagedResponse)
+		Expect(page.Items).To(HaveLen(2))
+		Expect(page.Count).To(Equal(int32(2)))
+		Expect(page.Bookmark).NotTo(BeEmpty())
+
+		page = expectcc.PayloadIs(cc.Query(`assetList`, 2, page.Bookmark),
+			&response.PagedResponse{}).(response.PagedResponse)
+		Expect(page.Items).To(HaveLen(1))
+		Expect(page.Bookmark).To(BeEmpty())
+	})
+
+	It("Disallow to get, update or delete not existing entry", func() {
+		res := cc.Query(`assetGet`, `unknown`)
+		Expect(res.Status).To(BeEquivalentTo(http.StatusNotFound))
+		Expect(res.Message).To(ContainSubstring(router.ErrResourceNotFound.Error()))
+
+		expectcc.ResponseNotFound(cc.Invoke(`assetUpdate`, `unknown`, &Asset{Name: `name`}))
+		expectcc.ResponseNotFound(cc.Invoke(`assetDelete`, `unknown`))
+	})
+
+	It("Disallow to create entry with existing key", func() {
+		expectcc.ResponseOk(cc.Invoke(`assetCreate`, `a1`, &Asset{Name: `first`}))
+
+		res := cc.Invoke(`assetCreate`, `a1`, &Asset{Name: `second`})
+		Expect(res.Status).To(BeEquivalentTo(http.StatusConflict))
+		Expect(res.Message).To(ContainSubstring(router.ErrResourceExists.Error()))
+	})
+
+	It("Disallow to invoke resource methods with invalid params", func() {
+		expectcc.ResponseErrorClass(cc.Invoke(`assetCreate`, `a1`), expectcc.ErrorClassValidation)
+		expectcc.ResponseErrorClass(cc.Invoke(`assetCreate`, `a1`, &Asset{}), expectcc.ErrorClassValidation)
+		expectcc.ResponseErrorClass(cc.Query(`assetGet`), expectcc.ErrorClassValidation)
+
+		res := cc.Query(`assetList`, `not a number`)
+		Expect(res.Status).To(BeEquivalentTo(http.StatusBadRequest))
+		expectcc.ResponseErrorClass(cc.Query(`assetList`), expectcc.ErrorClassValidation)
+	})
+})
@@ -26,19 +26,23 @@ func (c ContextResponse) Error(err interface{}) peer.Response {
 	return res
 }
 
-// Success response
+// Success response, proto message is serialized in format, requested with FormatKey
 func (c ContextResponse) Success(data interface{}) peer.Response {
-	res := response.Success(data)
+	format, err := ResponseFormat(c.context)
+	if err != nil {
+		return c.Error(err)
+	}
+	res := response.SuccessFormat(data, format)
 	c.context.Logger().Debug(`route handle success`, zap.String(`path`, c.context.Path()), zap.ByteString(`data`, res.Payload))
 	return res
 }
 
 // Create  returns error response if err != nil
 func (c ContextResponse) Create(data interface{}, err interface{}) peer.Response {
-	result := response.Create(data, err)
+	result := response.Create(nil, err)
 
 	if result.Status == shim.ERROR {
 		return c.Error(result.Message)
 	}
-	return c.Success(result.Payload)
+	return c.Success(data)
 }
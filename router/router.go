@@ -40,12 +40,17 @@ type (
 	// Group of chain code functions
 	Group struct {
 		logger *zap.Logger
+		name   string
 		prefix string
+		// format default format of proto message payloads
+		format string
 
 		// mapping chaincode method  => handler
 		stubHandlers    map[string]StubHandlerFunc
 		contextHandlers map[string]ContextHandlerFunc
 		handlers        map[string]*HandlerMeta
+		// mapping chaincode method => description, shared with groups
+		descriptions map[string]*MethodDescription
 
 		contextMiddleware []ContextMiddlewareFunc
 		middleware        []MiddlewareFunc
@@ -136,7 +141,14 @@ func (g *Group) handleContext(c Context) peer.Response {
 
 			return h(c)
 		}
-		resp := response.Create(h(c))
+		format, err := ResponseFormat(c)
+		if err != nil {
+			g.logger.Error(`router handler error`, zap.String(`path`, c.Path()), zap.Error(err))
+			return response.Error(err)
+		}
+
+		data, err := h(c)
+		resp := response.CreateFormat(data, err, format)
 		if resp.Status != shim.OK {
 			g.logger.Error(`router handler error`, zap.String(`path`, c.Path()), zap.String(`message`, resp.Message))
 		}
@@ -169,10 +181,13 @@ func (g *Group) Use(middleware ...MiddlewareFunc) *Group {
 func (g *Group) Group(path string) *Group {
 	return &Group{
 		logger:          g.logger,
+		name:            g.name,
 		prefix:          g.prefix + path,
+		format:          g.format,
 		stubHandlers:    g.stubHandlers,
 		contextHandlers: g.contextHandlers,
 		handlers:        g.handlers,
+		descriptions:    g.descriptions,
 		middleware:      g.middleware,
 	}
 }
@@ -209,6 +224,7 @@ func (g *Group) addHandler(t MethodType, path string, handler HandlerFunc, middl
 			}
 			return h(context)
 		}}
+	g.describeMethod(t, path, middleware)
 	return g
 }
 
@@ -218,16 +234,22 @@ func (g *Group) Init(handler HandlerFunc, middleware ...MiddlewareFunc) *Group {
 
 // Context returns chain code invoke context  for provided path and stub
 func (g *Group) Context(stub shim.ChaincodeStubInterface) Context {
-	return NewContext(stub, g.logger)
+	c := NewContext(stub, g.logger)
+	if g.format != `` {
+		c.Set(defaultFormatKey, g.format)
+	}
+	return c
 }
 
 // New group of chain code functions
 func New(name string) *Group {
 	g := new(Group)
 	g.logger = NewLogger(name)
+	g.name = name
 	g.stubHandlers = make(map[string]StubHandlerFunc)
 	g.contextHandlers = make(map[string]ContextHandlerFunc)
 	g.handlers = make(map[string]*HandlerMeta)
+	g.descriptions = make(map[string]*MethodDescription)
 
 	return g
 }
@@ -10,6 +10,7 @@ import (
 	"github.com/hyperledger/fabric-protos-go/peer"
 	"github.com/s7techlab/cckit/router"
 	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
 )
 
 func TestRouter(t *testing.T) {
@@ -22,16 +23,19 @@ func New() *router.Chaincode {
 		Init(router.EmptyContextHandler).
 		Invoke(`empty`, func(c router.Context) (interface{}, error) {
 			return nil, nil
+		}).
+		Query(`chaincodeId`, func(c router.Context) (interface{}, error) {
+			return router.ChaincodeID(c.Stub())
 		})
 
 	return router.NewChaincode(r)
 }
 
-var cc *testcc.MockStub
-
 var _ = Describe(`Router`, func() {
 
-	BeforeSuite(func() {
+	var cc *testcc.MockStub
+
+	BeforeEach(func() {
 		cc = testcc.NewMockStub(`Router`, New())
 	})
 
@@ -44,4 +48,17 @@ var _ = Describe(`Router`, func() {
 		}))
 	})
 
+	It(`Allow to get chaincode name and version derived from mockstub name`, func() {
+		ccID := expectcc.PayloadIs(cc.Query(`chaincodeId`), &peer.ChaincodeID{}).(*peer.ChaincodeID)
+		Expect(ccID.Name).To(Equal(`Router`))
+		Expect(ccID.Version).To(Equal(testcc.DefaultChaincodeVersion))
+	})
+
+	It(`Allow to get mocked chaincode name and version`, func() {
+		cc.WithChaincodeID(`router`, `1.2`)
+		ccID := expectcc.PayloadIs(cc.Query(`chaincodeId`), &peer.ChaincodeID{}).(*peer.ChaincodeID)
+		Expect(ccID.Name).To(Equal(`router`))
+		Expect(ccID.Version).To(Equal(`1.2`))
+	})
+
 })
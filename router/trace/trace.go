@@ -0,0 +1,101 @@
+// Package trace provides router middleware, emitting spans of chaincode method invocations to pluggable sink
+package trace
+
+import (
+	"time"
+
+	"github.com/s7techlab/cckit/router"
+)
+
+// SpanContextKey router context key of span of current invocation
+const SpanContextKey = `trace.span`
+
+type (
+	// Span of chaincode method invocation
+	Span struct {
+		TxID     string            `json:"txId"`
+		Method   string            `json:"method"`
+		Type     router.MethodType `json:"type"`
+		Started  time.Time         `json:"started"`
+		Duration time.Duration     `json:"duration"`
+		// Middleware outcomes of guards, wrapped with Guard, in invocation order
+		Middleware []Outcome `json:"middleware,omitempty"`
+		Error      string    `json:"error,omitempty"`
+	}
+
+	// Outcome of middleware: passed to next handler or rejected invocation with error
+	Outcome struct {
+		Middleware string `json:"middleware"`
+		Passed     bool   `json:"passed"`
+		Error      string `json:"error,omitempty"`
+	}
+
+	// Sink receives finished spans
+	Sink interface {
+		Record(span Span)
+	}
+
+	// SinkFunc func, implementing Sink
+	SinkFunc func(span Span)
+)
+
+// Record calls f
+func (f SinkFunc) Record(span Span) {
+	f(span)
+}
+
+// Middleware returns router middleware, which records span of each handler invocation to sink.
+// Should be used before other middleware, i.e. r.Use(trace.Middleware(sink))
+func Middleware(sink Sink) router.MiddlewareFunc {
+	return func(next router.HandlerFunc, pos ...int) router.HandlerFunc {
+		return func(c router.Context) (interface{}, error) {
+			span := &Span{
+				TxID:    c.Stub().GetTxID(),
+				Method:  c.Path(),
+				Started: time.Now(),
+			}
+			if handler := c.Handler(); handler != nil {
+				span.Type = handler.Type
+			}
+
+			c.Set(SpanContextKey, span)
+			res, err := next(c)
+
+			span.Duration = time.Since(span.Started)
+			if err != nil {
+				span.Error = err.Error()
+			}
+			sink.Record(*span)
+
+			return res, err
+		}
+	}
+}
+
+// Guard wraps middleware with name, outcome of middleware is recorded to span of current invocation
+func Guard(name string, middleware router.MiddlewareFunc) router.MiddlewareFunc {
+	return func(next router.HandlerFunc, pos ...int) router.HandlerFunc {
+		return func(c router.Context) (interface{}, error) {
+			span, _ := c.Get(SpanContextKey).(*Span)
+			if span == nil {
+				return middleware(next, pos...)(c)
+			}
+
+			passed := false
+			res, err := middleware(func(c router.Context) (interface{}, error) {
+				passed = true
+				span.Middleware = append(span.Middleware, Outcome{Middleware: name, Passed: true})
+				return next(c)
+			}, pos...)(c)
+
+			if !passed {
+				outcome := Outcome{Middleware: name}
+				if err != nil {
+					outcome.Error = err.Error()
+				}
+				span.Middleware = append(span.Middleware, outcome)
+			}
+			return res, err
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package router_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/router"
+	p "github.com/s7techlab/cckit/router/param"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+type Secret struct {
+	Secret string
+	Note   string
+}
+
+func NewSecretsCC() *router.Chaincode {
+	r := router.New(`secrets`).
+		Invoke(`createSecret`, func(c router.Context) (interface{}, error) {
+			return Secret{Secret: string(c.Transient(`secret`)), Note: string(c.Transient(`note`))}, nil
+		}, p.TransientRequired(`secret`), p.TransientOptional(`note`))
+
+	return router.NewChaincode(r)
+}
+
+var _ = Describe(`Transient requirements`, func() {
+
+	secrets := testcc.NewMockStub(`secrets`, NewSecretsCC())
+
+	It("Disallow invoke without required transient key", func() {
+		res := secrets.WithTransient(map[string][]byte{`note`: []byte(`note`)}).Invoke(`createSecret`)
+		expectcc.ResponseError(res, `transient key required: method "createSecret", key "secret"`)
+	})
+
+	It("Allow invoke without optional transient key", func() {
+		res := secrets.WithTransient(map[string][]byte{`secret`: []byte(`value`)}).Invoke(`createSecret`)
+		Expect(expectcc.PayloadIs(res, &Secret{})).To(Equal(Secret{Secret: `value`}))
+	})
+
+	It("Allow handler to receive prefetched transient values", func() {
+		res := secrets.WithTransient(map[string][]byte{
+			`secret`: []byte(`value`), `note`: []byte(`note`)}).Invoke(`createSecret`)
+		Expect(expectcc.PayloadIs(res, &Secret{})).To(Equal(Secret{Secret: `value`, Note: `note`}))
+	})
+})
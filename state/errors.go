@@ -26,6 +26,9 @@ var (
 
 	ErrEventEntryNotSupportNamerInterface = errors.New(`event entry not support name interface`)
 
+	// ErrEventNameInvalid occurs when event name is empty, too long or contains not allowed bytes
+	ErrEventNameInvalid = errors.New(`event name invalid`)
+
 	// ErrKeyPartsLength can occurs when trying to create key consisting of zero parts
 	ErrKeyPartsLength = errors.New(`key parts length must be greater than zero`)
 )
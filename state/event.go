@@ -1,6 +1,9 @@
 package state
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/s7techlab/cckit/convert"
 )
@@ -95,3 +98,32 @@ func NormalizeEventName(name interface{}) (string, error) {
 
 	return ``, ErrUnableToCreateEventName
 }
+
+// DefaultEventNameMaxLength max length of event name, used when event names are validated at registration
+const DefaultEventNameMaxLength = 128
+
+// ValidateEventName checks event name is not empty, is not longer than maxLength bytes (zero means unlimited)
+// and contains only ASCII letters, digits and `_ - . : /`, so listeners, keyed on event names,
+// can't be confused with control characters, spaces or invalid UTF-8. Error identifies offending byte
+func ValidateEventName(name string, maxLength int) error {
+	if name == `` {
+		return fmt.Errorf(`%w: empty`, ErrEventNameInvalid)
+	}
+	if maxLength > 0 && len(name) > maxLength {
+		return fmt.Errorf(`%w: length %d exceeds %d bytes`, ErrEventNameInvalid, len(name), maxLength)
+	}
+	for i := 0; i < len(name); i++ {
+		if !isEventNameByte(name[i]) {
+			return fmt.Errorf(`%w: byte 0x%02x at offset %d of %q is not allowed`, ErrEventNameInvalid, name[i], i, name)
+		}
+	}
+	return nil
+}
+
+func isEventNameByte(b byte) bool {
+	switch {
+	case b >= 'a' && b <= 'z', b >= 'A' && b <= 'Z', b >= '0' && b <= '9':
+		return true
+	}
+	return strings.IndexByte(`_-.:/`, b) >= 0
+}
@@ -0,0 +1,276 @@
+package state
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/pkg/errors"
+
+	"github.com/s7techlab/cckit/convert"
+)
+
+// ExpiryObjectType composite key object type of expiry records, stored in parallel with expiring entries
+const ExpiryObjectType = `EXPIRY`
+
+type (
+	// Expiring state wrapper for temporary entries: entries, put with TTL, are treated by Get, Exists and List
+	// as absent after expiration, relative to tx timestamp, and deleted by SweepExpired
+	Expiring struct {
+		State
+		impl *Impl
+		stub shim.ChaincodeStubInterface
+	}
+
+	// ExpiryRecord expiration time of state entry
+	ExpiryRecord struct {
+		Key       string    `json:"key"`
+		ExpiresAt time.Time `json:"expiresAt"`
+	}
+)
+
+// WithExpiry returns state with TTL of entries
+func WithExpiry(ss State) *Expiring {
+	s := ss.(*Impl)
+	return &Expiring{
+		State: s,
+		impl:  s,
+		stub:  s.stub,
+	}
+}
+
+// PutWithTTL puts entry to state, entry expires after ttl, relative to tx timestamp
+func (e *Expiring) PutWithTTL(entry, value interface{}, ttl time.Duration) error {
+	key, err := e.impl.Key(entry)
+	if err != nil {
+		return err
+	}
+
+	now, err := txTime(e.stub)
+	if err != nil {
+		return err
+	}
+
+	if err = e.State.Put(entry, value); err != nil {
+		return err
+	}
+	return e.putRecord(&ExpiryRecord{Key: key.String, ExpiresAt: now.Add(ttl)})
+}
+
+// Put puts entry to state without TTL, previously set TTL of entry is removed
+func (e *Expiring) Put(entry interface{}, value ...interface{}) error {
+	if err := e.State.Put(entry, value...); err != nil {
+		return err
+	}
+	return e.deleteRecord(entry)
+}
+
+// Delete deletes entry with its TTL
+func (e *Expiring) Delete(entry interface{}) error {
+	if err := e.State.Delete(entry); err != nil {
+		return err
+	}
+	return e.deleteRecord(entry)
+}
+
+// Get returns entry, expired entry is returned as absent
+func (e *Expiring) Get(entry interface{}, config ...interface{}) (interface{}, error) {
+	key, err := e.impl.Key(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	expired, err := e.expired(key.String)
+	if err != nil {
+		return nil, err
+	}
+	if !expired {
+		return e.State.Get(entry, config...)
+	}
+
+	if len(config) >= 2 {
+		return config[1], nil
+	}
+	return nil, fmt.Errorf(`%w: %s`, ErrKeyNotFound, key.Origin)
+}
+
+// GetInt returns entry, converted to int, default value is returned for expired entry
+func (e *Expiring) GetInt(entry interface{}, defaultValue int) (int, error) {
+	val, err := e.Get(entry, convert.TypeInt, defaultValue)
+	if err != nil {
+		return 0, err
+	}
+	return val.(int), nil
+}
+
+// Exists returns false for expired entry
+func (e *Expiring) Exists(entry interface{}) (bool, error) {
+	key, err := e.impl.Key(entry)
+	if err != nil {
+		return false, err
+	}
+
+	if expired, err := e.expired(key.String); err != nil || expired {
+		return false, err
+	}
+	return e.State.Exists(entry)
+}
+
+// List returns slice of target type without expired entries and expiry records
+func (e *Expiring) List(namespace interface{}, target ...interface{}) (interface{}, error) {
+	stateList, err := NewStateList(target...)
+	if err != nil {
+		return nil, err
+	}
+
+	recordsPrefix, err := e.stub.CreateCompositeKey(ExpiryObjectType, []string{})
+	if err != nil {
+		return nil, err
+	}
+
+	iter, err := e.impl.createStateQueryIterator(namespace)
+	if err != nil {
+		return nil, errors.Wrap(err, `state iterator`)
+	}
+
+	err = IterateKV(iter, func(kv *queryresult.KV) (bool, error) {
+		if strings.HasPrefix(kv.Key, recordsPrefix) {
+			return false, nil
+		}
+		if expired, err := e.expired(kv.Key); err != nil || expired {
+			return false, err
+		}
+		item, err := e.impl.StateGetTransformer(kv.Value, stateList.itemTarget)
+		if err != nil {
+			return false, errors.Wrap(err, `transform list entry`)
+		}
+		stateList.list = append(stateList.list, item)
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stateList.Get()
+}
+
+// SweepExpired deletes up to limit expired entries (all, if limit <= 0) with their expiry records,
+// starting after bookmark. Bookmark to resume sweeping in next tx is returned, it's empty if all
+// expiry records are checked
+func (e *Expiring) SweepExpired(limit int, bookmark string) (deleted int, next string, err error) {
+	now, err := txTime(e.stub)
+	if err != nil {
+		return 0, ``, err
+	}
+
+	iter, err := e.stub.GetStateByPartialCompositeKey(ExpiryObjectType, []string{})
+	if err != nil {
+		return 0, ``, errors.Wrap(err, `expiry records iterator`)
+	}
+
+	err = IterateKV(iter, func(kv *queryresult.KV) (bool, error) {
+		if kv.Key <= bookmark {
+			return false, nil
+		}
+
+		record := &ExpiryRecord{}
+		if err := json.Unmarshal(kv.Value, record); err != nil {
+			return false, fmt.Errorf(`unmarshal expiry record %s: %w`, kv.Key, err)
+		}
+		if now.Before(record.ExpiresAt) {
+			return false, nil
+		}
+
+		if err := e.delIfExists(record.Key); err != nil {
+			return false, err
+		}
+		if err := e.stub.DelState(kv.Key); err != nil {
+			return false, err
+		}
+
+		deleted++
+		if limit > 0 && deleted >= limit {
+			next = kv.Key
+			return true, nil
+		}
+		return false, nil
+	})
+	if err != nil {
+		return 0, ``, err
+	}
+	return deleted, next, nil
+}
+
+func (e *Expiring) expired(key string) (bool, error) {
+	recordKey, err := e.recordKey(key)
+	if err != nil {
+		return false, err
+	}
+
+	bb, err := e.stub.GetState(recordKey)
+	if err != nil || len(bb) == 0 {
+		return false, err
+	}
+
+	record := &ExpiryRecord{}
+	if err = json.Unmarshal(bb, record); err != nil {
+		return false, fmt.Errorf(`unmarshal expiry record %s: %w`, recordKey, err)
+	}
+
+	now, err := txTime(e.stub)
+	if err != nil {
+		return false, err
+	}
+	return !now.Before(record.ExpiresAt), nil
+}
+
+func (e *Expiring) putRecord(record *ExpiryRecord) error {
+	recordKey, err := e.recordKey(record.Key)
+	if err != nil {
+		return err
+	}
+
+	bb, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return e.stub.PutState(recordKey, bb)
+}
+
+func (e *Expiring) deleteRecord(entry interface{}) error {
+	key, err := e.impl.Key(entry)
+	if err != nil {
+		return err
+	}
+
+	recordKey, err := e.recordKey(key.String)
+	if err != nil {
+		return err
+	}
+	return e.delIfExists(recordKey)
+}
+
+func (e *Expiring) delIfExists(key string) error {
+	bb, err := e.stub.GetState(key)
+	if err != nil || len(bb) == 0 {
+		return err
+	}
+	return e.stub.DelState(key)
+}
+
+// recordKey returns key of expiry record, state key is hex encoded, cause composite key can't be attribute
+func (e *Expiring) recordKey(key string) (string, error) {
+	return e.stub.CreateCompositeKey(ExpiryObjectType, []string{hex.EncodeToString([]byte(key))})
+}
+
+func txTime(stub shim.ChaincodeStubInterface) (time.Time, error) {
+	txTimestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, `get tx timestamp`)
+	}
+	return ptypes.Timestamp(txTimestamp)
+}
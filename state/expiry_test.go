@@ -0,0 +1,100 @@
+package state_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/router"
+	p "github.com/s7techlab/cckit/router/param"
+	"github.com/s7techlab/cckit/state"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+type (
+	Offer struct {
+		ID    string
+		Price int
+	}
+
+	SweepResult struct {
+		Deleted  int
+		Bookmark string
+	}
+)
+
+func NewOffersCC(ttl time.Duration) *router.Chaincode {
+	r := router.New(`offers`)
+
+	r.Invoke(`offerCreate`, func(c router.Context) (interface{}, error) {
+		offer := Offer{ID: c.ParamString(`id`), Price: c.ParamInt(`price`)}
+		return offer, state.WithExpiry(c.State()).PutWithTTL([]string{`OFFER`, offer.ID}, offer, ttl)
+	}, p.String(`id`), p.Int(`price`))
+
+	r.Query(`offerGet`, func(c router.Context) (interface{}, error) {
+		return state.WithExpiry(c.State()).Get([]string{`OFFER`, c.ParamString(`id`)}, &Offer{})
+	}, p.String(`id`))
+
+	r.Query(`offerList`, func(c router.Context) (interface{}, error) {
+		return state.WithExpiry(c.State()).List(`OFFER`, &Offer{})
+	})
+
+	r.Invoke(`offersSweep`, func(c router.Context) (interface{}, error) {
+		deleted, bookmark, err := state.WithExpiry(c.State()).SweepExpired(c.ParamInt(`limit`), c.ParamString(`bookmark`))
+		return SweepResult{Deleted: deleted, Bookmark: bookmark}, err
+	}, p.Int(`limit`), p.String(`bookmark`))
+
+	return router.NewChaincode(r)
+}
+
+var _ = Describe(`State expiry`, func() {
+
+	var (
+		clock *testcc.MockClock
+		cc    *testcc.MockStub
+	)
+
+	BeforeEach(func() {
+		clock = testcc.NewMockClock(time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC))
+		cc = testcc.NewMockStub(`offers`, NewOffersCC(time.Hour), testcc.WithClock(clock))
+
+		for _, id := range []string{`1`, `2`, `3`} {
+			expectcc.ResponseOk(cc.Invoke(`offerCreate`, id, 100))
+		}
+	})
+
+	It("Allow to get entry before expiry", func() {
+		clock.Advance(59 * time.Minute)
+		Expect(expectcc.PayloadIs(cc.Query(`offerGet`, `1`), &Offer{})).To(Equal(Offer{ID: `1`, Price: 100}))
+		Expect(expectcc.PayloadIs(cc.Query(`offerList`), &[]Offer{})).To(HaveLen(3))
+	})
+
+	It("Disallow to get entry after expiry", func() {
+		clock.Advance(time.Hour)
+		expectcc.ResponseError(cc.Query(`offerGet`, `1`), state.ErrKeyNotFound)
+		Expect(expectcc.PayloadIs(cc.Query(`offerList`), &[]Offer{})).To(BeEmpty())
+
+		// entry is still in state, until it's swept
+		Expect(cc.State).To(HaveLen(6))
+	})
+
+	It("Allow to sweep expired entries with limit per tx", func() {
+		clock.Advance(30 * time.Minute)
+		expectcc.ResponseOk(cc.Invoke(`offerCreate`, `4`, 200))
+		clock.Advance(45 * time.Minute)
+
+		first := expectcc.PayloadIs(cc.Invoke(`offersSweep`, 2, ``), &SweepResult{}).(SweepResult)
+		Expect(first.Deleted).To(Equal(2))
+		Expect(first.Bookmark).NotTo(BeEmpty())
+
+		second := expectcc.PayloadIs(cc.Invoke(`offersSweep`, 2, first.Bookmark), &SweepResult{}).(SweepResult)
+		Expect(second.Deleted).To(Equal(1))
+		Expect(second.Bookmark).To(BeEmpty())
+
+		// not expired entry with its expiry record is kept
+		Expect(cc.State).To(HaveLen(2))
+		Expect(expectcc.PayloadIs(cc.Query(`offerList`), &[]Offer{})).To(Equal([]Offer{{ID: `4`, Price: 200}}))
+	})
+})
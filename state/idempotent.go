@@ -0,0 +1,96 @@
+package state
+
+import (
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/pkg/errors"
+	"github.com/s7techlab/cckit/convert"
+)
+
+// IdempotencyObjectType composite key object type of idempotency records
+const IdempotencyObjectType = `IDEMPOTENCY`
+
+type (
+	// Idempotency state wrapper, running tx logic once per idempotency key
+	Idempotency struct {
+		State
+		stub shim.ChaincodeStubInterface
+		ttl  time.Duration
+	}
+
+	// IdempotencyRecord stored result of tx logic, executed with idempotency key
+	IdempotencyRecord struct {
+		TxID      string    `json:"txId"`
+		Timestamp time.Time `json:"timestamp"`
+		Result    []byte    `json:"result"`
+	}
+)
+
+// WithIdempotency returns state with idempotency key helper
+func WithIdempotency(ss State) *Idempotency {
+	s := ss.(*Impl)
+	return &Idempotency{
+		State: s,
+		stub:  s.stub,
+	}
+}
+
+// WithTTL sets idempotency records lifetime, relative to tx timestamp. Records without TTL never expire
+func (i *Idempotency) WithTTL(ttl time.Duration) *Idempotency {
+	i.ttl = ttl
+	return i
+}
+
+// Idempotent runs fn once per idempotency key: if record for key exists, stored result is returned,
+// converted to target type (as []byte, if target is not set). Otherwise fn result is stored
+// with tx metadata in current tx, so record commits atomically with fn writes
+func (i *Idempotency) Idempotent(key string, fn func() (interface{}, error), target ...interface{}) (interface{}, error) {
+	recordKey := []string{IdempotencyObjectType, key}
+
+	txTime, err := txTime(i.stub)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := i.State.Get(recordKey, &IdempotencyRecord{}, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, `get idempotency record`)
+	}
+
+	if stored != nil {
+		record := stored.(IdempotencyRecord)
+		if i.ttl == 0 || !txTime.After(record.Timestamp.Add(i.ttl)) {
+			return storedResult(record.Result, target...)
+		}
+	}
+
+	result, err := fn()
+	if err != nil {
+		return nil, err
+	}
+
+	record := IdempotencyRecord{
+		TxID:      i.stub.GetTxID(),
+		Timestamp: txTime,
+	}
+	if record.Result, err = convert.ToBytes(result); err != nil {
+		return nil, errors.Wrap(err, `convert idempotent result`)
+	}
+
+	if err = i.State.Put(recordKey, record); err != nil {
+		return nil, errors.Wrap(err, `put idempotency record`)
+	}
+
+	return result, nil
+}
+
+func storedResult(result []byte, target ...interface{}) (interface{}, error) {
+	if result == nil {
+		return nil, nil
+	}
+	if len(target) == 0 {
+		return result, nil
+	}
+	return convert.FromBytes(result, target[0])
+}
@@ -0,0 +1,65 @@
+package state_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/router"
+	p "github.com/s7techlab/cckit/router/param"
+	"github.com/s7techlab/cckit/state"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+type Payment struct {
+	ID     string
+	Amount int
+}
+
+func NewPaymentsCC(executions *int, ttl time.Duration) *router.Chaincode {
+	r := router.New(`payments`)
+
+	r.Invoke(`pay`, func(c router.Context) (interface{}, error) {
+		return state.WithIdempotency(c.State()).WithTTL(ttl).Idempotent(c.ParamString(`idempotencyKey`),
+			func() (interface{}, error) {
+				*executions++
+				payment := Payment{ID: c.Stub().GetTxID(), Amount: c.ParamInt(`amount`)}
+				return payment, c.State().Put(`payment`+payment.ID, payment)
+			}, &Payment{})
+	}, p.String(`idempotencyKey`), p.Int(`amount`))
+
+	return router.NewChaincode(r)
+}
+
+var _ = Describe(`Idempotency`, func() {
+
+	It("Allow to execute fn once per idempotency key", func() {
+		var executions int
+		cc := testcc.NewMockStub(`payments`, NewPaymentsCC(&executions, 0))
+
+		first := expectcc.PayloadIs(cc.Invoke(`pay`, `key-1`, 100), &Payment{}).(Payment)
+		Expect(executions).To(Equal(1))
+
+		retry := expectcc.PayloadIs(cc.Invoke(`pay`, `key-1`, 100), &Payment{}).(Payment)
+		Expect(executions).To(Equal(1))
+		Expect(retry).To(Equal(first))
+
+		other := expectcc.PayloadIs(cc.Invoke(`pay`, `key-2`, 100), &Payment{}).(Payment)
+		Expect(executions).To(Equal(2))
+		Expect(other.ID).NotTo(Equal(first.ID))
+	})
+
+	It("Allow to execute fn again after idempotency record expiration", func() {
+		var executions int
+		cc := testcc.NewMockStub(`payments`, NewPaymentsCC(&executions, time.Hour))
+
+		expectcc.ResponseOk(cc.At(testcc.MustTime(`2020-01-01T10:00:00Z`)).Invoke(`pay`, `key-1`, 100))
+		expectcc.ResponseOk(cc.At(testcc.MustTime(`2020-01-01T10:30:00Z`)).Invoke(`pay`, `key-1`, 100))
+		Expect(executions).To(Equal(1))
+
+		expectcc.ResponseOk(cc.At(testcc.MustTime(`2020-01-01T11:30:00Z`)).Invoke(`pay`, `key-1`, 100))
+		Expect(executions).To(Equal(2))
+	})
+})
@@ -0,0 +1,92 @@
+package state
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+type (
+	// KVHandler handles iterator entry, returns stop = true to finish iteration early
+	KVHandler func(kv *queryresult.KV) (stop bool, err error)
+
+	// KVDecoder decodes iterator entry value
+	KVDecoder func(value []byte) (interface{}, error)
+)
+
+// IterateKV calls fn for each iterator entry until iterator is exhausted, fn returns stop or error.
+// Iterator is closed exactly once when IterateKV returns, close error is returned if iteration succeeded
+func IterateKV(iter shim.StateQueryIteratorInterface, fn KVHandler) (err error) {
+	defer func() {
+		if closeErr := iter.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf(`close iterator: %w`, closeErr)
+		}
+	}()
+
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return fmt.Errorf(`get key value: %w`, err)
+		}
+
+		stop, err := fn(kv)
+		if err != nil {
+			return err
+		}
+		if stop {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// Collect decodes iterator entry values into slice, at most limit entries are collected (all, if limit <= 0).
+// Iterator is closed when Collect returns
+func Collect(iter shim.StateQueryIteratorInterface, decode KVDecoder, limit int) ([]interface{}, error) {
+	var items []interface{}
+
+	err := IterateKV(iter, func(kv *queryresult.KV) (bool, error) {
+		item, err := decode(kv.Value)
+		if err != nil {
+			return false, fmt.Errorf(`decode entry %s: %w`, kv.Key, err)
+		}
+		items = append(items, item)
+		return limit > 0 && len(items) >= limit, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// Paginate returns page of iterator entries, starting from bookmark key, for queries without pagination support
+// in shim (i.e. private data queries). Bookmark of next page is the key to start next page from,
+// it's empty for last page. Page size 0 means all entries. Iterator is closed when Paginate returns
+func Paginate(iter shim.StateQueryIteratorInterface, pageSize int32, bookmark string) (
+	[]*queryresult.KV, *pb.QueryResponseMetadata, error) {
+	var (
+		page []*queryresult.KV
+		next string
+	)
+
+	err := IterateKV(iter, func(kv *queryresult.KV) (bool, error) {
+		if kv.Key < bookmark {
+			return false, nil
+		}
+		if pageSize > 0 && len(page) == int(pageSize) {
+			next = kv.Key
+			return true, nil
+		}
+		page = append(page, kv)
+		return false, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return page, &pb.QueryResponseMetadata{FetchedRecordsCount: int32(len(page)), Bookmark: next}, nil
+}
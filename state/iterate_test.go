@@ -0,0 +1,212 @@
+package state_test
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"go.uber.org/zap"
+
+	"github.com/s7techlab/cckit/state"
+	testcc "github.com/s7techlab/cckit/testing"
+)
+
+var errDecode = errors.New(`decode failed`)
+
+// countingIterator counts Next and Close calls
+type countingIterator struct {
+	items  []*queryresult.KV
+	pos    int
+	nexts  int
+	closes int
+}
+
+func newCountingIterator(values ...string) *countingIterator {
+	iter := &countingIterator{}
+	for i, v := range values {
+		iter.items = append(iter.items, &queryresult.KV{Key: fmt.Sprintf(`key%d`, i), Value: []byte(v)})
+	}
+	return iter
+}
+
+func (iter *countingIterator) HasNext() bool {
+	return iter.pos < len(iter.items)
+}
+
+func (iter *countingIterator) Next() (*queryresult.KV, error) {
+	iter.nexts++
+	kv := iter.items[iter.pos]
+	iter.pos++
+	return kv, nil
+}
+
+func (iter *countingIterator) Close() error {
+	iter.closes++
+	return nil
+}
+
+func decodeInt(value []byte) (interface{}, error) {
+	n, err := strconv.Atoi(string(value))
+	if err != nil {
+		return nil, errDecode
+	}
+	return n, nil
+}
+
+var _ = Describe(`Iterate`, func() {
+
+	It("Allow to iterate all entries", func() {
+		iter := newCountingIterator(`1`, `2`, `3`)
+		var keys []string
+
+		Expect(state.IterateKV(iter, func(kv *queryresult.KV) (bool, error) {
+			keys = append(keys, kv.Key)
+			return false, nil
+		})).To(Succeed())
+
+		Expect(keys).To(Equal([]string{`key0`, `key1`, `key2`}))
+		Expect(iter.closes).To(Equal(1))
+	})
+
+	It("Allow to stop iteration early", func() {
+		iter := newCountingIterator(`1`, `2`, `3`)
+
+		Expect(state.IterateKV(iter, func(kv *queryresult.KV) (bool, error) {
+			return kv.Key == `key1`, nil
+		})).To(Succeed())
+
+		Expect(iter.nexts).To(Equal(2))
+		Expect(iter.closes).To(Equal(1))
+	})
+
+	It("Disallow to continue iteration after handler error", func() {
+		iter := newCountingIterator(`1`, `2`, `3`)
+
+		err := state.IterateKV(iter, func(kv *queryresult.KV) (bool, error) {
+			return false, errDecode
+		})
+
+		Expect(errors.Is(err, errDecode)).To(BeTrue())
+		Expect(iter.nexts).To(Equal(1))
+		Expect(iter.closes).To(Equal(1))
+	})
+
+	It("Allow to collect decoded entries with limit", func() {
+		iter := newCountingIterator(`1`, `2`, `3`)
+
+		items, err := state.Collect(iter, decodeInt, 2)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(items).To(Equal([]interface{}{1, 2}))
+		Expect(iter.nexts).To(Equal(2))
+		Expect(iter.closes).To(Equal(1))
+	})
+
+	It("Allow to collect all entries without limit", func() {
+		iter := newCountingIterator(`1`, `2`, `3`)
+
+		items, err := state.Collect(iter, decodeInt, 0)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(items).To(HaveLen(3))
+		Expect(iter.closes).To(Equal(1))
+	})
+
+	It("Disallow to collect entries with decode error mid-stream", func() {
+		iter := newCountingIterator(`1`, `not-a-number`, `3`)
+
+		items, err := state.Collect(iter, decodeInt, 0)
+
+		Expect(errors.Is(err, errDecode)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring(`key1`))
+		Expect(items).To(BeNil())
+		Expect(iter.nexts).To(Equal(2))
+		Expect(iter.closes).To(Equal(1))
+	})
+
+	It("Allow to paginate entries starting from bookmark", func() {
+		iter := newCountingIterator(`1`, `2`, `3`, `4`, `5`)
+
+		page, metadata, err := state.Paginate(iter, 2, `key1`)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(page).To(HaveLen(2))
+		Expect(page[0].Key).To(Equal(`key1`))
+		Expect(metadata.Bookmark).To(Equal(`key3`))
+		Expect(metadata.FetchedRecordsCount).To(BeEquivalentTo(2))
+		Expect(iter.nexts).To(Equal(4))
+		Expect(iter.closes).To(Equal(1))
+	})
+
+	It("Allow to paginate last page with empty bookmark", func() {
+		page, metadata, err := state.Paginate(newCountingIterator(`1`, `2`, `3`), 3, ``)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(page).To(HaveLen(3))
+		Expect(metadata.Bookmark).To(BeEmpty())
+	})
+})
+
+var _ = Describe(`Private state pagination`, func() {
+
+	type Book struct {
+		Id    string `json:"id"`
+		Title string `json:"title"`
+	}
+
+	It("Allow to page through private state entries", func() {
+		stub := testcc.NewMockStub(`books`, nil)
+		stub.MockTransactionStart(`seed`)
+		st := state.NewState(stub, zap.NewNop())
+		for i := 0; i < 30; i++ {
+			id := fmt.Sprintf(`%03d`, i)
+			Expect(st.PutPrivate(`books`, []string{`BOOK`, id}, &Book{Id: id, Title: `title ` + id})).To(Succeed())
+		}
+		stub.MockTransactionEnd(`seed`)
+
+		stub.MockTransactionStart(`list`)
+		defer stub.MockTransactionEnd(`list`)
+		st = state.NewState(stub, zap.NewNop())
+
+		var (
+			books    []Book
+			pages    int
+			bookmark string
+		)
+		for {
+			list, metadata, err := st.ListPrivatePaginated(`books`, `BOOK`, 12, bookmark, &Book{})
+			Expect(err).NotTo(HaveOccurred())
+			for _, book := range list.([]interface{}) {
+				books = append(books, book.(Book))
+			}
+			pages++
+			if bookmark = metadata.Bookmark; bookmark == `` {
+				break
+			}
+		}
+
+		Expect(pages).To(Equal(3))
+		Expect(books).To(HaveLen(30))
+		Expect(books[12].Id).To(Equal(`012`))
+		Expect(books[29].Title).To(Equal(`title 029`))
+	})
+})
+
+var _ = Describe(`Padding`, func() {
+
+	It("Allow to pad numbers to sort them as strings in numeric order", func() {
+		Expect(state.PadUint(2, 4)).To(Equal(`0002`))
+		Expect(state.PadUint(2, 4) < state.PadUint(10, 4)).To(BeTrue())
+
+		n, err := state.UnpadUint(state.PadUint(10, 4))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n).To(BeEquivalentTo(10))
+
+		_, err = state.UnpadUint(`not-a-number`)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -25,4 +25,10 @@ var (
 
 	// ErrIndexReferenceNotFound occurs when trying to find entry by index
 	ErrIndexReferenceNotFound = errors.New(`index reference not found`)
+
+	// ErrNumericWidthMismatch occurs when numeric key attributes padding width differs from width, stored in state
+	ErrNumericWidthMismatch = errors.New(`numeric key attribute width mismatch`)
+
+	// ErrNumericAttrOverflow occurs when numeric key attribute value can't be padded to width
+	ErrNumericAttrOverflow = errors.New(`numeric key attribute exceeds width`)
 )
@@ -16,6 +16,9 @@ type (
 	EventMapping struct {
 		schema interface{}
 		name   string
+		// if set, event name is validated at registration
+		validateName  bool
+		nameMaxLength int
 	}
 
 	EventMappings map[string]*EventMapping
@@ -38,6 +41,8 @@ type (
 	EventMappingOpt func(*EventMapping)
 )
 
+// Add registers event mapping for schema. If WithEventNameValidation is set, event name is checked
+// with state.ValidateEventName and mapping with invalid event name panics at registration, before any event is set
 func (emm EventMappings) Add(schema interface{}, opts ...EventMappingOpt) EventMappings {
 	em := &EventMapping{
 		schema: schema,
@@ -48,10 +53,31 @@ func (emm EventMappings) Add(schema interface{}, opts ...EventMappingOpt) EventM
 	}
 
 	applyEventMappingDefaults(em)
+	if em.validateName {
+		if err := state.ValidateEventName(em.name, em.nameMaxLength); err != nil {
+			panic(fmt.Errorf(`event mapping %s: %w`, mapKey(schema), err))
+		}
+	}
 	emm[mapKey(schema)] = em
 	return emm
 }
 
+// EventName sets event name instead of default one, based on schema type name
+func EventName(name string) EventMappingOpt {
+	return func(em *EventMapping) {
+		em.name = name
+	}
+}
+
+// WithEventNameValidation enables event name validation at registration with state.ValidateEventName,
+// zero maxLength means unlimited length
+func WithEventNameValidation(maxLength int) EventMappingOpt {
+	return func(em *EventMapping) {
+		em.validateName = true
+		em.nameMaxLength = maxLength
+	}
+}
+
 func applyEventMappingDefaults(em *EventMapping) {
 	// default namespace based on type names
 	if len(em.name) == 0 {
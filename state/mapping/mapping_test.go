@@ -1,6 +1,8 @@
 package mapping_test
 
 import (
+	"errors"
+	"fmt"
 	"strings"
 	"testing"
 
@@ -72,7 +74,7 @@ var _ = Describe(`Mapping`, func() {
 		})
 
 		It("Allow to add data to chaincode state", func(done Done) {
-			events := compositeIDCC.EventSubscription()
+			events, _ := compositeIDCC.EventSubscription()
 			expectcc.ResponseOk(compositeIDCC.Invoke(`create`, create1))
 
 			Expect(<-events).To(BeEquivalentTo(&peer.ChaincodeEvent{
@@ -359,4 +361,80 @@ var _ = Describe(`Mapping`, func() {
 		})
 
 	})
+
+	Describe(`Delete cascade`, func() {
+		var cascadeCC *testcc.MockStub
+
+		It("Allow to delete parent with children and index entries", func() {
+			cascadeCC = testcc.NewMockStub(`cascade`, testdata.NewCascadeCC())
+			expectcc.ResponseOk(cascadeCC.From(Owner).Init())
+
+			expectcc.ResponseOk(cascadeCC.Invoke(`createParent`, &schema.CreateEntityWithIndexes{
+				Id:                  `parent`,
+				ExternalId:          `parent_ext`,
+				OptionalExternalIds: []string{`parent_opt`},
+			}))
+
+			for i, date := range testdata.Dates {
+				expectcc.ResponseOk(cascadeCC.Invoke(`createChild`, &schema.CreateEntityWithCompositeId{
+					IdFirstPart:  `parent`,
+					IdSecondPart: fmt.Sprint(i),
+					IdThirdPart:  testcc.MustTime(date + `T00:00:00Z`),
+					Name:         fmt.Sprintf(`parent_child_%d`, i),
+				}))
+			}
+			// another parent child
+			expectcc.ResponseOk(cascadeCC.Invoke(`createChild`, &schema.CreateEntityWithCompositeId{
+				IdFirstPart:  `other`,
+				IdSecondPart: `1`,
+				IdThirdPart:  testcc.MustTime(testdata.Dates[0] + `T00:00:00Z`),
+				Name:         `other_child`,
+			}))
+
+			// owner, parent, 2 parent index entries, 4 children with index entries
+			Expect(cascadeCC.State).To(HaveLen(12))
+
+			expectcc.PayloadInt(cascadeCC.Invoke(`deleteCascade`, `parent`), 3)
+
+			// owner and other parent child with index entry
+			Expect(cascadeCC.State).To(HaveLen(3))
+			for key := range cascadeCC.State {
+				Expect(key).NotTo(ContainSubstring(`parent`))
+			}
+		})
+
+		It("Disallow to delete cascade non existent parent", func() {
+			expectcc.ResponseError(cascadeCC.Invoke(`deleteCascade`, `parent`), state.ErrKeyNotFound)
+		})
+	})
+
+	Describe(`Event mapping`, func() {
+
+		It("Disallow to register event mapping with invalid event name", func() {
+			var recovered interface{}
+			func() {
+				defer func() { recovered = recover() }()
+				mapping.EventMappings{}.Add(&schema.CreateEntityWithCompositeId{}, mapping.EventName("Created\nEntity"),
+					mapping.WithEventNameValidation(state.DefaultEventNameMaxLength))
+			}()
+
+			err, ok := recovered.(error)
+			Expect(ok).To(BeTrue())
+			Expect(errors.Is(err, state.ErrEventNameInvalid)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring(`byte 0x0a at offset 7`))
+		})
+
+		It("Allow to register event mapping without event name validation by default", func() {
+			mappings := mapping.EventMappings{}.Add(&schema.CreateEntityWithCompositeId{}, mapping.EventName("Created\nEntity"))
+			Expect(mappings.Exists(&schema.CreateEntityWithCompositeId{})).To(BeTrue())
+		})
+
+		It("Allow to register event mapping with custom event name", func() {
+			mappings := mapping.EventMappings{}.Add(&schema.CreateEntityWithCompositeId{}, mapping.EventName(`Entity.Created`))
+
+			mapper, err := mappings.Get(&schema.CreateEntityWithCompositeId{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(mapper.Name(nil)).To(Equal(`Entity.Created`))
+		})
+	})
 })
@@ -0,0 +1,194 @@
+package mapping
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/s7techlab/cckit/state"
+)
+
+// NumericWidthKeyPrefix prefix of state key, containing padding width of numeric primary key attributes of mapping
+const NumericWidthKeyPrefix = `_numeric`
+
+// NumericAttr pads integer attributes of primary key and field indexes with zeros to width on write,
+// so listings by primary key and index are returned in numeric order, not in byte order (10 before 2).
+// Key values, passed to ListWith and GetByKey, are padded the same way.
+// Width is stored in state with first write, changed width is detected on write and list
+func NumericAttr(width int) StateMappingOpt {
+	return func(sm *StateMapping, smm StateMappings) {
+		sm.numericWidth = width
+	}
+}
+
+// numericAttrsKeyer creates instance keyer, integer attributes are padded to width
+func numericAttrsKeyer(attrs []string, width int) InstanceKeyer {
+	return func(instance interface{}) (state.Key, error) {
+		inst := reflect.Indirect(reflect.ValueOf(instance))
+
+		var key = state.Key{}
+		for _, attr := range attrs {
+			v := inst.FieldByName(attr)
+			if !v.IsValid() {
+				return nil, fmt.Errorf(`%s: %s`, ErrFieldNotExists, attr)
+			}
+
+			var n uint64
+			switch v.Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				if v.Int() < 0 {
+					return nil, fmt.Errorf(`%w: %s.%s is negative`, ErrNumericAttrOverflow, mapKey(instance), attr)
+				}
+				n = uint64(v.Int())
+			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+				n = v.Uint()
+			default:
+				// non numeric attributes are keyed as usual
+				part, err := keyFromValue(v)
+				if err != nil {
+					return nil, fmt.Errorf(`key from field %s.%s: %s`, mapKey(instance), attr, err)
+				}
+				key = key.Append(part)
+				continue
+			}
+
+			padded := state.PadUint(n, width)
+			if len(padded) > width {
+				return nil, fmt.Errorf(`%w: %s.%s=%d, width %d`, ErrNumericAttrOverflow, mapKey(instance), attr, n, width)
+			}
+			key = append(key, padded)
+		}
+		return key, nil
+	}
+}
+
+// numericWidthKey returns key of padding width of numeric primary key attributes
+func numericWidthKey(m StateMapper) state.Key {
+	return append(state.Key{NumericWidthKeyPrefix}, m.Namespace()...)
+}
+
+// checkNumericWidth checks padding width of mapping against width, stored in state.
+// On write width is stored, if not stored yet
+func (s *Impl) checkNumericWidth(m StateMapper, write bool) error {
+	if m.NumericWidth() == 0 {
+		return nil
+	}
+
+	stored, err := s.State.Get(numericWidthKey(m), ``, ``)
+	if err != nil {
+		return fmt.Errorf(`get numeric attributes width: %w`, err)
+	}
+
+	if stored.(string) == `` {
+		if !write {
+			return nil
+		}
+		return s.State.Put(numericWidthKey(m), strconv.Itoa(m.NumericWidth()))
+	}
+
+	if width, _ := strconv.Atoi(stored.(string)); width != m.NumericWidth() {
+		return fmt.Errorf(`%w: %s padded to %d, mapping width %d`,
+			ErrNumericWidthMismatch, m.Namespace().String(), width, m.NumericWidth())
+	}
+	return nil
+}
+
+// PrimaryKeys returns primary key attributes of all mapped entries (without namespace),
+// padding of numeric attributes is stripped
+func (s *Impl) PrimaryKeys(schema interface{}) ([]state.Key, error) {
+	m, err := s.mappings.Get(schema)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.checkNumericWidth(m, false); err != nil {
+		return nil, err
+	}
+
+	keys, err := s.State.Keys(m.Namespace())
+	if err != nil {
+		return nil, err
+	}
+
+	numeric := numericKeyParts(m)
+	var primaryKeys []state.Key
+	for _, k := range keys {
+		// composite key is \x00objectType\x00attr1\x00...attrN\x00
+		key := state.Key(strings.Split(strings.Trim(k, "\x00"), "\x00"))[len(m.Namespace()):]
+		for i := range key {
+			if !numeric[i] {
+				continue
+			}
+			n, err := state.UnpadUint(key[i])
+			if err != nil {
+				return nil, fmt.Errorf(`primary key %s: %w`, k, err)
+			}
+			key[i] = strconv.FormatUint(n, 10)
+		}
+		primaryKeys = append(primaryKeys, key)
+	}
+	return primaryKeys, nil
+}
+
+// numericKeyParts returns positions of padded numeric attributes in primary key
+func numericKeyParts(m StateMapper) map[int]bool {
+	sm, ok := m.(*StateMapping)
+	if !ok || sm.numericWidth == 0 {
+		return nil
+	}
+	return numericAttrsParts(sm.schema, sm.primaryAttrs)
+}
+
+// numericIndexParts returns positions of padded numeric attributes in index key
+func numericIndexParts(m StateMapper, idx string) map[int]bool {
+	sm, ok := m.(*StateMapping)
+	if !ok || sm.numericWidth == 0 {
+		return nil
+	}
+	index := sm.Index(idx)
+	if index == nil {
+		return nil
+	}
+	return numericAttrsParts(sm.schema, index.attrs)
+}
+
+// padNumericKey pads numeric parts of key values, passed as decimal strings, to width of mapping
+func padNumericKey(m StateMapper, key state.Key, numeric map[int]bool) state.Key {
+	if len(numeric) == 0 {
+		return key
+	}
+
+	padded := make(state.Key, len(key))
+	copy(padded, key)
+	for i := range padded {
+		if !numeric[i] {
+			continue
+		}
+		if n, err := strconv.ParseUint(padded[i], 10, 64); err == nil {
+			padded[i] = state.PadUint(n, m.NumericWidth())
+		}
+	}
+	return padded
+}
+
+// numericAttrsParts returns positions of numeric attributes in key, created from attributes
+func numericAttrsParts(schemaInstance interface{}, attrs []string) map[int]bool {
+	schema := reflect.Indirect(reflect.ValueOf(schemaInstance)).Type()
+	numeric := make(map[int]bool)
+	for i, attr := range attrs {
+		field, ok := schema.FieldByName(attr)
+		if !ok {
+			return numeric
+		}
+		switch field.Type.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			numeric[i] = true
+		case reflect.String, reflect.Bool:
+		default:
+			// attributes, keyed with multiple parts, shift positions of following attributes
+			return numeric
+		}
+	}
+	return numeric
+}
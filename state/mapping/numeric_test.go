@@ -0,0 +1,110 @@
+package mapping_test
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+
+	"github.com/s7techlab/cckit/state"
+	"github.com/s7techlab/cckit/state/mapping"
+	"github.com/s7techlab/cckit/state/mapping/testdata/schema"
+	testcc "github.com/s7techlab/cckit/testing"
+)
+
+var _ = Describe(`Numeric key attributes`, func() {
+
+	var stub *testcc.MockStub
+
+	numericMappings := func(width int) mapping.StateMappings {
+		return mapping.StateMappings{}.Add(&schema.EntityWithCompositeId{},
+			mapping.PKeyAttr(`Value`), mapping.NumericAttr(width), mapping.List(&schema.EntityWithCompositeIdList{}))
+	}
+
+	inTx := func(mappings mapping.StateMappings, fn func(st *mapping.Impl)) {
+		stub.MockTransactionStart(`tx`)
+		defer stub.MockTransactionEnd(`tx`)
+		fn(mapping.WrapState(state.NewState(stub, zap.NewNop()), mappings))
+	}
+
+	BeforeEach(func() {
+		stub = testcc.NewMockStub(`numeric`, nil)
+
+		inTx(numericMappings(4), func(st *mapping.Impl) {
+			for _, i := range rand.New(rand.NewSource(1)).Perm(15) {
+				Expect(st.Insert(&schema.EntityWithCompositeId{Value: int32(i + 1)})).To(Succeed())
+			}
+		})
+	})
+
+	It("Allow to list entities with numeric ids in natural order", func() {
+		inTx(numericMappings(4), func(st *mapping.Impl) {
+			list, err := st.List(&schema.EntityWithCompositeId{})
+			Expect(err).NotTo(HaveOccurred())
+
+			entities := list.(*schema.EntityWithCompositeIdList).Items
+			Expect(entities).To(HaveLen(15))
+			for i, entity := range entities {
+				Expect(entity.Value).To(BeEquivalentTo(i + 1))
+			}
+
+			keys, err := st.PrimaryKeys(&schema.EntityWithCompositeId{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(keys[1]).To(Equal(state.Key{`2`}))
+			Expect(keys[9]).To(Equal(state.Key{`10`}))
+
+			list, err = st.ListWith(&schema.EntityWithCompositeId{}, state.Key{`2`})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(list.(*schema.EntityWithCompositeIdList).Items).To(HaveLen(1))
+			Expect(list.(*schema.EntityWithCompositeIdList).Items[0].Value).To(BeEquivalentTo(2))
+		})
+	})
+
+	It("Disallow to write and list entities with changed padding width", func() {
+		inTx(numericMappings(6), func(st *mapping.Impl) {
+			err := st.Put(&schema.EntityWithCompositeId{Value: 16})
+			Expect(errors.Is(err, mapping.ErrNumericWidthMismatch)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring(`padded to 4, mapping width 6`))
+
+			_, err = st.List(&schema.EntityWithCompositeId{})
+			Expect(errors.Is(err, mapping.ErrNumericWidthMismatch)).To(BeTrue())
+		})
+	})
+
+	It("Disallow to write numeric id exceeding padding width", func() {
+		inTx(numericMappings(4), func(st *mapping.Impl) {
+			err := st.Put(&schema.EntityWithCompositeId{Value: 10000})
+			Expect(errors.Is(err, mapping.ErrNumericAttrOverflow)).To(BeTrue())
+		})
+	})
+	It("Allow to list and get entities by numeric index in natural order", func() {
+		indexMappings := mapping.StateMappings{}.Add(&schema.EntityWithIndexes{},
+			mapping.PKeyId(), mapping.UniqKey(`Value`), mapping.NumericAttr(4),
+			mapping.List(&schema.EntityWithIndexesList{}))
+
+		inTx(indexMappings, func(st *mapping.Impl) {
+			for _, i := range rand.New(rand.NewSource(1)).Perm(15) {
+				Expect(st.Insert(&schema.EntityWithIndexes{
+					Id: fmt.Sprintf(`id-%d`, i+1), Value: int32(i + 1)})).To(Succeed())
+			}
+		})
+
+		inTx(indexMappings, func(st *mapping.Impl) {
+			refKeys, err := st.State.Keys(state.Key{mapping.KeyRefNamespace, `EntityWithIndexes`, `Value`})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(refKeys).To(HaveLen(15))
+			for i, refKey := range refKeys {
+				_, attrs, err := stub.SplitCompositeKey(refKey)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(attrs[len(attrs)-1]).To(Equal(state.PadUint(uint64(i+1), 4)))
+			}
+
+			entity, err := st.GetByKey(&schema.EntityWithIndexes{}, `Value`, []string{`10`}, &schema.EntityWithIndexes{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entity.(*schema.EntityWithIndexes).Id).To(Equal(`id-10`))
+		})
+	})
+})
@@ -91,6 +91,10 @@ func (s *Impl) Put(entry interface{}, value ...interface{}) error {
 		return s.State.Put(entry, value...) // return as is
 	}
 
+	if err = s.checkNumericWidth(mapped.Mapper(), true); err != nil {
+		return err
+	}
+
 	// update ref keys
 	if len(mapped.Mapper().Indexes()) > 0 {
 		keyRefs, err := mapped.Keys() // key refs based on current entry value, defined by mapping indexes
@@ -148,6 +152,10 @@ func (s *Impl) Insert(entry interface{}, value ...interface{}) error {
 		return s.State.Insert(entry, value...) // return as is
 	}
 
+	if err = s.checkNumericWidth(mapped.Mapper(), true); err != nil {
+		return err
+	}
+
 	keyRefs, err := mapped.Keys() // key refs, defined by mapping indexes
 	if err != nil {
 		return err
@@ -173,6 +181,10 @@ func (s *Impl) List(entry interface{}, target ...interface{}) (interface{}, erro
 		return nil, errors.Wrap(err, `mapping`)
 	}
 
+	if err = s.checkNumericWidth(m, false); err != nil {
+		return nil, err
+	}
+
 	namespace := m.Namespace()
 	s.Logger().Debug(`state mapped LIST`, zap.String(`namespace`, namespace.String()))
 
@@ -188,6 +200,7 @@ func (s *Impl) ListWith(entry interface{}, key state.Key) (result interface{}, e
 		return nil, errors.Wrap(err, `mapping`)
 	}
 
+	key = padNumericKey(m, key, numericKeyParts(m))
 	namespace := m.Namespace()
 	s.Logger().Debug(`state mapped LIST`, zap.String(`namespace`, namespace.String()), zap.String(`list`, namespace.Append(key).String()))
 
@@ -202,9 +215,11 @@ func (s *Impl) GetByUniqKey(
 func (s *Impl) GetByKey(
 	entry interface{}, idx string, idxVal []string, target ...interface{}) (result interface{}, err error) {
 
-	if !s.mappings.Exists(entry) {
+	m, err := s.mappings.Get(entry)
+	if err != nil {
 		return nil, ErrStateMappingNotFound
 	}
+	idxVal = padNumericKey(m, idxVal, numericIndexParts(m, idx))
 
 	keyRef, err := s.State.Get(NewKeyRefIDMapped(entry, idx, idxVal), &schema.KeyRef{})
 	if err != nil {
@@ -247,6 +262,48 @@ func (s *Impl) Delete(entry interface{}) error {
 	return s.State.Delete(mapped)
 }
 
+// DeleteCascade deletes mapped parent entry with children entries, including their key refs.
+// Mapped entries are loaded and deleted as typed entries, so key refs of their indexes are deleted
+func (s *Impl) DeleteCascade(parentKey interface{}, childObjectTypes ...string) (int, error) {
+	mapped, err := s.mappings.Map(parentKey)
+	if err != nil { // mapping is not exists
+		return s.State.DeleteCascade(parentKey, childObjectTypes...)
+	}
+
+	key, err := mapped.Key()
+	if err != nil {
+		return 0, err
+	}
+
+	children, err := state.CascadeKeys(s, key, childObjectTypes...)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, child := range children {
+		s.Logger().Debug(`state mapped cascade DELETE`, zap.String(`key`, child.String()))
+		if err = s.deleteByKey(child); err != nil {
+			return 0, errors.Wrap(err, `delete child`)
+		}
+	}
+
+	return len(children), s.Delete(parentKey)
+}
+
+// deleteByKey deletes entry, loaded with mapping of key namespace, or deletes key as is, if mapping not exists
+func (s *Impl) deleteByKey(key state.Key) error {
+	mapper, err := s.mappings.GetByNamespace(key[:1])
+	if err != nil {
+		return s.State.Delete([]string(key))
+	}
+
+	entry, err := s.State.Get([]string(key), mapper.Schema())
+	if err != nil {
+		return err
+	}
+	return s.Delete(entry)
+}
+
 func (s *Impl) Logger() *zap.Logger {
 	return s.State.Logger()
 }
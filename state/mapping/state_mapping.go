@@ -31,6 +31,8 @@ type (
 		//KeyerFor returns target entity if mapper is key mapper
 		KeyerFor() (schema interface{})
 		Indexes() []*StateIndex
+		// NumericWidth returns padding width of numeric primary key attributes, 0 if not padded
+		NumericWidth() int
 	}
 
 	// InstanceKeyer returns key of an state entry instance
@@ -48,6 +50,8 @@ type (
 		namespace      state.Key     // prefix for primary key
 		keyerForSchema interface{}   // schema is keyer for another schema ( for example *schema.StaffId for *schema.Staff )
 		primaryKeyer   InstanceKeyer // primary key always one
+		primaryAttrs   []string      // attributes of primary key, if key is built from attributes
+		numericWidth   int           // padding width of numeric primary key attributes
 		list           interface{}   // list schema
 		indexes        []*StateIndex // additional keys
 	}
@@ -58,6 +62,7 @@ type (
 		Uniq     bool
 		Required bool
 		Keyer    InstanceMultiKeyer // index can have multiple keys
+		attrs    []string           // fields of index key, if index is keyed by fields
 	}
 
 	StateIndexDef struct {
@@ -96,6 +101,17 @@ func applyStateMappingDefaults(sm *StateMapping) {
 	if len(sm.namespace) == 0 {
 		sm.namespace = SchemaNamespace(sm.schema)
 	}
+
+	if sm.numericWidth > 0 && len(sm.primaryAttrs) > 0 {
+		sm.primaryKeyer = numericAttrsKeyer(sm.primaryAttrs, sm.numericWidth)
+	}
+	if sm.numericWidth > 0 {
+		for _, idx := range sm.indexes {
+			if len(idx.attrs) > 0 {
+				idx.Keyer = keyerAsMulti(numericAttrsKeyer(idx.attrs, sm.numericWidth))
+			}
+		}
+	}
 }
 
 func SchemaNamespace(schema interface{}) state.Key {
@@ -169,6 +185,10 @@ func (sm *StateMapping) Indexes() []*StateIndex {
 	return sm.indexes
 }
 
+func (sm *StateMapping) NumericWidth() int {
+	return sm.numericWidth
+}
+
 func (sm *StateMapping) Schema() interface{} {
 	return sm.schema
 }
@@ -55,7 +55,10 @@ func WithIndex(idx *StateIndexDef) StateMappingOpt {
 			return
 		}
 
-		var keyer InstanceMultiKeyer
+		var (
+			keyer InstanceMultiKeyer
+			attrs []string
+		)
 		if idx.Keyer != nil {
 			keyer = idx.Keyer
 		} else {
@@ -68,6 +71,7 @@ func WithIndex(idx *StateIndexDef) StateMappingOpt {
 				keyer = attrMultiKeyer(aa[0])
 			} else {
 				keyer = keyerAsMulti(attrsKeyer(aa))
+				attrs = aa
 			}
 		}
 
@@ -76,6 +80,7 @@ func WithIndex(idx *StateIndexDef) StateMappingOpt {
 			Uniq:     true,
 			Required: idx.Required,
 			Keyer:    keyer,
+			attrs:    attrs,
 		})
 	}
 }
@@ -87,6 +92,7 @@ func PKeySchema(pkeySchema interface{}) StateMappingOpt {
 
 	return func(sm *StateMapping, smm StateMappings) {
 		sm.primaryKeyer = attrsKeyer(attrs)
+		sm.primaryAttrs = attrs
 
 		//add mapping namespace for id schema same as schema
 		smm.Add(pkeySchema, StateNamespace(SchemaNamespace(sm.schema)), PKeyAttr(attrs...), KeyerFor(sm.schema))
@@ -96,6 +102,7 @@ func PKeySchema(pkeySchema interface{}) StateMappingOpt {
 func PKeyAttr(attrs ...string) StateMappingOpt {
 	return func(sm *StateMapping, smm StateMappings) {
 		sm.primaryKeyer = attrsKeyer(attrs)
+		sm.primaryAttrs = attrs
 	}
 }
 
@@ -110,6 +117,7 @@ func PKeyConst(key state.Key) StateMappingOpt {
 		sm.primaryKeyer = func(instance interface{}) (state.Key, error) {
 			return key, nil
 		}
+		sm.primaryAttrs = nil
 	}
 }
 
@@ -118,6 +126,7 @@ func PKeyConst(key state.Key) StateMappingOpt {
 func PKeyComplexId(pkeySchema interface{}) StateMappingOpt {
 	return func(sm *StateMapping, smm StateMappings) {
 		sm.primaryKeyer = attrsKeyer([]string{`Id`})
+		sm.primaryAttrs = []string{`Id`}
 		smm.Add(pkeySchema,
 			StateNamespace(SchemaNamespace(sm.schema)),
 			PKeyAttr(attrsFrom(pkeySchema)...),
@@ -128,6 +137,7 @@ func PKeyComplexId(pkeySchema interface{}) StateMappingOpt {
 func PKeyer(pkeyer InstanceKeyer) StateMappingOpt {
 	return func(sm *StateMapping, smm StateMappings) {
 		sm.primaryKeyer = pkeyer
+		sm.primaryAttrs = nil
 	}
 }
 
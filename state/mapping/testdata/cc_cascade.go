@@ -0,0 +1,51 @@
+package testdata
+
+import (
+	"github.com/s7techlab/cckit/extensions/owner"
+	"github.com/s7techlab/cckit/router"
+	"github.com/s7techlab/cckit/router/param/defparam"
+	"github.com/s7techlab/cckit/state/mapping"
+	"github.com/s7techlab/cckit/state/mapping/testdata/schema"
+)
+
+var (
+	// CascadeStateMapping parent entity with indexes, children with composite id, first part is parent id,
+	// and uniq key
+	CascadeStateMapping = mapping.StateMappings{}.
+		Add(&schema.EntityWithIndexes{},
+			mapping.PKeyId(),
+			mapping.List(&schema.EntityWithIndexesList{}),
+			mapping.UniqKey(`ExternalId`),
+			mapping.WithIndex(&mapping.StateIndexDef{
+				Name:     `OptionalExternalIds`,
+				Required: false,
+				Multi:    true,
+			})).
+		Add(&schema.EntityWithCompositeId{},
+			mapping.PKeySchema(&schema.EntityCompositeId{}),
+			mapping.List(&schema.EntityWithCompositeIdList{}),
+			mapping.UniqKey(`Name`))
+)
+
+func NewCascadeCC() *router.Chaincode {
+	r := router.New("cascade")
+
+	r.Use(mapping.MapStates(CascadeStateMapping))
+
+	r.Use(mapping.MapEvents(mapping.EventMappings{}.
+		Add(&schema.CreateEntityWithCompositeId{})))
+
+	r.Init(owner.InvokeSetFromCreator)
+
+	r.
+		Invoke("createParent", invokeCreateIndexes, defparam.Proto(&schema.CreateEntityWithIndexes{})).
+		Invoke("createChild", invokeCreateComposite, defparam.Proto(&schema.CreateEntityWithCompositeId{})).
+		Invoke("deleteCascade", invokeDeleteCascade, defparam.String())
+
+	return router.NewChaincode(r)
+}
+
+func invokeDeleteCascade(c router.Context) (interface{}, error) {
+	return c.State().DeleteCascade(
+		&schema.EntityWithIndexes{Id: c.Param().(string)}, `EntityWithCompositeId`)
+}
@@ -0,0 +1,22 @@
+package state
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// PadUint returns decimal representation of n, left padded with zeros to width.
+// Padded numbers of the same width are ordered as strings in numeric order, so they can be used
+// as composite key attributes. Numbers longer than width are not truncated
+func PadUint(n uint64, width int) string {
+	return fmt.Sprintf(`%0*d`, width, n)
+}
+
+// UnpadUint decodes number, padded with PadUint
+func UnpadUint(s string) (uint64, error) {
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf(`unpad uint "%s": %w`, s, err)
+	}
+	return n, nil
+}
@@ -0,0 +1,27 @@
+package state
+
+import (
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// ExtensionsObjectType composite key object type, reserved for state of cckit extensions
+const ExtensionsObjectType = `_CCKIT`
+
+// ExtensionKey returns key in reserved extensions state: extension namespace and attributes
+func ExtensionKey(namespace string, attributes ...string) Key {
+	return append(Key{ExtensionsObjectType, namespace}, attributes...)
+}
+
+// ReservedKeysGuard can be implemented by chaincode stub guarding writes to keys reserved by extensions,
+// i.e. testing MockStub. Returned func restores guard
+type ReservedKeysGuard interface {
+	AllowReservedKeys() (restore func())
+}
+
+// WithReservedKeys runs fn with allowed access to reserved keys, used by extensions owning reserved keys
+func WithReservedKeys(stub shim.ChaincodeStubInterface, fn func() error) error {
+	if guard, ok := stub.(ReservedKeysGuard); ok {
+		defer guard.AllowReservedKeys()()
+	}
+	return fn()
+}
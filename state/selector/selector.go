@@ -0,0 +1,401 @@
+// Package selector provides builder of CouchDB rich queries, used with GetQueryResult
+package selector
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Operators, supported by builder
+const (
+	OpEq        = `$eq`
+	OpNe        = `$ne`
+	OpIn        = `$in`
+	OpNin       = `$nin`
+	OpAll       = `$all`
+	OpRegex     = `$regex`
+	OpElemMatch = `$elemMatch`
+	OpGt        = `$gt`
+	OpGte       = `$gte`
+	OpLt        = `$lt`
+	OpLte       = `$lte`
+	OpExists    = `$exists`
+	OpType      = `$type`
+	OpSize      = `$size`
+	OpAnd       = `$and`
+	OpOr        = `$or`
+	OpNor       = `$nor`
+	OpNot       = `$not`
+)
+
+// JSON type names of $type condition
+const (
+	TypeNull    = `null`
+	TypeBoolean = `boolean`
+	TypeNumber  = `number`
+	TypeString  = `string`
+	TypeArray   = `array`
+	TypeObject  = `object`
+)
+
+// Sort directions
+const (
+	SortAsc  = `asc`
+	SortDesc = `desc`
+)
+
+// ErrUnsupported occurs when query is built with unsupported operator combination or argument
+var ErrUnsupported = errors.New(`unsupported selector`)
+
+type (
+	// Query CouchDB rich query builder. First construction error is kept and returned by Build,
+	// all further builder calls are ignored
+	Query struct {
+		selector map[string]interface{}
+		// field conditions are applied to, empty for element query
+		field string
+		elem  bool
+		sort  []map[string]string
+		limit int
+		err   error
+	}
+
+	query struct {
+		Selector map[string]interface{} `json:"selector"`
+		Sort     []map[string]string    `json:"sort,omitempty"`
+		Limit    int                    `json:"limit,omitempty"`
+	}
+)
+
+// New creates query builder, conditions of different fields are combined with implicit $and
+func New() *Query {
+	return &Query{selector: make(map[string]interface{})}
+}
+
+// Elem creates builder of $elemMatch condition for array elements, which are not objects:
+// operators are applied to element itself, i.e. Elem().Eq(`red`)
+func Elem() *Query {
+	return &Query{selector: make(map[string]interface{}), elem: true}
+}
+
+// Field sets field, following conditions are applied to
+func (q *Query) Field(name string) *Query {
+	if q.err != nil {
+		return q
+	}
+	if q.elem {
+		return q.fail(`field %s in element query`, name)
+	}
+	if name == `` || strings.HasPrefix(name, `$`) {
+		return q.fail(`field name "%s"`, name)
+	}
+	if err := q.checkFieldConditions(); err != nil {
+		q.err = err
+		return q
+	}
+
+	q.field = name
+	return q
+}
+
+// Eq adds $eq condition
+func (q *Query) Eq(value interface{}) *Query {
+	return q.condition(OpEq, value)
+}
+
+// Ne adds $ne condition
+func (q *Query) Ne(value interface{}) *Query {
+	return q.condition(OpNe, value)
+}
+
+// In adds $in condition
+func (q *Query) In(values ...interface{}) *Query {
+	if values == nil {
+		values = []interface{}{}
+	}
+	return q.condition(OpIn, values)
+}
+
+// Nin adds $nin condition
+func (q *Query) Nin(values ...interface{}) *Query {
+	if values == nil {
+		values = []interface{}{}
+	}
+	return q.condition(OpNin, values)
+}
+
+// All adds $all condition, field array must contain all values
+func (q *Query) All(values ...interface{}) *Query {
+	if values == nil {
+		values = []interface{}{}
+	}
+	return q.condition(OpAll, values)
+}
+
+// Gt adds $gt condition
+func (q *Query) Gt(value interface{}) *Query {
+	return q.condition(OpGt, value)
+}
+
+// Gte adds $gte condition
+func (q *Query) Gte(value interface{}) *Query {
+	return q.condition(OpGte, value)
+}
+
+// Lt adds $lt condition
+func (q *Query) Lt(value interface{}) *Query {
+	return q.condition(OpLt, value)
+}
+
+// Lte adds $lte condition
+func (q *Query) Lte(value interface{}) *Query {
+	return q.condition(OpLte, value)
+}
+
+// Regex adds $regex condition, pattern must be valid regular expression
+func (q *Query) Regex(pattern string) *Query {
+	if _, err := regexp.Compile(pattern); err != nil && q.err == nil {
+		return q.fail(`$regex pattern: %s`, err)
+	}
+	return q.condition(OpRegex, pattern)
+}
+
+// Exists adds $exists condition, field must be present or absent
+func (q *Query) Exists(exists bool) *Query {
+	return q.condition(OpExists, exists)
+}
+
+// Type adds $type condition, type name must be one of JSON type names: TypeNull, TypeBoolean, ...
+func (q *Query) Type(name string) *Query {
+	switch name {
+	case TypeNull, TypeBoolean, TypeNumber, TypeString, TypeArray, TypeObject:
+	default:
+		if q.err == nil {
+			return q.fail(`$type %s`, name)
+		}
+	}
+	return q.condition(OpType, name)
+}
+
+// Size adds $size condition, field array must have size elements
+func (q *Query) Size(size int) *Query {
+	if size < 0 && q.err == nil {
+		return q.fail(`$size %d`, size)
+	}
+	return q.condition(OpSize, size)
+}
+
+// ElemMatch adds $elemMatch condition, built with New for object elements or Elem for other elements.
+// Sort and limit are not allowed in element query
+func (q *Query) ElemMatch(elem *Query) *Query {
+	if q.err != nil {
+		return q
+	}
+	if elem == nil {
+		return q.fail(`$elemMatch without element query`)
+	}
+
+	cond, err := elem.Selector()
+	if err != nil {
+		q.err = fmt.Errorf(`$elemMatch: %w`, err)
+		return q
+	}
+	if len(elem.sort) > 0 || elem.limit > 0 {
+		return q.fail(`$elemMatch with sort or limit`)
+	}
+	return q.condition(OpElemMatch, cond)
+}
+
+// And adds $and combination of sub queries, built with New
+func (q *Query) And(queries ...*Query) *Query {
+	return q.combination(OpAnd, queries)
+}
+
+// Or adds $or combination of sub queries, built with New
+func (q *Query) Or(queries ...*Query) *Query {
+	return q.combination(OpOr, queries)
+}
+
+// Nor adds $nor combination of sub queries, built with New
+func (q *Query) Nor(queries ...*Query) *Query {
+	return q.combination(OpNor, queries)
+}
+
+// Not adds $not condition with sub query, built with New
+func (q *Query) Not(query *Query) *Query {
+	return q.combination(OpNot, []*Query{query})
+}
+
+// SortAsc adds ascending sort by field
+func (q *Query) SortAsc(field string) *Query {
+	return q.addSort(field, SortAsc)
+}
+
+// SortDesc adds descending sort by field
+func (q *Query) SortDesc(field string) *Query {
+	return q.addSort(field, SortDesc)
+}
+
+// Limit sets maximum number of results, must be positive and set once
+func (q *Query) Limit(limit int) *Query {
+	if q.err != nil {
+		return q
+	}
+	if limit <= 0 || q.limit > 0 || q.elem {
+		return q.fail(`limit %d`, limit)
+	}
+	q.limit = limit
+	return q
+}
+
+// Err returns first construction error
+func (q *Query) Err() error {
+	return q.err
+}
+
+// Selector returns selector with JSON values, as they are decoded from query JSON
+func (q *Query) Selector() (map[string]interface{}, error) {
+	if q.err == nil {
+		q.err = q.checkFieldConditions()
+	}
+	if q.err != nil {
+		return nil, q.err
+	}
+	return q.selector, nil
+}
+
+// Build returns query JSON
+func (q *Query) Build() (string, error) {
+	selector, err := q.Selector()
+	if err != nil {
+		return ``, err
+	}
+
+	bb, err := json.Marshal(query{Selector: selector, Sort: q.sort, Limit: q.limit})
+	if err != nil {
+		return ``, errors.Wrap(err, `marshal query`)
+	}
+	return string(bb), nil
+}
+
+// MustBuild returns query JSON, panics on construction error
+func (q *Query) MustBuild() string {
+	s, err := q.Build()
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func (q *Query) condition(op string, arg interface{}) *Query {
+	if q.err != nil {
+		return q
+	}
+	if q.field == `` && !q.elem {
+		return q.fail(`%s without field`, op)
+	}
+
+	// arguments are normalized to decoded JSON values: numbers are float64, arrays are []interface{}
+	bb, err := json.Marshal(arg)
+	if err != nil {
+		return q.fail(`%s argument: %s`, op, err)
+	}
+	var value interface{}
+	if err = json.Unmarshal(bb, &value); err != nil {
+		return q.fail(`%s argument: %s`, op, err)
+	}
+
+	ops := q.selector
+	if !q.elem {
+		ops, _ = q.selector[q.field].(map[string]interface{})
+		if ops == nil {
+			ops = make(map[string]interface{})
+			q.selector[q.field] = ops
+		}
+	}
+	if _, exists := ops[op]; exists {
+		return q.fail(`duplicate %s on field "%s"`, op, q.field)
+	}
+
+	ops[op] = value
+	return q
+}
+
+// combination adds combination operator with sub query selectors, following conditions require field.
+// Sort and limit are not allowed in sub queries
+func (q *Query) combination(op string, queries []*Query) *Query {
+	if q.err != nil {
+		return q
+	}
+	if q.elem || len(queries) == 0 {
+		return q.fail(`%s without sub queries`, op)
+	}
+	if err := q.checkFieldConditions(); err != nil {
+		q.err = err
+		return q
+	}
+	if _, exists := q.selector[op]; exists {
+		return q.fail(`duplicate %s`, op)
+	}
+
+	selectors := make([]interface{}, len(queries))
+	for i, sub := range queries {
+		if sub == nil || sub.elem {
+			return q.fail(`%s with invalid sub query`, op)
+		}
+		selector, err := sub.Selector()
+		if err != nil {
+			q.err = fmt.Errorf(`%s: %w`, op, err)
+			return q
+		}
+		if len(sub.sort) > 0 || sub.limit > 0 {
+			return q.fail(`%s with sort or limit`, op)
+		}
+		selectors[i] = selector
+	}
+
+	q.field = ``
+	if op == OpNot {
+		q.selector[op] = selectors[0]
+	} else {
+		q.selector[op] = selectors
+	}
+	return q
+}
+
+func (q *Query) addSort(field, direction string) *Query {
+	if q.err != nil {
+		return q
+	}
+	if field == `` || q.elem {
+		return q.fail(`sort by "%s"`, field)
+	}
+	for _, s := range q.sort {
+		if _, exists := s[field]; exists {
+			return q.fail(`duplicate sort by "%s"`, field)
+		}
+	}
+
+	q.sort = append(q.sort, map[string]string{field: direction})
+	return q
+}
+
+// checkFieldConditions checks current field has conditions
+func (q *Query) checkFieldConditions() error {
+	if q.elem && len(q.selector) == 0 {
+		return fmt.Errorf(`%w: element query without conditions`, ErrUnsupported)
+	}
+	if q.field != `` && q.selector[q.field] == nil {
+		return fmt.Errorf(`%w: field "%s" without conditions`, ErrUnsupported, q.field)
+	}
+	return nil
+}
+
+func (q *Query) fail(format string, args ...interface{}) *Query {
+	q.err = fmt.Errorf(`%w: `+format, append([]interface{}{ErrUnsupported}, args...)...)
+	return q
+}
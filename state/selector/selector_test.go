@@ -0,0 +1,203 @@
+package selector_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/state"
+	"github.com/s7techlab/cckit/state/selector"
+	testcc "github.com/s7techlab/cckit/testing"
+)
+
+func TestSelector(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Selector suite")
+}
+
+func keys(stub *testcc.MockStub, query string, built *selector.Query) []string {
+	var byJSON, byQuery []string
+
+	iter, err := stub.GetQueryResult(query)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(state.IterateKV(iter, func(kv *queryresult.KV) (bool, error) {
+		byJSON = append(byJSON, kv.Key)
+		return false, nil
+	})).To(Succeed())
+
+	iter, err = stub.GetSelectorQueryResult(built)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(state.IterateKV(iter, func(kv *queryresult.KV) (bool, error) {
+		byQuery = append(byQuery, kv.Key)
+		return false, nil
+	})).To(Succeed())
+
+	Expect(byQuery).To(Equal(byJSON))
+	return byJSON
+}
+
+var _ = Describe(`Selector`, func() {
+
+	var stub *testcc.MockStub
+
+	BeforeEach(func() {
+		stub = testcc.NewMockStub(`assets`, nil)
+		Expect(stub.SeedState(map[string][]byte{
+			`a1`: []byte(`{"docType":"asset","owner":"Org1MSP","amount":10,"tags":["red","fast"]}`),
+			`a2`: []byte(`{"docType":"asset","owner":"Org2MSP","amount":20,"tags":["blue"]}`),
+			`a3`: []byte(`{"docType":"asset","owner":"Org3MSP","amount":10,"parts":[{"name":"wheel","count":4}]}`),
+			`o1`: []byte(`{"docType":"owner","owner":"Org1MSP"}`),
+		})).To(Succeed())
+	})
+
+	It("Allow to build query with equality and $in conditions, sort and limit", func() {
+		q := selector.New().
+			Field(`docType`).Eq(`asset`).
+			Field(`owner`).In(`Org1MSP`, `Org2MSP`).
+			SortDesc(`amount`).Limit(20)
+
+		query, err := q.Build()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(query).To(MatchJSON(`{"selector":{"docType":{"$eq":"asset"},"owner":{"$in":["Org1MSP","Org2MSP"]}},` +
+			`"sort":[{"amount":"desc"}],"limit":20}`))
+
+		Expect(keys(stub, query, q)).To(Equal([]string{`a2`, `a1`}))
+	})
+
+	It("Allow to limit built query results", func() {
+		q := selector.New().Field(`docType`).Eq(`asset`).Limit(2)
+
+		Expect(keys(stub, q.MustBuild(), q)).To(Equal([]string{`a1`, `a2`}))
+	})
+
+	It("Allow to build query with numeric and $regex conditions", func() {
+		q := selector.New().Field(`amount`).Eq(10).Field(`owner`).Regex(`^Org[13]`)
+
+		query := q.MustBuild()
+		Expect(query).To(MatchJSON(`{"selector":{"amount":{"$eq":10},"owner":{"$regex":"^Org[13]"}}}`))
+
+		Expect(keys(stub, query, q)).To(Equal([]string{`a1`, `a3`}))
+	})
+
+	It("Allow to build numeric range conditions", func() {
+		q := selector.New().Field(`amount`).Gt(5).Lte(10)
+
+		query := q.MustBuild()
+		Expect(query).To(MatchJSON(`{"selector":{"amount":{"$gt":5,"$lte":10}}}`))
+
+		Expect(keys(stub, query, q)).To(Equal([]string{`a1`, `a3`}))
+		Expect(keys(stub, selector.New().Field(`amount`).Gte(20).Lt(30).MustBuild(),
+			selector.New().Field(`amount`).Gte(20).Lt(30))).To(Equal([]string{`a2`}))
+
+		ne := selector.New().Field(`amount`).Ne(10)
+		Expect(ne.MustBuild()).To(MatchJSON(`{"selector":{"amount":{"$ne":10}}}`))
+		Expect(keys(stub, ne.MustBuild(), ne)).To(Equal([]string{`a2`}))
+	})
+
+	It("Allow to build $elemMatch queries for scalar and object elements", func() {
+		scalar := selector.New().Field(`tags`).ElemMatch(selector.Elem().In(`red`, `green`))
+		Expect(scalar.MustBuild()).To(MatchJSON(`{"selector":{"tags":{"$elemMatch":{"$in":["red","green"]}}}}`))
+		Expect(keys(stub, scalar.MustBuild(), scalar)).To(Equal([]string{`a1`}))
+
+		object := selector.New().Field(`parts`).ElemMatch(
+			selector.New().Field(`name`).Eq(`wheel`).Field(`count`).Eq(4))
+		Expect(object.MustBuild()).To(MatchJSON(
+			`{"selector":{"parts":{"$elemMatch":{"name":{"$eq":"wheel"},"count":{"$eq":4}}}}}`))
+		Expect(keys(stub, object.MustBuild(), object)).To(Equal([]string{`a3`}))
+	})
+
+	It("Allow to build $nin and $all conditions", func() {
+		nin := selector.New().Field(`docType`).Eq(`asset`).Field(`amount`).Nin(20, 30)
+		Expect(nin.MustBuild()).To(MatchJSON(`{"selector":{"docType":{"$eq":"asset"},"amount":{"$nin":[20,30]}}}`))
+		Expect(keys(stub, nin.MustBuild(), nin)).To(Equal([]string{`a1`, `a3`}))
+
+		all := selector.New().Field(`tags`).All(`red`)
+		Expect(all.MustBuild()).To(MatchJSON(`{"selector":{"tags":{"$all":["red"]}}}`))
+		Expect(keys(stub, all.MustBuild(), all)).To(Equal([]string{`a1`}))
+	})
+
+	It("Allow to build $exists, $type and $size conditions", func() {
+		exists := selector.New().Field(`tags`).Exists(true)
+		Expect(exists.MustBuild()).To(MatchJSON(`{"selector":{"tags":{"$exists":true}}}`))
+		Expect(keys(stub, exists.MustBuild(), exists)).To(Equal([]string{`a1`, `a2`}))
+
+		absent := selector.New().Field(`docType`).Eq(`asset`).Field(`tags`).Exists(false)
+		Expect(keys(stub, absent.MustBuild(), absent)).To(Equal([]string{`a3`}))
+
+		typed := selector.New().Field(`parts`).Type(selector.TypeArray)
+		Expect(typed.MustBuild()).To(MatchJSON(`{"selector":{"parts":{"$type":"array"}}}`))
+		Expect(keys(stub, typed.MustBuild(), typed)).To(Equal([]string{`a3`}))
+
+		size := selector.New().Field(`tags`).Type(selector.TypeArray).Size(2)
+		Expect(size.MustBuild()).To(MatchJSON(`{"selector":{"tags":{"$type":"array","$size":2}}}`))
+		Expect(keys(stub, size.MustBuild(), size)).To(Equal([]string{`a1`}))
+	})
+
+	It("Allow to combine sub queries with $and, $or, $nor and $not", func() {
+		or := selector.New().Field(`docType`).Eq(`asset`).Or(
+			selector.New().Field(`owner`).Eq(`Org1MSP`),
+			selector.New().Field(`amount`).Gte(20))
+		Expect(or.MustBuild()).To(MatchJSON(`{"selector":{"docType":{"$eq":"asset"},` +
+			`"$or":[{"owner":{"$eq":"Org1MSP"}},{"amount":{"$gte":20}}]}}`))
+		Expect(keys(stub, or.MustBuild(), or)).To(Equal([]string{`a1`, `a2`}))
+
+		and := selector.New().And(
+			selector.New().Field(`amount`).Eq(10),
+			selector.New().Field(`owner`).Ne(`Org1MSP`))
+		Expect(keys(stub, and.MustBuild(), and)).To(Equal([]string{`a3`}))
+
+		nor := selector.New().Nor(
+			selector.New().Field(`docType`).Eq(`owner`),
+			selector.New().Field(`amount`).Eq(20))
+		Expect(keys(stub, nor.MustBuild(), nor)).To(Equal([]string{`a1`, `a3`}))
+
+		not := selector.New().Not(selector.New().Field(`docType`).Eq(`asset`)).Field(`owner`).Regex(`^Org`)
+		Expect(not.MustBuild()).To(MatchJSON(
+			`{"selector":{"$not":{"docType":{"$eq":"asset"}},"owner":{"$regex":"^Org"}}}`))
+		Expect(keys(stub, not.MustBuild(), not)).To(Equal([]string{`o1`}))
+	})
+
+	It("Allow to build several conditions on one field", func() {
+		q := selector.New().Field(`owner`).In(`Org1MSP`, `Org3MSP`).Regex(`^Org3`)
+
+		Expect(q.MustBuild()).To(MatchJSON(`{"selector":{"owner":{"$in":["Org1MSP","Org3MSP"],"$regex":"^Org3"}}}`))
+		Expect(keys(stub, q.MustBuild(), q)).To(Equal([]string{`a3`}))
+	})
+
+	It("Disallow to build unsupported combinations", func() {
+		for name, q := range map[string]*selector.Query{
+			`condition without field`:          selector.New().Eq(`asset`),
+			`field without condition`:          selector.New().Field(`docType`),
+			`field switched without condition`: selector.New().Field(`docType`).Field(`owner`).Eq(`Org1MSP`),
+			`operator as field`:                selector.New().Field(`$or`).Eq(1),
+			`duplicate operator`:               selector.New().Field(`docType`).Eq(`asset`).Eq(`owner`),
+			`invalid regex`:                    selector.New().Field(`owner`).Regex(`(`),
+			`field in element query`:           selector.New().Field(`tags`).ElemMatch(selector.Elem().Field(`a`)),
+			`empty element query`:              selector.New().Field(`tags`).ElemMatch(selector.Elem()),
+			`element query with sort`: selector.New().Field(`parts`).ElemMatch(
+				selector.New().Field(`name`).Eq(`wheel`).SortAsc(`name`)),
+			`non positive limit`: selector.New().Field(`docType`).Eq(`asset`).Limit(0),
+			`duplicate sort`:     selector.New().Field(`docType`).Eq(`asset`).SortAsc(`n`).SortDesc(`n`),
+			`unknown type`:       selector.New().Field(`tags`).Type(`list`),
+			`negative size`:      selector.New().Field(`tags`).Size(-1),
+			`empty $or`:          selector.New().Or(),
+			`nil $not`:           selector.New().Not(nil),
+			`duplicate $or`: selector.New().Or(selector.New().Field(`a`).Eq(1)).
+				Or(selector.New().Field(`b`).Eq(1)),
+			`invalid sub query`:    selector.New().And(selector.New().Field(`a`)),
+			`sub query with limit`: selector.New().Or(selector.New().Field(`a`).Eq(1).Limit(1)),
+			`condition after $and`: selector.New().And(selector.New().Field(`a`).Eq(1)).Eq(2),
+			`field without condition before $or`: selector.New().Field(`a`).
+				Or(selector.New().Field(`b`).Eq(1)),
+		} {
+			_, err := q.Build()
+			Expect(errors.Is(err, selector.ErrUnsupported)).To(BeTrue(), name)
+
+			_, err = stub.GetSelectorQueryResult(q)
+			Expect(errors.Is(err, testcc.ErrQueryInvalid)).To(BeTrue(), name)
+		}
+	})
+})
@@ -2,9 +2,11 @@ package state
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
 	"github.com/pkg/errors"
 	"github.com/s7techlab/cckit/convert"
 	"go.uber.org/zap"
@@ -55,6 +57,11 @@ type State interface {
 	// namespace can be part of key (string or []string) or entity with defined mapping
 	List(namespace interface{}, target ...interface{}) (interface{}, error)
 
+	// ListPaginated returns page of slice of target type and metadata with bookmark of next page
+	// namespace can be part of key (string or []string) or entity with defined mapping
+	ListPaginated(namespace interface{}, pageSize int32, bookmark string, target ...interface{}) (
+		interface{}, *pb.QueryResponseMetadata, error)
+
 	// Keys returns slice of keys
 	// namespace can be part of key (string or []string) or entity with defined mapping
 	Keys(namespace interface{}) ([]string, error)
@@ -63,6 +70,11 @@ type State interface {
 	// entry can be Key (string or []string) or type implementing Keyer interface
 	Delete(entry interface{}) (err error)
 
+	// DeleteCascade deletes parent entry and all entries of child object types with composite key,
+	// which first attribute equals parent id (last part of parent key), returns count of deleted children
+	// parentKey can be Key (string or []string) or type implementing Keyer interface
+	DeleteCascade(parentKey interface{}, childObjectTypes ...string) (deletedChildren int, err error)
+
 	Logger() *zap.Logger
 
 	UseKeyTransformer(KeyTransformer) State
@@ -93,6 +105,12 @@ type State interface {
 	// if false, used public state for iterate over keys and GetPrivateData for each key
 	ListPrivate(collection string, usePrivateDataIterator bool, namespace interface{}, target ...interface{}) (interface{}, error)
 
+	// ListPrivatePaginated returns page of slice of target type from private state and metadata with bookmark
+	// of next page, pagination semantics is the same as in ListPaginated
+	// namespace can be part of key (string or []string) or entity with defined mapping
+	ListPrivatePaginated(collection string, namespace interface{}, pageSize int32, bookmark string, target ...interface{}) (
+		interface{}, *pb.QueryResponseMetadata, error)
+
 	// DeletePrivate returns result of deleting entry from private state
 	// entry can be Key (string or []string) or type implementing Keyer interface
 	DeletePrivate(collection string, entry interface{}) error
@@ -266,37 +284,77 @@ func (s *Impl) List(namespace interface{}, target ...interface{}) (interface{},
 		return nil, errors.Wrap(err, `state iterator`)
 	}
 
-	defer func() { _ = iter.Close() }()
-
 	return stateList.Fill(iter, s.StateGetTransformer)
 }
 
+// ListPaginated returns page of slice of target type, bookmark of next page is empty for last page
+func (s *Impl) ListPaginated(namespace interface{}, pageSize int32, bookmark string, target ...interface{}) (
+	interface{}, *pb.QueryResponseMetadata, error) {
+	stateList, err := NewStateList(target...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	objectType, attrs, err := s.namespaceKey(namespace)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		iter     shim.StateQueryIteratorInterface
+		metadata *pb.QueryResponseMetadata
+	)
+	if objectType == `` {
+		iter, metadata, err = s.stub.GetStateByRangeWithPagination(``, ``, pageSize, bookmark)
+	} else {
+		iter, metadata, err = s.stub.GetStateByPartialCompositeKeyWithPagination(objectType, attrs, pageSize, bookmark)
+	}
+	if err != nil {
+		return nil, nil, errors.Wrap(err, `state iterator`)
+	}
+
+	list, err := stateList.Fill(iter, s.StateGetTransformer)
+	if err != nil {
+		return nil, nil, err
+	}
+	return list, metadata, nil
+}
+
 func (s *Impl) createStateQueryIterator(namespace interface{}) (shim.StateQueryIteratorInterface, error) {
+	objectType, attrs, err := s.namespaceKey(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	if objectType == `` {
+		return s.stub.GetStateByRange(``, ``) // all state entries
+	}
+
+	return s.stub.GetStateByPartialCompositeKey(objectType, attrs)
+}
+
+// namespaceKey returns object type and attributes of transformed namespace key, empty object type for all entries
+func (s *Impl) namespaceKey(namespace interface{}) (objectType string, attrs []string, err error) {
 	key, err := NormalizeKey(s.stub, namespace)
 	if err != nil {
-		return nil, fmt.Errorf(`list prefix: %w`, err)
+		return ``, nil, fmt.Errorf(`list prefix: %w`, err)
 	}
 
 	keyTransformed, err := s.StateKeyTransformer(key)
 	if err != nil {
-		return nil, err
+		return ``, nil, err
 	}
 	s.logger.Debug(`state KEYS with composite key`,
 		zap.String(`key`, key.String()), zap.String(`transformed`, keyTransformed.String()))
 
 	if len(keyTransformed) == 0 || keyTransformed[0] == `` {
-		return s.stub.GetStateByRange(``, ``) // all state entries
+		return ``, nil, nil
 	}
-	var (
-		objectType = keyTransformed[0]
-		attrs      []string
-	)
 
 	if len(keyTransformed) > 1 {
 		attrs = keyTransformed[1:]
 	}
-
-	return s.stub.GetStateByPartialCompositeKey(objectType, attrs)
+	return keyTransformed[0], attrs, nil
 }
 
 func (s *Impl) Keys(namespace interface{}) ([]string, error) {
@@ -305,31 +363,28 @@ func (s *Impl) Keys(namespace interface{}) ([]string, error) {
 		return nil, errors.Wrap(err, `state iterator`)
 	}
 
-	defer func() { _ = iter.Close() }()
-
 	var keys []string
-	for iter.HasNext() {
-		v, err := iter.Next()
+	err = IterateKV(iter, func(kv *queryresult.KV) (bool, error) {
+		key, err := KeyFromComposite(s.stub, kv.Key)
 		if err != nil {
-			return nil, err
-		}
-
-		key, err := KeyFromComposite(s.stub, v.Key)
-		if err != nil {
-			return nil, err
+			return false, err
 		}
 
 		reverseTranformedKey, err := s.StateKeyReverseTransformer(key)
 		if err != nil {
-			return nil, fmt.Errorf(`reverse transform key: %w`, err)
+			return false, fmt.Errorf(`reverse transform key: %w`, err)
 		}
 
 		keyStr, err := KeyToString(s.stub, reverseTranformedKey)
 		if err != nil {
-			return nil, err
+			return false, err
 		}
 
 		keys = append(keys, keyStr)
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return keys, nil
@@ -399,6 +454,74 @@ func (s *Impl) Delete(entry interface{}) error {
 	return s.stub.DelState(key.String)
 }
 
+// DeleteCascade deletes parent entry with children entries from state
+func (s *Impl) DeleteCascade(parentKey interface{}, childObjectTypes ...string) (int, error) {
+	key, err := NormalizeKey(s.stub, parentKey)
+	if err != nil {
+		return 0, errors.Wrap(err, `deleting cascade from state`)
+	}
+
+	return DeleteCascade(s, key, childObjectTypes...)
+}
+
+// DeleteCascade deletes parent entry and children entries using state s,
+// so wrapped state (i.e. with mapping) can delete related entries like secondary indexes
+func DeleteCascade(s State, parentKey Key, childObjectTypes ...string) (int, error) {
+	children, err := CascadeKeys(s, parentKey, childObjectTypes...)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, child := range children {
+		s.Logger().Debug(`state cascade DELETE`, zap.String(`key`, child.String()))
+		if err = s.Delete([]string(child)); err != nil {
+			return 0, errors.Wrap(err, `delete child`)
+		}
+	}
+
+	return len(children), s.Delete([]string(parentKey))
+}
+
+// CascadeKeys returns keys of children entries of existing parent entry: keys with child object type
+// and last part of parent key as first attribute
+func CascadeKeys(s State, parentKey Key, childObjectTypes ...string) ([]Key, error) {
+	if len(parentKey) == 0 {
+		return nil, ErrKeyPartsLength
+	}
+
+	exists, err := s.Exists([]string(parentKey))
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.Errorf(`%s: %s`, ErrKeyNotFound, parentKey)
+	}
+
+	parentID := parentKey[len(parentKey)-1]
+
+	// collect children keys before deleting, iterator must not see own deletes
+	var children []Key
+	for _, objectType := range childObjectTypes {
+		keys, err := s.Keys([]string{objectType, parentID})
+		if err != nil {
+			return nil, errors.Wrap(err, `children keys`)
+		}
+
+		for _, key := range keys {
+			children = append(children, splitCompositeKey(key))
+		}
+	}
+	return children, nil
+}
+
+// splitCompositeKey splits composite key without stub, format is the same as in shim.CreateCompositeKey
+func splitCompositeKey(key string) Key {
+	if len(key) < 2 || key[0] != 0 {
+		return Key{key}
+	}
+	return strings.Split(key[1:len(key)-1], "\000")
+}
+
 func (s *Impl) UseKeyTransformer(kt KeyTransformer) State {
 	s.StateKeyTransformer = kt
 	return s
@@ -483,7 +606,6 @@ func (s *Impl) ListPrivate(collection string, usePrivateDataIterator bool, names
 		if err != nil {
 			return nil, errors.Wrap(err, `create list iterator`)
 		}
-		defer func() { _ = iter.Close() }()
 		return stateList.Fill(iter, s.StateGetTransformer)
 	}
 
@@ -491,31 +613,70 @@ func (s *Impl) ListPrivate(collection string, usePrivateDataIterator bool, names
 	if err != nil {
 		return nil, errors.Wrap(err, `create list iterator`)
 	}
-	defer func() { _ = iter.Close() }()
-
-	var (
-		kv       *queryresult.KV
-		objKey   string
-		keyParts []string
-	)
-	for iter.HasNext() {
-		if kv, err = iter.Next(); err != nil {
-			return nil, errors.Wrap(err, `get key value`)
-		}
-		if objKey, keyParts, err = s.stub.SplitCompositeKey(kv.Key); err != nil {
-			return nil, err
+	err = IterateKV(iter, func(kv *queryresult.KV) (bool, error) {
+		objKey, keyParts, err := s.stub.SplitCompositeKey(kv.Key)
+		if err != nil {
+			return false, err
 		}
 
 		object, err := s.GetPrivate(collection, append([]string{objKey}, keyParts...), target...)
 		if err != nil {
-			return nil, err
+			return false, err
 		}
 		stateList.AddElementToList(object)
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return stateList.Get()
 }
 
+// ListPrivatePaginated returns page of slice of target type from private state, bookmark of next page
+// is empty for last page. Shim has no paginated private data queries, so pages are selected from iterator
+func (s *Impl) ListPrivatePaginated(collection string, namespace interface{}, pageSize int32, bookmark string,
+	target ...interface{}) (interface{}, *pb.QueryResponseMetadata, error) {
+	stateList, err := NewStateList(target...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	objectType, attrs, err := s.namespaceKey(namespace)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var iter shim.StateQueryIteratorInterface
+	if objectType == `` {
+		iter, err = s.stub.GetPrivateDataByRange(collection, ``, ``)
+	} else {
+		iter, err = s.stub.GetPrivateDataByPartialCompositeKey(collection, objectType, attrs)
+	}
+	if err != nil {
+		return nil, nil, errors.Wrap(err, `create list iterator`)
+	}
+
+	page, metadata, err := Paginate(iter, pageSize, bookmark)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, kv := range page {
+		item, err := s.StateGetTransformer(kv.Value, stateList.itemTarget)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, `transform list entry`)
+		}
+		stateList.AddElementToList(item)
+	}
+
+	list, err := stateList.Get()
+	if err != nil {
+		return nil, nil, err
+	}
+	return list, metadata, nil
+}
+
 // Put data value in private state with key, trying convert data to []byte
 func (s *Impl) PutPrivate(collection string, entry interface{}, values ...interface{}) (err error) {
 	entryKey, value, err := s.argKeyValue(entry, values)
@@ -33,20 +33,22 @@ func NewStateList(config ...interface{}) (sl *StateList, err error) {
 	return &StateList{itemTarget: itemTarget, listTarget: listTarget}, nil
 }
 
+// Fill appends iterator entries, transformed with fromBytes, to list. Iterator is closed when Fill returns
 func (sl *StateList) Fill(
 	iter shim.StateQueryIteratorInterface, fromBytes FromBytesTransformer) (list interface{}, err error) {
 
-	for iter.HasNext() {
-		kv, err := iter.Next()
-		if err != nil {
-			return nil, err
-		}
-		item, err := fromBytes(kv.Value, sl.itemTarget)
+	items, err := Collect(iter, func(value []byte) (interface{}, error) {
+		item, err := fromBytes(value, sl.itemTarget)
 		if err != nil {
 			return nil, errors.Wrap(err, `transform list entry`)
 		}
-		sl.list = append(sl.list, item)
+		return item, nil
+	}, 0)
+	if err != nil {
+		return nil, err
 	}
+
+	sl.list = append(sl.list, items...)
 	return sl.Get()
 }
 
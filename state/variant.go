@@ -0,0 +1,152 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrVariantDecoderNotFound occurs when no decoder is registered for doc type of state entry
+	ErrVariantDecoderNotFound = errors.New(`variant decoder not found`)
+
+	// ErrDocTypeNotExtracted occurs when doc type can't be obtained from state entry
+	ErrDocTypeNotExtracted = errors.New(`doc type not extracted`)
+)
+
+type (
+	// VariantDecoder decodes state value of entity variant
+	VariantDecoder func(raw []byte) (interface{}, error)
+
+	// DocTypeExtractor returns doc type of state entry, used for choosing variant decoder
+	DocTypeExtractor func(key string, raw []byte) (string, error)
+
+	// VariantVisitor receives decoded state entries
+	VariantVisitor func(key string, value interface{}) error
+
+	// Variants state wrapper for polymorphic entities: Get and List without target
+	// decode entries with decoder, registered for entry doc type
+	Variants struct {
+		State
+		impl      *Impl
+		extractor DocTypeExtractor
+		decoders  map[string]VariantDecoder
+	}
+)
+
+// WithVariants returns state with decode dispatch of entity variants, doc type is obtained with extractor
+func WithVariants(ss State, extractor DocTypeExtractor) *Variants {
+	return &Variants{
+		State:     ss,
+		impl:      ss.(*Impl),
+		extractor: extractor,
+		decoders:  make(map[string]VariantDecoder),
+	}
+}
+
+// DocTypeFromKeyPart returns extractor, using part of composite key as doc type:
+// 0 - object type, 1 and more - key attributes
+func DocTypeFromKeyPart(index int) DocTypeExtractor {
+	return func(key string, _ []byte) (string, error) {
+		parts := strings.Split(key, "\x00")
+		// composite key is \x00objectType\x00attr1\x00...attrN\x00
+		if len(parts) < 3 || parts[0] != `` || index+1 >= len(parts)-1 || parts[index+1] == `` {
+			return ``, fmt.Errorf(`%w: key %q has no composite key part %d`, ErrDocTypeNotExtracted, key, index)
+		}
+		return parts[index+1], nil
+	}
+}
+
+// DocTypeFromJSONField returns extractor, peek-decoding string field of JSON value as doc type
+func DocTypeFromJSONField(field string) DocTypeExtractor {
+	return func(key string, raw []byte) (string, error) {
+		var doc map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &doc); err != nil {
+			return ``, fmt.Errorf(`%w: key %s: %s`, ErrDocTypeNotExtracted, key, err)
+		}
+
+		var docType string
+		if err := json.Unmarshal(doc[field], &docType); err != nil || docType == `` {
+			return ``, fmt.Errorf(`%w: key %s: field %s`, ErrDocTypeNotExtracted, key, field)
+		}
+		return docType, nil
+	}
+}
+
+// RegisterVariantDecoder registers decoder of entries with doc type
+func (v *Variants) RegisterVariantDecoder(docType string, decoder VariantDecoder) *Variants {
+	v.decoders[docType] = decoder
+	return v
+}
+
+// Get returns entry converted to target type, or decoded with variant decoder, if target is not set
+func (v *Variants) Get(entry interface{}, target ...interface{}) (interface{}, error) {
+	if len(target) > 0 {
+		return v.State.Get(entry, target...)
+	}
+
+	key, err := v.impl.Key(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	bb, err := v.State.Get(entry, []byte{})
+	if err != nil {
+		return nil, err
+	}
+	return v.decode(key.String, bb.([]byte))
+}
+
+// List returns slice of target type, or []interface{} of entries decoded with variant decoders, if target is not set
+func (v *Variants) List(namespace interface{}, target ...interface{}) (interface{}, error) {
+	if len(target) > 0 {
+		return v.State.List(namespace, target...)
+	}
+
+	list := []interface{}{}
+	err := v.Visit(namespace, func(_ string, value interface{}) error {
+		list = append(list, value)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Visit calls visitor for each entry in namespace, decoded with variant decoder. Iteration stops on visitor error
+func (v *Variants) Visit(namespace interface{}, visitor VariantVisitor) error {
+	iter, err := v.impl.createStateQueryIterator(namespace)
+	if err != nil {
+		return errors.Wrap(err, `state iterator`)
+	}
+
+	return IterateKV(iter, func(kv *queryresult.KV) (bool, error) {
+		value, err := v.decode(kv.Key, kv.Value)
+		if err != nil {
+			return false, err
+		}
+		return false, visitor(kv.Key, value)
+	})
+}
+
+func (v *Variants) decode(key string, raw []byte) (interface{}, error) {
+	docType, err := v.extractor(key, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder, ok := v.decoders[docType]
+	if !ok {
+		return nil, fmt.Errorf(`%w: doc type %s, key %s`, ErrVariantDecoderNotFound, docType, key)
+	}
+
+	value, err := decoder(raw)
+	if err != nil {
+		return nil, fmt.Errorf(`decode variant %s, key %s: %w`, docType, key, err)
+	}
+	return value, nil
+}
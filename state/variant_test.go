@@ -0,0 +1,118 @@
+package state_test
+
+import (
+	"errors"
+
+	"github.com/golang/protobuf/proto"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+
+	"github.com/s7techlab/cckit/state"
+	"github.com/s7techlab/cckit/state/mapping/testdata/schema"
+	testcc "github.com/s7techlab/cckit/testing"
+)
+
+type (
+	JSONCar struct {
+		DocType string `json:"docType"`
+		Model   string `json:"model"`
+	}
+
+	JSONBike struct {
+		DocType string `json:"docType"`
+		Gears   int    `json:"gears"`
+	}
+)
+
+func protoDecoder(target proto.Message) state.VariantDecoder {
+	return func(raw []byte) (interface{}, error) {
+		msg := proto.Clone(target)
+		return msg, proto.Unmarshal(raw, msg)
+	}
+}
+
+var _ = Describe(`State variants`, func() {
+
+	var stub *testcc.MockStub
+
+	inTx := func(fn func(st state.State)) {
+		stub.MockTransactionStart(`tx`)
+		fn(state.NewState(stub, zap.NewNop()))
+		stub.MockTransactionEnd(`tx`)
+	}
+
+	BeforeEach(func() {
+		stub = testcc.NewMockStub(`variants`, nil)
+	})
+
+	It("Allow to list mixed proto variants, dispatched by composite key part", func() {
+		inTx(func(st state.State) {
+			Expect(st.Put([]string{`ASSET`, `INDEXED`, `1`}, &schema.EntityWithIndexes{Id: `1`})).To(Succeed())
+			Expect(st.Put([]string{`ASSET`, `COMPOSITE`, `2`}, &schema.CreateEntityWithCompositeId{Name: `2`})).To(Succeed())
+			Expect(st.Put([]string{`ASSET`, `INDEXED`, `3`}, &schema.EntityWithIndexes{Id: `3`})).To(Succeed())
+		})
+
+		inTx(func(st state.State) {
+			variants := state.WithVariants(st, state.DocTypeFromKeyPart(1)).
+				RegisterVariantDecoder(`INDEXED`, protoDecoder(&schema.EntityWithIndexes{})).
+				RegisterVariantDecoder(`COMPOSITE`, protoDecoder(&schema.CreateEntityWithCompositeId{}))
+
+			list, err := variants.List(`ASSET`)
+			Expect(err).NotTo(HaveOccurred())
+
+			items := list.([]interface{})
+			Expect(items).To(HaveLen(3))
+			Expect(items[0]).To(BeAssignableToTypeOf(&schema.CreateEntityWithCompositeId{}))
+			Expect(items[0].(*schema.CreateEntityWithCompositeId).Name).To(Equal(`2`))
+			Expect(items[1]).To(BeAssignableToTypeOf(&schema.EntityWithIndexes{}))
+			Expect(items[2].(*schema.EntityWithIndexes).Id).To(Equal(`3`))
+
+			entity, err := variants.Get([]string{`ASSET`, `COMPOSITE`, `2`})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entity).To(BeAssignableToTypeOf(&schema.CreateEntityWithCompositeId{}))
+		})
+	})
+
+	It("Allow to visit JSON variants, dispatched by doc type field", func() {
+		inTx(func(st state.State) {
+			Expect(st.Put([]string{`VEHICLE`, `1`}, &JSONCar{DocType: `car`, Model: `A`})).To(Succeed())
+			Expect(st.Put([]string{`VEHICLE`, `2`}, &JSONBike{DocType: `bike`, Gears: 21})).To(Succeed())
+		})
+
+		inTx(func(st state.State) {
+			variants := state.WithVariants(st, state.DocTypeFromJSONField(`docType`)).
+				RegisterVariantDecoder(`car`, func(raw []byte) (interface{}, error) {
+					return st.(*state.Impl).StateGetTransformer(raw, &JSONCar{})
+				}).
+				RegisterVariantDecoder(`bike`, func(raw []byte) (interface{}, error) {
+					return st.(*state.Impl).StateGetTransformer(raw, &JSONBike{})
+				})
+
+			var visited []interface{}
+			Expect(variants.Visit(`VEHICLE`, func(key string, value interface{}) error {
+				visited = append(visited, value)
+				return nil
+			})).To(Succeed())
+
+			Expect(visited).To(Equal([]interface{}{
+				JSONCar{DocType: `car`, Model: `A`}, JSONBike{DocType: `bike`, Gears: 21}}))
+
+			// listing with explicit target is not dispatched
+			list, err := variants.List(`VEHICLE`, &JSONCar{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(list).To(HaveLen(2))
+		})
+	})
+
+	It("Disallow to list entries without registered decoder", func() {
+		inTx(func(st state.State) {
+			Expect(st.Put([]string{`VEHICLE`, `1`}, &JSONCar{DocType: `car`})).To(Succeed())
+		})
+
+		inTx(func(st state.State) {
+			_, err := state.WithVariants(st, state.DocTypeFromJSONField(`docType`)).List(`VEHICLE`)
+			Expect(errors.Is(err, state.ErrVariantDecoderNotFound)).To(BeTrue())
+		})
+	})
+})
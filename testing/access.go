@@ -0,0 +1,254 @@
+package testing
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/pkg/errors"
+)
+
+// ErrUndeclaredAccess occurs when invoke reads or writes state key, not declared with DeclareAccess
+var ErrUndeclaredAccess = errors.New(`undeclared state access`)
+
+const (
+	AccessRead  = `read`
+	AccessWrite = `write`
+)
+
+type (
+	// AccessViolationError describes first undeclared state access of invoke
+	AccessViolationError struct {
+		Operation string
+		Key       string
+	}
+
+	// AccessSet keys, accessed by tx
+	AccessSet struct {
+		Reads []string
+		// Ranges range, partial composite key and rich query reads
+		Ranges []KeyRange
+		Writes []string
+	}
+
+	// KeyRange half-open key range [Start, End) of range read, empty End means end of key space
+	KeyRange struct {
+		Start string
+		End   string
+		// Query range of rich query, which reads whole key space. Results are not re-validated on commit
+		Query bool
+	}
+
+	// ErrorReporter subset of testing.TB, GinkgoT() can be used as well
+	ErrorReporter interface {
+		Errorf(format string, args ...interface{})
+	}
+)
+
+func (e *AccessViolationError) Error() string {
+	return fmt.Sprintf(`%s: %s of key "%s"`, ErrUndeclaredAccess, e.Operation, e.Key)
+}
+
+func (e *AccessViolationError) Unwrap() error {
+	return ErrUndeclaredAccess
+}
+
+// String returns prefix with `*` suffix for prefix range, `*` for whole key space and [Start, End) otherwise
+func (r KeyRange) String() string {
+	switch {
+	case r.End == r.Start+string(maxUnicodeRuneValue) || r.End != `` && r.End == prefixEnd(r.Start):
+		return r.Start + `*`
+	case r.Start == `` && r.End == ``:
+		return `*`
+	}
+	return fmt.Sprintf(`[%s, %s)`, r.Start, r.End)
+}
+
+// Contains checks key is in range
+func (r KeyRange) Contains(key string) bool {
+	return key >= r.Start && (r.End == `` || key < r.End)
+}
+
+// prefixEnd returns the least key, greater than all keys with prefix, or empty key if there is no such key
+func prefixEnd(prefix string) string {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return string(end[:i+1])
+		}
+	}
+	return ``
+}
+
+// WithAccessReporter reports undeclared access violations to reporter, i.e. fails test
+func WithAccessReporter(reporter ErrorReporter) MockStubOpt {
+	return func(stub *MockStub) {
+		stub.accessReporter = reporter
+	}
+}
+
+// DeclareAccess declares keys, next invoke is allowed to read and write. Key with `*` suffix declares prefix.
+// Range and partial composite key reads are allowed, if all keys of range have declared prefix,
+// rich query reads are reads of whole state, so require `*` declaration.
+// Undeclared access is stored in LastAccessError and reported to access reporter
+func (stub *MockStub) DeclareAccess(reads []string, writes []string) *MockStub {
+	stub.declaredAccess = &AccessSet{Reads: reads, Writes: writes}
+	return stub
+}
+
+// LastAccess returns public state keys, accessed by last tx
+func (stub *MockStub) LastAccess() AccessSet {
+	return stub.txAccess
+}
+
+// GetState mocked, read is recorded in tx access set, copy of value is returned if defensive copies are enabled
+func (stub *MockStub) GetState(key string) ([]byte, error) {
+	if err := stub.injectedErr(OperationGetState, key); err != nil {
+		return nil, err
+	}
+	if fn, ok := stub.overrides[`GetState`].(func(string) ([]byte, error)); ok {
+		return fn(key)
+	}
+	stub.recordAccess(AccessRead, key)
+	value, err := stub.MockStub.GetState(key)
+	return stub.copyValue(value), err
+}
+
+// GetStateByRange mocked, returns keys of half-open range [startKey, endKey) in lexicographic order,
+// empty start or end key means start or end of key space. Range read is recorded in tx access set
+func (stub *MockStub) GetStateByRange(startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+	if fn, ok := stub.overrides[`GetStateByRange`].(func(string, string) (shim.StateQueryIteratorInterface, error)); ok {
+		return fn(startKey, endKey)
+	}
+	for _, key := range []string{startKey, endKey} {
+		if strings.HasPrefix(key, compositeKeyNamespace) {
+			return nil, fmt.Errorf(`first character of the key [%s] contains a null character which is not allowed`, key)
+		}
+	}
+
+	stub.recordRangeAccess(KeyRange{Start: startKey, End: endKey})
+	iter := NewQueryResultIterator(fmt.Sprintf(`range [%q, %q)`, startKey, endKey), stub.rangeEntries(startKey, endKey))
+	return stub.trackIterator(iter, nil, fmt.Sprintf(`GetStateByRange(%q, %q)`, startKey, endKey))
+}
+
+// rangeEntries returns copies of state entries with startKey <= key < endKey, sorted by key.
+// Empty endKey means end of key space
+func (stub *MockStub) rangeEntries(startKey, endKey string) []*QueryResultEntry {
+	keys := make([]string, 0, len(stub.State))
+	for key := range stub.State {
+		if key >= startKey && (endKey == `` || key < endKey) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	entries := make([]*QueryResultEntry, len(keys))
+	for i, key := range keys {
+		entries[i] = &QueryResultEntry{Key: key, Value: stub.copyValue(stub.State[key])}
+	}
+	return entries
+}
+
+// GetStateByPartialCompositeKey mocked, read of partial key range is recorded in tx access set
+func (stub *MockStub) GetStateByPartialCompositeKey(
+	objectType string, attributes []string) (shim.StateQueryIteratorInterface, error) {
+	partialKey, err := stub.MockStub.CreateCompositeKey(objectType, attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	stub.recordRangeAccess(KeyRange{Start: partialKey, End: partialKey + string(maxUnicodeRuneValue)})
+	iter, err := stub.copyingIterator(stub.MockStub.GetStateByPartialCompositeKey(objectType, attributes))
+	return stub.trackIterator(iter, err, fmt.Sprintf(`GetStateByPartialCompositeKey(%q, %q)`, objectType, attributes))
+}
+
+func (stub *MockStub) recordAccess(operation, key string) {
+	if stub.TxID == `` {
+		return
+	}
+
+	declared := stub.txDeclaredAccess
+	violation := false
+	switch operation {
+	case AccessRead:
+		stub.txAccess.Reads = append(stub.txAccess.Reads, key)
+		violation = declared != nil && !accessDeclared(declared.Reads, key)
+	case AccessWrite:
+		stub.txAccess.Writes = append(stub.txAccess.Writes, key)
+		violation = declared != nil && !accessDeclared(declared.Writes, key)
+	}
+
+	if violation && stub.txAccessError == nil {
+		stub.txAccessError = &AccessViolationError{Operation: operation, Key: key}
+		stub.Warn(WarningUndeclaredAccess, key, operation+` of undeclared key`)
+	}
+}
+
+// recordRangeAccess records range read, range must be covered by declared prefix
+func (stub *MockStub) recordRangeAccess(r KeyRange) {
+	if stub.TxID == `` {
+		return
+	}
+
+	stub.txAccess.Ranges = append(stub.txAccess.Ranges, r)
+	declared := stub.txDeclaredAccess
+	if declared != nil && !rangeDeclared(declared.Reads, r) && stub.txAccessError == nil {
+		stub.txAccessError = &AccessViolationError{Operation: AccessRead, Key: r.String()}
+		stub.Warn(WarningUndeclaredAccess, r.String(), AccessRead+` of undeclared range`)
+	}
+}
+
+// rangeDeclared checks all keys of range have one of declared prefixes
+func rangeDeclared(declared []string, r KeyRange) bool {
+	for _, d := range declared {
+		if !strings.HasSuffix(d, `*`) {
+			continue
+		}
+		prefix := strings.TrimSuffix(d, `*`)
+		if !strings.HasPrefix(r.Start, prefix) {
+			continue
+		}
+		if end := prefixEnd(prefix); end == `` || r.End != `` && r.End <= end {
+			return true
+		}
+	}
+	return false
+}
+
+// accessDeclared checks key against declared keys
+func accessDeclared(declared []string, key string) bool {
+	for _, d := range declared {
+		if strings.HasSuffix(d, `*`) {
+			if strings.HasPrefix(key, strings.TrimSuffix(d, `*`)) {
+				return true
+			}
+		} else if d == key {
+			return true
+		}
+	}
+	return false
+}
+
+// startAccessCheck applies declared access to tx, called on tx start.
+// Declaration is kept until invoke ends, so it applies to each run of determinism check
+func (stub *MockStub) startAccessCheck() {
+	stub.txDeclaredAccess = stub.declaredAccess
+	stub.txAccess = AccessSet{}
+	stub.txAccessError = nil
+}
+
+// finishAccessCheck reports undeclared access, called on tx end
+func (stub *MockStub) finishAccessCheck() {
+	stub.LastAccessError = stub.txAccessError
+	if stub.LastAccessError != nil && stub.accessReporter != nil {
+		stub.accessReporter.Errorf(`tx %s: %s`, stub.TxID, stub.LastAccessError)
+	}
+	stub.txDeclaredAccess = nil
+}
+
+// clearDeclaredAccess called after invoke
+func (stub *MockStub) clearDeclaredAccess() {
+	stub.declaredAccess = nil
+}
@@ -0,0 +1,123 @@
+package testing_test
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/router"
+	p "github.com/s7techlab/cckit/router/param"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+type errorsCollector []string
+
+func (c *errorsCollector) Errorf(format string, args ...interface{}) {
+	*c = append(*c, fmt.Sprintf(format, args...))
+}
+
+func NewAccessCC() *router.Chaincode {
+	r := router.New(`access`)
+
+	copyValue := func(c router.Context) (interface{}, error) {
+		value, err := c.Stub().GetState(`balance/` + c.ParamString(`from`))
+		if err != nil {
+			return nil, err
+		}
+		return nil, c.Stub().PutState(`balance/`+c.ParamString(`to`), value)
+	}
+
+	r.Invoke(`copy`, copyValue, p.String(`from`), p.String(`to`)).
+		Invoke(`copyAndPeek`, func(c router.Context) (interface{}, error) {
+			if _, err := c.Stub().GetState(`secret`); err != nil {
+				return nil, err
+			}
+			return copyValue(c)
+		}, p.String(`from`), p.String(`to`)).
+		Invoke(`list`, func(c router.Context) (interface{}, error) {
+			iter, err := c.Stub().GetStateByRange(`balance/`, `balance0`)
+			if err != nil {
+				return nil, err
+			}
+			return nil, iter.Close()
+		}).
+		Invoke(`listRange`, func(c router.Context) (interface{}, error) {
+			iter, err := c.Stub().GetStateByRange(c.ParamString(`start`), c.ParamString(`end`))
+			if err != nil {
+				return nil, err
+			}
+			return nil, iter.Close()
+		}, p.String(`start`), p.String(`end`))
+
+	return router.NewChaincode(r)
+}
+
+var _ = Describe(`Declared access`, func() {
+
+	It("Allow to pass handler with declared access", func() {
+		var reported errorsCollector
+		cc := testcc.NewMockStub(`access`, NewAccessCC(), testcc.WithAccessReporter(&reported))
+
+		expectcc.ResponseOk(cc.DeclareAccess([]string{`balance/a`}, []string{`balance/*`}).
+			Invoke(`copy`, `a`, `b`))
+		Expect(cc.LastAccessError).NotTo(HaveOccurred())
+		Expect(cc.LastAccess()).To(Equal(testcc.AccessSet{
+			Reads: []string{`balance/a`}, Writes: []string{`balance/b`}}))
+
+		expectcc.ResponseOk(cc.DeclareAccess([]string{`balance/*`}, nil).Invoke(`list`))
+		Expect(cc.LastAccessError).NotTo(HaveOccurred())
+		Expect(reported).To(BeEmpty())
+	})
+
+	It("Disallow handler to read undeclared key", func() {
+		var reported errorsCollector
+		cc := testcc.NewMockStub(`access`, NewAccessCC(), testcc.WithAccessReporter(&reported))
+
+		expectcc.ResponseOk(cc.DeclareAccess([]string{`balance/a`}, []string{`balance/b`}).
+			Invoke(`copyAndPeek`, `a`, `b`))
+
+		Expect(cc.LastAccessError).To(MatchError(testcc.ErrUndeclaredAccess))
+		Expect(cc.LastAccessError).To(Equal(&testcc.AccessViolationError{Operation: testcc.AccessRead, Key: `secret`}))
+		Expect(reported).To(HaveLen(1))
+		Expect(reported[0]).To(ContainSubstring(`read of key "secret"`))
+	})
+
+	It("Disallow handler to write undeclared key and to read range outside declared prefix", func() {
+		cc := testcc.NewMockStub(`access`, NewAccessCC())
+
+		expectcc.ResponseOk(cc.DeclareAccess([]string{`balance/a`}, []string{`balance/c`}).
+			Invoke(`copy`, `a`, `b`))
+		Expect(cc.LastAccessError).To(Equal(&testcc.AccessViolationError{Operation: testcc.AccessWrite, Key: `balance/b`}))
+
+		expectcc.ResponseOk(cc.DeclareAccess([]string{`balance/a`}, nil).Invoke(`list`))
+		Expect(cc.LastAccessError).To(Equal(&testcc.AccessViolationError{Operation: testcc.AccessRead, Key: `balance/*`}))
+	})
+
+	It("Disallow handler to read range wider than declared prefix", func() {
+		cc := testcc.NewMockStub(`access`, NewAccessCC())
+
+		expectcc.ResponseOk(cc.DeclareAccess([]string{`BOOK_1*`}, nil).Invoke(`listRange`, `BOOK_1`, `BOOK_2`))
+		Expect(cc.LastAccessError).NotTo(HaveOccurred())
+		Expect(cc.LastAccess().Ranges).To(Equal([]testcc.KeyRange{{Start: `BOOK_1`, End: `BOOK_2`}}))
+
+		expectcc.ResponseOk(cc.DeclareAccess([]string{`BOOK_1*`}, nil).Invoke(`listRange`, `BOOK_1`, `BOOK_9`))
+		Expect(cc.LastAccessError).To(Equal(&testcc.AccessViolationError{
+			Operation: testcc.AccessRead, Key: `[BOOK_1, BOOK_9)`}))
+
+		expectcc.ResponseOk(cc.DeclareAccess([]string{`BOOK_1*`}, nil).Invoke(`listRange`, `BOOK_1`, ``))
+		Expect(cc.LastAccessError).To(HaveOccurred())
+	})
+
+	It("Allow to invoke without access check when access is not declared", func() {
+		cc := testcc.NewMockStub(`access`, NewAccessCC())
+
+		expectcc.ResponseOk(cc.DeclareAccess([]string{`balance/a`}, nil).Invoke(`copyAndPeek`, `a`, `b`))
+		Expect(cc.LastAccessError).To(HaveOccurred())
+
+		// declaration applies only to next invoke
+		expectcc.ResponseOk(cc.Invoke(`copyAndPeek`, `a`, `b`))
+		Expect(cc.LastAccessError).NotTo(HaveOccurred())
+	})
+})
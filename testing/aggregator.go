@@ -0,0 +1,99 @@
+package testing
+
+import (
+	"context"
+	"sync"
+
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+type (
+	// EventsAggregator merges committed events of several stubs in commit order
+	EventsAggregator struct {
+		m      sync.Mutex
+		events []*AggregatedEvent
+		notify chan struct{} // closed on each publish
+	}
+
+	// AggregatedEvent committed chaincode event, tagged with originating chaincode and channel
+	AggregatedEvent struct {
+		// Seq commit sequence number, global across aggregated stubs, starting from 1
+		Seq       uint64
+		Chaincode string
+		Channel   string
+		Event     *peer.ChaincodeEvent
+	}
+)
+
+// NewEventsAggregator creates aggregator of events, committed by stubs after aggregator creation.
+// Per stub subscriptions are not affected
+func NewEventsAggregator(stubs ...*MockStub) *EventsAggregator {
+	a := &EventsAggregator{notify: make(chan struct{})}
+	for _, stub := range stubs {
+		stub.subscriptionsM.Lock()
+		stub.eventsAggregators = append(stub.eventsAggregators, a)
+		stub.subscriptionsM.Unlock()
+	}
+	return a
+}
+
+// Events returns aggregated events, in commit order
+func (a *EventsAggregator) Events() []*AggregatedEvent {
+	a.m.Lock()
+	defer a.m.Unlock()
+	return append([]*AggregatedEvent{}, a.events...)
+}
+
+// Subscribe returns channel with events, committed after subscription, in commit order.
+// Channel is closed on ctx done
+func (a *EventsAggregator) Subscribe(ctx context.Context) <-chan *AggregatedEvent {
+	a.m.Lock()
+	next := len(a.events)
+	a.m.Unlock()
+
+	events := make(chan *AggregatedEvent, EventChannelBufferSize)
+	go func() {
+		defer close(events)
+		for {
+			a.m.Lock()
+			if next < len(a.events) {
+				event := a.events[next]
+				next++
+				a.m.Unlock()
+
+				select {
+				case events <- event:
+					continue
+				case <-ctx.Done():
+					return
+				}
+			}
+			notify := a.notify
+			a.m.Unlock()
+
+			select {
+			case <-notify:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events
+}
+
+// publish assigns commit sequence number to event, should be called on event commit
+func (a *EventsAggregator) publish(chaincode, channel string, event *peer.ChaincodeEvent) {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	a.events = append(a.events, &AggregatedEvent{
+		Seq:       uint64(len(a.events) + 1),
+		Chaincode: chaincode,
+		Channel:   channel,
+		Event:     event,
+	})
+
+	close(a.notify)
+	a.notify = make(chan struct{})
+}
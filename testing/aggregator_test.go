@@ -0,0 +1,57 @@
+package testing_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+var _ = Describe(`Events aggregator`, func() {
+
+	It("Allow to merge events of several chaincodes in commit order", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		first := testcc.NewMockStub(`first`, NewStatsCC())
+		first.ChannelID = `channel-1`
+		second := testcc.NewMockStub(`second`, NewStatsCC())
+		second.ChannelID = `channel-2`
+		firstEvents, closeFirst := first.EventSubscription()
+		defer closeFirst()
+
+		aggregator := testcc.NewEventsAggregator(first, second)
+		merged := aggregator.Subscribe(ctx)
+
+		expectcc.ResponseOk(first.Invoke(`put`, `a`))
+		expectcc.ResponseOk(second.Invoke(`put`, `b`))
+		expectcc.ResponseError(second.Invoke(`fail`), `failed`)
+		expectcc.ResponseOk(first.Invoke(`put`, `c`))
+
+		expected := []testcc.AggregatedEvent{
+			{Seq: 1, Chaincode: `first`, Channel: `channel-1`},
+			{Seq: 2, Chaincode: `second`, Channel: `channel-2`},
+			{Seq: 3, Chaincode: `first`, Channel: `channel-1`},
+		}
+		payloads := []string{`a`, `b`, `c`}
+
+		events := aggregator.Events()
+		Expect(events).To(HaveLen(3))
+		for i, event := range events {
+			Expect(event.Seq).To(Equal(expected[i].Seq))
+			Expect(event.Chaincode).To(Equal(expected[i].Chaincode))
+			Expect(event.Channel).To(Equal(expected[i].Channel))
+			Expect(string(event.Event.Payload)).To(Equal(payloads[i]))
+
+			var streamed *testcc.AggregatedEvent
+			Eventually(merged).Should(Receive(&streamed))
+			Expect(streamed).To(Equal(event))
+		}
+
+		// per stub subscription is not affected
+		Expect(firstEvents).To(HaveLen(2))
+	})
+})
@@ -0,0 +1,19 @@
+package testing_test
+
+import (
+	. "github.com/onsi/ginkgo"
+
+	"github.com/s7techlab/cckit/convert"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+var _ = Describe(`Invoke args`, func() {
+
+	It("Disallow to invoke with unsupported args", func() {
+		cc := testcc.NewMockStub(`args`, NewStatsCC())
+
+		expectcc.ResponseError(cc.Invoke(`put`, func() {}), convert.ErrUnsupportedArg)
+		expectcc.ResponseError(cc.Invoke(`put`, func() {}), `arg[0] of type func()`)
+	})
+})
@@ -0,0 +1,100 @@
+package testing
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/pkg/errors"
+
+	"github.com/s7techlab/cckit/convert"
+)
+
+// BatchFunc function name of batch invoke in invocation log, followed by function names of batch ops
+const BatchFunc = `batch`
+
+// ErrBatchOpFailed occurs when operation of batch invoke responds with error, batch is not committed
+var ErrBatchOpFailed = errors.New(`batch op failed`)
+
+// BatchOp operation of batch invoke
+type BatchOp struct {
+	Func string
+	Args []interface{}
+}
+
+// InvokeBatch invokes chaincode once per op inside single mock transaction. Ops share state buffer, creator,
+// tx id, timestamp and event rules: event, set by later op, overwrites event of earlier op. As in single tx,
+// ops read state, committed before batch, writes of earlier ops are not visible to later ops.
+// Ops are invoked until first failed op, state writes, deletes, private data writes and event
+// are committed only if all ops succeed.
+//
+// It's a test composition facility for handlers, multiplexed in one invoke, not a Fabric feature:
+// peer invokes chaincode once per transaction. Responses of invoked ops are returned
+func (stub *MockStub) InvokeBatch(ops []BatchOp) ([]peer.Response, error) {
+	stub.checkReentrant(`InvokeBatch`)
+	stub.m.Lock()
+	defer stub.m.Unlock()
+	if err := stub.checkInjectedInvoker(); err != nil {
+		return nil, err
+	}
+	defer stub.clearDeclaredAccess()
+	defer stub.enterPhase(PhaseInvoke)()
+
+	uuid := stub.generateTxUID()
+	batchArgs := [][]byte{[]byte(BatchFunc)}
+	for _, op := range ops {
+		batchArgs = append(batchArgs, []byte(op.Func))
+	}
+
+	var (
+		responses []peer.Response
+		err       error
+	)
+
+	// deletes and private writes are applied to state immediately, so failed batch is rolled back to snapshot
+	snap := stub.snapshot()
+	stub.startTx(uuid)
+	txStub := stub.txStub()
+	for i, op := range ops {
+		res := stub.invokeBatchOp(txStub, op)
+		responses = append(responses, res)
+		if res.Status >= shim.ERRORTHRESHOLD {
+			err = fmt.Errorf(`%w: op %d %s: %s`, ErrBatchOpFailed, i, op.Func, res.Message)
+			break
+		}
+	}
+
+	res := shim.Success(nil)
+	if err != nil {
+		res = shim.Error(err.Error())
+		// failed batch is not committed
+		stub.restore(snap)
+		stub.StateBuffer = nil
+		stub.txDeletes = nil
+		stub.txPrivateWrites = nil
+		stub.ChaincodeEvent = nil
+	}
+	res = stub.endorse(res)
+	if err == nil && res.Status >= shim.ERRORTHRESHOLD {
+		err = errors.New(res.Message)
+	}
+
+	stub.SetArgs(batchArgs)
+	stub.logInvocation(uuid, batchArgs, res)
+	stub.MockTransactionEnd(uuid)
+	stub.countTx(batchArgs, res)
+
+	if err == nil && stub.LastValidationError != nil {
+		err = stub.LastValidationError
+	}
+	return responses, err
+}
+
+func (stub *MockStub) invokeBatchOp(txStub shim.ChaincodeStubInterface, op BatchOp) peer.Response {
+	args, err := convert.ArgsToBytes(op.Args...)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	stub.SetArgs(append([][]byte{[]byte(op.Func)}, args...))
+	return stub.cc.Invoke(txStub)
+}
@@ -0,0 +1,125 @@
+package testing_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/router"
+	p "github.com/s7techlab/cckit/router/param"
+	testcc "github.com/s7techlab/cckit/testing"
+)
+
+// NewAccountsCC accounts with balances, withdraw fails on insufficient balance
+func NewAccountsCC() *router.Chaincode {
+	r := router.New(`accounts`)
+
+	r.Invoke(`deposit`, func(c router.Context) (interface{}, error) {
+		if err := c.Event().Set(`Deposited`, c.ParamString(`account`)); err != nil {
+			return nil, err
+		}
+		return nil, c.State().Put(c.ParamString(`account`), c.ParamInt(`amount`))
+	}, p.String(`account`), p.Int(`amount`))
+
+	r.Invoke(`withdraw`, func(c router.Context) (interface{}, error) {
+		balance, err := c.State().GetInt(c.ParamString(`account`), 0)
+		if err != nil {
+			return nil, err
+		}
+		if balance < c.ParamInt(`amount`) {
+			return nil, errors.New(`insufficient balance`)
+		}
+		return nil, c.State().Put(c.ParamString(`account`), balance-c.ParamInt(`amount`))
+	}, p.String(`account`), p.Int(`amount`))
+
+	r.Invoke(`close`, func(c router.Context) (interface{}, error) {
+		return nil, c.Stub().DelState(c.ParamString(`account`))
+	}, p.String(`account`))
+
+	r.Invoke(`note`, func(c router.Context) (interface{}, error) {
+		return nil, c.Stub().PutPrivateData(`notes`, c.ParamString(`account`), []byte(c.ParamString(`note`)))
+	}, p.String(`account`), p.String(`note`))
+
+	return router.NewChaincode(r)
+}
+
+var _ = Describe(`Batch invoke`, func() {
+
+	var cc *testcc.MockStub
+
+	BeforeEach(func() {
+		cc = testcc.NewMockStub(`accounts`, NewAccountsCC())
+	})
+
+	It("Allow to commit ops of batch together in one tx", func() {
+		responses, err := cc.InvokeBatch([]testcc.BatchOp{
+			{Func: `deposit`, Args: []interface{}{`alice`, 10}},
+			{Func: `deposit`, Args: []interface{}{`bob`, 20}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(responses).To(HaveLen(2))
+		Expect(responses[0].Status).To(BeEquivalentTo(200))
+		Expect(responses[1].Status).To(BeEquivalentTo(200))
+
+		Expect(cc.State).To(HaveKeyWithValue(`alice`, []byte(`10`)))
+		Expect(cc.State).To(HaveKeyWithValue(`bob`, []byte(`20`)))
+		Expect(cc.ChaincodeEvent.Payload).To(Equal([]byte(`bob`)))
+
+		records := cc.Transactions()
+		Expect(records).To(HaveLen(1))
+		Expect(records[0].Function).To(Equal(testcc.BatchFunc))
+		Expect(records[0].Writes).To(HaveLen(2))
+	})
+
+	It("Disallow to commit batch, if op fails", func() {
+		responses, err := cc.InvokeBatch([]testcc.BatchOp{
+			{Func: `deposit`, Args: []interface{}{`alice`, 10}},
+			{Func: `withdraw`, Args: []interface{}{`alice`, 20}},
+			{Func: `deposit`, Args: []interface{}{`bob`, 20}},
+		})
+		Expect(errors.Is(err, testcc.ErrBatchOpFailed)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring(`op 1 withdraw: insufficient balance`))
+
+		// ops after failed op are not invoked
+		Expect(responses).To(HaveLen(2))
+		Expect(responses[0].Status).To(BeEquivalentTo(200))
+		Expect(responses[1].Status).To(BeEquivalentTo(500))
+
+		Expect(cc.State).To(BeEmpty())
+		Expect(cc.ChaincodeEvent).To(BeNil())
+		Expect(cc.Transactions()[0].Failed).To(BeTrue())
+	})
+
+	It("Disallow to commit deletes and private writes of failed batch", func() {
+		Expect(cc.Invoke(`deposit`, `alice`, 10).Status).To(BeEquivalentTo(200))
+		Expect(cc.Invoke(`note`, `alice`, `vip`).Status).To(BeEquivalentTo(200))
+
+		_, err := cc.InvokeBatch([]testcc.BatchOp{
+			{Func: `close`, Args: []interface{}{`alice`}},
+			{Func: `note`, Args: []interface{}{`alice`, `closed`}},
+			{Func: `withdraw`, Args: []interface{}{`bob`, 1}},
+		})
+		Expect(errors.Is(err, testcc.ErrBatchOpFailed)).To(BeTrue())
+
+		Expect(cc.State).To(HaveKeyWithValue(`alice`, []byte(`10`)))
+		Expect(cc.PvtState[`notes`]).To(HaveKeyWithValue(`alice`, []byte(`vip`)))
+		Expect(cc.KeyHistory(`alice`)).To(HaveLen(1))
+
+		_, err = cc.InvokeBatch([]testcc.BatchOp{{Func: `close`, Args: []interface{}{`alice`}}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cc.State).NotTo(HaveKey(`alice`))
+	})
+
+	It("Allow ops of batch to read state, committed before batch, like single tx", func() {
+		Expect(cc.Invoke(`deposit`, `alice`, 10).Status).To(BeEquivalentTo(200))
+
+		_, err := cc.InvokeBatch([]testcc.BatchOp{
+			{Func: `withdraw`, Args: []interface{}{`alice`, 4}},
+			{Func: `withdraw`, Args: []interface{}{`alice`, 3}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		// writes of earlier ops are not visible to later ops, last write wins
+		Expect(cc.State).To(HaveKeyWithValue(`alice`, []byte(`7`)))
+	})
+})
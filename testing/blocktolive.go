@@ -0,0 +1,96 @@
+package testing
+
+import (
+	"crypto/sha256"
+	"strings"
+)
+
+// WithCollectionBlockToLive sets number of blocks, private data of collection lives after write block.
+// Expired data is purged from collection, hashes remain available via GetPrivateDataHash, as in Fabric
+func WithCollectionBlockToLive(name string, blocks uint64) MockStubOpt {
+	return func(stub *MockStub) {
+		if stub.blockToLive == nil {
+			stub.blockToLive = make(map[string]uint64)
+		}
+		stub.blockToLive[name] = blocks
+	}
+}
+
+// BlockHeight returns block height of stub clock, incremented on each tx end
+func (stub *MockStub) BlockHeight() uint64 {
+	return stub.clock.BlockHeight()
+}
+
+// AdvanceBlocks increments block height of stub clock, as if n blocks without txs of this chaincode were committed
+func (stub *MockStub) AdvanceBlocks(n uint64) *MockStub {
+	stub.clock.SetBlock(stub.clock.BlockHeight() + n)
+	stub.purgeExpiredPrivateData()
+	return stub
+}
+
+// txBlock returns number of block, current tx will be committed in
+func (stub *MockStub) txBlock() uint64 {
+	if stub.TxID == `` {
+		return stub.clock.BlockHeight()
+	}
+	return stub.clock.BlockHeight() + 1
+}
+
+// trackPrivateWrite stores write block of private data key of collection with block to live
+func (stub *MockStub) trackPrivateWrite(collection, key string) {
+	delete(stub.purgedHashes[collection], key)
+
+	if _, ok := stub.blockToLive[collection]; !ok {
+		return
+	}
+
+	if stub.privateWriteBlocks == nil {
+		stub.privateWriteBlocks = make(map[string]map[string]uint64)
+	}
+	if stub.privateWriteBlocks[collection] == nil {
+		stub.privateWriteBlocks[collection] = make(map[string]uint64)
+	}
+	stub.privateWriteBlocks[collection][key] = stub.txBlock()
+}
+
+// purgeExpiredPrivateData removes private data, written more than block to live blocks ago, keeping value hashes
+func (stub *MockStub) purgeExpiredPrivateData() {
+	blockHeight := stub.clock.BlockHeight()
+	for collection, writeBlocks := range stub.privateWriteBlocks {
+		blockToLive := stub.blockToLive[collection]
+		for key, writeBlock := range writeBlocks {
+			if writeBlock+blockToLive >= blockHeight {
+				continue
+			}
+
+			if value, ok := stub.PvtState[collection][key]; ok {
+				if stub.purgedHashes == nil {
+					stub.purgedHashes = make(map[string]map[string][]byte)
+				}
+				if stub.purgedHashes[collection] == nil {
+					stub.purgedHashes[collection] = make(map[string][]byte)
+				}
+				hash := sha256.Sum256(value)
+				stub.purgedHashes[collection][key] = hash[:]
+				stub.removePrivateKey(collection, key)
+			}
+			delete(writeBlocks, key)
+		}
+	}
+}
+
+// removePrivateKey removes key from private state and sorted private keys list of collection
+func (stub *MockStub) removePrivateKey(collection, key string) {
+	delete(stub.PvtState[collection], key)
+
+	keys, ok := stub.PrivateKeys[collection]
+	if !ok {
+		return
+	}
+	for elem := keys.Front(); elem != nil; elem = elem.Next() {
+		if strings.Compare(key, elem.Value.(string)) == 0 {
+			keys.Remove(elem)
+			return
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package testing_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/router"
+	p "github.com/s7techlab/cckit/router/param"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+const expiringCollection = `expiring`
+
+func NewExpiringCC() *router.Chaincode {
+	r := router.New(`expiring`)
+
+	r.Invoke(`put`, func(c router.Context) (interface{}, error) {
+		return nil, c.Stub().PutPrivateData(expiringCollection, c.ParamString(`key`), []byte(c.ParamString(`value`)))
+	}, p.String(`key`), p.String(`value`)).
+		Query(`get`, func(c router.Context) (interface{}, error) {
+			return c.Stub().GetPrivateData(expiringCollection, c.ParamString(`key`))
+		}, p.String(`key`)).
+		Query(`keys`, func(c router.Context) (interface{}, error) {
+			iter, err := c.Stub().GetPrivateDataByRange(expiringCollection, ``, ``)
+			if err != nil {
+				return nil, err
+			}
+			defer func() { _ = iter.Close() }()
+
+			keys := []string{}
+			for iter.HasNext() {
+				kv, err := iter.Next()
+				if err != nil {
+					return nil, err
+				}
+				keys = append(keys, kv.Key)
+			}
+			return keys, nil
+		})
+
+	return router.NewChaincode(r)
+}
+
+var _ = Describe(`Block to live`, func() {
+
+	It("Allow to expire private data after block to live", func() {
+		cc := testcc.NewMockStub(`expiring`, NewExpiringCC(), testcc.WithCollectionBlockToLive(expiringCollection, 2))
+
+		expectcc.ResponseOk(cc.Invoke(`put`, `a`, `value-a`))
+		hash, err := cc.GetPrivateDataHash(expiringCollection, `a`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hash).NotTo(BeNil())
+
+		cc.AdvanceBlocks(2)
+		expectcc.PayloadBytes(cc.Query(`get`, `a`), []byte(`value-a`))
+
+		expectcc.ResponseOk(cc.Invoke(`put`, `b`, `value-b`))
+		Expect(expectcc.PayloadIs(cc.Query(`keys`), &[]string{})).To(Equal([]string{`b`}))
+		Expect(cc.Query(`get`, `a`).Payload).To(BeEmpty())
+
+		expiredHash, err := cc.GetPrivateDataHash(expiringCollection, `a`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(expiredHash).To(Equal(hash))
+	})
+
+	It("Allow to keep private data of collections without block to live", func() {
+		cc := testcc.NewMockStub(`expiring`, NewExpiringCC())
+
+		expectcc.ResponseOk(cc.Invoke(`put`, `a`, `value-a`))
+		cc.AdvanceBlocks(100)
+		expectcc.PayloadBytes(cc.Query(`get`, `a`), []byte(`value-a`))
+	})
+
+	It("Allow to renew block to live on rewrite", func() {
+		cc := testcc.NewMockStub(`expiring`, NewExpiringCC(), testcc.WithCollectionBlockToLive(expiringCollection, 1))
+
+		expectcc.ResponseOk(cc.Invoke(`put`, `a`, `value-a`))
+		cc.AdvanceBlocks(1)
+		expectcc.ResponseOk(cc.Invoke(`put`, `a`, `value-a2`))
+		cc.AdvanceBlocks(1)
+		expectcc.PayloadBytes(cc.Query(`get`, `a`), []byte(`value-a2`))
+	})
+})
@@ -0,0 +1,160 @@
+package testing_test
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/router"
+	p "github.com/s7techlab/cckit/router/param"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+type StatusCC struct{}
+
+func (cc StatusCC) Init(stub shim.ChaincodeStubInterface) peer.Response {
+	return shim.Success(nil)
+}
+
+// Invoke responds with status from first arg, message and payload from second
+func (cc StatusCC) Invoke(stub shim.ChaincodeStubInterface) peer.Response {
+	args := stub.GetStringArgs()
+	status, _ := strconv.Atoi(args[0])
+	return peer.Response{Status: int32(status), Message: args[1], Payload: []byte(args[1])}
+}
+
+type ObservedResponse struct {
+	Status  int32
+	Message string
+	Payload string
+}
+
+func NewStatusCallerCC() *router.Chaincode {
+	r := router.New(`status-caller`)
+
+	r.Query(`call`, func(c router.Context) (interface{}, error) {
+		res := c.Stub().InvokeChaincode(`status`,
+			[][]byte{[]byte(c.ParamString(`status`)), []byte(`callee response`)}, ``)
+		return ObservedResponse{Status: res.Status, Message: res.Message, Payload: string(res.Payload)}, nil
+	}, p.String(`status`))
+
+	return router.NewChaincode(r)
+}
+
+var _ = Describe(`Chaincode to chaincode response`, func() {
+
+	var caller *testcc.MockStub
+
+	BeforeEach(func() {
+		caller = testcc.NewMockStub(`status-caller`, NewStatusCallerCC())
+		caller.MockPeerChaincode(`status`, testcc.NewMockStub(`status`, StatusCC{}))
+	})
+
+	observe := func(status string) ObservedResponse {
+		return expectcc.PayloadIs(caller.Invoke(`call`, status), &ObservedResponse{}).(ObservedResponse)
+	}
+
+	It("Allow caller to observe callee success response", func() {
+		Expect(observe(`200`)).To(Equal(ObservedResponse{
+			Status: shim.OK, Message: `callee response`, Payload: `callee response`}))
+	})
+
+	It("Allow caller to observe callee client error response as is", func() {
+		Expect(observe(`400`)).To(Equal(ObservedResponse{
+			Status: 400, Message: `callee response`, Payload: `callee response`}))
+	})
+
+	It("Allow caller to observe callee error response as is", func() {
+		Expect(observe(`500`)).To(Equal(ObservedResponse{
+			Status: shim.ERROR, Message: `callee response`, Payload: `callee response`}))
+	})
+
+	It("Disallow to invoke not mocked chaincode", func() {
+		res := caller.InvokeChaincode(`unknown`, [][]byte{[]byte(`200`)}, ``)
+		Expect(res.Status).To(BeNumerically(`==`, shim.ERROR))
+		Expect(res.Message).To(ContainSubstring(testcc.ErrChaincodeNotExists.Error()))
+	})
+})
+
+var _ = Describe(`Mocked peer chaincodes`, func() {
+
+	It("Allow to link chaincodes concurrently with invokes", func() {
+		caller := testcc.NewMockStub(`status-caller`, NewStatusCallerCC())
+		Expect(caller.MockPeerChaincode(`status`, testcc.NewMockStub(`status`, StatusCC{}))).To(Succeed())
+
+		var wg sync.WaitGroup
+		for g := 0; g < 4; g++ {
+			wg.Add(1)
+			go func(g int) {
+				defer GinkgoRecover()
+				defer wg.Done()
+				for i := 0; i < 50; i++ {
+					Expect(caller.MockPeerChaincode(
+						fmt.Sprintf(`linked-%d-%d`, g, i), testcc.NewMockStub(`linked`, StatusCC{}))).To(Succeed())
+					caller.MockedPeerChaincodes()
+				}
+			}(g)
+		}
+
+		for i := 0; i < 100; i++ {
+			expectcc.ResponseOk(caller.Invoke(`call`, `200`))
+		}
+		wg.Wait()
+
+		Expect(caller.MockedPeerChaincodes()).To(HaveLen(201))
+	})
+
+	It("Allow to list mocked chaincodes sorted by chaincode and channel", func() {
+		caller := testcc.NewMockStub(`status-caller`, NewStatusCallerCC())
+		for _, name := range []string{`status/ch2`, `audit`, `status/ch1`, `status`} {
+			Expect(caller.MockPeerChaincode(name, testcc.NewMockStub(`linked`, StatusCC{}))).To(Succeed())
+		}
+
+		Expect(caller.MockedPeerChaincodes()).To(Equal([]string{`audit`, `status`, `status/ch1`, `status/ch2`}))
+		Expect(caller.MockedPeerChaincodeLinks()).To(Equal([]testcc.PeerChaincodeLink{
+			{Chaincode: `audit`}, {Chaincode: `status`},
+			{Chaincode: `status`, Channel: `ch1`}, {Chaincode: `status`, Channel: `ch2`}}))
+	})
+
+	It("Disallow to invoke not mocked chaincode with deterministic error", func() {
+		caller := testcc.NewMockStub(`status-caller`, NewStatusCallerCC())
+		Expect(caller.MockPeerChaincode(`status/ch2`, testcc.NewMockStub(`status`, StatusCC{}))).To(Succeed())
+		Expect(caller.MockPeerChaincode(`audit`, testcc.NewMockStub(`audit`, StatusCC{}))).To(Succeed())
+		Expect(caller.MockPeerChaincode(`status/ch1`, testcc.NewMockStub(`status`, StatusCC{}))).To(Succeed())
+
+		res := caller.InvokeChaincode(`unknown`, [][]byte{[]byte(`200`)}, `ch1`)
+		Expect(res.Message).To(Equal(`chaincode not exists: chaincode "unknown" is not mocked in any channel: ` +
+			`link it with MockPeerChaincode("unknown/ch1", stub). ` +
+			`Available mocked chaincodes: [audit, status/ch1, status/ch2]`))
+
+		res = caller.InvokeChaincode(`status`, [][]byte{[]byte(`200`)}, `ch3`)
+		Expect(res.Message).To(Equal(`chaincode not exists: chaincode "status" is not mocked in channel "ch3", ` +
+			`but is mocked in channels "ch1", "ch2": link it with MockPeerChaincode("status/ch3", stub) ` +
+			`or invoke it in mocked channel. Available mocked chaincodes: [audit, status/ch1, status/ch2]`))
+
+		res = caller.InvokeChaincode(`audit`, [][]byte{[]byte(`200`)}, `ch1`)
+		Expect(res.Message).To(ContainSubstring(`chaincode "audit" is not mocked in channel "ch1", ` +
+			`but is mocked in channels ""`))
+	})
+
+	It("Disallow to relink invoked chaincode to another stub", func() {
+		caller := testcc.NewMockStub(`status-caller`, NewStatusCallerCC())
+		status := testcc.NewMockStub(`status`, StatusCC{})
+		Expect(caller.MockPeerChaincode(`status`, testcc.NewMockStub(`status`, StatusCC{}))).To(Succeed())
+		// not invoked yet
+		Expect(caller.MockPeerChaincode(`status`, status)).To(Succeed())
+
+		expectcc.ResponseOk(caller.Invoke(`call`, `200`))
+		Expect(caller.MockPeerChaincode(`status`, status)).To(Succeed())
+
+		err := caller.MockPeerChaincode(`status`, testcc.NewMockStub(`status`, StatusCC{}))
+		Expect(errors.Is(err, testcc.ErrPeerChaincodeRelinked)).To(BeTrue())
+	})
+})
@@ -0,0 +1,73 @@
+package testing_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/router"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+func NewChannelCallerCC() *router.Chaincode {
+	r := router.New(`caller`)
+
+	r.Query(`channels`, func(c router.Context) (interface{}, error) {
+		before := c.Stub().GetChannelID()
+		res := c.Stub().InvokeChaincode(`reference`, [][]byte{[]byte(`channel`)}, `reference`)
+		return []string{before, string(res.Payload), c.Stub().GetChannelID()}, nil
+	})
+
+	return router.NewChaincode(r)
+}
+
+func NewChannelReferenceCC() *router.Chaincode {
+	r := router.New(`reference`)
+
+	r.Query(`channel`, func(c router.Context) (interface{}, error) {
+		return c.Stub().GetChannelID(), nil
+	}).
+		Query(`panic`, func(c router.Context) (interface{}, error) {
+			panic(`reference failure`)
+		})
+
+	return router.NewChaincode(r)
+}
+
+var _ = Describe(`Cross channel invoke`, func() {
+
+	It("Allow callee to observe channel of invocation", func() {
+		caller := testcc.NewMockStub(`caller`, NewChannelCallerCC())
+		caller.ChannelID = `main`
+		reference := testcc.NewMockStub(`reference`, NewChannelReferenceCC())
+		caller.MockPeerChaincode(`reference/reference`, reference)
+
+		Expect(expectcc.PayloadIs(caller.Query(`channels`), &[]string{})).To(
+			Equal([]string{`main`, `reference`, `main`}))
+		Expect(reference.ChannelID).To(BeEmpty())
+	})
+
+	It("Allow callee on same channel to observe caller channel", func() {
+		caller := testcc.NewMockStub(`caller`, nil)
+		caller.ChannelID = `main`
+		reference := testcc.NewMockStub(`reference`, NewChannelReferenceCC())
+		caller.MockPeerChaincode(`reference`, reference)
+
+		expectcc.PayloadString(caller.InvokeChaincode(`reference`, [][]byte{[]byte(`channel`)}, ``), `main`)
+	})
+
+	It("Allow to restore callee channel after panic", func() {
+		caller := testcc.NewMockStub(`caller`, nil)
+		caller.ChannelID = `main`
+		reference := testcc.NewMockStub(`reference`, NewChannelReferenceCC())
+		reference.ChannelID = `reference-default`
+		caller.MockPeerChaincode(`reference/reference`, reference)
+
+		Expect(func() {
+			caller.InvokeChaincode(`reference`, [][]byte{[]byte(`panic`)}, `reference`)
+		}).To(Panic())
+
+		Expect(caller.GetChannelID()).To(Equal(`main`))
+		Expect(reference.GetChannelID()).To(Equal(`reference-default`))
+	})
+})
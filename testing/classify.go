@@ -0,0 +1,27 @@
+package testing
+
+import (
+	"github.com/hyperledger/fabric-protos-go/peer"
+
+	"github.com/s7techlab/cckit/testing/expect"
+)
+
+// IsNotFoundResponse returns true if response is not found error, see expect.Classify
+func IsNotFoundResponse(response peer.Response, opts ...expect.ClassifyOpt) bool {
+	return isErrorClass(response, expect.ErrorClassNotFound, opts)
+}
+
+// IsForbiddenResponse returns true if response is forbidden error, see expect.Classify
+func IsForbiddenResponse(response peer.Response, opts ...expect.ClassifyOpt) bool {
+	return isErrorClass(response, expect.ErrorClassForbidden, opts)
+}
+
+// IsValidationResponse returns true if response is validation error, see expect.Classify
+func IsValidationResponse(response peer.Response, opts ...expect.ClassifyOpt) bool {
+	return isErrorClass(response, expect.ErrorClassValidation, opts)
+}
+
+func isErrorClass(response peer.Response, class expect.ErrorClass, opts []expect.ClassifyOpt) bool {
+	actual, ok := expect.Classify(response, opts...)
+	return ok && actual == class
+}
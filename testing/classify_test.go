@@ -0,0 +1,74 @@
+package testing_test
+
+import (
+	"errors"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/router"
+	p "github.com/s7techlab/cckit/router/param"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+// shimErrorCC returns plain shim.Error with message from first arg or response with status from second arg
+type shimErrorCC struct{}
+
+func (shimErrorCC) Init(shim.ChaincodeStubInterface) peer.Response {
+	return shim.Success(nil)
+}
+
+func (shimErrorCC) Invoke(stub shim.ChaincodeStubInterface) peer.Response {
+	args := stub.GetStringArgs()
+	if len(args) > 1 {
+		return peer.Response{Status: map[string]int32{`404`: 404, `403`: 403, `400`: 400}[args[1]], Message: args[0]}
+	}
+	return shim.Error(args[0])
+}
+
+var _ = Describe(`Error response classification`, func() {
+
+	It("Allow to classify router errors", func() {
+		r := router.New(`classified`)
+		r.Query(`get`, func(c router.Context) (interface{}, error) {
+			return nil, errors.New(`state entry not found`)
+		}, p.String(`key`))
+		cc := testcc.NewMockStub(`classified`, router.NewChaincode(r))
+
+		Expect(testcc.IsNotFoundResponse(cc.Query(`unknown`))).To(BeTrue())
+		Expect(testcc.IsNotFoundResponse(cc.Query(`get`, `key`))).To(BeTrue())
+		Expect(testcc.IsValidationResponse(cc.Query(`get`))).To(BeTrue())
+		Expect(testcc.IsForbiddenResponse(cc.Query(`get`))).To(BeFalse())
+
+		expectcc.ResponseError(cc.Query(`unknown`), expectcc.ErrorClassNotFound)
+		expectcc.ResponseValidation(cc.Query(`get`))
+	})
+
+	It("Allow to classify plain shim errors by message patterns of Fabric versions", func() {
+		cc := testcc.NewMockStub(`shim`, shimErrorCC{})
+
+		v14Forbidden := cc.Invoke(`access denied: channel [ch] creator org [Org1MSP]`)
+		v2Forbidden := cc.Invoke(`access denied for [query]: signature set did not satisfy policy`)
+
+		Expect(testcc.IsForbiddenResponse(v14Forbidden)).To(BeTrue())
+		Expect(testcc.IsForbiddenResponse(v2Forbidden)).To(BeTrue())
+
+		Expect(testcc.IsForbiddenResponse(v14Forbidden, expectcc.WithFabricVersions(expectcc.FabricV2))).To(BeFalse())
+		Expect(testcc.IsForbiddenResponse(v2Forbidden, expectcc.WithFabricVersions(expectcc.FabricV2))).To(BeTrue())
+
+		Expect(testcc.IsNotFoundResponse(cc.Invoke(`chaincode definition for 'cars' not found`))).To(BeTrue())
+		Expect(testcc.IsValidationResponse(cc.Invoke(`Incorrect number of arguments. Expecting 2`))).To(BeTrue())
+		Expect(testcc.IsNotFoundResponse(cc.Invoke(`something went wrong`))).To(BeFalse())
+	})
+
+	It("Allow to classify responses with structured status regardless of message", func() {
+		cc := testcc.NewMockStub(`shim`, shimErrorCC{})
+
+		Expect(testcc.IsNotFoundResponse(cc.Invoke(`no such car`, `404`))).To(BeTrue())
+		Expect(testcc.IsForbiddenResponse(cc.Invoke(`go away`, `403`))).To(BeTrue())
+		expectcc.ResponseValidation(cc.Invoke(`bad car`, `400`))
+	})
+})
@@ -0,0 +1,120 @@
+package testing
+
+import (
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+)
+
+type (
+	// Clock source of tx timestamps and block height of MockStub. Timestamp of tx, set via At,
+	// takes precedence over clock for this tx only
+	Clock interface {
+		// Now returns timestamp of next tx
+		Now() time.Time
+		// BlockHeight returns number of committed blocks
+		BlockHeight() uint64
+		// SetBlock sets block height, MockStub increments block height on each tx end
+		SetBlock(n uint64)
+	}
+
+	// MockClock test controllable clock, safe for sharing between stubs
+	MockClock struct {
+		m             sync.Mutex
+		now           time.Time
+		block         uint64
+		blockInterval time.Duration
+	}
+
+	// systemClock default clock: wall clock time and logical block counter
+	systemClock struct {
+		block uint64
+	}
+)
+
+// WithClock sets clock, used for tx timestamps, block height and private data block to live
+func WithClock(clock Clock) MockStubOpt {
+	return func(stub *MockStub) {
+		stub.clock = clock
+	}
+}
+
+// Clock returns clock of stub
+func (stub *MockStub) Clock() Clock {
+	return stub.clock
+}
+
+// NewMockClock creates clock, stopped at now
+func NewMockClock(now time.Time) *MockClock {
+	return &MockClock{now: now}
+}
+
+// WithBlockInterval sets block interval: Advance increments block height by number of whole intervals
+func (c *MockClock) WithBlockInterval(interval time.Duration) *MockClock {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.blockInterval = interval
+	return c
+}
+
+// Now returns current clock time
+func (c *MockClock) Now() time.Time {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	return c.now
+}
+
+// BlockHeight returns current block height
+func (c *MockClock) BlockHeight() uint64 {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	return c.block
+}
+
+// SetBlock sets block height
+func (c *MockClock) SetBlock(n uint64) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.block = n
+}
+
+// Advance moves clock time forward, block height is advanced too, if block interval is set
+func (c *MockClock) Advance(d time.Duration) *MockClock {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.now = c.now.Add(d)
+	if c.blockInterval > 0 {
+		c.block += uint64(d / c.blockInterval)
+	}
+	return c
+}
+
+func (c *systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (c *systemClock) BlockHeight() uint64 {
+	return c.block
+}
+
+func (c *systemClock) SetBlock(n uint64) {
+	c.block = n
+}
+
+// clockTimestamp returns timestamp of next tx: mocked via At or clock time
+func (stub *MockStub) clockTimestamp() *timestamp.Timestamp {
+	if stub.txTimestamp != nil {
+		return stub.txTimestamp
+	}
+
+	ts, err := ptypes.TimestampProto(stub.clock.Now())
+	PanicIfError(err)
+	return ts
+}
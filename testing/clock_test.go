@@ -0,0 +1,86 @@
+package testing_test
+
+import (
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/access"
+	"github.com/s7techlab/cckit/identity"
+	"github.com/s7techlab/cckit/router"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+func NewGrantCheckCC() *router.Chaincode {
+	r := router.New(`grant`)
+
+	r.Query(`checkGrant`, func(c router.Context) (interface{}, error) {
+		creator, err := identity.FromStub(c.Stub())
+		if err != nil {
+			return nil, err
+		}
+		grant, err := access.GrantFromIdentity(creator)
+		if err != nil {
+			return nil, err
+		}
+		return nil, access.CheckGrantNotExpired(c.Stub(), grant)
+	})
+
+	return router.NewChaincode(r)
+}
+
+var _ = Describe(`Clock`, func() {
+
+	var (
+		expiresAt time.Time
+		clock     *testcc.MockClock
+	)
+
+	BeforeEach(func() {
+		grant, err := access.GrantFromIdentity(Authority)
+		Expect(err).NotTo(HaveOccurred())
+		expiresAt, err = access.GrantExpiresAt(grant)
+		Expect(err).NotTo(HaveOccurred())
+
+		clock = testcc.NewMockClock(expiresAt.Add(-time.Hour)).WithBlockInterval(time.Hour)
+	})
+
+	It("Allow to use clock as tx timestamp source", func() {
+		cc := testcc.NewMockStub(`grant`, NewGrantCheckCC(), testcc.WithClock(clock))
+
+		cc.MockTransactionStart(`tx`)
+		ts, err := cc.GetTxTimestamp()
+		cc.MockTransactionEnd(`tx`)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ptypes.Timestamp(ts)).To(Equal(expiresAt.Add(-time.Hour)))
+		Expect(clock.BlockHeight()).To(BeNumerically(`==`, 1))
+		Expect(cc.BlockHeight()).To(BeNumerically(`==`, 1))
+	})
+
+	It("Allow to expire grant and private data in one clock advance", func() {
+		grantCC := testcc.NewMockStub(`grant`, NewGrantCheckCC(), testcc.WithClock(clock))
+		expiringCC := testcc.NewMockStub(`expiring`, NewExpiringCC(),
+			testcc.WithClock(clock), testcc.WithCollectionBlockToLive(expiringCollection, 2))
+
+		expectcc.ResponseOk(expiringCC.Invoke(`put`, `a`, `value-a`))
+		expectcc.ResponseOk(grantCC.From(Authority).Query(`checkGrant`))
+		expectcc.PayloadBytes(expiringCC.Query(`get`, `a`), []byte(`value-a`))
+
+		clock.Advance(3 * time.Hour)
+
+		expectcc.ResponseError(grantCC.From(Authority).Query(`checkGrant`), access.ErrGrantExpired)
+		Expect(expiringCC.Query(`get`, `a`).Payload).To(BeEmpty())
+	})
+
+	It("Allow to override clock for single tx with At", func() {
+		cc := testcc.NewMockStub(`grant`, NewGrantCheckCC(), testcc.WithClock(clock))
+		clock.Advance(2 * time.Hour)
+
+		expectcc.ResponseOk(cc.From(Authority).At(testcc.MustProtoTimestamp(expiresAt)).Query(`checkGrant`))
+		expectcc.ResponseError(cc.From(Authority).Query(`checkGrant`), access.ErrGrantExpired)
+	})
+})
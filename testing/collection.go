@@ -0,0 +1,148 @@
+package testing
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/pkg/errors"
+)
+
+// ImplicitCollectionPrefix prefix of org implicit private data collection name
+const ImplicitCollectionPrefix = `_implicit_org_`
+
+// ErrCollectionReadDenied occurs when tx creator MSP is not a member of private data collection
+var ErrCollectionReadDenied = errors.New(`tx creator does not have read access permission on collection`)
+
+// WithCollection configures private data collection members. Only members can read collection data
+// during tx, writes and GetPrivateDataHash are allowed for all orgs, as in Fabric.
+// Not configured collections are not restricted, except implicit org collections
+func WithCollection(name string, memberMSPs ...string) MockStubOpt {
+	return func(stub *MockStub) {
+		if stub.collections == nil {
+			stub.collections = make(map[string][]string)
+		}
+		stub.collections[name] = memberMSPs
+	}
+}
+
+// ImplicitCollection returns name of org implicit private data collection, member of which is only this org
+func ImplicitCollection(mspID string) string {
+	return ImplicitCollectionPrefix + mspID
+}
+
+// GetPrivateData mocked, collection read access is checked
+func (stub *MockStub) GetPrivateData(collection string, key string) ([]byte, error) {
+	if fn, ok := stub.overrides[`GetPrivateData`].(func(string, string) ([]byte, error)); ok {
+		return fn(collection, key)
+	}
+	if err := stub.checkPrivateDataInit(); err != nil {
+		return nil, err
+	}
+	if err := stub.checkCollectionRead(collection); err != nil {
+		return nil, err
+	}
+	value, err := stub.MockStub.GetPrivateData(collection, key)
+	stub.trackPrivateValue(collection, key, value)
+	return stub.copyValue(value), err
+}
+
+// GetPrivateDataByRange mocked, returns keys of half-open range [startKey, endKey) in lexicographic order,
+// empty start or end key means start or end of key space. Collection read access is checked
+func (stub *MockStub) GetPrivateDataByRange(collection, startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+	if err := checkCollectionName(collection); err != nil {
+		return nil, err
+	}
+	if err := stub.checkPrivateDataInit(); err != nil {
+		return nil, err
+	}
+	if err := stub.checkCollectionRead(collection); err != nil {
+		return nil, err
+	}
+	return stub.trackIterator(NewPrivateMockStateRangeQueryIterator(stub, collection, startKey, endKey), nil,
+		fmt.Sprintf(`GetPrivateDataByRange(%q, %q, %q)`, collection, startKey, endKey))
+}
+
+// GetPrivateDataHash mocked, returns sha256 hash of private data value, nil if key not exists.
+// Hash of private data, purged by block to live, is returned as well
+func (stub *MockStub) GetPrivateDataHash(collection, key string) ([]byte, error) {
+	if err := stub.checkPrivateDataInit(); err != nil {
+		return nil, err
+	}
+	value := stub.PvtState[collection][key]
+	if value == nil {
+		return stub.purgedHashes[collection][key], nil
+	}
+
+	hash := sha256.Sum256(value)
+	return hash[:], nil
+}
+
+// PrivateCollections returns sorted names of private data collections in committed private state
+func (stub *MockStub) PrivateCollections() []string {
+	collections := make([]string, 0, len(stub.PvtState))
+	for collection := range stub.PvtState {
+		collections = append(collections, collection)
+	}
+	sort.Strings(collections)
+	return collections
+}
+
+// PrivateCollectionSize returns number of keys of collection in committed private state
+func (stub *MockStub) PrivateCollectionSize(collection string) int {
+	return len(stub.PvtState[collection])
+}
+
+// PrivateKeysOf returns sorted copy of collection keys in committed private state
+func (stub *MockStub) PrivateKeysOf(collection string) []string {
+	keys := make([]string, 0, len(stub.PvtState[collection]))
+	for key := range stub.PvtState[collection] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// collectionMembers returns collection member orgs, false if collection is not restricted
+func (stub *MockStub) collectionMembers(collection string) ([]string, bool) {
+	if strings.HasPrefix(collection, ImplicitCollectionPrefix) {
+		return []string{strings.TrimPrefix(collection, ImplicitCollectionPrefix)}, true
+	}
+
+	members, ok := stub.collections[collection]
+	return members, ok
+}
+
+// checkCollectionRead checks tx creator is collection member, reads outside tx are not restricted
+func (stub *MockStub) checkCollectionRead(collection string) error {
+	if stub.TxID == `` {
+		return nil
+	}
+
+	members, restricted := stub.collectionMembers(collection)
+	if !restricted {
+		return nil
+	}
+
+	creator, err := stub.creatorIdentity()
+	if err != nil {
+		return fmt.Errorf(`%w %s: creator: %s`, ErrCollectionReadDenied, collection, err)
+	}
+
+	for _, member := range members {
+		if member == creator.Mspid {
+			return nil
+		}
+	}
+
+	return fmt.Errorf(`%w %s: %s`, ErrCollectionReadDenied, collection, creator.Mspid)
+}
+
+func checkCollectionName(collection string) error {
+	if collection == `` {
+		return ErrCollectionNameEmpty
+	}
+	return nil
+}
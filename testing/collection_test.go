@@ -0,0 +1,114 @@
+package testing_test
+
+import (
+	"crypto/sha256"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	idtestdata "github.com/s7techlab/cckit/identity/testdata"
+	"github.com/s7techlab/cckit/router"
+	p "github.com/s7techlab/cckit/router/param"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+func NewCollectionsCC() *router.Chaincode {
+	r := router.New(`collections`)
+
+	r.Invoke(`put`, func(c router.Context) (interface{}, error) {
+		return nil, c.Stub().PutPrivateData(c.ParamString(`collection`), `key`, []byte(`value`))
+	}, p.String(`collection`)).
+		Query(`get`, func(c router.Context) (interface{}, error) {
+			return c.Stub().GetPrivateData(c.ParamString(`collection`), `key`)
+		}, p.String(`collection`)).
+		Query(`hash`, func(c router.Context) (interface{}, error) {
+			return c.Stub().GetPrivateDataHash(c.ParamString(`collection`), `key`)
+		}, p.String(`collection`))
+
+	return router.NewChaincode(r)
+}
+
+var _ = Describe(`Private data collections`, func() {
+
+	member := idtestdata.Certificates[0].MustIdentity(`Org1MSP`)
+	nonMember := idtestdata.Certificates[1].MustIdentity(`Org2MSP`)
+	valueHash := sha256.Sum256([]byte(`value`))
+
+	It("Allow only collection members to read collection data", func() {
+		cc := testcc.NewMockStub(`collections`, NewCollectionsCC(), testcc.WithCollection(`shared`, `Org1MSP`))
+
+		expectcc.ResponseOk(cc.From(nonMember).Invoke(`put`, `shared`))
+		expectcc.PayloadBytes(cc.From(member).Query(`get`, `shared`), []byte(`value`))
+		expectcc.ResponseError(cc.From(nonMember).Query(`get`, `shared`), testcc.ErrCollectionReadDenied)
+		expectcc.PayloadBytes(cc.From(nonMember).Query(`hash`, `shared`), valueHash[:])
+
+		// not configured collections are not restricted
+		expectcc.ResponseOk(cc.From(member).Invoke(`put`, `other`))
+		expectcc.PayloadBytes(cc.From(nonMember).Query(`get`, `other`), []byte(`value`))
+	})
+
+	It("Allow only org to read its implicit collection", func() {
+		cc := testcc.NewMockStub(`collections`, NewCollectionsCC())
+		implicit := testcc.ImplicitCollection(`Org1MSP`)
+
+		expectcc.ResponseOk(cc.From(nonMember).Invoke(`put`, implicit))
+		expectcc.PayloadBytes(cc.From(member).Query(`get`, implicit), []byte(`value`))
+		expectcc.ResponseError(cc.From(nonMember).Query(`get`, implicit), testcc.ErrCollectionReadDenied)
+		expectcc.PayloadBytes(cc.From(nonMember).Query(`hash`, implicit), valueHash[:])
+	})
+
+	It("Allow to inspect keys of committed private collections", func() {
+		cc := testcc.NewMockStub(`collections`, nil)
+		cc.MockTransactionStart(`tx`)
+		for _, write := range [][2]string{{`b`, `2`}, {`a`, `1`}, {`a`, `3`}, {`a`, `2`}} {
+			Expect(cc.PutPrivateData(write[0], write[1], []byte(`value`))).To(Succeed())
+		}
+		cc.MockTransactionEnd(`tx`)
+
+		Expect(cc.PrivateCollections()).To(Equal([]string{`a`, `b`}))
+		Expect(cc.PrivateCollectionSize(`a`)).To(Equal(3))
+		Expect(cc.PrivateCollectionSize(`c`)).To(Equal(0))
+		Expect(cc.PrivateKeysOf(`a`)).To(Equal([]string{`1`, `2`, `3`}))
+		Expect(cc.PrivateKeysOf(`c`)).To(BeEmpty())
+
+		// returned slices are copies
+		collections, keys := cc.PrivateCollections(), cc.PrivateKeysOf(`a`)
+		collections[0], keys[0] = `changed`, `changed`
+		Expect(cc.PrivateCollections()).To(Equal([]string{`a`, `b`}))
+		Expect(cc.PrivateKeysOf(`a`)).To(Equal([]string{`1`, `2`, `3`}))
+	})
+
+	It("Allow to get private data range in lexicographic order", func() {
+		cc := testcc.NewMockStub(`collections`, nil)
+		Expect(cc.SeedPrivateState(`shared`, map[string][]byte{
+			`d`: []byte(`4`), `b`: []byte(`2`), `e`: []byte(`5`), `a`: []byte(`1`), `c`: []byte(`3`),
+		})).To(Succeed())
+
+		rangeKeys := func(startKey, endKey string) []string {
+			iter, err := cc.GetPrivateDataByRange(`shared`, startKey, endKey)
+			Expect(err).NotTo(HaveOccurred())
+			keys := []string{}
+			for _, kv := range readAll(iter) {
+				keys = append(keys, kv.Key)
+			}
+			return keys
+		}
+
+		Expect(rangeKeys(`b`, `d`)).To(Equal([]string{`b`, `c`}))
+		Expect(rangeKeys(`bb`, `x`)).To(Equal([]string{`c`, `d`, `e`}))
+		Expect(rangeKeys(``, `c`)).To(Equal([]string{`a`, `b`}))
+		Expect(rangeKeys(`c`, ``)).To(Equal([]string{`c`, `d`, `e`}))
+		Expect(rangeKeys(``, ``)).To(Equal([]string{`a`, `b`, `c`, `d`, `e`}))
+		Expect(rangeKeys(`x`, ``)).To(BeEmpty())
+	})
+
+	It("Disallow to query private data with empty collection name", func() {
+		cc := testcc.NewMockStub(`collections`, nil)
+
+		_, err := cc.GetPrivateDataByRange(``, ``, ``)
+		Expect(err).To(MatchError(testcc.ErrCollectionNameEmpty))
+		_, err = cc.GetPrivateDataQueryResult(``, `{"selector":{}}`)
+		Expect(err).To(MatchError(testcc.ErrCollectionNameEmpty))
+	})
+})
@@ -0,0 +1,126 @@
+package testing
+
+import (
+	"sort"
+)
+
+// CommittedView read-only view of committed state and private data, created with CommittedView.
+// Each read waits for in-flight invoke, init or simulation commit, so buffered and not yet committed
+// writes of concurrently running tx are never observed. Returned values are copies.
+// Manual tx, started with MockTransactionStart, is not guarded: its deletes and private writes are visible
+type CommittedView struct {
+	stub *MockStub
+}
+
+// CommittedView returns read-only view of committed data, safe to use for assertions from other goroutines
+// while invokes are running
+func (stub *MockStub) CommittedView() *CommittedView {
+	return &CommittedView{stub: stub}
+}
+
+// read runs fn with stub locked, panics with ErrReentrantInvoke if called from hook
+func (v *CommittedView) read(api string, fn func()) {
+	v.stub.checkReentrant(`CommittedView.` + api)
+	v.stub.m.Lock()
+	defer v.stub.m.Unlock()
+	fn()
+}
+
+// GetState returns committed value of key
+func (v *CommittedView) GetState(key string) (value []byte, ok bool) {
+	v.read(`GetState`, func() {
+		value, ok = v.stub.State[key]
+		value = copyBytes(value)
+	})
+	return value, ok
+}
+
+// GetStateByRange returns committed entries in half-open key range [startKey, endKey) ordered by key,
+// empty endKey means open range
+func (v *CommittedView) GetStateByRange(startKey, endKey string) []*QueryResultEntry {
+	var entries []*QueryResultEntry
+	v.read(`GetStateByRange`, func() {
+		entries = committedRange(v.stub.State, startKey, endKey)
+	})
+	return entries
+}
+
+// GetQueryResult evaluates rich query against committed state, see MockStub.GetQueryResult
+func (v *CommittedView) GetQueryResult(query string) ([]*QueryResultEntry, error) {
+	q, err := ParseRichQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*QueryResultEntry
+	v.read(`GetQueryResult`, func() {
+		entries, err = v.stub.queryDocuments(q, v.stub.queryCandidateKeys(q.Selector), v.stub.State)
+		copyEntries(entries)
+	})
+	return entries, err
+}
+
+// GetPrivateData returns committed value of private key
+func (v *CommittedView) GetPrivateData(collection, key string) (value []byte, ok bool) {
+	v.read(`GetPrivateData`, func() {
+		value, ok = v.stub.PvtState[collection][key]
+		value = copyBytes(value)
+	})
+	return value, ok
+}
+
+// GetPrivateDataByRange returns committed private entries of collection in key range, see GetStateByRange
+func (v *CommittedView) GetPrivateDataByRange(collection, startKey, endKey string) []*QueryResultEntry {
+	var entries []*QueryResultEntry
+	v.read(`GetPrivateDataByRange`, func() {
+		entries = committedRange(v.stub.PvtState[collection], startKey, endKey)
+	})
+	return entries
+}
+
+// GetPrivateDataQueryResult evaluates rich query against committed private data of collection
+func (v *CommittedView) GetPrivateDataQueryResult(collection, query string) ([]*QueryResultEntry, error) {
+	q, err := ParseRichQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*QueryResultEntry
+	v.read(`GetPrivateDataQueryResult`, func() {
+		values := v.stub.PvtState[collection]
+		entries, err = v.stub.queryDocuments(q, sortedKeys(values), values)
+		copyEntries(entries)
+	})
+	return entries, err
+}
+
+func committedRange(values map[string][]byte, startKey, endKey string) []*QueryResultEntry {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		if key >= startKey && (endKey == `` || key < endKey) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	entries := make([]*QueryResultEntry, len(keys))
+	for i, key := range keys {
+		entries[i] = &QueryResultEntry{Key: key, Value: copyBytes(values[key])}
+	}
+	return entries
+}
+
+// copyEntries replaces entry values with copies, query entries can share values with state
+// if defensive copies are disabled
+func copyEntries(entries []*QueryResultEntry) {
+	for _, entry := range entries {
+		entry.Value = copyBytes(entry.Value)
+	}
+}
+
+func copyBytes(value []byte) []byte {
+	if value == nil {
+		return nil
+	}
+	return append(make([]byte, 0, len(value)), value...)
+}
@@ -0,0 +1,104 @@
+package testing_test
+
+import (
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/router"
+	p "github.com/s7techlab/cckit/router/param"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+// NewMoveCC moves value between keys: source key is deleted before destination key is put,
+// so the value exists under exactly one key in committed state
+func NewMoveCC() *router.Chaincode {
+	r := router.New(`move`)
+
+	r.Invoke(`move`, func(c router.Context) (interface{}, error) {
+		value, err := c.Stub().GetState(c.ParamString(`from`))
+		if err != nil {
+			return nil, err
+		}
+		if err = c.Stub().DelState(c.ParamString(`from`)); err != nil {
+			return nil, err
+		}
+		return nil, c.Stub().PutState(c.ParamString(`to`), value)
+	}, p.String(`from`), p.String(`to`))
+
+	r.Invoke(`putPrivate`, func(c router.Context) (interface{}, error) {
+		return nil, c.Stub().PutPrivateData(`secrets`, c.ParamString(`key`), []byte(`{"value":1}`))
+	}, p.String(`key`))
+
+	return router.NewChaincode(r)
+}
+
+var _ = Describe(`Committed view`, func() {
+
+	It("Allow to read committed state concurrently with invokes", func() {
+		cc := testcc.NewMockStub(`move`, NewMoveCC())
+		Expect(cc.SeedState(map[string][]byte{`a`: []byte(`{"value":1}`)})).To(Succeed())
+		view := cc.CommittedView()
+
+		const moves = 200
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer GinkgoRecover()
+			for i := 0; i < moves; i++ {
+				if i%2 == 0 {
+					expectcc.ResponseOk(cc.Invoke(`move`, `a`, `b`))
+				} else {
+					expectcc.ResponseOk(cc.Invoke(`move`, `b`, `a`))
+				}
+			}
+		}()
+
+		for i := 0; i < moves; i++ {
+			if value, ok := view.GetState(`a`); ok {
+				Expect(value).To(Equal([]byte(`{"value":1}`)))
+			}
+
+			entries := view.GetStateByRange(``, ``)
+			Expect(entries).To(HaveLen(1))
+			Expect(entries[0].Value).To(Equal([]byte(`{"value":1}`)))
+
+			entries, err := view.GetQueryResult(`{"selector":{"value":1}}`)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries).To(HaveLen(1))
+		}
+		wg.Wait()
+
+		value, ok := view.GetState(`a`)
+		Expect(ok).To(BeTrue())
+		Expect(value).To(Equal([]byte(`{"value":1}`)))
+	})
+
+	It("Allow to read committed private data", func() {
+		cc := testcc.NewMockStub(`move`, NewMoveCC())
+		view := cc.CommittedView()
+
+		expectcc.ResponseOk(cc.Invoke(`putPrivate`, `k1`))
+		expectcc.ResponseOk(cc.Invoke(`putPrivate`, `k2`))
+
+		value, ok := view.GetPrivateData(`secrets`, `k1`)
+		Expect(ok).To(BeTrue())
+		Expect(value).To(Equal([]byte(`{"value":1}`)))
+
+		entries := view.GetPrivateDataByRange(`secrets`, `k2`, ``)
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].Key).To(Equal(`k2`))
+
+		entries, err := view.GetPrivateDataQueryResult(`secrets`, `{"selector":{"value":1}}`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(2))
+
+		// returned values are copies
+		value[0] = 'x'
+		value, _ = view.GetPrivateData(`secrets`, `k1`)
+		Expect(value).To(Equal([]byte(`{"value":1}`)))
+	})
+})
@@ -0,0 +1,30 @@
+package testing
+
+import (
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// Compatibility layer with s7techlab/cckit testing package.
+// Intentional behavioral differences are documented with deprecation notices
+
+// At mocks timestamp of next tx, cleared after invoke with creator and transient map
+func (stub *MockStub) At(txTimestamp *timestamp.Timestamp) *MockStub {
+	stub.txTimestamp = txTimestamp
+	return stub
+}
+
+// EventSubscriptionWithCloser returns channel with events and subscription closer, see EventSubscription
+//
+// Deprecated: use EventSubscription, it has the same signature as in s7techlab/cckit
+func (stub *MockStub) EventSubscriptionWithCloser(from ...int64) (events chan *peer.ChaincodeEvent, closer func() error) {
+	return stub.EventSubscription(from...)
+}
+
+// ChaincodeEvents returns committed events history
+func (stub *MockStub) ChaincodeEvents() []*peer.ChaincodeEvent {
+	stub.subscriptionsM.Lock()
+	defer stub.subscriptionsM.Unlock()
+
+	return append([]*peer.ChaincodeEvent{}, stub.chaincodeEvents...)
+}
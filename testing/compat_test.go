@@ -0,0 +1,86 @@
+package testing_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/s7techlab/cckit/examples/cars"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+	"github.com/s7techlab/cckit/testing/testdata"
+)
+
+// upstreamUsage mirrors typical s7techlab/cckit testing package usage, compile only
+var _ = func() {
+	cc := testcc.NewMockStub(ChaincodeName, cars.New())
+	proxy := testcc.NewMockStub(ChaincodeProxyName, cars.NewProxy(Channel, ChaincodeName))
+	cc.MockPeerChaincode(ChaincodeProxyName+`/`+Channel, proxy)
+	_ = cc.MockedPeerChaincodes()
+
+	expectcc.ResponseOk(cc.From(Authority).Init())
+	expectcc.ResponseOk(cc.From(Authority).At(testcc.MustProtoTimestamp(time.Now())).
+		WithTransient(map[string][]byte{`key`: []byte(`value`)}).
+		Invoke(`carRegister`, cars.Payloads[0]))
+	expectcc.ResponseError(cc.Query(`carGet`, `unknown`), `not found`)
+	_ = expectcc.PayloadIs(cc.Query(`carList`), &[]cars.Car{}).([]cars.Car)
+	_ = expectcc.EventPayloadIs(cc.ChaincodeEvent, &cars.Car{}).(cars.Car)
+
+	events, closer := cc.EventSubscription(0)
+	_ = <-events
+	_ = closer()
+	cc.ClearEvents()
+
+	_ = cc.MockInvoke(`tx`, [][]byte{[]byte(`carList`)})
+	_ = cc.DumpStateBuffer
+	_ = cc.State
+	_ = cc.PvtState
+	_ = cc.ChaincodeEventsChannel
+
+	mockedPeer := testcc.NewPeer().WithChannel(Channel, cc, proxy)
+	_, _, _ = mockedPeer.Invoke(context.Background(), Authority, Channel, ChaincodeName, `carList`, nil, nil)
+	_, _ = mockedPeer.Query(context.Background(), Authority, Channel, ChaincodeName, `carList`, nil, nil)
+	_, _ = mockedPeer.Subscribe(context.Background(), Authority, Channel, ChaincodeName)
+
+	_ = testcc.MustProtoMarshal(&peer.ChaincodeEvent{})
+	_ = testcc.MustJSONMarshal(cars.Payloads[0])
+	_ = testcc.MustTime(`2021-01-01T00:00:00Z`)
+}
+
+var _ = Describe(`Compatibility`, func() {
+
+	It("Allow to mock tx timestamp", func() {
+		cc := testcc.NewMockStub(`entities`, testdata.NewEntitiesCC())
+		ts := testcc.MustTime(`2021-02-15T00:00:00Z`)
+
+		expectcc.ResponseOk(cc.At(ts).Invoke(`entityCreate`, testdata.Entity{Id: `a`, Owner: `owner1`}))
+		Expect(cc.TxTimestamp).To(Equal(ts))
+
+		expectcc.ResponseOk(cc.Invoke(`entityCreate`, testdata.Entity{Id: `b`, Owner: `owner1`}))
+		Expect(cc.TxTimestamp).NotTo(Equal(ts))
+	})
+
+	It("Allow to replay committed events to subscription and close it", func() {
+		cc := testcc.NewMockStub(`entities`, testdata.NewEntitiesCC())
+		expectcc.ResponseOk(cc.Invoke(`entityCreate`, testdata.Entity{Id: `a`, Owner: `owner1`}))
+		expectcc.ResponseOk(cc.Invoke(`entityCreate`, testdata.Entity{Id: `b`, Owner: `owner1`}))
+
+		events, closer := cc.EventSubscription(1)
+		Expect(events).To(HaveLen(1))
+
+		expectcc.ResponseOk(cc.Invoke(`entityCreate`, testdata.Entity{Id: `c`, Owner: `owner1`}))
+		Expect(events).To(HaveLen(2))
+
+		Expect(closer()).To(Succeed())
+		expectcc.ResponseOk(cc.Invoke(`entityCreate`, testdata.Entity{Id: `d`, Owner: `owner1`}))
+
+		var received []*peer.ChaincodeEvent
+		for e := range events {
+			received = append(received, e)
+		}
+		Expect(received).To(HaveLen(2))
+	})
+})
@@ -0,0 +1,325 @@
+package testing
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	gotesting "testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+type (
+	// StubCapabilities optional features of stub, sections of conformance suite for missing features are skipped
+	StubCapabilities struct {
+		Pagination  bool
+		PrivateData bool
+		Events      bool
+	}
+
+	// ConformanceCapabilitiesReporter stub, reporting its capabilities.
+	// Stubs not implementing it are tested with all capabilities
+	ConformanceCapabilitiesReporter interface {
+		ConformanceCapabilities() StubCapabilities
+	}
+
+	// StubTxLifecycle stub with explicit tx boundaries: writes of tx are committed on MockTransactionEnd
+	StubTxLifecycle interface {
+		MockTransactionStart(txID string)
+		MockTransactionEnd(txID string)
+	}
+
+	// conformance runs suite sections against stubs, created with factory
+	conformance struct {
+		t       *gotesting.T
+		factory func() shim.ChaincodeStubInterface
+		txSeq   int
+	}
+)
+
+// ConformanceCapabilities MockStub supports all features, checked by conformance suite
+func (stub *MockStub) ConformanceCapabilities() StubCapabilities {
+	return StubCapabilities{Pagination: true, PrivateData: true, Events: true}
+}
+
+// RunStubConformance runs suite, checking stub implementation follows Fabric peer semantics of state,
+// range and composite key queries, pagination, private data and events. Each section runs as subtest
+// with fresh stub from factory. Stub must implement StubTxLifecycle, sections for capabilities,
+// not reported via ConformanceCapabilitiesReporter, are skipped
+func RunStubConformance(t *gotesting.T, factory func() shim.ChaincodeStubInterface) {
+	c := &conformance{t: t, factory: factory}
+
+	caps := StubCapabilities{Pagination: true, PrivateData: true, Events: true}
+	if reporter, ok := factory().(ConformanceCapabilitiesReporter); ok {
+		caps = reporter.ConformanceCapabilities()
+	}
+
+	c.section(`state`, true, c.state)
+	c.section(`range`, true, c.rangeQuery)
+	c.section(`composite key`, true, c.compositeKey)
+	c.section(`pagination`, caps.Pagination, c.pagination)
+	c.section(`private data`, caps.PrivateData, c.privateData)
+	c.section(`events`, caps.Events, c.events)
+}
+
+func (c *conformance) section(name string, supported bool, fn func(t *gotesting.T, stub shim.ChaincodeStubInterface)) {
+	c.t.Run(name, func(t *gotesting.T) {
+		if !supported {
+			t.Skipf(`stub does not support %s`, name)
+		}
+
+		stub := c.factory()
+		if _, ok := stub.(StubTxLifecycle); !ok {
+			t.Skipf(`stub %T does not implement tx lifecycle`, stub)
+		}
+		fn(t, stub)
+	})
+}
+
+// tx runs fn in tx, writes are committed after fn returns
+func (c *conformance) tx(stub shim.ChaincodeStubInterface, fn func()) {
+	c.txSeq++
+	txID := fmt.Sprintf(`conformance-%d`, c.txSeq)
+
+	lifecycle := stub.(StubTxLifecycle)
+	lifecycle.MockTransactionStart(txID)
+	defer lifecycle.MockTransactionEnd(txID)
+	fn()
+}
+
+func (c *conformance) state(t *gotesting.T, stub shim.ChaincodeStubInterface) {
+	c.tx(stub, func() {
+		// GetState returns nil value and nil error for key, not existing in state
+		value, err := stub.GetState(`missing`)
+		expectNoError(t, err, `get missing key`)
+		expectBytes(t, value, nil, `value of missing key`)
+
+		expectNoError(t, stub.PutState(`key`, []byte(`v1`)), `put key`)
+
+		// reads are performed against committed state, tx doesn't observe its own writes
+		value, err = stub.GetState(`key`)
+		expectNoError(t, err, `get key written in same tx`)
+		expectBytes(t, value, nil, `value written in same tx`)
+
+		// if key is written several times in tx, last write wins
+		expectNoError(t, stub.PutState(`key`, []byte(`v2`)), `put key again`)
+	})
+
+	c.tx(stub, func() {
+		// writes of committed tx are visible to next tx
+		value, err := stub.GetState(`key`)
+		expectNoError(t, err, `get committed key`)
+		expectBytes(t, value, []byte(`v2`), `value of committed key`)
+
+		expectNoError(t, stub.DelState(`key`), `delete key`)
+	})
+
+	c.tx(stub, func() {
+		// deleted key has no value
+		value, err := stub.GetState(`key`)
+		expectNoError(t, err, `get deleted key`)
+		expectBytes(t, value, nil, `value of deleted key`)
+	})
+}
+
+func (c *conformance) rangeQuery(t *gotesting.T, stub shim.ChaincodeStubInterface) {
+	c.tx(stub, func() {
+		for _, key := range []string{`d`, `b`, `a`, `c`} {
+			expectNoError(t, stub.PutState(key, []byte(key)), `put `+key)
+		}
+	})
+
+	c.tx(stub, func() {
+		// range query returns keys in lexical order, start key is inclusive and end key is exclusive
+		iter, err := stub.GetStateByRange(`b`, `d`)
+		expectNoError(t, err, `range query`)
+		expectKeys(t, iter, []string{`b`, `c`}, `range [b, d)`)
+
+		// start key greater than all keys gives empty result
+		iter, err = stub.GetStateByRange(`x`, `z`)
+		expectNoError(t, err, `empty range query`)
+		expectKeys(t, iter, nil, `range [x, z)`)
+	})
+}
+
+func (c *conformance) compositeKey(t *gotesting.T, stub shim.ChaincodeStubInterface) {
+	// composite key is split to the same object type and attributes, it is created from
+	key, err := stub.CreateCompositeKey(`CAR`, []string{`AUDI`, `A4`})
+	expectNoError(t, err, `create composite key`)
+	objectType, attrs, err := stub.SplitCompositeKey(key)
+	expectNoError(t, err, `split composite key`)
+	if objectType != `CAR` || len(attrs) != 2 || attrs[0] != `AUDI` || attrs[1] != `A4` {
+		t.Errorf(`split composite key: got %s %v, expected CAR [AUDI A4]`, objectType, attrs)
+	}
+
+	// attributes must not contain U+0000, used as composite key delimiter
+	if _, err = stub.CreateCompositeKey(`CAR`, []string{"AU\x00DI"}); err == nil {
+		t.Errorf(`create composite key with U+0000 in attribute: expected error`)
+	}
+
+	c.tx(stub, func() {
+		for _, attrs := range [][]string{{`BMW`, `X5`}, {`AUDI`, `A6`}, {`AUDI`, `A4`}} {
+			carKey, err := stub.CreateCompositeKey(`CAR`, attrs)
+			expectNoError(t, err, `create car key`)
+			expectNoError(t, stub.PutState(carKey, []byte(`car`)), `put car`)
+		}
+		ownerKey, err := stub.CreateCompositeKey(`OWNER`, []string{`AUDI`})
+		expectNoError(t, err, `create owner key`)
+		expectNoError(t, stub.PutState(ownerKey, []byte(`owner`)), `put owner`)
+	})
+
+	c.tx(stub, func() {
+		// partial composite key query matches leading attributes of object type, ordered by attributes
+		iter, err := stub.GetStateByPartialCompositeKey(`CAR`, []string{`AUDI`})
+		expectNoError(t, err, `partial composite key query`)
+		expectSplitKeys(t, stub, iter, []string{`CAR/AUDI/A4`, `CAR/AUDI/A6`}, `CAR AUDI`)
+
+		// query without attributes matches all entries of object type only
+		iter, err = stub.GetStateByPartialCompositeKey(`CAR`, []string{})
+		expectNoError(t, err, `object type query`)
+		expectSplitKeys(t, stub, iter, []string{`CAR/AUDI/A4`, `CAR/AUDI/A6`, `CAR/BMW/X5`}, `CAR`)
+	})
+}
+
+func (c *conformance) pagination(t *gotesting.T, stub shim.ChaincodeStubInterface) {
+	keys := []string{`k1`, `k2`, `k3`, `k4`, `k5`}
+	c.tx(stub, func() {
+		for _, key := range keys {
+			expectNoError(t, stub.PutState(key, []byte(key)), `put `+key)
+		}
+	})
+
+	c.tx(stub, func() {
+		var (
+			fetched  []string
+			bookmark string
+		)
+		for page := 0; page < len(keys); page++ {
+			iter, metadata, err := stub.GetStateByRangeWithPagination(`k1`, `k9`, 2, bookmark)
+			if err != nil {
+				t.Errorf(`paginated range query: %s`, err)
+				return
+			}
+			pageKeys := readKeys(t, iter)
+
+			// page contains at most page size records, metadata contains number of fetched records
+			if len(pageKeys) > 2 || int(metadata.FetchedRecordsCount) != len(pageKeys) {
+				t.Errorf(`page %d: %d records, fetched records count %d, page size 2`,
+					page, len(pageKeys), metadata.FetchedRecordsCount)
+			}
+			fetched = append(fetched, pageKeys...)
+
+			// bookmark of last page is empty or yields no records
+			if metadata.Bookmark == `` || len(pageKeys) < 2 {
+				break
+			}
+			bookmark = metadata.Bookmark
+		}
+
+		// pages, requested with bookmark of previous page, contain all keys in order without duplicates
+		expectStrings(t, fetched, keys, `paginated keys`)
+	})
+}
+
+func (c *conformance) privateData(t *gotesting.T, stub shim.ChaincodeStubInterface) {
+	c.tx(stub, func() {
+		// GetPrivateData returns nil value and nil error for key, not existing in collection
+		value, err := stub.GetPrivateData(`collection`, `missing`)
+		expectNoError(t, err, `get missing private key`)
+		expectBytes(t, value, nil, `value of missing private key`)
+
+		for _, key := range []string{`c`, `a`, `b`} {
+			expectNoError(t, stub.PutPrivateData(`collection`, key, []byte(key)), `put private `+key)
+		}
+	})
+
+	c.tx(stub, func() {
+		// private writes of committed tx are visible to next tx
+		value, err := stub.GetPrivateData(`collection`, `a`)
+		expectNoError(t, err, `get committed private key`)
+		expectBytes(t, value, []byte(`a`), `value of committed private key`)
+
+		// private data of one collection is not visible in other collection
+		value, err = stub.GetPrivateData(`other`, `a`)
+		expectNoError(t, err, `get key of other collection`)
+		expectBytes(t, value, nil, `value of key of other collection`)
+
+		// private data range query returns keys in lexical order, end key is exclusive
+		iter, err := stub.GetPrivateDataByRange(`collection`, `a`, `c`)
+		expectNoError(t, err, `private data range query`)
+		expectKeys(t, iter, []string{`a`, `b`}, `private range [a, c)`)
+	})
+}
+
+func (c *conformance) events(t *gotesting.T, stub shim.ChaincodeStubInterface) {
+	c.tx(stub, func() {
+		// event name must not be empty
+		if err := stub.SetEvent(``, []byte(`payload`)); err == nil {
+			t.Errorf(`set event with empty name: expected error`)
+		}
+
+		// event can be set several times in tx
+		expectNoError(t, stub.SetEvent(`first`, []byte(`payload`)), `set event`)
+		expectNoError(t, stub.SetEvent(`second`, nil), `set event with nil payload`)
+	})
+}
+
+func readKeys(t *gotesting.T, iter shim.StateQueryIteratorInterface) []string {
+	t.Helper()
+	var keys []string
+	if iter == nil {
+		return keys
+	}
+	defer func() {
+		// iterator must be closed to release resources
+		expectNoError(t, iter.Close(), `close iterator`)
+	}()
+
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			t.Errorf(`iterator next: %s`, err)
+			return keys
+		}
+		keys = append(keys, kv.Key)
+	}
+	return keys
+}
+
+func expectKeys(t *gotesting.T, iter shim.StateQueryIteratorInterface, expected []string, msg string) {
+	t.Helper()
+	expectStrings(t, readKeys(t, iter), expected, msg)
+}
+
+func expectSplitKeys(t *gotesting.T, stub shim.ChaincodeStubInterface,
+	iter shim.StateQueryIteratorInterface, expected []string, msg string) {
+	t.Helper()
+	var keys []string
+	for _, key := range readKeys(t, iter) {
+		objectType, attrs, err := stub.SplitCompositeKey(key)
+		expectNoError(t, err, `split key`)
+		keys = append(keys, strings.Join(append([]string{objectType}, attrs...), `/`))
+	}
+	expectStrings(t, keys, expected, msg)
+}
+
+func expectStrings(t *gotesting.T, actual, expected []string, msg string) {
+	t.Helper()
+	if fmt.Sprint(actual) != fmt.Sprint(expected) {
+		t.Errorf(`%s: got %v, expected %v`, msg, actual, expected)
+	}
+}
+
+func expectBytes(t *gotesting.T, actual, expected []byte, msg string) {
+	t.Helper()
+	if !bytes.Equal(actual, expected) {
+		t.Errorf(`%s: got %q, expected %q`, msg, actual, expected)
+	}
+}
+
+func expectNoError(t *gotesting.T, err error, msg string) {
+	t.Helper()
+	if err != nil {
+		t.Errorf(`%s: %s`, msg, err)
+	}
+}
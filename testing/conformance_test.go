@@ -0,0 +1,15 @@
+package testing_test
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+
+	testcc "github.com/s7techlab/cckit/testing"
+)
+
+func TestMockStubConformance(t *testing.T) {
+	testcc.RunStubConformance(t, func() shim.ChaincodeStubInterface {
+		return testcc.NewMockStub(`conformance`, nil)
+	})
+}
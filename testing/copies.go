@@ -0,0 +1,49 @@
+package testing
+
+import (
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+)
+
+// copyingIterator returns copies of values of underlying iterator
+type copyingIterator struct {
+	shim.StateQueryIteratorInterface
+}
+
+// WithDefensiveCopies enables or disables (enabled by default) copying of values, passed to PutState
+// and PutPrivateData and returned by GetState, GetPrivateData, range and rich queries,
+// so chaincode can't change mocked ledger by mutating byte slices, as real peer doesn't allow it
+func WithDefensiveCopies(enabled bool) MockStubOpt {
+	return func(stub *MockStub) {
+		stub.noDefensiveCopies = !enabled
+	}
+}
+
+// copyValue returns copy of value, if defensive copies are enabled
+func (stub *MockStub) copyValue(value []byte) []byte {
+	if stub.noDefensiveCopies || value == nil {
+		return value
+	}
+	return append(make([]byte, 0, len(value)), value...)
+}
+
+// copyingIterator wraps iterator over mocked state, if defensive copies are enabled
+func (stub *MockStub) copyingIterator(
+	iter shim.StateQueryIteratorInterface, err error) (shim.StateQueryIteratorInterface, error) {
+	if stub.noDefensiveCopies || err != nil {
+		return iter, err
+	}
+	return &copyingIterator{StateQueryIteratorInterface: iter}, nil
+}
+
+func (iter *copyingIterator) Next() (*queryresult.KV, error) {
+	kv, err := iter.StateQueryIteratorInterface.Next()
+	if err != nil || kv == nil || kv.Value == nil {
+		return kv, err
+	}
+	return &queryresult.KV{
+		Namespace: kv.Namespace,
+		Key:       kv.Key,
+		Value:     append(make([]byte, 0, len(kv.Value)), kv.Value...),
+	}, nil
+}
@@ -0,0 +1,94 @@
+package testing_test
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/router"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+func NewMutatingCC() *router.Chaincode {
+	r := router.New(`mutating`)
+
+	r.Invoke(`putAndMutate`, func(c router.Context) (interface{}, error) {
+		value := make([]byte, 0, 16)
+		value = append(value, `original`...)
+		if err := c.Stub().PutState(`key`, value); err != nil {
+			return nil, err
+		}
+
+		copy(value, `mutated!`)
+		_ = append(value, `-appended`...)
+		return nil, nil
+	}).
+		Invoke(`getAndMutate`, func(c router.Context) (interface{}, error) {
+			value, err := c.Stub().GetState(`key`)
+			if err != nil {
+				return nil, err
+			}
+			copy(value, `mutated!`)
+			return nil, nil
+		}).
+		Query(`get`, func(c router.Context) (interface{}, error) {
+			return c.Stub().GetState(`key`)
+		})
+
+	return router.NewChaincode(r)
+}
+
+var _ = Describe(`Defensive copies`, func() {
+
+	It("Disallow chaincode to change committed state by mutating put value", func() {
+		cc := testcc.NewMockStub(`mutating`, NewMutatingCC())
+
+		expectcc.ResponseOk(cc.Invoke(`putAndMutate`))
+		expectcc.PayloadBytes(cc.Query(`get`), []byte(`original`))
+		Expect(cc.State[`key`]).To(Equal([]byte(`original`)))
+	})
+
+	It("Disallow chaincode to change committed state by mutating got value", func() {
+		cc := testcc.NewMockStub(`mutating`, NewMutatingCC())
+
+		expectcc.ResponseOk(cc.Invoke(`putAndMutate`))
+		expectcc.ResponseOk(cc.Invoke(`getAndMutate`))
+		expectcc.PayloadBytes(cc.Query(`get`), []byte(`original`))
+	})
+
+	It("Allow to disable defensive copies", func() {
+		cc := testcc.NewMockStub(`mutating`, NewMutatingCC(), testcc.WithDefensiveCopies(false))
+
+		expectcc.ResponseOk(cc.Invoke(`putAndMutate`))
+		expectcc.PayloadBytes(cc.Query(`get`), []byte(`mutated!`))
+	})
+})
+
+func benchmarkPutGet(b *testing.B, opts ...testcc.MockStubOpt) {
+	stub := testcc.NewMockStub(`copies`, nil, opts...)
+	value := bytes.Repeat([]byte(`x`), 1<<20)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		stub.MockTransactionStart(`tx`)
+		if err := stub.PutState(`key`, value); err != nil {
+			b.Fatal(err)
+		}
+		stub.MockTransactionEnd(`tx`)
+
+		if _, err := stub.GetState(`key`); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkPutGetLargeValueWithDefensiveCopies(b *testing.B) {
+	benchmarkPutGet(b)
+}
+
+func BenchmarkPutGetLargeValueWithoutDefensiveCopies(b *testing.B) {
+	benchmarkPutGet(b, testcc.WithDefensiveCopies(false))
+}
@@ -0,0 +1,98 @@
+package testing
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// CouchBinaryPolicy handling of state values, which are not JSON objects, in CouchDB export
+type CouchBinaryPolicy int
+
+const (
+	// CouchBinarySkip values, which are not JSON objects, are not exported
+	CouchBinarySkip CouchBinaryPolicy = iota
+	// CouchBinaryBase64 values, which are not JSON objects, are exported as base64 attachment,
+	// same as peer stores binary values in CouchDB state database
+	CouchBinaryBase64
+)
+
+// CouchBinaryAttachment name of attachment with binary value, used by peer CouchDB state database
+const CouchBinaryAttachment = `valueBytes`
+
+type (
+	// CouchBulkDocs body of CouchDB _bulk_docs request
+	CouchBulkDocs struct {
+		Docs []map[string]interface{} `json:"docs"`
+	}
+
+	// CouchAttachment inline CouchDB document attachment
+	CouchAttachment struct {
+		ContentType string `json:"content_type"`
+		Data        string `json:"data"`
+	}
+)
+
+// WithCouchBinaryPolicy sets handling of values, which are not JSON objects, in ExportCouchDocs
+func WithCouchBinaryPolicy(policy CouchBinaryPolicy) MockStubOpt {
+	return func(stub *MockStub) {
+		stub.couchBinaryPolicy = policy
+	}
+}
+
+// ExportCouchDocs writes committed state as CouchDB _bulk_docs body, ordered by key.
+// JSON object value becomes document with _id set to state key, composite keys keep \u0000 delimiters.
+// Other values are skipped or exported as attachments, according to CouchBinaryPolicy
+func (stub *MockStub) ExportCouchDocs(w io.Writer) error {
+	keys := make([]string, 0, len(stub.State))
+	for key := range stub.State {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	bulk := CouchBulkDocs{Docs: []map[string]interface{}{}}
+	for _, key := range keys {
+		doc, ok := couchDoc(key, stub.State[key], stub.couchBinaryPolicy)
+		if ok {
+			bulk.Docs = append(bulk.Docs, doc)
+		}
+	}
+
+	bb, err := marshalNoEscape(bulk)
+	if err != nil {
+		return errors.Wrap(err, `marshal couchdb docs`)
+	}
+
+	_, err = w.Write(bb)
+	return err
+}
+
+// couchDoc converts state entry to CouchDB document, numbers are kept as is
+func couchDoc(key string, value []byte, policy CouchBinaryPolicy) (map[string]interface{}, bool) {
+	var doc map[string]interface{}
+
+	decoder := json.NewDecoder(bytes.NewReader(value))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err == nil && doc != nil && !decoder.More() {
+		doc[QueryIDField] = key
+		return doc, true
+	}
+
+	if policy != CouchBinaryBase64 {
+		return nil, false
+	}
+
+	return map[string]interface{}{
+		QueryIDField: key,
+		`_attachments`: map[string]CouchAttachment{
+			CouchBinaryAttachment: {
+				ContentType: `application/octet-stream`,
+				Data:        base64.StdEncoding.EncodeToString(value),
+			},
+		},
+	}, true
+}
@@ -0,0 +1,81 @@
+package testing_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	testcc "github.com/s7techlab/cckit/testing"
+)
+
+var _ = Describe(`CouchDB export`, func() {
+
+	var couchState map[string][]byte
+
+	BeforeEach(func() {
+		couchState = map[string][]byte{
+			"car-1":             []byte(`{"docType":"car","price":123456789012345678}`),
+			"\x00CAR\x00A1\x00": []byte(`{"docType":"car","_id":"spoofed"}`),
+			`counter`:           []byte(`42`),
+			`raw`:               []byte("binary \x00\x01"),
+		}
+	})
+
+	export := func(stub *testcc.MockStub) (string, map[string]map[string]interface{}) {
+		buf := &bytes.Buffer{}
+		Expect(stub.ExportCouchDocs(buf)).To(Succeed())
+
+		var bulk struct {
+			Docs []map[string]interface{} `json:"docs"`
+		}
+		decoder := json.NewDecoder(bytes.NewReader(buf.Bytes()))
+		decoder.DisallowUnknownFields()
+		Expect(decoder.Decode(&bulk)).To(Succeed())
+
+		docs := make(map[string]map[string]interface{})
+		for _, doc := range bulk.Docs {
+			id, ok := doc[`_id`].(string)
+			Expect(ok).To(BeTrue())
+			docs[id] = doc
+		}
+		return buf.String(), docs
+	}
+
+	It("Allow to export JSON object values as documents with state key as _id", func() {
+		stub := testcc.NewMockStub(`couch`, nil)
+		Expect(stub.SeedState(couchState)).To(Succeed())
+
+		body, docs := export(stub)
+
+		Expect(docs).To(HaveLen(2))
+		Expect(docs).To(HaveKey(`car-1`))
+		Expect(docs["\x00CAR\x00A1\x00"][`docType`]).To(Equal(`car`))
+		Expect(body).To(ContainSubstring(`"_id":"\u0000CAR\u0000A1\u0000"`))
+		Expect(body).To(ContainSubstring(`123456789012345678`))
+		Expect(body).NotTo(ContainSubstring(`spoofed`))
+	})
+
+	It("Allow to export binary values as base64 attachments", func() {
+		stub := testcc.NewMockStub(`couch`, nil, testcc.WithCouchBinaryPolicy(testcc.CouchBinaryBase64))
+		Expect(stub.SeedState(couchState)).To(Succeed())
+
+		_, docs := export(stub)
+
+		Expect(docs).To(HaveLen(4))
+		attachments := docs[`raw`][`_attachments`].(map[string]interface{})
+		attachment := attachments[testcc.CouchBinaryAttachment].(map[string]interface{})
+		Expect(attachment[`content_type`]).To(Equal(`application/octet-stream`))
+
+		data, err := base64.StdEncoding.DecodeString(attachment[`data`].(string))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(data).To(Equal(couchState[`raw`]))
+	})
+
+	It("Allow to export empty state as empty docs list", func() {
+		body, _ := export(testcc.NewMockStub(`couch`, nil))
+		Expect(body).To(Equal(`{"docs":[]}`))
+	})
+})
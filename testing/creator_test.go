@@ -0,0 +1,91 @@
+package testing_test
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	pmsp "github.com/hyperledger/fabric-protos-go/msp"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/identity"
+	idtestdata "github.com/s7techlab/cckit/identity/testdata"
+	"github.com/s7techlab/cckit/router"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+var identityToken = []byte(`identity-token`)
+
+// serializeEnveloped wraps serialized identity in envelope, as custom MSP implementation does
+func serializeEnveloped(mspID string, cert []byte) ([]byte, error) {
+	serialized, err := proto.Marshal(&pmsp.SerializedIdentity{Mspid: mspID, IdBytes: cert})
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(&common.SignatureHeader{Creator: serialized, Nonce: identityToken})
+}
+
+func parseEnveloped(creator []byte) (*pmsp.SerializedIdentity, error) {
+	envelope := &common.SignatureHeader{}
+	if err := proto.Unmarshal(creator, envelope); err != nil {
+		return nil, err
+	}
+	return identity.ParseSerializedIdentity(envelope.Creator)
+}
+
+func NewEnvelopedCreatorCC() *router.Chaincode {
+	r := router.New(`enveloped`)
+
+	r.Query(`creator`, func(c router.Context) (interface{}, error) {
+		return c.Stub().GetCreator()
+	}).
+		Query(`mspID`, func(c router.Context) (interface{}, error) {
+			invoker, err := identity.FromStubWithParser(c.Stub(), parseEnveloped)
+			if err != nil {
+				return nil, err
+			}
+			return invoker.GetMSPIdentifier(), nil
+		}).
+		Query(`get`, func(c router.Context) (interface{}, error) {
+			return c.Stub().GetPrivateData(`shared`, `key`)
+		})
+
+	return router.NewChaincode(r)
+}
+
+var _ = Describe(`Creator serializer`, func() {
+
+	member := idtestdata.Certificates[0].MustIdentity(`Org1MSP`)
+	nonMember := idtestdata.Certificates[1].MustIdentity(`Org2MSP`)
+
+	It("Allow chaincode parser to receive serialized creator bytes", func() {
+		cc := testcc.NewMockStub(`enveloped`, NewEnvelopedCreatorCC()).
+			RegisterCreatorSerializer(serializeEnveloped, parseEnveloped)
+
+		expected, err := serializeEnveloped(`Org1MSP`, member.GetPEM())
+		Expect(err).NotTo(HaveOccurred())
+
+		expectcc.PayloadBytes(cc.From(member).Query(`creator`), expected)
+		expectcc.PayloadString(cc.From(member).Query(`mspID`), `Org1MSP`)
+	})
+
+	It("Allow mocked access checks to parse enveloped creator", func() {
+		cc := testcc.NewMockStub(`enveloped`, NewEnvelopedCreatorCC(), testcc.WithCollection(`shared`, `Org1MSP`)).
+			RegisterCreatorSerializer(serializeEnveloped, parseEnveloped)
+
+		expectcc.ResponseOk(cc.From(member).Query(`get`))
+		expectcc.ResponseError(cc.From(nonMember).Query(`get`), testcc.ErrCollectionReadDenied)
+	})
+
+	It("Disallow default identity parser to parse enveloped creator", func() {
+		creator, err := serializeEnveloped(`Org1MSP`, member.GetPEM())
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = identity.FromCreator(creator, nil)
+		Expect(err).To(HaveOccurred())
+
+		id, err := identity.FromCreator(creator, parseEnveloped)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(id.GetMSPIdentifier()).To(Equal(`Org1MSP`))
+	})
+})
@@ -0,0 +1,359 @@
+package testing
+
+import (
+	"bytes"
+	"container/list"
+	"fmt"
+	"sort"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/pkg/errors"
+	"github.com/s7techlab/cckit/convert"
+)
+
+// DefaultDeterminismRuns number of invoke runs for determinism check
+const DefaultDeterminismRuns = 3
+
+// ErrNondeterministic occurs when same invoke against same state produces different output
+var ErrNondeterministic = errors.New(`nondeterministic chaincode output`)
+
+type (
+	// DeterminismError describes first difference between determinism check runs
+	DeterminismError struct {
+		Run      int
+		Subject  string
+		Offset   int
+		Expected []byte
+		Actual   []byte
+	}
+
+	// snapshot of mocked ledger, used for replaying invoke against identical state
+	snapshot struct {
+		state       map[string][]byte
+		pvtState    map[string]map[string][]byte
+		privateKeys map[string][]string
+		creator     []byte
+		transient   map[string][]byte
+		keyHistory  map[string][]*queryresult.KeyModification
+		evicted     int
+		blockHeight uint64
+		writeBlocks map[string]map[string]uint64
+		purged      map[string]map[string][]byte
+	}
+
+	// txOutput bytes produced by tx
+	txOutput struct {
+		response peer.Response
+		event    *peer.ChaincodeEvent
+		writes   []*StateItem
+	}
+)
+
+func (e *DeterminismError) Error() string {
+	return fmt.Sprintf(`%s: %s differs in run %d at offset %d: %q != %q`,
+		ErrNondeterministic, e.Subject, e.Run, e.Offset, e.Expected, e.Actual)
+}
+
+func (e *DeterminismError) Unwrap() error {
+	return ErrNondeterministic
+}
+
+// WithDeterminismCheck enables determinism linter mode: each invoke runs several times against identical state snapshots,
+// any difference in response payload, event payload or written values turns response into error
+func WithDeterminismCheck(runs int) MockStubOpt {
+	return func(stub *MockStub) {
+		if runs <= 0 {
+			runs = DefaultDeterminismRuns
+		}
+		stub.determinismRuns = runs
+	}
+}
+
+// CheckDeterminism invokes chaincode function several times against identical state snapshots
+// and returns first difference in response payload, event payload or written values.
+// State changes of last run are committed
+func (stub *MockStub) CheckDeterminism(runs int, funcName string, iargs ...interface{}) (peer.Response, error) {
+	fargs, err := convert.ArgsToBytes(iargs...)
+	if err != nil {
+		return shim.Error(err.Error()), err
+	}
+
+	stub.checkReentrant(`CheckDeterminism`)
+	stub.m.Lock()
+	defer stub.m.Unlock()
+
+	return stub.checkDeterminism(runs, stub.generateTxUID(), append([][]byte{[]byte(funcName)}, fargs...))
+}
+
+func (stub *MockStub) checkDeterminism(runs int, uuid string, args [][]byte) (peer.Response, error) {
+	if runs <= 0 {
+		runs = DefaultDeterminismRuns
+	}
+
+	var (
+		snap        = stub.snapshot()
+		txTimestamp = stub.clockTimestamp()
+		first       *txOutput
+		last        *txOutput
+		detErr      error
+	)
+
+	for run := 0; run < runs; run++ {
+		stub.restore(snap)
+		last = stub.runTx(uuid, args, txTimestamp, run == runs-1, snap)
+
+		if first == nil {
+			first = last
+		} else if detErr == nil {
+			detErr = compareTxOutputs(run, first, last)
+		}
+	}
+
+	return last.response, detErr
+}
+
+// runTx invokes chaincode with fixed tx timestamp, events of non final runs are not delivered
+func (stub *MockStub) runTx(
+	uuid string, args [][]byte, txTimestamp *timestamp.Timestamp, deliverEvents bool, snap *snapshot) *txOutput {
+
+	subscriptions, eventsChannel := stub.chaincodeEventSubscriptions, stub.ChaincodeEventsChannel
+	aggregators := stub.eventsAggregators
+	eventsHistoryLen := len(stub.chaincodeEvents)
+	if !deliverEvents {
+		stub.chaincodeEventSubscriptions = nil
+		stub.ChaincodeEventsChannel = make(chan *peer.ChaincodeEvent, EventChannelBufferSize)
+		stub.eventsAggregators = nil
+	}
+
+	stub.SetArgs(args)
+	stub.startTx(uuid)
+	stub.TxTimestamp = txTimestamp
+	response := stub.endorse(stub.cc.Invoke(stub.txStub()))
+	event := stub.ChaincodeEvent
+	stub.MockTransactionEnd(uuid)
+
+	stub.chaincodeEventSubscriptions, stub.ChaincodeEventsChannel = subscriptions, eventsChannel
+	stub.eventsAggregators = aggregators
+	if !deliverEvents {
+		stub.chaincodeEvents = stub.chaincodeEvents[:eventsHistoryLen]
+	}
+
+	return &txOutput{
+		response: response,
+		event:    event,
+		writes:   snap.diff(stub),
+	}
+}
+
+func (stub *MockStub) snapshot() *snapshot {
+	snap := &snapshot{
+		state:       copyBytesMap(stub.State),
+		pvtState:    make(map[string]map[string][]byte, len(stub.PvtState)),
+		privateKeys: make(map[string][]string, len(stub.PrivateKeys)),
+		creator:     stub.mockCreator,
+		transient:   copyBytesMap(stub.transient),
+		keyHistory:  make(map[string][]*queryresult.KeyModification, len(stub.keyHistory)),
+		evicted:     stub.keyHistoryEvicted,
+		blockHeight: stub.clock.BlockHeight(),
+		writeBlocks: make(map[string]map[string]uint64, len(stub.privateWriteBlocks)),
+		purged:      make(map[string]map[string][]byte, len(stub.purgedHashes)),
+	}
+
+	for collection, writeBlocks := range stub.privateWriteBlocks {
+		snap.writeBlocks[collection] = copyBlocksMap(writeBlocks)
+	}
+
+	for collection, hashes := range stub.purgedHashes {
+		snap.purged[collection] = copyBytesMap(hashes)
+	}
+
+	for key, history := range stub.keyHistory {
+		snap.keyHistory[key] = history
+	}
+
+	for collection, state := range stub.PvtState {
+		snap.pvtState[collection] = copyBytesMap(state)
+	}
+
+	for collection, keys := range stub.PrivateKeys {
+		for elem := keys.Front(); elem != nil; elem = elem.Next() {
+			snap.privateKeys[collection] = append(snap.privateKeys[collection], elem.Value.(string))
+		}
+	}
+
+	return snap
+}
+
+func (stub *MockStub) restore(snap *snapshot) {
+	stub.State = copyBytesMap(snap.state)
+	stub.Keys = list.New()
+	for _, key := range sortedKeys(snap.state) {
+		stub.Keys.PushBack(key)
+	}
+
+	stub.PvtState = make(map[string]map[string][]byte, len(snap.pvtState))
+	for collection, state := range snap.pvtState {
+		stub.PvtState[collection] = copyBytesMap(state)
+	}
+
+	stub.PrivateKeys = make(map[string]*list.List, len(snap.privateKeys))
+	for collection, keys := range snap.privateKeys {
+		stub.PrivateKeys[collection] = list.New()
+		for _, key := range keys {
+			stub.PrivateKeys[collection].PushBack(key)
+		}
+	}
+
+	stub.mockCreator = snap.creator
+	stub.transient = copyBytesMap(snap.transient)
+
+	stub.keyHistory = make(map[string][]*queryresult.KeyModification, len(snap.keyHistory))
+	for key, history := range snap.keyHistory {
+		stub.keyHistory[key] = history
+	}
+	stub.keyHistoryEvicted = snap.evicted
+
+	stub.clock.SetBlock(snap.blockHeight)
+	stub.privateWriteBlocks = make(map[string]map[string]uint64, len(snap.writeBlocks))
+	for collection, writeBlocks := range snap.writeBlocks {
+		stub.privateWriteBlocks[collection] = copyBlocksMap(writeBlocks)
+	}
+	stub.purgedHashes = make(map[string]map[string][]byte, len(snap.purged))
+	for collection, hashes := range snap.purged {
+		stub.purgedHashes[collection] = copyBytesMap(hashes)
+	}
+
+	stub.RebuildDocTypeIndex()
+}
+
+// diff returns changed public and private state entries, deleted entries have nil value
+func (snap *snapshot) diff(stub *MockStub) []*StateItem {
+	writes := diffBytesMaps(``, snap.state, stub.State)
+	for collection, state := range stub.PvtState {
+		writes = append(writes, diffBytesMaps(collection+`/`, snap.pvtState[collection], state)...)
+	}
+
+	for collection, state := range snap.pvtState {
+		if _, ok := stub.PvtState[collection]; !ok {
+			writes = append(writes, diffBytesMaps(collection+`/`, state, nil)...)
+		}
+	}
+
+	sort.Slice(writes, func(i, j int) bool {
+		return writes[i].Key < writes[j].Key
+	})
+	return writes
+}
+
+func diffBytesMaps(prefix string, before, after map[string][]byte) []*StateItem {
+	var items []*StateItem
+	for key, value := range after {
+		if prev, ok := before[key]; !ok || !bytes.Equal(prev, value) {
+			items = append(items, &StateItem{Key: prefix + key, Value: value})
+		}
+	}
+
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			items = append(items, &StateItem{Key: prefix + key})
+		}
+	}
+	return items
+}
+
+func compareTxOutputs(run int, expected, actual *txOutput) error {
+	if err := compareBytes(run, `response status`,
+		[]byte(fmt.Sprint(expected.response.Status)), []byte(fmt.Sprint(actual.response.Status))); err != nil {
+		return err
+	}
+
+	if err := compareBytes(run, `response message`,
+		[]byte(expected.response.Message), []byte(actual.response.Message)); err != nil {
+		return err
+	}
+
+	if err := compareBytes(run, `response payload`, expected.response.Payload, actual.response.Payload); err != nil {
+		return err
+	}
+
+	if err := compareBytes(run, `event name`,
+		[]byte(expected.event.GetEventName()), []byte(actual.event.GetEventName())); err != nil {
+		return err
+	}
+
+	if err := compareBytes(run, `event payload`, expected.event.GetPayload(), actual.event.GetPayload()); err != nil {
+		return err
+	}
+
+	if err := compareBytes(run, `written keys`,
+		[]byte(fmt.Sprint(stateItemKeys(expected.writes))), []byte(fmt.Sprint(stateItemKeys(actual.writes)))); err != nil {
+		return err
+	}
+
+	for i := range expected.writes {
+		if err := compareBytes(run, `value of key `+expected.writes[i].Key,
+			expected.writes[i].Value, actual.writes[i].Value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func compareBytes(run int, subject string, expected, actual []byte) error {
+	if bytes.Equal(expected, actual) {
+		return nil
+	}
+
+	offset := 0
+	for offset < len(expected) && offset < len(actual) && expected[offset] == actual[offset] {
+		offset++
+	}
+
+	return &DeterminismError{
+		Run:      run,
+		Subject:  subject,
+		Offset:   offset,
+		Expected: expected,
+		Actual:   actual,
+	}
+}
+
+func stateItemKeys(items []*StateItem) []string {
+	keys := make([]string, len(items))
+	for i, item := range items {
+		keys[i] = item.Key
+	}
+	return keys
+}
+
+func copyBytesMap(m map[string][]byte) map[string][]byte {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string][]byte, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+func copyBlocksMap(m map[string]uint64) map[string]uint64 {
+	c := make(map[string]uint64, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+func sortedKeys(m map[string][]byte) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
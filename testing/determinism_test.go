@@ -0,0 +1,89 @@
+package testing_test
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/router"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+func renderMap(sorted bool) string {
+	m := make(map[string]int)
+	for i := 0; i < 20; i++ {
+		m[fmt.Sprintf(`key%02d`, i)] = i
+	}
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	if sorted {
+		sort.Strings(keys)
+	}
+
+	var out []string
+	for _, k := range keys {
+		out = append(out, fmt.Sprintf(`%s=%d`, k, m[k]))
+	}
+	return strings.Join(out, `;`)
+}
+
+func NewMapRenderCC() *router.Chaincode {
+	r := router.New(`render`)
+
+	r.Invoke(`unsorted`, func(c router.Context) (interface{}, error) {
+		return renderMap(false), c.Stub().PutState(`rendered`, []byte(renderMap(false)))
+	}).
+		Invoke(`sorted`, func(c router.Context) (interface{}, error) {
+			rendered := renderMap(true)
+			if err := c.Event().Set(`Rendered`, rendered); err != nil {
+				return nil, err
+			}
+			return rendered, c.Stub().PutState(`rendered`, []byte(rendered))
+		})
+
+	return router.NewChaincode(r)
+}
+
+var _ = Describe(`Determinism check`, func() {
+
+	It("Allow to detect map iteration order in payload", func() {
+		cc := testcc.NewMockStub(`render`, NewMapRenderCC())
+
+		_, err := cc.CheckDeterminism(10, `unsorted`)
+		Expect(errors.Is(err, testcc.ErrNondeterministic)).To(BeTrue())
+
+		detErr := &testcc.DeterminismError{}
+		Expect(errors.As(err, &detErr)).To(BeTrue())
+		Expect(detErr.Subject).To(Equal(`response payload`))
+		Expect(detErr.Expected[:detErr.Offset]).To(Equal(detErr.Actual[:detErr.Offset]))
+		Expect(detErr.Expected).NotTo(Equal(detErr.Actual))
+	})
+
+	It("Allow to pass determinism check with sorted keys", func() {
+		cc := testcc.NewMockStub(`render`, NewMapRenderCC())
+		events, _ := cc.EventSubscription()
+
+		res, err := cc.CheckDeterminism(10, `sorted`)
+		Expect(err).NotTo(HaveOccurred())
+		expectcc.ResponseOk(res)
+
+		Expect(cc.State[`rendered`]).To(Equal([]byte(renderMap(true))))
+		// event delivered once, from committed run
+		Expect(events).To(HaveLen(1))
+	})
+
+	It("Allow to enable determinism linter mode for all invokes", func() {
+		cc := testcc.NewMockStub(`render`, NewMapRenderCC(), testcc.WithDeterminismCheck(10))
+
+		expectcc.ResponseOk(cc.Invoke(`sorted`))
+		expectcc.ResponseError(cc.Invoke(`unsorted`), testcc.ErrNondeterministic)
+	})
+})
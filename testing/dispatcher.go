@@ -0,0 +1,149 @@
+package testing
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// eventDispatcher delivers events to subscriptions with full channel buffers.
+// Queues and closing list are guarded by MockStub subscriptionsM
+type eventDispatcher struct {
+	wake    chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+	queues  map[chan *peer.ChaincodeEvent][]*peer.ChaincodeEvent
+	closing []chan *peer.ChaincodeEvent
+	drained *sync.Cond // broadcasted when all queues are delivered or dispatcher is stopped
+}
+
+// Event delivery guarantee: each subscription receives events strictly in commit order,
+// so all subscriptions observe the same order. Event is sent to subscription channel immediately
+// if channel buffer has room and there are no queued events for subscription,
+// otherwise event is queued and delivered by dispatcher goroutine, started on demand
+
+// deliverEvent sends event to all subscriptions, should be called with locked subscriptionsM
+func (stub *MockStub) deliverEvent(event *peer.ChaincodeEvent) {
+	for _, sub := range stub.chaincodeEventSubscriptions {
+		if d := stub.dispatcher; d == nil || len(d.queues[sub]) == 0 {
+			select {
+			case sub <- event:
+				continue
+			default:
+			}
+		}
+
+		d := stub.startDispatcher()
+		d.queues[sub] = append(d.queues[sub], event)
+		d.signal()
+	}
+}
+
+// closeSubscription closes subscription channel, should be called with locked subscriptionsM.
+// If dispatcher is running, channel is closed by dispatcher, the only goroutine sending to channel concurrently
+func (stub *MockStub) closeSubscription(sub chan *peer.ChaincodeEvent) {
+	if stub.dispatcher == nil {
+		close(sub)
+		return
+	}
+
+	stub.dispatcher.closing = append(stub.dispatcher.closing, sub)
+	stub.dispatcher.signal()
+}
+
+// Close stops events dispatcher, queued events are dropped.
+// Should be called on test cleanup if subscriptions are not read until the end,
+// stub created with NewMockStubT is closed on cleanup automatically
+func (stub *MockStub) Close() {
+	stub.subscriptionsM.Lock()
+	d := stub.dispatcher
+	stub.subscriptionsM.Unlock()
+
+	if d == nil {
+		return
+	}
+
+	close(d.stop)
+	<-d.done
+
+	stub.subscriptionsM.Lock()
+	defer stub.subscriptionsM.Unlock()
+	for _, sub := range d.closing {
+		close(sub)
+	}
+	stub.dispatcher = nil
+	d.drained.Broadcast()
+}
+
+// startDispatcher should be called with locked subscriptionsM
+func (stub *MockStub) startDispatcher() *eventDispatcher {
+	if stub.dispatcher != nil {
+		return stub.dispatcher
+	}
+
+	stub.dispatcher = &eventDispatcher{
+		wake:    make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+		queues:  make(map[chan *peer.ChaincodeEvent][]*peer.ChaincodeEvent),
+		drained: sync.NewCond(&stub.subscriptionsM),
+	}
+	go stub.dispatchEvents(stub.dispatcher)
+
+	return stub.dispatcher
+}
+
+func (stub *MockStub) dispatchEvents(d *eventDispatcher) {
+	defer close(d.done)
+
+	for {
+		stub.subscriptionsM.Lock()
+		for _, sub := range d.closing {
+			delete(d.queues, sub)
+			close(sub)
+		}
+		d.closing = nil
+		if len(d.queues) == 0 {
+			d.drained.Broadcast()
+		}
+
+		cases := []reflect.SelectCase{
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(d.stop)},
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(d.wake)},
+		}
+		var subs []chan *peer.ChaincodeEvent
+		for sub, queue := range d.queues {
+			cases = append(cases, reflect.SelectCase{
+				Dir: reflect.SelectSend, Chan: reflect.ValueOf(sub), Send: reflect.ValueOf(queue[0])})
+			subs = append(subs, sub)
+		}
+		stub.subscriptionsM.Unlock()
+
+		chosen, _, _ := reflect.Select(cases)
+		switch chosen {
+		case 0:
+			return
+		case 1:
+			continue
+		}
+
+		// head of queue is sent, queue can only be deleted by this goroutine
+		stub.subscriptionsM.Lock()
+		sub := subs[chosen-2]
+		if d.queues[sub] = d.queues[sub][1:]; len(d.queues[sub]) == 0 {
+			delete(d.queues, sub)
+		}
+		if len(d.queues) == 0 {
+			d.drained.Broadcast()
+		}
+		stub.subscriptionsM.Unlock()
+	}
+}
+
+func (d *eventDispatcher) signal() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
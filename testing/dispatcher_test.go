@@ -0,0 +1,137 @@
+package testing_test
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/hyperledger/fabric-protos-go/peer"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+var _ = Describe(`Event dispatcher`, func() {
+
+	It("Allow to receive events in commit order in each of subscriptions", func() {
+		const (
+			subscriptionsCount = 5
+			commits            = 500
+		)
+
+		cc := testcc.NewMockStub(`counter`, NewCounterCC())
+		defer cc.Close()
+
+		var (
+			wg       sync.WaitGroup
+			received = make([][]int, subscriptionsCount)
+			// readers start after half of commits, so subscription buffers are overflowed
+			start = make(chan struct{})
+		)
+
+		for s := 0; s < subscriptionsCount; s++ {
+			events, closer := cc.EventSubscription()
+			wg.Add(1)
+
+			go func(s int, events chan *peer.ChaincodeEvent, closer func() error) {
+				defer wg.Done()
+				defer func() { _ = closer() }()
+
+				<-start
+				for len(received[s]) < commits {
+					e := <-events
+					value, _ := strconv.Atoi(string(e.Payload))
+					received[s] = append(received[s], value)
+
+					if value%(50*(s+1)) == 0 {
+						time.Sleep(time.Millisecond)
+					}
+				}
+			}(s, events, closer)
+		}
+
+		for i := 0; i < commits; i++ {
+			if i == commits/2 {
+				close(start)
+			}
+			expectcc.ResponseOk(cc.Invoke(`set`, i))
+			cc.ClearEvents()
+		}
+
+		wg.Wait()
+
+		for s := 0; s < subscriptionsCount; s++ {
+			Expect(received[s]).To(HaveLen(commits))
+			for i, value := range received[s] {
+				Expect(value).To(Equal(i))
+			}
+		}
+	})
+})
+
+var _ = Describe(`Events pause`, func() {
+
+	payloads := func(events chan *peer.ChaincodeEvent) []string {
+		var received []string
+		for len(events) > 0 {
+			received = append(received, string((<-events).Payload))
+		}
+		return received
+	}
+
+	It("Allow to pause and resume events delivery to all subscriptions", func() {
+		cc := testcc.NewMockStub(`counter`, NewCounterCC())
+		defer cc.Close()
+
+		first, closeFirst := cc.EventSubscription()
+		defer func() { _ = closeFirst() }()
+		second, closeSecond := cc.EventSubscription()
+		defer func() { _ = closeSecond() }()
+
+		cc.PauseEvents()
+		for i := 1; i <= 3; i++ {
+			expectcc.ResponseOk(cc.Invoke(`set`, i))
+		}
+
+		Expect(payloads(first)).To(BeEmpty())
+		Expect(payloads(second)).To(BeEmpty())
+		Expect(cc.State[`counter`]).To(Equal([]byte(`3`)))
+
+		cc.ResumeEvents()
+		Expect(payloads(first)).To(Equal([]string{`1`, `2`, `3`}))
+		Expect(payloads(second)).To(Equal([]string{`1`, `2`, `3`}))
+
+		expectcc.ResponseOk(cc.Invoke(`set`, 4))
+		Expect(payloads(first)).To(Equal([]string{`4`}))
+	})
+
+	It("Allow to wait until queued events are delivered", func() {
+		const commits = testcc.EventChannelBufferSize * 3
+
+		cc := testcc.NewMockStub(`counter`, NewCounterCC())
+		defer cc.Close()
+
+		events, closer := cc.EventSubscription()
+		defer func() { _ = closer() }()
+
+		for i := 0; i < commits; i++ {
+			expectcc.ResponseOk(cc.Invoke(`set`, i))
+			cc.ClearEvents()
+		}
+
+		// reader leaves exactly channel buffer of events undelivered to test
+		done := make(chan struct{})
+		go func() {
+			for i := 0; i < commits-testcc.EventChannelBufferSize; i++ {
+				<-events
+			}
+			close(done)
+		}()
+
+		cc.FlushEvents()
+		<-done
+		Expect(events).To(HaveLen(testcc.EventChannelBufferSize))
+	})
+})
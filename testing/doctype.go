@@ -0,0 +1,148 @@
+package testing
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// DefaultDocTypeField top level JSON field with document type
+const DefaultDocTypeField = `docType`
+
+type (
+	// DocTypeExtractor identifies type of committed document
+	DocTypeExtractor func(key string, value []byte) (docType string, ok bool)
+
+	// docTypeIndex committed keys grouped by document type
+	docTypeIndex struct {
+		field     string
+		extractor DocTypeExtractor
+		buckets   map[string]map[string]struct{}
+		keyTypes  map[string]string
+	}
+)
+
+// WithDocTypeIndex enables index of committed documents by type. Rich queries with selector,
+// pinning field with plain value or $eq, evaluate only documents of this type.
+// Extractor must return same type as field value in document, by default it is top level JSON field value
+func WithDocTypeIndex(field string, extractor DocTypeExtractor) MockStubOpt {
+	return func(stub *MockStub) {
+		if field == `` {
+			field = DefaultDocTypeField
+		}
+		if extractor == nil {
+			extractor = JSONFieldDocTypeExtractor(field)
+		}
+
+		stub.docTypes = &docTypeIndex{
+			field:     field,
+			extractor: extractor,
+		}
+		stub.RebuildDocTypeIndex()
+	}
+}
+
+// JSONFieldDocTypeExtractor returns extractor of document type from top level string JSON field
+func JSONFieldDocTypeExtractor(field string) DocTypeExtractor {
+	return func(_ string, value []byte) (string, bool) {
+		var doc map[string]interface{}
+		if json.Unmarshal(value, &doc) != nil {
+			return ``, false
+		}
+		docType, ok := doc[field].(string)
+		return docType, ok
+	}
+}
+
+// RebuildDocTypeIndex rebuilds doc type index from committed state,
+// required after direct modification of State map
+func (stub *MockStub) RebuildDocTypeIndex() {
+	if stub.docTypes == nil {
+		return
+	}
+
+	stub.docTypes.buckets = make(map[string]map[string]struct{})
+	stub.docTypes.keyTypes = make(map[string]string)
+	for key, value := range stub.State {
+		stub.docTypes.put(key, value)
+	}
+}
+
+// DocTypeKeys returns sorted keys of committed documents with type, nil if index is not enabled
+func (stub *MockStub) DocTypeKeys(docType string) []string {
+	if stub.docTypes == nil {
+		return nil
+	}
+	return stub.docTypes.keys(docType)
+}
+
+// docTypeCandidateKeys returns keys of doc type bucket if selector pins indexed field
+func (stub *MockStub) docTypeCandidateKeys(selector map[string]interface{}) ([]string, bool) {
+	if stub.docTypes == nil {
+		return nil, false
+	}
+
+	condition, ok := selector[stub.docTypes.field]
+	if !ok {
+		return nil, false
+	}
+
+	if operators, isMap := condition.(map[string]interface{}); isMap {
+		if len(operators) != 1 {
+			return nil, false
+		}
+		condition = operators[`$eq`]
+	}
+
+	docType, ok := condition.(string)
+	if !ok {
+		return nil, false
+	}
+
+	return stub.docTypes.keys(docType), true
+}
+
+func (stub *MockStub) indexDocType(key string, value []byte, isDelete bool) {
+	if stub.docTypes == nil {
+		return
+	}
+
+	stub.docTypes.delete(key)
+	if !isDelete {
+		stub.docTypes.put(key, value)
+	}
+}
+
+func (idx *docTypeIndex) put(key string, value []byte) {
+	docType, ok := idx.extractor(key, value)
+	if !ok {
+		return
+	}
+
+	if idx.buckets[docType] == nil {
+		idx.buckets[docType] = make(map[string]struct{})
+	}
+	idx.buckets[docType][key] = struct{}{}
+	idx.keyTypes[key] = docType
+}
+
+func (idx *docTypeIndex) delete(key string) {
+	docType, ok := idx.keyTypes[key]
+	if !ok {
+		return
+	}
+
+	delete(idx.buckets[docType], key)
+	if len(idx.buckets[docType]) == 0 {
+		delete(idx.buckets, docType)
+	}
+	delete(idx.keyTypes, key)
+}
+
+func (idx *docTypeIndex) keys(docType string) []string {
+	keys := make([]string, 0, len(idx.buckets[docType]))
+	for key := range idx.buckets[docType] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
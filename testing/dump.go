@@ -0,0 +1,137 @@
+package testing
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/s7techlab/cckit/state"
+)
+
+// DumpState returns committed state as fixture. Values, which are canonical JSON (not strings), are stored
+// as JSON, other values as JSON strings, so state is byte identical after WriteFixture, ReadFixture and ApplyFixture.
+// Entries of cckit extensions are dumped to Extensions section. State is read with CommittedView
+func (stub *MockStub) DumpState() *Fixture {
+	fixture := &Fixture{}
+	stub.CommittedView().read(`DumpState`, func() {
+		fixture.State = make(map[string]json.RawMessage, len(stub.State))
+		for key, value := range stub.State {
+			if !isExtensionKey(key) {
+				fixture.State[key] = dumpValue(value)
+				continue
+			}
+
+			if fixture.Extensions == nil {
+				fixture.Extensions = make(map[string]json.RawMessage)
+			}
+			fixture.Extensions[key] = dumpValue(value)
+		}
+	})
+	return fixture
+}
+
+// isExtensionKey checks key is composite key with object type reserved for cckit extensions
+func isExtensionKey(key string) bool {
+	return strings.HasPrefix(key, compositeKeyNamespace+state.ExtensionsObjectType+compositeKeyNamespace)
+}
+
+func dumpValue(value []byte) json.RawMessage {
+	if len(value) > 0 && value[0] != '"' {
+		if canonical, err := CanonicalJSON(value); err == nil && bytes.Equal(canonical, value) {
+			return value
+		}
+	}
+
+	str, _ := marshalNoEscape(string(value))
+	return str
+}
+
+// WriteFixture writes fixture as JSON, numbers and raw state values are written as is
+func WriteFixture(w io.Writer, fixture *Fixture) error {
+	bb, err := marshalNoEscape(fixture)
+	if err != nil {
+		return errors.Wrap(err, `marshal fixture`)
+	}
+
+	_, err = w.Write(bb)
+	return err
+}
+
+// ReadFixture reads fixture JSON, numbers are decoded as json.Number
+func ReadFixture(r io.Reader) (*Fixture, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+
+	fixture := &Fixture{}
+	if err := decoder.Decode(fixture); err != nil {
+		return nil, errors.Wrap(err, `unmarshal fixture`)
+	}
+	return fixture, nil
+}
+
+// CanonicalJSON returns compact JSON with sorted object keys. Numbers are kept as is without float64 conversion,
+// integers in exponent notation are rendered with digits
+func CanonicalJSON(bb []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(bb))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, errors.New(`unexpected data after JSON value`)
+	}
+
+	return marshalNoEscape(canonicalNumbers(value))
+}
+
+func canonicalNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			v[key] = canonicalNumbers(v[key])
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = canonicalNumbers(v[i])
+		}
+	case json.Number:
+		return canonicalNumber(v)
+	}
+	return value
+}
+
+// canonicalNumber renders integer in exponent notation (1e18) with digits, other numbers are kept as is
+func canonicalNumber(n json.Number) json.Number {
+	if !strings.ContainsAny(string(n), `eE`) {
+		return n
+	}
+
+	rat, ok := new(big.Rat).SetString(string(n))
+	if !ok || !rat.IsInt() {
+		return n
+	}
+	return json.Number(rat.Num().String())
+}
+
+// marshalNoEscape marshals without trailing new line and HTML escaping
+func marshalNoEscape(value interface{}) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
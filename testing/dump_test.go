@@ -0,0 +1,76 @@
+package testing_test
+
+import (
+	"bytes"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/extensions/owner"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+var _ = Describe(`State dump`, func() {
+
+	It("Allow to restore byte identical state after dump and load", func() {
+		state := map[string][]byte{
+			`balance`:  []byte(`{"amount":123456789012345678,"owner":"a<b>&c"}`),
+			`rate`:     []byte(`{"rate":"0.123456789012345678901234567890"}`),
+			`number`:   []byte(`999999999999999999`),
+			`exponent`: []byte(`{"amount":1e18}`),
+			`spaced`:   []byte(`{ "amount": 1 }`),
+			`string`:   []byte(`"quoted"`),
+			`raw`:      []byte("not json \x00\x01"),
+		}
+
+		source := testcc.NewMockStub(`dump`, nil)
+		Expect(source.SeedState(state)).To(Succeed())
+
+		buf := &bytes.Buffer{}
+		Expect(testcc.WriteFixture(buf, source.DumpState())).To(Succeed())
+		Expect(buf.String()).To(ContainSubstring(`123456789012345678`))
+
+		fixture, err := testcc.ReadFixture(buf)
+		Expect(err).NotTo(HaveOccurred())
+
+		restored := testcc.NewMockStub(`dump`, nil)
+		Expect(restored.ApplyFixture(fixture)).To(Succeed())
+		Expect(restored.State).To(Equal(source.State))
+		Expect(restored.State).To(Equal(state))
+	})
+
+	It("Allow to render canonical JSON without float conversion", func() {
+		canonical, err := testcc.CanonicalJSON(
+			[]byte(`{"b": 1e18, "a": 123456789012345678, "c": [2.50, 1.5E2, "x"]}`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(canonical)).To(Equal(
+			`{"a":123456789012345678,"b":1000000000000000000,"c":[2.50,150,"x"]}`))
+	})
+})
+
+var _ = Describe(`State dump extensions`, func() {
+
+	It("Allow to dump extensions state to dedicated section and restore it", func() {
+		source := testcc.NewMockStub(`keys`, NewKeysCC(), testcc.WithFabricDefaults())
+		expectcc.ResponseOk(source.From(Authority).Init())
+		expectcc.ResponseOk(source.From(Authority).Invoke(`put`, `OTHER`, `value`))
+
+		ownerKey, _ := shim.CreateCompositeKey(owner.StateKey()[0], owner.StateKey()[1:])
+		fixture := source.DumpState()
+		Expect(fixture.State).To(HaveLen(1))
+		Expect(fixture.State).To(HaveKey(`OTHER`))
+		Expect(fixture.Extensions).To(HaveLen(1))
+		Expect(fixture.Extensions).To(HaveKey(ownerKey))
+
+		buf := &bytes.Buffer{}
+		Expect(testcc.WriteFixture(buf, fixture)).To(Succeed())
+		restored, err := testcc.ReadFixture(buf)
+		Expect(err).NotTo(HaveOccurred())
+
+		target := testcc.NewMockStub(`keys`, NewKeysCC(), testcc.WithFabricDefaults())
+		Expect(target.ApplyFixture(restored)).To(Succeed())
+		Expect(target.State).To(Equal(source.State))
+	})
+})
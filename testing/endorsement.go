@@ -0,0 +1,79 @@
+package testing
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/statebased"
+	"github.com/pkg/errors"
+)
+
+// ErrEndorsementPolicyFailure occurs when tx writes key with validation parameter, not including creator MSP
+var ErrEndorsementPolicyFailure = errors.New(`endorsement policy failure`)
+
+// WithKeyEndorsementValidation enables validation of key level endorsement policies on tx end.
+// Simplified single endorser model is used: tx creator MSP must be in validation parameter orgs of each written,
+// deleted or private key. If validation fails, tx writes, deletes and private writes are not applied
+// and LastValidationError is set
+func WithKeyEndorsementValidation() MockStubOpt {
+	return func(stub *MockStub) {
+		stub.keyEndorsementValidation = true
+	}
+}
+
+func (stub *MockStub) validateKeyEndorsements() error {
+	if len(stub.StateBuffer) == 0 && len(stub.txDeletes) == 0 && len(stub.txPrivateWrites) == 0 {
+		return nil
+	}
+
+	creator, err := stub.creatorIdentity()
+	if err != nil {
+		return fmt.Errorf(`creator: %s: %w`, err, ErrEndorsementPolicyFailure)
+	}
+
+	for _, item := range stub.StateBuffer {
+		if err = stub.validateKeyEndorsement(``, item.Key, creator.Mspid); err != nil {
+			return err
+		}
+	}
+	for _, key := range stub.txDeletes {
+		if err = stub.validateKeyEndorsement(``, key, creator.Mspid); err != nil {
+			return err
+		}
+	}
+	for _, write := range stub.txPrivateWrites {
+		if err = stub.validateKeyEndorsement(write.Collection, write.Key, creator.Mspid); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateKeyEndorsement checks msp is in orgs of validation parameter of public (empty collection) or private key
+func (stub *MockStub) validateKeyEndorsement(collection, key, mspID string) error {
+	name := `key ` + key
+	if collection != `` {
+		name = fmt.Sprintf(`collection %s key %s`, collection, key)
+	}
+
+	policy, err := stub.GetPrivateDataValidationParameter(collection, key)
+	if err != nil {
+		return fmt.Errorf(`%s: %s: %w`, name, err, ErrEndorsementPolicyFailure)
+	}
+	if len(policy) == 0 {
+		return nil
+	}
+
+	ep, err := statebased.NewStateEP(policy)
+	if err != nil {
+		return fmt.Errorf(`%s: %s: %w`, name, err, ErrEndorsementPolicyFailure)
+	}
+
+	for _, org := range ep.ListOrgs() {
+		if org == mspID {
+			return nil
+		}
+	}
+	return fmt.Errorf(`%s requires orgs %v, endorsed by %s: %w`,
+		name, ep.ListOrgs(), mspID, ErrEndorsementPolicyFailure)
+}
@@ -0,0 +1,113 @@
+package testing_test
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/statebased"
+	idtestdata "github.com/s7techlab/cckit/identity/testdata"
+	"github.com/s7techlab/cckit/router"
+	p "github.com/s7techlab/cckit/router/param"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+const GuardedKey = `guarded`
+
+func NewGuardedCC() *router.Chaincode {
+	r := router.New(`guarded`)
+
+	r.Init(func(c router.Context) (interface{}, error) {
+		ep, err := statebased.NewStateEP(nil)
+		if err != nil {
+			return nil, err
+		}
+		if err = ep.AddOrgs(statebased.RoleTypeMember, `Org2MSP`); err != nil {
+			return nil, err
+		}
+		policy, err := ep.Policy()
+		if err != nil {
+			return nil, err
+		}
+		if err = c.Stub().SetPrivateDataValidationParameter(GuardedKey, GuardedKey, policy); err != nil {
+			return nil, err
+		}
+		return nil, c.Stub().SetStateValidationParameter(GuardedKey, policy)
+	}).
+		Invoke(`put`, func(c router.Context) (interface{}, error) {
+			return nil, c.Stub().PutState(c.ParamString(`key`), []byte(c.ParamString(`value`)))
+		}, p.String(`key`), p.String(`value`)).
+		Invoke(`del`, func(c router.Context) (interface{}, error) {
+			return nil, c.Stub().DelState(c.ParamString(`key`))
+		}, p.String(`key`)).
+		Invoke(`putPrivate`, func(c router.Context) (interface{}, error) {
+			return nil, c.Stub().PutPrivateData(GuardedKey, c.ParamString(`key`), []byte(c.ParamString(`value`)))
+		}, p.String(`key`), p.String(`value`))
+
+	return router.NewChaincode(r)
+}
+
+var _ = Describe(`Key endorsement validation`, func() {
+
+	org1 := idtestdata.MustIdentities(idtestdata.Certificates, `Org1MSP`)[0]
+	org2 := idtestdata.MustIdentities(idtestdata.Certificates, `Org2MSP`)[0]
+
+	cc := testcc.NewMockStub(`guarded`, NewGuardedCC(), testcc.WithKeyEndorsementValidation())
+
+	It("Allow to init chaincode", func() {
+		expectcc.ResponseOk(cc.From(org1).Init())
+	})
+
+	It("Disallow to commit write to guarded key endorsed by wrong org", func() {
+		expectcc.ResponseOk(cc.From(org1).Invoke(`put`, GuardedKey, `value1`))
+
+		Expect(errors.Is(cc.LastValidationError, testcc.ErrEndorsementPolicyFailure)).To(BeTrue())
+		Expect(cc.State).NotTo(HaveKey(GuardedKey))
+	})
+
+	It("Allow to commit write to non guarded key by any org", func() {
+		expectcc.ResponseOk(cc.From(org1).Invoke(`put`, `free`, `value1`))
+
+		Expect(cc.LastValidationError).NotTo(HaveOccurred())
+		Expect(cc.State[`free`]).To(Equal([]byte(`value1`)))
+	})
+
+	It("Allow to commit write to guarded key endorsed by right org", func() {
+		expectcc.ResponseOk(cc.From(org2).Invoke(`put`, GuardedKey, `value2`))
+
+		Expect(cc.LastValidationError).NotTo(HaveOccurred())
+		Expect(cc.State[GuardedKey]).To(Equal([]byte(`value2`)))
+	})
+
+	It("Disallow to commit delete of guarded key endorsed by wrong org", func() {
+		expectcc.ResponseOk(cc.From(org1).Invoke(`del`, GuardedKey))
+
+		Expect(errors.Is(cc.LastValidationError, testcc.ErrEndorsementPolicyFailure)).To(BeTrue())
+		Expect(cc.State[GuardedKey]).To(Equal([]byte(`value2`)))
+		Expect(cc.KeyHistory(GuardedKey)).To(HaveLen(1))
+	})
+
+	It("Disallow to commit private write to guarded key endorsed by wrong org", func() {
+		expectcc.ResponseOk(cc.From(org1).Invoke(`putPrivate`, GuardedKey, `secret1`))
+
+		Expect(errors.Is(cc.LastValidationError, testcc.ErrEndorsementPolicyFailure)).To(BeTrue())
+		Expect(cc.LastValidationError.Error()).To(ContainSubstring(`collection guarded key guarded`))
+		Expect(cc.PvtState[GuardedKey]).NotTo(HaveKey(GuardedKey))
+
+		expectcc.ResponseOk(cc.From(org2).Invoke(`putPrivate`, GuardedKey, `secret2`))
+		Expect(cc.LastValidationError).NotTo(HaveOccurred())
+		Expect(cc.PvtState[GuardedKey][GuardedKey]).To(Equal([]byte(`secret2`)))
+	})
+
+	It("Allow to get validation error via mocked peer", func() {
+		peer := testcc.NewPeer().WithChannel(Channel, cc)
+
+		_, _, err := peer.Invoke(context.Background(), org1, Channel, `guarded`,
+			`put`, [][]byte{[]byte(GuardedKey), []byte(`value3`)}, nil)
+		Expect(errors.Is(err, testcc.ErrEndorsementPolicyFailure)).To(BeTrue())
+		Expect(cc.State[GuardedKey]).To(Equal([]byte(`value2`)))
+	})
+})
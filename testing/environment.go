@@ -0,0 +1,451 @@
+package testing
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/pkg/errors"
+
+	"github.com/s7techlab/cckit/identity"
+)
+
+// ErrEnvironmentInvalid occurs when environment file declares missing, duplicate or unknown entities
+var ErrEnvironmentInvalid = errors.New(`environment invalid`)
+
+type (
+	// Environment declares organizations with identities and chaincodes with channels, links,
+	// private data collections and initial state, see LoadEnvironment
+	Environment struct {
+		Organizations []*EnvironmentOrg       `json:"organizations"`
+		Chaincodes    []*EnvironmentChaincode `json:"chaincodes"`
+	}
+
+	// EnvironmentOrg organization and its identities
+	EnvironmentOrg struct {
+		MSPID      string                 `json:"mspId"`
+		Identities []*EnvironmentIdentity `json:"identities"`
+	}
+
+	// EnvironmentIdentity identity, loaded from PEM certificate file or generated, if cert is not set.
+	// Cert path is relative to environment file
+	EnvironmentIdentity struct {
+		Name string `json:"name"`
+		Cert string `json:"cert,omitempty"`
+	}
+
+	// EnvironmentChaincode chaincode, instantiated on each of channels. Implementation is provided
+	// with WithEnvironmentChaincode, chaincode without implementation is state only
+	EnvironmentChaincode struct {
+		Name        string                   `json:"name"`
+		Channels    []string                 `json:"channels"`
+		Links       []*EnvironmentLink       `json:"links,omitempty"`
+		Collections []*EnvironmentCollection `json:"collections,omitempty"`
+		// Init invoke args and identity name, chaincode is initialized on each channel before state is seeded
+		Init *SeedInvoke `json:"init,omitempty"`
+		// State raw state entries, string values are put as is, other values as JSON
+		State map[string]json.RawMessage `json:"state,omitempty"`
+		// PrivateState raw private data entries by collection
+		PrivateState map[string]map[string]json.RawMessage `json:"privateState,omitempty"`
+		// Invokes replayed after state is seeded, From field refers to identity name
+		Invokes []*SeedInvoke `json:"invokes,omitempty"`
+	}
+
+	// EnvironmentLink chaincode, available for invoke with InvokeChaincode
+	EnvironmentLink struct {
+		Chaincode string `json:"chaincode"`
+		Channel   string `json:"channel"`
+	}
+
+	// EnvironmentCollection private data collection with member MSPs
+	EnvironmentCollection struct {
+		Name    string   `json:"name"`
+		Members []string `json:"members"`
+	}
+
+	// EnvironmentError describes invalid environment field
+	EnvironmentError struct {
+		File string
+		// Field path, i.e. chaincodes[0].links[1].chaincode
+		Field string
+		Err   error
+	}
+
+	// EnvironmentOpts options of environment loading
+	EnvironmentOpts struct {
+		// Chaincodes implementations by chaincode name
+		Chaincodes map[string]shim.Chaincode
+		// StubOpts applied to each chaincode stub
+		StubOpts []MockStubOpt
+		// Rand source of keys and serials of generated identities, crypto/rand is used if not set
+		Rand *Rand
+	}
+
+	// EnvironmentOpt option of environment loading
+	EnvironmentOpt func(*EnvironmentOpts)
+
+	// Network mocked peer with chaincodes and identities of environment
+	Network struct {
+		*MockedPeer
+		// Identities by name
+		Identities map[string]*identity.CertIdentity
+	}
+)
+
+func (e *EnvironmentError) Error() string {
+	if e.Field == `` {
+		return fmt.Sprintf(`environment %s: %s`, e.File, e.Err)
+	}
+	return fmt.Sprintf(`environment %s: %s: %s`, e.File, e.Field, e.Err)
+}
+
+func (e *EnvironmentError) Unwrap() error {
+	return e.Err
+}
+
+// WithEnvironmentChaincode sets implementation of environment chaincode
+func WithEnvironmentChaincode(name string, cc shim.Chaincode) EnvironmentOpt {
+	return func(opts *EnvironmentOpts) {
+		opts.Chaincodes[name] = cc
+	}
+}
+
+// WithEnvironmentStubOpts sets options of each chaincode stub
+func WithEnvironmentStubOpts(stubOpts ...MockStubOpt) EnvironmentOpt {
+	return func(opts *EnvironmentOpts) {
+		opts.StubOpts = append(opts.StubOpts, stubOpts...)
+	}
+}
+
+// WithEnvironmentRand sets random source of generated identities, so identities keys and serials
+// are reproduced with the same seed
+func WithEnvironmentRand(r *Rand) EnvironmentOpt {
+	return func(opts *EnvironmentOpts) {
+		opts.Rand = r
+	}
+}
+
+// WithEnvironmentSeed sets random source of generated identities with seed, see WithEnvironmentRand
+func WithEnvironmentSeed(seed int64) EnvironmentOpt {
+	return WithEnvironmentRand(NewRand(seed))
+}
+
+// LoadEnvironment reads environment JSON file and creates network: identities are loaded or generated,
+// chaincode stubs are created on channels with collections, linked, initialized and seeded.
+// Validation errors are EnvironmentError, referencing file and field
+func LoadEnvironment(path string, opts ...EnvironmentOpt) (*Network, error) {
+	l := &environmentLoader{
+		file:       path,
+		opts:       &EnvironmentOpts{Chaincodes: make(map[string]shim.Chaincode)},
+		network:    &Network{MockedPeer: NewPeer(), Identities: make(map[string]*identity.CertIdentity)},
+		identities: make(map[string]interface{}),
+		orgs:       make(map[string]bool),
+		channels:   make(map[string]map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(l.opts)
+	}
+
+	bb, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, l.wrap(``, err)
+	}
+	env := &Environment{}
+	decoder := json.NewDecoder(bytes.NewReader(bb))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(env); err != nil {
+		return nil, l.fail(``, `%s`, err)
+	}
+
+	if err = l.load(env); err != nil {
+		return nil, err
+	}
+	return l.network, nil
+}
+
+// Identity returns identity by name
+func (n *Network) Identity(name string) (*identity.CertIdentity, error) {
+	id, ok := n.Identities[name]
+	if !ok {
+		return nil, fmt.Errorf(`%w: identity %s`, ErrEnvironmentInvalid, name)
+	}
+	return id, nil
+}
+
+type environmentLoader struct {
+	file       string
+	opts       *EnvironmentOpts
+	network    *Network
+	identities map[string]interface{}
+	orgs       map[string]bool
+	// chaincode name => declared channels
+	channels map[string]map[string]bool
+}
+
+func (l *environmentLoader) fail(field string, format string, args ...interface{}) error {
+	return l.wrap(field, fmt.Errorf(`%w: `+format, append([]interface{}{ErrEnvironmentInvalid}, args...)...))
+}
+
+func (l *environmentLoader) wrap(field string, err error) error {
+	return &EnvironmentError{File: l.file, Field: field, Err: err}
+}
+
+func (l *environmentLoader) load(env *Environment) error {
+	for i, org := range env.Organizations {
+		if err := l.loadOrg(fmt.Sprintf(`organizations[%d]`, i), org); err != nil {
+			return err
+		}
+	}
+
+	for i, cc := range env.Chaincodes {
+		if err := l.declareChaincode(fmt.Sprintf(`chaincodes[%d]`, i), cc); err != nil {
+			return err
+		}
+	}
+	for i, cc := range env.Chaincodes {
+		if err := l.createChaincode(fmt.Sprintf(`chaincodes[%d]`, i), cc); err != nil {
+			return err
+		}
+	}
+	// chaincodes are linked after all stubs are created and seeded after all are linked,
+	// so init and invokes can call linked chaincodes
+	for i, cc := range env.Chaincodes {
+		if err := l.linkChaincode(fmt.Sprintf(`chaincodes[%d]`, i), cc); err != nil {
+			return err
+		}
+	}
+	for i, cc := range env.Chaincodes {
+		if err := l.seedChaincode(fmt.Sprintf(`chaincodes[%d]`, i), cc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *environmentLoader) loadOrg(field string, org *EnvironmentOrg) error {
+	if org.MSPID == `` {
+		return l.fail(field+`.mspId`, `required`)
+	}
+	if l.orgs[org.MSPID] {
+		return l.fail(field+`.mspId`, `duplicate organization %s`, org.MSPID)
+	}
+	l.orgs[org.MSPID] = true
+
+	for i, envID := range org.Identities {
+		idField := fmt.Sprintf(`%s.identities[%d]`, field, i)
+		if envID.Name == `` {
+			return l.fail(idField+`.name`, `required`)
+		}
+		if _, ok := l.identities[envID.Name]; ok {
+			return l.fail(idField+`.name`, `duplicate identity %s`, envID.Name)
+		}
+
+		var (
+			id  *identity.CertIdentity
+			err error
+		)
+		switch {
+		case envID.Cert == `` && l.opts.Rand != nil:
+			id, err = GenerateIdentityRand(l.opts.Rand, org.MSPID, envID.Name)
+		case envID.Cert == ``:
+			id, err = GenerateIdentity(org.MSPID, envID.Name)
+		default:
+			id, err = IdentityFromFile(org.MSPID, filepath.Join(filepath.Dir(l.file), envID.Cert), ioutil.ReadFile)
+		}
+		if err != nil {
+			return l.wrap(idField+`.cert`, err)
+		}
+		l.network.Identities[envID.Name] = id
+		l.identities[envID.Name] = id
+	}
+	return nil
+}
+
+func (l *environmentLoader) declareChaincode(field string, cc *EnvironmentChaincode) error {
+	if cc.Name == `` {
+		return l.fail(field+`.name`, `required`)
+	}
+	if _, ok := l.channels[cc.Name]; ok {
+		return l.fail(field+`.name`, `duplicate chaincode %s`, cc.Name)
+	}
+	if len(cc.Channels) == 0 {
+		return l.fail(field+`.channels`, `at least one channel required`)
+	}
+
+	l.channels[cc.Name] = make(map[string]bool)
+	for i, channel := range cc.Channels {
+		if channel == `` || l.channels[cc.Name][channel] {
+			return l.fail(fmt.Sprintf(`%s.channels[%d]`, field, i), `empty or duplicate channel "%s"`, channel)
+		}
+		l.channels[cc.Name][channel] = true
+	}
+	return nil
+}
+
+func (l *environmentLoader) createChaincode(field string, cc *EnvironmentChaincode) error {
+	stubOpts := append([]MockStubOpt(nil), l.opts.StubOpts...)
+	for i, collection := range cc.Collections {
+		colField := fmt.Sprintf(`%s.collections[%d]`, field, i)
+		if collection.Name == `` {
+			return l.fail(colField+`.name`, `required`)
+		}
+		for j, member := range collection.Members {
+			if !l.orgs[member] {
+				return l.fail(fmt.Sprintf(`%s.members[%d]`, colField, j), `unknown organization %s`, member)
+			}
+		}
+		stubOpts = append(stubOpts, WithCollection(collection.Name, collection.Members...))
+	}
+
+	for i, link := range cc.Links {
+		if !l.channels[link.Chaincode][link.Channel] {
+			return l.fail(fmt.Sprintf(`%s.links[%d]`, field, i),
+				`chaincode %s is not declared on channel "%s"`, link.Chaincode, link.Channel)
+		}
+	}
+
+	for _, channel := range cc.Channels {
+		stub := NewMockStub(cc.Name, l.opts.Chaincodes[cc.Name], stubOpts...)
+		stub.ChannelID = channel
+		if _, ok := l.network.ChannelCC[channel]; !ok {
+			l.network.ChannelCC[channel] = make(ChannelMockStubs)
+		}
+		l.network.ChannelCC[channel][cc.Name] = stub
+	}
+	return nil
+}
+
+func (l *environmentLoader) linkChaincode(field string, cc *EnvironmentChaincode) error {
+	for _, channel := range cc.Channels {
+		stub := l.network.ChannelCC[channel][cc.Name]
+		for _, link := range cc.Links {
+			linked := l.network.ChannelCC[link.Channel][link.Chaincode]
+			if err := stub.MockPeerChaincode(link.Chaincode+`/`+link.Channel, linked); err != nil {
+				return l.wrap(field+`.links`, err)
+			}
+			// invoke without channel targets chaincode on the same channel
+			if link.Channel == channel {
+				if err := stub.MockPeerChaincode(link.Chaincode, linked); err != nil {
+					return l.wrap(field+`.links`, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (l *environmentLoader) seedChaincode(field string, cc *EnvironmentChaincode) error {
+	if err := l.checkFrom(field+`.init.from`, cc.Init); err != nil {
+		return err
+	}
+	for i, invoke := range cc.Invokes {
+		if err := l.checkFrom(fmt.Sprintf(`%s.invokes[%d].from`, field, i), invoke); err != nil {
+			return err
+		}
+	}
+
+	for _, channel := range cc.Channels {
+		stub := l.network.ChannelCC[channel][cc.Name]
+		if cc.Init != nil {
+			if cc.Init.From != nil {
+				stub.From(l.identities[cc.Init.From.(string)])
+			}
+			if res := stub.Init(cc.Init.Args...); res.Status != shim.OK {
+				return l.fail(field+`.init`, `channel %s: %s`, channel, res.Message)
+			}
+		}
+
+		if len(cc.State) > 0 {
+			if err := stub.SeedState(rawJSONEntries(cc.State)); err != nil {
+				return l.wrap(field+`.state`, err)
+			}
+		}
+
+		collections := make([]string, 0, len(cc.PrivateState))
+		for collection := range cc.PrivateState {
+			collections = append(collections, collection)
+		}
+		sort.Strings(collections)
+		for _, collection := range collections {
+			if err := stub.SeedPrivateState(collection, rawJSONEntries(cc.PrivateState[collection])); err != nil {
+				return l.wrap(fmt.Sprintf(`%s.privateState.%s`, field, collection), err)
+			}
+		}
+
+		if err := stub.SeedInvokes(cc.Invokes, WithSeedIdentities(l.identities)); err != nil {
+			return l.wrap(field+`.invokes`, fmt.Errorf(`channel %s: %w`, channel, err))
+		}
+	}
+	return nil
+}
+
+// checkFrom checks invoke From field refers to environment identity
+func (l *environmentLoader) checkFrom(field string, invoke *SeedInvoke) error {
+	if invoke == nil || invoke.From == nil {
+		return nil
+	}
+	if name, _ := invoke.From.(string); l.identities[name] == nil {
+		return l.fail(field, `unknown identity %v`, invoke.From)
+	}
+	return nil
+}
+
+// GenerateIdentity creates identity with self-signed ECDSA certificate, subject common name is name
+func GenerateIdentity(mspID, name string) (*identity.CertIdentity, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, errors.Wrap(err, `generate identity key`)
+	}
+
+	serial, err := rand.Int(rand.Reader, maxIdentitySerial)
+	if err != nil {
+		return nil, errors.Wrap(err, `generate identity serial`)
+	}
+	return selfSignedIdentity(mspID, name, key, serial)
+}
+
+// GenerateIdentityRand creates identity as GenerateIdentity, key and serial are derived from r.
+// Certificate signature and validity period are not reproducible
+func GenerateIdentityRand(r *Rand, mspID, name string) (*identity.CertIdentity, error) {
+	curve := elliptic.P256()
+	// private key scalar in [1, N-1]
+	d := new(big.Int).Rand(r.Rand, new(big.Int).Sub(curve.Params().N, big.NewInt(1)))
+	d.Add(d, big.NewInt(1))
+
+	key := &ecdsa.PrivateKey{PublicKey: ecdsa.PublicKey{Curve: curve}, D: d}
+	key.PublicKey.X, key.PublicKey.Y = curve.ScalarBaseMult(d.Bytes())
+
+	return selfSignedIdentity(mspID, name, key, new(big.Int).Rand(r.Rand, maxIdentitySerial))
+}
+
+// maxIdentitySerial upper bound of generated certificate serial number
+var maxIdentitySerial = new(big.Int).Lsh(big.NewInt(1), 64)
+
+func selfSignedIdentity(mspID, name string, key *ecdsa.PrivateKey, serial *big.Int) (*identity.CertIdentity, error) {
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name, Organization: []string{mspID}},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(365 * 24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, errors.Wrap(err, `generate identity certificate`)
+	}
+
+	return identity.New(mspID, pem.EncodeToMemory(&pem.Block{Type: `CERTIFICATE`, Bytes: der}))
+}
@@ -0,0 +1,118 @@
+package testing_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/identity"
+	idtestdata "github.com/s7techlab/cckit/identity/testdata"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+var _ = Describe(`Environment`, func() {
+
+	var network *testcc.Network
+
+	BeforeEach(func() {
+		var err error
+		network, err = testcc.LoadEnvironment(`testdata/environment/network.json`,
+			testcc.WithEnvironmentChaincode(`status`, StatusCC{}),
+			testcc.WithEnvironmentChaincode(`status-caller`, NewStatusCallerCC()),
+			testcc.WithEnvironmentChaincode(`collections`, NewCollectionsCC()),
+			testcc.WithEnvironmentChaincode(`accounts`, NewAccountsCC()))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	chaincode := func(channel, name string) *testcc.MockStub {
+		stub, err := network.Chaincode(channel, name)
+		Expect(err).NotTo(HaveOccurred())
+		return stub
+	}
+
+	id := func(name string) *identity.CertIdentity {
+		id, err := network.Identity(name)
+		Expect(err).NotTo(HaveOccurred())
+		return id
+	}
+
+	It("Allow to load identities from certificate files or generate them", func() {
+		Expect(id(`alice`).GetID()).To(Equal(idtestdata.Certificates[0].MustIdentity(`Org1MSP`).GetID()))
+
+		Expect(id(`bob`).GetMSPIdentifier()).To(Equal(`Org1MSP`))
+		Expect(id(`carol`).GetMSPIdentifier()).To(Equal(`Org2MSP`))
+		Expect(id(`bob`).GetID()).NotTo(Equal(id(`carol`).GetID()))
+
+		_, err := network.Identity(`unknown`)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("Allow to instantiate chaincode on each of declared channels", func() {
+		Expect(chaincode(`ch1`, `status`).ChannelID).To(Equal(`ch1`))
+		Expect(chaincode(`ch2`, `status`).ChannelID).To(Equal(`ch2`))
+
+		_, err := network.Chaincode(`ch2`, `accounts`)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("Allow to invoke linked chaincode", func() {
+		observed := expectcc.PayloadIs(chaincode(`ch1`, `status-caller`).Invoke(`call`, `200`),
+			&ObservedResponse{}).(ObservedResponse)
+		Expect(observed.Payload).To(Equal(`callee response`))
+	})
+
+	It("Allow to seed private state of collections with members", func() {
+		collections := chaincode(`ch1`, `collections`)
+
+		expectcc.PayloadBytes(collections.From(id(`alice`)).Query(`get`, `shared`), []byte(`value`))
+		expectcc.ResponseError(collections.From(id(`carol`)).Query(`get`, `shared`), testcc.ErrCollectionReadDenied)
+	})
+
+	It("Allow to seed state and replay invokes from identities", func() {
+		accounts := chaincode(`ch1`, `accounts`)
+
+		Expect(accounts.State[`alice`]).To(Equal([]byte(`100`)))
+		Expect(accounts.State[`bob`]).To(Equal([]byte(`10`)))
+	})
+
+	It("Allow to reproduce generated identities with the same seed", func() {
+		load := func(seed int64) *testcc.Network {
+			network, err := testcc.LoadEnvironment(`testdata/environment/network.json`,
+				testcc.WithEnvironmentSeed(seed),
+				testcc.WithEnvironmentChaincode(`status`, StatusCC{}),
+				testcc.WithEnvironmentChaincode(`status-caller`, NewStatusCallerCC()),
+				testcc.WithEnvironmentChaincode(`collections`, NewCollectionsCC()),
+				testcc.WithEnvironmentChaincode(`accounts`, NewAccountsCC()))
+			Expect(err).NotTo(HaveOccurred())
+			return network
+		}
+
+		first, second, other := load(1), load(1), load(2)
+		for _, name := range []string{`bob`, `carol`} {
+			Expect(second.Identities[name].Cert.PublicKey).To(Equal(first.Identities[name].Cert.PublicKey))
+			Expect(second.Identities[name].Cert.SerialNumber).To(Equal(first.Identities[name].Cert.SerialNumber))
+			Expect(other.Identities[name].Cert.PublicKey).NotTo(Equal(first.Identities[name].Cert.PublicKey))
+		}
+		Expect(first.Identities[`bob`].Cert.PublicKey).NotTo(Equal(first.Identities[`carol`].Cert.PublicKey))
+	})
+
+	It("Disallow to link chaincode, not declared on channel", func() {
+		_, err := testcc.LoadEnvironment(`testdata/environment/invalid_link.json`)
+
+		Expect(errors.Is(err, testcc.ErrEnvironmentInvalid)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring(`testdata/environment/invalid_link.json`))
+		Expect(err.Error()).To(ContainSubstring(`chaincodes[1].links[0]`))
+	})
+
+	It("Disallow to invoke from unknown identity", func() {
+		_, err := testcc.LoadEnvironment(`testdata/environment/unknown_identity.json`)
+
+		var envErr *testcc.EnvironmentError
+		Expect(errors.As(err, &envErr)).To(BeTrue())
+		Expect(envErr.File).To(Equal(`testdata/environment/unknown_identity.json`))
+		Expect(envErr.Field).To(Equal(`chaincodes[0].invokes[0].from`))
+		Expect(errors.Is(err, testcc.ErrEnvironmentInvalid)).To(BeTrue())
+	})
+})
@@ -0,0 +1,152 @@
+package testing
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// ErrStubsDiverged occurs when scenario, run against two stubs, produces different results
+var ErrStubsDiverged = errors.New(`stubs diverged`)
+
+type (
+	// StubFactory creates stub with initialized chaincode for equivalence run
+	StubFactory func() *MockStub
+
+	// equivalenceAspect compared part of stub results
+	equivalenceAspect struct {
+		name              string
+		legacy, candidate []byte
+	}
+)
+
+// AssertEquivalence runs scenario against stubs, created by legacy and candidate factories,
+// and reports to t first divergence of responses, final state, private state, events or key histories
+func AssertEquivalence(t ErrorReporter, invokes []*SeedInvoke, legacy, candidate StubFactory, opts ...SeedOpt) bool {
+	err := CheckEquivalence(invokes, legacy, candidate, opts...)
+	if err != nil {
+		t.Errorf(`%s`, err)
+	}
+	return err == nil
+}
+
+// CheckEquivalence runs scenario against stubs, created by legacy and candidate factories, and returns
+// first divergence. Invokes are committed under the same deterministic tx ids in both stubs,
+// chaincode, depending on tx timestamp, requires factories to set the same clock
+func CheckEquivalence(invokes []*SeedInvoke, legacy, candidate StubFactory, opts ...SeedOpt) error {
+	legacyStub, candidateStub := legacy(), candidate()
+
+	for i, invoke := range invokes {
+		legacyErr := legacyStub.SeedInvokes([]*SeedInvoke{invoke}, withSeedStep(opts, i))
+		candidateErr := candidateStub.SeedInvokes([]*SeedInvoke{invoke}, withSeedStep(opts, i))
+		if (legacyErr == nil) != (candidateErr == nil) {
+			return fmt.Errorf(`%w: step %d, fn %s: legacy error "%v", candidate error "%v"`,
+				ErrStubsDiverged, i, invoke.Fn, legacyErr, candidateErr)
+		}
+
+		legacyRes, candidateRes := legacyStub.lastInvocation(), candidateStub.lastInvocation()
+		for _, aspect := range []equivalenceAspect{
+			{`response status`, []byte(fmt.Sprint(legacyRes.Response.Status)), []byte(fmt.Sprint(candidateRes.Response.Status))},
+			{`response message`, []byte(legacyRes.Response.Message), []byte(candidateRes.Response.Message)},
+			{`response payload`, legacyRes.Response.Payload, candidateRes.Response.Payload},
+		} {
+			if err := aspect.diff(); err != nil {
+				return fmt.Errorf(`step %d, fn %s: %w`, i, invoke.Fn, err)
+			}
+		}
+	}
+
+	return equivalentResults(legacyStub, candidateStub)
+}
+
+// withSeedStep returns seed options with deterministic tx id of scenario step
+func withSeedStep(opts []SeedOpt, step int) SeedOpt {
+	return func(o *SeedOpts) {
+		for _, opt := range opts {
+			opt(o)
+		}
+		o.TxIDPrefix = fmt.Sprintf(`equivalence-%d-`, step)
+	}
+}
+
+// equivalentResults compares final state, private state, events and key histories of stubs
+func equivalentResults(legacy, candidate *MockStub) error {
+	var aspects []equivalenceAspect
+
+	for _, key := range sortedKeys(mergeKeys(legacy.State, candidate.State)) {
+		aspects = append(aspects, equivalenceAspect{`state ` + key, legacy.State[key], candidate.State[key]})
+	}
+
+	collections := make(map[string][]byte)
+	for collection := range legacy.PvtState {
+		collections[collection] = nil
+	}
+	for collection := range candidate.PvtState {
+		collections[collection] = nil
+	}
+	for _, collection := range sortedKeys(collections) {
+		lc, cc := legacy.PvtState[collection], candidate.PvtState[collection]
+		for _, key := range sortedKeys(mergeKeys(lc, cc)) {
+			aspects = append(aspects, equivalenceAspect{
+				fmt.Sprintf(`private state %s %s`, collection, key), lc[key], cc[key]})
+		}
+	}
+
+	legacyEvents, candidateEvents := legacy.ChaincodeEvents(), candidate.ChaincodeEvents()
+	aspects = append(aspects, equivalenceAspect{`events count`,
+		[]byte(fmt.Sprint(len(legacyEvents))), []byte(fmt.Sprint(len(candidateEvents)))})
+	for i := 0; i < len(legacyEvents) && i < len(candidateEvents); i++ {
+		aspects = append(aspects,
+			equivalenceAspect{fmt.Sprintf(`event %d name`, i),
+				[]byte(legacyEvents[i].EventName), []byte(candidateEvents[i].EventName)},
+			equivalenceAspect{fmt.Sprintf(`event %d payload`, i),
+				legacyEvents[i].Payload, candidateEvents[i].Payload})
+	}
+
+	for _, key := range sortedKeys(mergeKeys(legacy.State, candidate.State)) {
+		lh, ch := legacy.keyHistory[key], candidate.keyHistory[key]
+		aspects = append(aspects, equivalenceAspect{`history length ` + key,
+			[]byte(fmt.Sprint(len(lh))), []byte(fmt.Sprint(len(ch)))})
+		for i := 0; i < len(lh) && i < len(ch); i++ {
+			aspects = append(aspects, equivalenceAspect{
+				fmt.Sprintf(`history %s %d`, key, i),
+				[]byte(fmt.Sprintf(`%s %t %x`, lh[i].TxId, lh[i].IsDelete, lh[i].Value)),
+				[]byte(fmt.Sprintf(`%s %t %x`, ch[i].TxId, ch[i].IsDelete, ch[i].Value))})
+		}
+	}
+
+	for _, aspect := range aspects {
+		if err := aspect.diff(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a equivalenceAspect) diff() error {
+	if bytes.Equal(a.legacy, a.candidate) {
+		return nil
+	}
+	return fmt.Errorf(`%w: %s: legacy "%s", candidate "%s"`, ErrStubsDiverged, a.name, a.legacy, a.candidate)
+}
+
+// lastInvocation returns last logged invocation
+func (stub *MockStub) lastInvocation() *Invocation {
+	if len(stub.invocationLog) == 0 {
+		return &Invocation{}
+	}
+	return stub.invocationLog[len(stub.invocationLog)-1]
+}
+
+// mergeKeys returns map with keys of both maps
+func mergeKeys(a, b map[string][]byte) map[string][]byte {
+	keys := make(map[string][]byte, len(a))
+	for key := range a {
+		keys[key] = nil
+	}
+	for key := range b {
+		keys[key] = nil
+	}
+	return keys
+}
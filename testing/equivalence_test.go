@@ -0,0 +1,62 @@
+package testing_test
+
+import (
+	"errors"
+	"strconv"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/router"
+	p "github.com/s7techlab/cckit/router/param"
+	testcc "github.com/s7techlab/cckit/testing"
+)
+
+// NewDoublingCounterCC counter, storing doubled value: diverges from counter chaincode state only
+func NewDoublingCounterCC() *router.Chaincode {
+	r := router.New(`counter`)
+
+	r.Invoke(`set`, func(c router.Context) (interface{}, error) {
+		value := strconv.Itoa(c.ParamInt(`value`))
+		if err := c.Event().Set(`CounterSet`, value); err != nil {
+			return nil, err
+		}
+		return value, c.Stub().PutState(`counter`, []byte(value+value))
+	}, p.Int(`value`))
+
+	return router.NewChaincode(r)
+}
+
+var _ = Describe(`Stub equivalence`, func() {
+
+	scenario := []*testcc.SeedInvoke{
+		{Fn: `set`, Args: []interface{}{1}},
+		{Fn: `set`, Args: []interface{}{2}},
+		{Fn: `unknown`},
+	}
+
+	counter := func(cc func() *router.Chaincode, opts ...testcc.MockStubOpt) testcc.StubFactory {
+		return func() *testcc.MockStub {
+			return testcc.NewMockStub(`counter`, cc(), opts...)
+		}
+	}
+
+	It("Allow to check equivalence of stubs with the same results", func() {
+		Expect(testcc.CheckEquivalence(scenario,
+			counter(NewCounterCC), counter(NewCounterCC, testcc.WithDefensiveCopies(false)))).To(Succeed())
+	})
+
+	It("Disallow stubs with different state", func() {
+		err := testcc.CheckEquivalence(scenario, counter(NewCounterCC), counter(NewDoublingCounterCC))
+
+		Expect(errors.Is(err, testcc.ErrStubsDiverged)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring(`state counter: legacy "2", candidate "22"`))
+	})
+
+	It("Disallow stubs with different responses", func() {
+		err := testcc.CheckEquivalence(scenario, counter(NewCounterCC), counter(NewExportCC))
+
+		Expect(errors.Is(err, testcc.ErrStubsDiverged)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring(`step 0, fn set: legacy error "<nil>"`))
+	})
+})
@@ -0,0 +1,39 @@
+package testing
+
+import (
+	"github.com/pkg/errors"
+)
+
+// Sentinel errors of mocked stub methods, wrapped with %w and contextual detail. Messages of wrapping errors
+// start with messages, returned before sentinels introduction
+var (
+	// ErrCollectionNotFound occurs when private data collection has no data
+	ErrCollectionNotFound = errors.New(`collection not found`)
+
+	// ErrCollectionNameEmpty occurs when private data is queried with empty collection name
+	ErrCollectionNameEmpty = errors.New(`collection must not be an empty string`)
+
+	// ErrKeyEmpty occurs when state is changed with empty key, rejected by Fabric shim as well
+	ErrKeyEmpty = errors.New(`key must not be an empty string`)
+
+	// ErrKeyNotFound occurs when deleted key does not exist
+	ErrKeyNotFound = errors.New(`key not found`)
+
+	// ErrNoOpenTransaction occurs when state is changed outside of tx
+	ErrNoOpenTransaction = errors.New(`no open transaction`)
+
+	// ErrIteratorClosed occurs when iterator is used after Close
+	ErrIteratorClosed = errors.New(`iterator closed`)
+
+	// ErrIteratorExhausted occurs when Next is called on iterator without next item
+	ErrIteratorExhausted = errors.New(`iterator exhausted`)
+
+	// ErrQueryResultMalformed occurs when query result iterator contains malformed entry
+	ErrQueryResultMalformed = errors.New(`query result entry malformed`)
+
+	// ErrEventNameEmpty occurs when chaincode event is set with empty name
+	ErrEventNameEmpty = errors.New(`event name empty`)
+
+	// ErrEventPayloadTooLarge occurs when chaincode event payload exceeds size, set with WithEventValidation
+	ErrEventPayloadTooLarge = errors.New(`event payload too large`)
+)
@@ -0,0 +1,78 @@
+package testing_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	testcc "github.com/s7techlab/cckit/testing"
+)
+
+var _ = Describe(`Sentinel errors`, func() {
+
+	var stub *testcc.MockStub
+
+	BeforeEach(func() {
+		stub = testcc.NewMockStub(`errors`, nil)
+	})
+
+	It("Allow to match state change outside of tx", func() {
+		err := stub.PutState(`key`, []byte(`value`))
+		Expect(errors.Is(err, testcc.ErrNoOpenTransaction)).To(BeTrue())
+		Expect(err.Error()).To(HavePrefix(`cannot PutState without a transactions`))
+	})
+
+	It("Allow to match not found collection and key", func() {
+		stub.MockTransactionStart(`tx`)
+		defer stub.MockTransactionEnd(`tx`)
+
+		Expect(errors.Is(stub.DelPrivateData(`unknown`, `key`), testcc.ErrCollectionNotFound)).To(BeTrue())
+
+		Expect(stub.PutPrivateData(`collection`, `key`, []byte(`value`))).To(Succeed())
+		err := stub.DelPrivateData(`collection`, `unknown`)
+		Expect(errors.Is(err, testcc.ErrKeyNotFound)).To(BeTrue())
+		Expect(err.Error()).To(HavePrefix(`Key unknown not found`))
+	})
+
+	It("Allow to match empty event name", func() {
+		Expect(errors.Is(stub.SetEvent(``, nil), testcc.ErrEventNameEmpty)).To(BeTrue())
+	})
+
+	It("Allow to match closed and exhausted iterators", func() {
+		iter, err := stub.GetQueryResult(`{"selector":{"docType":"none"}}`)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = iter.Next()
+		Expect(errors.Is(err, testcc.ErrIteratorExhausted)).To(BeTrue())
+
+		Expect(iter.Close()).To(Succeed())
+		_, err = iter.Next()
+		Expect(errors.Is(err, testcc.ErrIteratorClosed)).To(BeTrue())
+		Expect(errors.Is(iter.Close(), testcc.ErrIteratorClosed)).To(BeTrue())
+
+		privateIter, err := stub.GetPrivateDataByRange(`collection`, ``, ``)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = privateIter.Next()
+		Expect(errors.Is(err, testcc.ErrIteratorExhausted)).To(BeTrue())
+		Expect(privateIter.Close()).To(Succeed())
+		_, err = privateIter.Next()
+		Expect(errors.Is(err, testcc.ErrIteratorClosed)).To(BeTrue())
+
+		historyIter, err := stub.GetHistoryForKey(`key`)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = historyIter.Next()
+		Expect(errors.Is(err, testcc.ErrIteratorExhausted)).To(BeTrue())
+	})
+
+	It("Allow to match invalid rich queries", func() {
+		_, err := stub.GetQueryResult(`not json`)
+		Expect(errors.Is(err, testcc.ErrQueryInvalid)).To(BeTrue())
+
+		_, err = stub.GetQueryResult(`{"selector":{}}`)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(stub.SeedState(map[string][]byte{`doc`: []byte(`{"n":1}`)})).To(Succeed())
+		_, err = stub.GetQueryResult(`{"selector":{"n":{"$mod":[2,1]}}}`)
+		Expect(errors.Is(err, testcc.ErrSelectorOperatorNotSupported)).To(BeTrue())
+	})
+})
@@ -0,0 +1,40 @@
+package testing
+
+import (
+	"fmt"
+
+	"github.com/s7techlab/cckit/state"
+)
+
+// DefaultEventPayloadMaxSize event payload size limit, set by WithFabricDefaults
+const DefaultEventPayloadMaxSize = 1 << 20
+
+type eventLimits struct {
+	enabled        bool
+	nameMaxLength  int
+	payloadMaxSize int
+}
+
+// WithEventValidation enables validation of events, set with SetEvent: name is checked with
+// state.ValidateEventName and nameMaxLength, payload size is limited by payloadMaxSize.
+// Zero limit means unlimited
+func WithEventValidation(nameMaxLength, payloadMaxSize int) MockStubOpt {
+	return func(stub *MockStub) {
+		stub.eventLimits = eventLimits{enabled: true, nameMaxLength: nameMaxLength, payloadMaxSize: payloadMaxSize}
+	}
+}
+
+// checkEvent validates event name and payload size, if event validation is enabled
+func (stub *MockStub) checkEvent(name string, payload []byte) error {
+	if !stub.eventLimits.enabled {
+		return nil
+	}
+	if err := state.ValidateEventName(name, stub.eventLimits.nameMaxLength); err != nil {
+		return err
+	}
+	if max := stub.eventLimits.payloadMaxSize; max > 0 && len(payload) > max {
+		return fmt.Errorf(`%w: event %s payload is %d bytes, bytes [%d:%d] exceed %d bytes limit`,
+			ErrEventPayloadTooLarge, name, len(payload), max, len(payload), max)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package testing_test
+
+import (
+	"errors"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/router"
+	p "github.com/s7techlab/cckit/router/param"
+	"github.com/s7techlab/cckit/state"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+// NewEventEmitterCC sets event with name and payload from args, i.e. from unvalidated user input
+func NewEventEmitterCC() *router.Chaincode {
+	r := router.New(`events`)
+
+	r.Invoke(`emit`, func(c router.Context) (interface{}, error) {
+		return nil, c.Stub().SetEvent(c.ParamString(`name`), []byte(c.ParamString(`payload`)))
+	}, p.String(`name`), p.String(`payload`))
+
+	return router.NewChaincode(r)
+}
+
+var _ = Describe(`Event validation`, func() {
+
+	It("Allow to set any event name and payload by default", func() {
+		cc := testcc.NewMockStub(`events`, NewEventEmitterCC())
+		expectcc.ResponseOk(cc.Invoke(`emit`, "Transfer\nApproved", `payload`))
+	})
+
+	It("Disallow to set event with not allowed bytes in name", func() {
+		cc := testcc.NewMockStub(`events`, NewEventEmitterCC(), testcc.WithFabricDefaults())
+
+		res := cc.Invoke(`emit`, "Transfer\nApproved", `payload`)
+		expectcc.ResponseError(res, state.ErrEventNameInvalid)
+		Expect(res.Message).To(ContainSubstring(`byte 0x0a at offset 8`))
+		Expect(cc.ChaincodeEvent).To(BeNil())
+
+		expectcc.ResponseError(cc.Invoke(`emit`, "Transfer\xff", `payload`), state.ErrEventNameInvalid)
+		expectcc.ResponseError(cc.Invoke(`emit`, strings.Repeat(`a`, state.DefaultEventNameMaxLength+1), ``),
+			state.ErrEventNameInvalid)
+		expectcc.ResponseOk(cc.Invoke(`emit`, `Transfer.Approved:v1`, `payload`))
+	})
+
+	It("Disallow to set event with oversized payload", func() {
+		cc := testcc.NewMockStub(`events`, NewEventEmitterCC(), testcc.WithEventValidation(0, 8))
+
+		expectcc.ResponseOk(cc.Invoke(`emit`, `Transfer`, `12345678`))
+
+		res := cc.Invoke(`emit`, `Transfer`, `123456789ab`)
+		expectcc.ResponseError(res, testcc.ErrEventPayloadTooLarge)
+		Expect(res.Message).To(ContainSubstring(`bytes [8:11] exceed 8 bytes limit`))
+	})
+
+	It("Allow to check event directly with SetEvent", func() {
+		cc := testcc.NewMockStub(`events`, nil, testcc.WithEventValidation(4, 0))
+		Expect(errors.Is(cc.SetEvent(`Transfer`, nil), state.ErrEventNameInvalid)).To(BeTrue())
+	})
+})
@@ -0,0 +1,195 @@
+package expect
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	g "github.com/onsi/gomega"
+)
+
+const (
+	// ErrorClassNotFound requested entry, method or chaincode doesn't exist
+	ErrorClassNotFound ErrorClass = `not found`
+	// ErrorClassForbidden invoker is not allowed to perform action
+	ErrorClassForbidden ErrorClass = `forbidden`
+	// ErrorClassValidation invoke args or payload are invalid
+	ErrorClassValidation ErrorClass = `validation`
+
+	// FabricV14 Fabric 1.4 shim and peer error messages
+	FabricV14 FabricVersion = `1.4`
+	// FabricV2 Fabric 2.x shim and peer error messages
+	FabricV2 FabricVersion = `2`
+)
+
+type (
+	// ErrorClass class of error response, independent of error message wording
+	ErrorClass string
+
+	// FabricVersion version of Fabric, error message patterns are curated for
+	FabricVersion string
+
+	// ClassifyOpts options of error response classification
+	ClassifyOpts struct {
+		// Versions of Fabric, message patterns of which are used. All known versions by default
+		Versions []FabricVersion
+	}
+
+	// ClassifyOpt option of error response classification
+	ClassifyOpt func(*ClassifyOpts)
+
+	errorPatterns map[ErrorClass][]*regexp.Regexp
+)
+
+var (
+	// errorClasses in order of classification, first matched class wins
+	errorClasses = []ErrorClass{ErrorClassNotFound, ErrorClassForbidden, ErrorClassValidation}
+
+	// statusClasses structured error statuses, set by chaincode instead of generic shim.ERROR
+	statusClasses = map[int32]ErrorClass{
+		http.StatusNotFound:   ErrorClassNotFound,
+		http.StatusForbidden:  ErrorClassForbidden,
+		http.StatusBadRequest: ErrorClassValidation,
+	}
+
+	// cckitPatterns messages of cckit router, state and extensions, used for all Fabric versions
+	cckitPatterns = newErrorPatterns(map[ErrorClass][]string{
+		ErrorClassNotFound: {
+			`chaincode method not found`,
+			`state entry not found`,
+			`resource not found`,
+			`collection not found`,
+			`chaincode not exists`,
+		},
+		ErrorClassForbidden: {
+			`owner only`,
+			`does not have (read|write) access permission on collection`,
+		},
+		ErrorClassValidation: {
+			`chaincode method args count mismatch`,
+			`param ".*" not exists`,
+			`empty args`,
+			`payload validation`,
+			`transient key required`,
+		},
+	})
+
+	// fabricPatterns shim and peer messages per Fabric version
+	fabricPatterns = map[FabricVersion]errorPatterns{
+		FabricV14: newErrorPatterns(map[ErrorClass][]string{
+			ErrorClassNotFound: {
+				`could not find chaincode with name`,
+				`cannot retrieve package for chaincode`,
+			},
+			ErrorClassForbidden: {
+				`access denied: channel \[.*\] creator org`,
+				`failed evaluating policy on signed data`,
+			},
+			ErrorClassValidation: {
+				`incorrect number of arguments`,
+			},
+		}),
+		FabricV2: newErrorPatterns(map[ErrorClass][]string{
+			ErrorClassNotFound: {
+				`chaincode definition for '.*' not found`,
+				`could not find chaincode with name`,
+			},
+			ErrorClassForbidden: {
+				`access denied for \[.*\]`,
+				`signature set did not satisfy policy`,
+			},
+			ErrorClassValidation: {
+				`incorrect number of arguments`,
+				`invalid (argument|function)`,
+			},
+		}),
+	}
+)
+
+// WithFabricVersions restricts message patterns to patterns of Fabric versions
+func WithFabricVersions(versions ...FabricVersion) ClassifyOpt {
+	return func(opts *ClassifyOpts) {
+		opts.Versions = versions
+	}
+}
+
+// Classify returns class of error response. Structured status (404, 403, 400) is used if present,
+// otherwise message is matched with cckit and Fabric version message patterns
+func Classify(response peer.Response, opts ...ClassifyOpt) (ErrorClass, bool) {
+	if response.Status < shim.ERRORTHRESHOLD {
+		return ``, false
+	}
+	if class, ok := statusClasses[response.Status]; ok {
+		return class, true
+	}
+	return ClassifyMessage(response.Message, opts...)
+}
+
+// ClassifyMessage returns class of error message, matched with cckit and Fabric version message patterns
+func ClassifyMessage(message string, opts ...ClassifyOpt) (ErrorClass, bool) {
+	o := &ClassifyOpts{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if len(o.Versions) == 0 {
+		o.Versions = []FabricVersion{FabricV14, FabricV2}
+	}
+
+	for _, class := range errorClasses {
+		if cckitPatterns.match(class, message) {
+			return class, true
+		}
+		for _, version := range o.Versions {
+			if fabricPatterns[version].match(class, message) {
+				return class, true
+			}
+		}
+	}
+	return ``, false
+}
+
+// ResponseErrorClass expects peer.Response has error status and error class
+func ResponseErrorClass(response peer.Response, class ErrorClass, opts ...ClassifyOpt) peer.Response {
+	g.Expect(int(response.Status)).To(g.BeNumerically(`>=`, shim.ERRORTHRESHOLD), response.Message)
+
+	actual, _ := Classify(response, opts...)
+	g.Expect(actual).To(g.Equal(class),
+		fmt.Sprintf("error class not match: status %d, %s", response.Status, response.Message))
+	return response
+}
+
+// ResponseNotFound expects peer.Response is not found error
+func ResponseNotFound(response peer.Response, opts ...ClassifyOpt) peer.Response {
+	return ResponseErrorClass(response, ErrorClassNotFound, opts...)
+}
+
+// ResponseForbidden expects peer.Response is forbidden error
+func ResponseForbidden(response peer.Response, opts ...ClassifyOpt) peer.Response {
+	return ResponseErrorClass(response, ErrorClassForbidden, opts...)
+}
+
+// ResponseValidation expects peer.Response is validation error
+func ResponseValidation(response peer.Response, opts ...ClassifyOpt) peer.Response {
+	return ResponseErrorClass(response, ErrorClassValidation, opts...)
+}
+
+func newErrorPatterns(patterns map[ErrorClass][]string) errorPatterns {
+	compiled := make(errorPatterns, len(patterns))
+	for class, classPatterns := range patterns {
+		for _, pattern := range classPatterns {
+			compiled[class] = append(compiled[class], regexp.MustCompile(`(?i)`+pattern))
+		}
+	}
+	return compiled
+}
+
+func (p errorPatterns) match(class ErrorClass, message string) bool {
+	for _, pattern := range p[class] {
+		if pattern.MatchString(message) {
+			return true
+		}
+	}
+	return false
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/hyperledger/fabric-protos-go/peer"
 	g "github.com/onsi/gomega"
 	"github.com/s7techlab/cckit/convert"
+	cckitresponse "github.com/s7techlab/cckit/response"
 )
 
 // ResponseOk expects peer.Response has shim.OK status and message has okMatcher matcher
@@ -27,8 +28,15 @@ func ResponseOk(response peer.Response, okMatcher ...interface{}) peer.Response
 	return response
 }
 
-// ResponseError expects peer.Response has shim.ERROR status and message has errMatcher matcher
+// ResponseError expects peer.Response has shim.ERROR status and message has errMatcher matcher.
+// ErrorClass matcher is checked with Classify and allows structured error status
 func ResponseError(response peer.Response, errMatcher ...interface{}) peer.Response {
+	if len(errMatcher) > 0 {
+		if class, ok := errMatcher[0].(ErrorClass); ok {
+			return ResponseErrorClass(response, class)
+		}
+	}
+
 	g.Expect(int(response.Status)).To(g.Equal(shim.ERROR), response.Message)
 
 	if len(errMatcher) > 0 {
@@ -46,10 +54,11 @@ func ResponseError(response peer.Response, errMatcher ...interface{}) peer.Respo
 	return response
 }
 
-// PayloadIs expects peer.Response payload can be marshalled to target interface{} and returns converted value
+// PayloadIs expects peer.Response payload can be marshalled to target interface{} and returns converted value.
+// Proto message payload is decoded in format, echoed in response message
 func PayloadIs(response peer.Response, target interface{}) interface{} {
 	ResponseOk(response)
-	data, err := convert.FromBytes(response.Payload, target)
+	data, err := convert.FromBytesFormat(response.Payload, target, cckitresponse.Format(response))
 	description := ``
 	if err != nil {
 		description = err.Error()
@@ -19,9 +19,14 @@ type (
 	}
 )
 
+// HasError expects tx error contains err, ErrorClass is checked with ClassifyMessage
 func (r *TxRes) HasError(err interface{}) *TxRes {
 	if err == nil {
 		g.Expect(r.Err).NotTo(g.HaveOccurred())
+	} else if class, ok := err.(ErrorClass); ok {
+		g.Expect(r.Err).To(g.HaveOccurred())
+		actual, _ := ClassifyMessage(r.Err.Error())
+		g.Expect(actual).To(g.Equal(class), "error class not match: "+r.Err.Error())
 	} else {
 		g.Expect(fmt.Sprintf(`%s`, r.Err)).To(g.ContainSubstring(fmt.Sprintf(`%s`, err)))
 	}
@@ -0,0 +1,267 @@
+package testing
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/pkg/errors"
+	"github.com/s7techlab/cckit/convert"
+)
+
+var (
+	// ErrSeedInvokeFailed occurs when fixture invoke returns non OK response
+	ErrSeedInvokeFailed = errors.New(`seed invoke failed`)
+	// ErrSeedIdentityNotFound occurs when fixture invoke refers to unknown identity
+	ErrSeedIdentityNotFound = errors.New(`seed identity not found`)
+)
+
+type (
+	// Fixture describes chaincode state, loaded directly to state and / or via chaincode invokes
+	Fixture struct {
+		// State raw state entries, put to state without chaincode validation
+		State map[string]json.RawMessage `json:"state"`
+		// Extensions raw state entries of cckit extensions, stored under reserved composite keys
+		Extensions map[string]json.RawMessage `json:"extensions,omitempty"`
+		// Invokes chaincode invokes, replayed against chaincode handlers
+		Invokes []*SeedInvoke `json:"invokes"`
+	}
+
+	// SeedInvoke chaincode invoke used for seeding state through chaincode own handlers
+	SeedInvoke struct {
+		Fn        string
+		Args      []interface{}
+		From      interface{}
+		Transient map[string]interface{}
+	}
+
+	// SeedOpts options of seeding
+	SeedOpts struct {
+		// Identities used for resolving invoke From field, if it's string
+		Identities map[string]interface{}
+		// TxIDPrefix, if set, invokes are committed under sequential deterministic tx ids
+		TxIDPrefix string
+	}
+
+	SeedOpt func(*SeedOpts)
+
+	seedInvokeJSON struct {
+		Fn        string                     `json:"fn"`
+		Args      []json.RawMessage          `json:"args"`
+		From      string                     `json:"from"`
+		Transient map[string]json.RawMessage `json:"transient"`
+	}
+)
+
+// WithSeedIdentities sets identities, available by name in fixture invokes From field
+func WithSeedIdentities(identities map[string]interface{}) SeedOpt {
+	return func(opts *SeedOpts) {
+		opts.Identities = identities
+	}
+}
+
+// WithSeedTxIDPrefix sets sequential deterministic tx ids for fixture invokes: prefix1, prefix2 ...
+func WithSeedTxIDPrefix(prefix string) SeedOpt {
+	return func(opts *SeedOpts) {
+		opts.TxIDPrefix = prefix
+	}
+}
+
+// UnmarshalJSON decodes invoke from fixture file, json string args are passed as is, other values - as json
+func (si *SeedInvoke) UnmarshalJSON(bb []byte) error {
+	raw := &seedInvokeJSON{}
+	if err := json.Unmarshal(bb, raw); err != nil {
+		return err
+	}
+
+	si.Fn = raw.Fn
+	si.Args = make([]interface{}, len(raw.Args))
+	for i, arg := range raw.Args {
+		si.Args[i] = rawJSONToBytes(arg)
+	}
+
+	if raw.From != `` {
+		si.From = raw.From
+	}
+
+	if len(raw.Transient) > 0 {
+		si.Transient = make(map[string]interface{}, len(raw.Transient))
+		for k, v := range raw.Transient {
+			si.Transient[k] = rawJSONToBytes(v)
+		}
+	}
+
+	return nil
+}
+
+func rawJSONToBytes(raw json.RawMessage) []byte {
+	var str string
+	if err := json.Unmarshal(raw, &str); err == nil {
+		return []byte(str)
+	}
+	return raw
+}
+
+func rawJSONEntries(entries map[string]json.RawMessage) map[string][]byte {
+	state := make(map[string][]byte, len(entries))
+	for k, v := range entries {
+		state[k] = rawJSONToBytes(v)
+	}
+	return state
+}
+
+// LoadFixture reads fixture from json file
+func LoadFixture(path string) (*Fixture, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, `read fixture`)
+	}
+	defer func() { _ = f.Close() }()
+
+	return ReadFixture(f)
+}
+
+// ApplyFixture puts raw fixture state and extensions entries to state and then replays fixture invokes.
+// Extensions entries are put with allowed reserved keys
+func (stub *MockStub) ApplyFixture(fixture *Fixture, opts ...SeedOpt) error {
+	if len(fixture.State) > 0 {
+		if err := stub.SeedState(rawJSONEntries(fixture.State)); err != nil {
+			return err
+		}
+	}
+
+	if len(fixture.Extensions) > 0 {
+		restore := stub.AllowReservedKeys()
+		err := stub.SeedState(rawJSONEntries(fixture.Extensions))
+		restore()
+		if err != nil {
+			return err
+		}
+	}
+
+	return stub.SeedInvokes(fixture.Invokes, opts...)
+}
+
+// SeedState puts entries directly to state in one manual transaction, bypassing chaincode handlers,
+// see MockTransactionStart. Nothing is committed if any entry can't be put
+func (stub *MockStub) SeedState(state map[string][]byte) error {
+	keys := make([]string, 0, len(state))
+	for k := range state {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return stub.seedTx(func() error {
+		for _, k := range keys {
+			if err := stub.PutState(k, state[k]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// SeedPrivateState puts entries directly to private data collection in one manual transaction,
+// bypassing chaincode handlers, see SeedState
+func (stub *MockStub) SeedPrivateState(collection string, state map[string][]byte) error {
+	keys := make([]string, 0, len(state))
+	for k := range state {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return stub.seedTx(func() error {
+		for _, k := range keys {
+			if err := stub.PutPrivateData(collection, k, state[k]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// seedTx runs seed writes in one manual transaction, writes are committed only if all of them succeed
+func (stub *MockStub) seedTx(write func() error) error {
+	// private writes are applied to state immediately, so failed seed is rolled back to snapshot
+	snap := stub.snapshot()
+	uuid := stub.generateTxUID()
+	stub.MockTransactionStart(uuid)
+
+	if err := write(); err != nil {
+		stub.abortSeedTx(uuid, snap, err)
+		return err
+	}
+
+	stub.MockTransactionEnd(uuid)
+	return nil
+}
+
+// abortSeedTx ends failed seed tx: state is rolled back to snapshot, tx is logged as failed manual tx,
+// block height is not advanced
+func (stub *MockStub) abortSeedTx(uuid string, snap *snapshot, err error) {
+	stub.restore(snap)
+	stub.StateBuffer = nil
+	stub.txDeletes = nil
+	stub.txPrivateWrites = nil
+	stub.ChaincodeEvent = nil
+	stub.txSnapshot = nil
+
+	stub.manualTx = false
+	stub.logInvocation(uuid, nil, shim.Error(err.Error()))
+	stub.invocationLog[len(stub.invocationLog)-1].Manual = true
+	stub.endedTx = stub.txOutcome()
+
+	stub.finishAccessCheck()
+	stub.MockStub.MockTransactionEnd(uuid)
+	stub.invalidateTxStub()
+}
+
+// SeedInvokes replays invokes against chaincode, stops on first non OK response
+func (stub *MockStub) SeedInvokes(invokes []*SeedInvoke, opts ...SeedOpt) error {
+	seedOpts := &SeedOpts{}
+	for _, o := range opts {
+		o(seedOpts)
+	}
+
+	for i, invoke := range invokes {
+		args, err := convert.ArgsToBytes(invoke.Args...)
+		if err != nil {
+			return fmt.Errorf(`step %d, fn %s: args: %w`, i, invoke.Fn, err)
+		}
+
+		transient := make(map[string][]byte, len(invoke.Transient))
+		for k, v := range invoke.Transient {
+			if transient[k], err = convert.ToBytes(v); err != nil {
+				return fmt.Errorf(`step %d, fn %s: transient %s: %w`, i, invoke.Fn, k, err)
+			}
+		}
+
+		if invoke.From != nil {
+			from := invoke.From
+			if name, ok := from.(string); ok {
+				if from, ok = seedOpts.Identities[name]; !ok {
+					return fmt.Errorf(`step %d, fn %s: %s: %w`, i, invoke.Fn, name, ErrSeedIdentityNotFound)
+				}
+			}
+			stub.From(from)
+		}
+
+		if len(transient) > 0 {
+			stub.WithTransient(transient)
+		}
+
+		uuid := stub.generateTxUID()
+		if seedOpts.TxIDPrefix != `` {
+			uuid = fmt.Sprintf(`%s%d`, seedOpts.TxIDPrefix, i+1)
+		}
+
+		res := stub.MockInvoke(uuid, append([][]byte{[]byte(invoke.Fn)}, args...))
+		if res.Status >= shim.ERRORTHRESHOLD {
+			return fmt.Errorf(`step %d, fn %s: %s: %w`, i, invoke.Fn, res.Message, ErrSeedInvokeFailed)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package testing_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+	"github.com/s7techlab/cckit/testing/testdata"
+)
+
+var _ = Describe(`Fixture`, func() {
+
+	It("Allow to seed state through chaincode create handlers", func() {
+		cc := testcc.NewMockStub(`entities`, testdata.NewEntitiesCC())
+		events, _ := cc.EventSubscription()
+
+		fixture, err := testcc.LoadFixture(`testdata/fixtures/entities.json`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fixture.Invokes).To(HaveLen(10))
+
+		Expect(cc.ApplyFixture(fixture,
+			testcc.WithSeedIdentities(map[string]interface{}{`authority`: Authority}),
+			testcc.WithSeedTxIDPrefix(`seed-`))).To(Succeed())
+
+		entities := expectcc.PayloadIs(cc.Query(`entityList`), &[]testdata.Entity{}).([]testdata.Entity)
+		Expect(entities).To(HaveLen(10))
+
+		Expect(expectcc.PayloadIs(cc.Query(`entityListByOwner`, `owner1`), &[]string{})).To(
+			Equal([]string{`e02`, `e04`, `e06`, `e08`, `e10`}))
+
+		Expect(events).To(HaveLen(10))
+		for i := 0; i < 10; i++ {
+			event := <-events
+			Expect(event.EventName).To(Equal(testdata.EntityCreatedEvent))
+		}
+	})
+
+	It("Disallow to continue seeding after failed invoke", func() {
+		cc := testcc.NewMockStub(`entities`, testdata.NewEntitiesCC())
+
+		err := cc.SeedInvokes([]*testcc.SeedInvoke{{
+			Fn:   `entityCreate`,
+			Args: []interface{}{testdata.Entity{Id: `a`, Owner: `owner1`}},
+		}, {
+			Fn:   `entityCreate`,
+			Args: []interface{}{testdata.Entity{Id: `b`}},
+		}, {
+			Fn:   `entityCreate`,
+			Args: []interface{}{testdata.Entity{Id: `c`, Owner: `owner1`}},
+		}})
+
+		Expect(errors.Is(err, testcc.ErrSeedInvokeFailed)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring(`step 1`))
+
+		Expect(expectcc.PayloadIs(cc.Query(`entityListByOwner`, `owner1`), &[]string{})).To(
+			Equal([]string{`a`}))
+	})
+
+	It("Disallow to seed with unknown identity", func() {
+		cc := testcc.NewMockStub(`entities`, testdata.NewEntitiesCC())
+
+		err := cc.SeedInvokes([]*testcc.SeedInvoke{{
+			Fn:   `entityCreate`,
+			Args: []interface{}{testdata.Entity{Id: `a`, Owner: `owner1`}},
+			From: `unknown`,
+		}})
+
+		Expect(errors.Is(err, testcc.ErrSeedIdentityNotFound)).To(BeTrue())
+	})
+
+	It("Allow to seed raw state", func() {
+		cc := testcc.NewMockStub(`entities`, testdata.NewEntitiesCC())
+
+		Expect(cc.SeedState(map[string][]byte{`raw`: []byte(`value`)})).To(Succeed())
+		Expect(cc.State[`raw`]).To(Equal([]byte(`value`)))
+	})
+
+	It("Disallow to commit partially seeded state", func() {
+		cc := testcc.NewMockStub(`entities`, testdata.NewEntitiesCC())
+		errUnavailable := errors.New(`unavailable`)
+		cc.InjectErrorOnce(testcc.OperationPutState, `b`, errUnavailable)
+
+		err := cc.SeedState(map[string][]byte{`a`: []byte(`1`), `b`: []byte(`2`)})
+		Expect(errors.Is(err, errUnavailable)).To(BeTrue())
+		Expect(cc.State).NotTo(HaveKey(`a`))
+		Expect(cc.KeyHistory(`a`)).To(BeEmpty())
+
+		// failed seed is logged as failed manual tx and doesn't advance block height
+		Expect(cc.BlockHeight()).To(BeZero())
+		txs := cc.Transactions()
+		Expect(txs).To(HaveLen(1))
+		Expect(txs.Failed()).To(Equal(1))
+		Expect(cc.InvocationLog()[0].Manual).To(BeTrue())
+		Expect(cc.InvocationLog()[0].Response.Message).To(ContainSubstring(`unavailable`))
+
+		Expect(cc.SeedState(map[string][]byte{`a`: []byte(`1`), `b`: []byte(`2`)})).To(Succeed())
+		Expect(cc.State).To(HaveKey(`a`))
+	})
+})
@@ -0,0 +1,198 @@
+package testing
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/s7techlab/cckit/state/mapping"
+)
+
+type (
+	// Snapshot of committed public and private state, used as baseline for footprint measurement
+	Snapshot struct {
+		State    map[string][]byte
+		PvtState map[string]map[string][]byte
+	}
+
+	// Footprint state size, added by scenario, totals or averages per entity
+	Footprint struct {
+		// Keys public keys, excluding index keys
+		Keys float64
+		// IndexKeys keys of uniq indexes, created by state mapping
+		IndexKeys  float64
+		KeyBytes   float64
+		ValueBytes float64
+		// PrivateBytes bytes of private keys and values per collection
+		PrivateBytes map[string]float64
+	}
+
+	// FootprintReport state size, added by scenario, creating entities
+	FootprintReport struct {
+		Entities  int
+		Total     Footprint
+		PerEntity Footprint
+		// Violations of footprint budget
+		Violations []string
+	}
+
+	// FootprintOpts options of footprint measurement
+	FootprintOpts struct {
+		// Entities number of entities, created by scenario, 1 by default
+		Entities int
+		// Budget max footprint per entity, zero fields are not checked
+		Budget *Footprint
+	}
+
+	// FootprintOpt option of footprint measurement
+	FootprintOpt func(*FootprintOpts)
+)
+
+// WithEntities sets number of entities, created by measured scenario
+func WithEntities(n int) FootprintOpt {
+	return func(opts *FootprintOpts) {
+		opts.Entities = n
+	}
+}
+
+// WithFootprintBudget sets max footprint per entity, exceeding budget is reported as test error
+func WithFootprintBudget(budget Footprint) FootprintOpt {
+	return func(opts *FootprintOpts) {
+		opts.Budget = &budget
+	}
+}
+
+// Snapshot returns copy of committed public and private state
+func (stub *MockStub) Snapshot() Snapshot {
+	snap := Snapshot{
+		State:    copyBytesMap(stub.State),
+		PvtState: make(map[string]map[string][]byte, len(stub.PvtState)),
+	}
+	for collection, state := range stub.PvtState {
+		snap.PvtState[collection] = copyBytesMap(state)
+	}
+	return snap
+}
+
+// MeasureFootprint returns state size, added to stub state since before snapshot, in total and per entity.
+// Changed and deleted entries are accounted by size difference. Per entity footprint, exceeding budget,
+// is reported to t
+func MeasureFootprint(t ErrorReporter, stub *MockStub, before Snapshot, opts ...FootprintOpt) FootprintReport {
+	o := &FootprintOpts{Entities: 1}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	report := FootprintReport{Entities: o.Entities, Total: Footprint{PrivateBytes: make(map[string]float64)}}
+
+	indexPrefix := compositeKeyNamespace + mapping.KeyRefNamespace + compositeKeyNamespace
+	diffState(before.State, stub.State, func(key string, sign float64, value []byte) {
+		if strings.HasPrefix(key, indexPrefix) {
+			report.Total.IndexKeys += sign
+		} else {
+			report.Total.Keys += sign
+		}
+		report.Total.KeyBytes += sign * float64(len(key))
+		report.Total.ValueBytes += sign * float64(len(value))
+	})
+
+	for _, collection := range collections(before.PvtState, stub.PvtState) {
+		diffState(before.PvtState[collection], stub.PvtState[collection], func(key string, sign float64, value []byte) {
+			report.Total.PrivateBytes[collection] += sign * float64(len(key)+len(value))
+		})
+	}
+
+	report.PerEntity = report.Total.divide(float64(o.Entities))
+	if o.Budget != nil {
+		report.Violations = report.PerEntity.exceeds(*o.Budget)
+	}
+
+	for _, violation := range report.Violations {
+		t.Errorf(`footprint per entity exceeds budget: %s`, violation)
+	}
+	return report
+}
+
+func (r FootprintReport) String() string {
+	return fmt.Sprintf("footprint of %d entities\n  total:      %s\n  per entity: %s",
+		r.Entities, r.Total, r.PerEntity)
+}
+
+func (f Footprint) String() string {
+	str := fmt.Sprintf(`keys %.1f, index keys %.1f, key bytes %.1f, value bytes %.1f`,
+		f.Keys, f.IndexKeys, f.KeyBytes, f.ValueBytes)
+	for _, collection := range sortedFloatKeys(f.PrivateBytes) {
+		str += fmt.Sprintf(`, private bytes of %s %.1f`, collection, f.PrivateBytes[collection])
+	}
+	return str
+}
+
+func (f Footprint) divide(n float64) Footprint {
+	if n <= 0 {
+		n = 1
+	}
+	divided := Footprint{
+		Keys:         f.Keys / n,
+		IndexKeys:    f.IndexKeys / n,
+		KeyBytes:     f.KeyBytes / n,
+		ValueBytes:   f.ValueBytes / n,
+		PrivateBytes: make(map[string]float64, len(f.PrivateBytes)),
+	}
+	for collection, bytes := range f.PrivateBytes {
+		divided.PrivateBytes[collection] = bytes / n
+	}
+	return divided
+}
+
+// exceeds returns descriptions of footprint fields, exceeding non zero budget fields
+func (f Footprint) exceeds(budget Footprint) []string {
+	var violations []string
+	check := func(name string, value, limit float64) {
+		if limit > 0 && value > limit {
+			violations = append(violations, fmt.Sprintf(`%s %.1f > %.1f`, name, value, limit))
+		}
+	}
+
+	check(`keys`, f.Keys, budget.Keys)
+	check(`index keys`, f.IndexKeys, budget.IndexKeys)
+	check(`key bytes`, f.KeyBytes, budget.KeyBytes)
+	check(`value bytes`, f.ValueBytes, budget.ValueBytes)
+	for _, collection := range sortedFloatKeys(budget.PrivateBytes) {
+		check(`private bytes of `+collection, f.PrivateBytes[collection], budget.PrivateBytes[collection])
+	}
+	return violations
+}
+
+// diffState calls fn with sign -1 for each entry of before and with sign 1 for each entry of after,
+// which is absent or changed in other state
+func diffState(before, after map[string][]byte, fn func(key string, sign float64, value []byte)) {
+	for key, value := range before {
+		if afterValue, ok := after[key]; !ok || string(afterValue) != string(value) {
+			fn(key, -1, value)
+		}
+	}
+	for key, value := range after {
+		if beforeValue, ok := before[key]; !ok || string(beforeValue) != string(value) {
+			fn(key, 1, value)
+		}
+	}
+}
+
+func collections(states ...map[string]map[string][]byte) []string {
+	set := make(map[string]float64)
+	for _, state := range states {
+		for collection := range state {
+			set[collection] = 0
+		}
+	}
+	return sortedFloatKeys(set)
+}
+
+func sortedFloatKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
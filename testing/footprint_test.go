@@ -0,0 +1,84 @@
+package testing_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/router"
+	p "github.com/s7techlab/cckit/router/param"
+	"github.com/s7techlab/cckit/state/mapping"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+// NewFootprintCC stores order with uniq index by number and private details, bloated order has padded value
+func NewFootprintCC(padding int) *router.Chaincode {
+	r := router.New(`footprint`)
+
+	r.Invoke(`orderCreate`, func(c router.Context) (interface{}, error) {
+		id := c.ParamString(`id`)
+		key, err := c.Stub().CreateCompositeKey(`ORDER`, []string{id})
+		if err != nil {
+			return nil, err
+		}
+		if err = c.Stub().PutState(key, []byte(`order`+strings.Repeat(`_`, padding))); err != nil {
+			return nil, err
+		}
+
+		indexKey, err := c.Stub().CreateCompositeKey(mapping.KeyRefNamespace, []string{`ORDER`, `number`, id})
+		if err != nil {
+			return nil, err
+		}
+		if err = c.Stub().PutState(indexKey, []byte(key)); err != nil {
+			return nil, err
+		}
+		return nil, c.Stub().PutPrivateData(`details`, id, []byte(`details`))
+	}, p.String(`id`))
+
+	return router.NewChaincode(r)
+}
+
+var _ = Describe(`State footprint`, func() {
+
+	createOrders := func(cc *testcc.MockStub) {
+		for _, id := range []string{`01`, `02`} {
+			expectcc.ResponseOk(cc.Invoke(`orderCreate`, id))
+		}
+	}
+
+	It("Allow to measure state footprint per entity", func() {
+		cc := testcc.NewMockStub(`footprint`, NewFootprintCC(0))
+		before := cc.Snapshot()
+		createOrders(cc)
+
+		report := testcc.MeasureFootprint(GinkgoT(), cc, before, testcc.WithEntities(2))
+		Expect(report.Total.Keys).To(Equal(2.0))
+		Expect(report.PerEntity.Keys).To(Equal(1.0))
+		Expect(report.PerEntity.IndexKeys).To(Equal(1.0))
+		// \x00ORDER\x0001\x00 + \x00_idx\x00ORDER\x00number\x0001\x00
+		Expect(report.PerEntity.KeyBytes).To(Equal(10.0 + 22.0))
+		// order + \x00ORDER\x0001\x00
+		Expect(report.PerEntity.ValueBytes).To(Equal(5.0 + 10.0))
+		// 01 + details
+		Expect(report.PerEntity.PrivateBytes).To(Equal(map[string]float64{`details`: 9}))
+		Expect(report.Violations).To(BeEmpty())
+
+		Expect(report.String()).To(ContainSubstring(
+			`per entity: keys 1.0, index keys 1.0, key bytes 32.0, value bytes 15.0, private bytes of details 9.0`))
+	})
+
+	It("Disallow footprint per entity, exceeding budget", func() {
+		cc := testcc.NewMockStub(`footprint`, NewFootprintCC(100))
+		before := cc.Snapshot()
+		createOrders(cc)
+
+		t := &errorsCollector{}
+		report := testcc.MeasureFootprint(t, cc, before, testcc.WithEntities(2), testcc.WithFootprintBudget(
+			testcc.Footprint{Keys: 1, ValueBytes: 50, PrivateBytes: map[string]float64{`details`: 10}}))
+
+		Expect(report.Violations).To(Equal([]string{`value bytes 115.0 > 50.0`}))
+		Expect(*t).To(Equal(errorsCollector{`footprint per entity exceeds budget: value bytes 115.0 > 50.0`}))
+	})
+})
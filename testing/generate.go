@@ -0,0 +1,60 @@
+package testing
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// GeneratedDocumentKey returns state key of i-th document, generated by GenerateDocuments.
+// Keys are zero padded, so documents are ordered by index in state
+func GeneratedDocumentKey(i int) string {
+	return fmt.Sprintf(`DOC%08d`, i)
+}
+
+// GenerateDocuments returns n JSON documents keyed by GeneratedDocumentKey. Each document is a copy of template,
+// modified by mutate, which receives document index and pointer to copy. Documents can be loaded with SeedState
+func GenerateDocuments(n int, template interface{}, mutate func(i int, doc interface{})) (map[string][]byte, error) {
+	templateType := reflect.TypeOf(template)
+	if templateType == nil {
+		return nil, errors.New(`document template required`)
+	}
+	if templateType.Kind() == reflect.Ptr {
+		templateType = templateType.Elem()
+	}
+
+	templateBytes, err := json.Marshal(template)
+	if err != nil {
+		return nil, errors.Wrap(err, `marshal document template`)
+	}
+
+	docs := make(map[string][]byte, n)
+	for i := 0; i < n; i++ {
+		doc := reflect.New(templateType).Interface()
+		if err = json.Unmarshal(templateBytes, doc); err != nil {
+			return nil, errors.Wrap(err, `copy document template`)
+		}
+
+		if mutate != nil {
+			mutate(i, doc)
+		}
+
+		if docs[GeneratedDocumentKey(i)], err = json.Marshal(doc); err != nil {
+			return nil, errors.Wrapf(err, `marshal document %d`, i)
+		}
+	}
+
+	return docs, nil
+}
+
+// SeedDocuments generates documents with GenerateDocuments and seeds them to state in one tx,
+// so doc type index and key history are consistent
+func (stub *MockStub) SeedDocuments(n int, template interface{}, mutate func(i int, doc interface{})) error {
+	docs, err := GenerateDocuments(n, template, mutate)
+	if err != nil {
+		return err
+	}
+	return stub.SeedState(docs)
+}
@@ -0,0 +1,141 @@
+package testing_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/router"
+	"github.com/s7techlab/cckit/state"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+// CompressThreshold payload size, above which export handler compresses response
+const CompressThreshold = 1 << 20
+
+type GeneratedDoc struct {
+	DocType string   `json:"docType"`
+	N       int      `json:"n"`
+	Owner   string   `json:"owner"`
+	Tags    []string `json:"tags"`
+}
+
+func mutateDoc(i int, doc interface{}) {
+	d := doc.(*GeneratedDoc)
+	d.N = i
+	d.Owner = fmt.Sprintf(`owner-%d`, i%10)
+}
+
+// NewExportCC returns all state values as JSON array, gzipped if array size exceeds CompressThreshold
+func NewExportCC() *router.Chaincode {
+	r := router.New(`export`)
+
+	r.Query(`export`, func(c router.Context) (interface{}, error) {
+		iter, err := c.Stub().GetStateByRange(``, ``)
+		if err != nil {
+			return nil, err
+		}
+
+		var docs []json.RawMessage
+		if err = state.IterateKV(iter, func(kv *queryresult.KV) (bool, error) {
+			docs = append(docs, kv.Value)
+			return false, nil
+		}); err != nil {
+			return nil, err
+		}
+
+		bb, err := json.Marshal(docs)
+		if err != nil || len(bb) <= CompressThreshold {
+			return bb, err
+		}
+
+		buf := &bytes.Buffer{}
+		zw := gzip.NewWriter(buf)
+		if _, err = zw.Write(bb); err != nil {
+			return nil, err
+		}
+		if err = zw.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	})
+
+	return router.NewChaincode(r)
+}
+
+var _ = Describe(`Generated documents`, func() {
+
+	template := &GeneratedDoc{DocType: `car`, Tags: []string{`generated`}}
+
+	It("Allow to generate document variations from template", func() {
+		docs, err := testcc.GenerateDocuments(3, template, mutateDoc)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(docs).To(HaveLen(3))
+		Expect(string(docs[testcc.GeneratedDocumentKey(2)])).To(Equal(
+			`{"docType":"car","n":2,"owner":"owner-2","tags":["generated"]}`))
+		Expect(template.N).To(Equal(0))
+	})
+
+	It("Allow to seed generated documents consistently with doc type index", func() {
+		stub := testcc.NewMockStub(`docs`, nil, testcc.WithDocTypeIndex(``, nil))
+		Expect(stub.SeedDocuments(100, template, mutateDoc)).To(Succeed())
+
+		Expect(stub.State).To(HaveLen(100))
+		Expect(queryAll(stub, `{"selector":{"docType":"car","owner":"owner-3"}}`)).To(HaveLen(10))
+	})
+
+	It("Allow to exercise large payload branch of handler", func() {
+		stub := testcc.NewMockStub(`export`, NewExportCC())
+		Expect(stub.SeedDocuments(20000, template, mutateDoc)).To(Succeed())
+
+		res := expectcc.ResponseOk(stub.Query(`export`))
+		zr, err := gzip.NewReader(bytes.NewReader(res.Payload))
+		Expect(err).NotTo(HaveOccurred())
+
+		var docs []GeneratedDoc
+		Expect(json.NewDecoder(zr).Decode(&docs)).To(Succeed())
+		Expect(docs).To(HaveLen(20000))
+
+		sizes := stub.PayloadSizes()
+		Expect(sizes.Max).To(Equal(len(res.Payload)))
+		Expect(sizes.Oversized).To(BeZero())
+	})
+
+	It("Allow to report payload sizes of invocations", func() {
+		stub := testcc.NewMockStub(`export`, NewExportCC(), testcc.WithPayloadWarningSize(100))
+		Expect(stub.SeedDocuments(10, template, mutateDoc)).To(Succeed())
+
+		res := expectcc.ResponseOk(stub.Query(`export`))
+		expectcc.ResponseOk(stub.Query(`export`))
+
+		sizes := stub.PayloadSizes()
+		Expect(sizes.Invocations).To(Equal(2))
+		Expect(sizes.Total).To(Equal(2 * len(res.Payload)))
+		Expect(sizes.Oversized).To(Equal(2))
+		// first tx is manual, seeding documents
+		Expect(stub.Transactions()[1].PayloadSize).To(Equal(len(res.Payload)))
+	})
+})
+
+func BenchmarkSeedDocuments(b *testing.B) {
+	template := &GeneratedDoc{DocType: `car`, Tags: []string{`generated`}}
+
+	for i := 0; i < b.N; i++ {
+		started := time.Now()
+		if err := testcc.NewMockStub(`docs`, nil).SeedDocuments(100000, template, mutateDoc); err != nil {
+			b.Fatal(err)
+		}
+		if elapsed := time.Since(started); elapsed > 2*time.Second {
+			b.Fatalf(`seeding 100k documents took %s`, elapsed)
+		}
+	}
+}
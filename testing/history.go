@@ -0,0 +1,121 @@
+package testing
+
+import (
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/pkg/errors"
+)
+
+// ErrSeedHistoryInvalid occurs when seeded history versions have no timestamp or timestamps are not monotonic
+var ErrSeedHistoryInvalid = errors.New(`seed history invalid`)
+
+type (
+	// SeedVersion synthetic historical version of key
+	SeedVersion struct {
+		Value     []byte
+		TxID      string // generated if not set
+		Timestamp *timestamp.Timestamp
+		IsDelete  bool
+	}
+
+	// historyIterator iterates over key modifications, oldest first
+	historyIterator struct {
+		releaser
+		modifications []*queryresult.KeyModification
+		current       int
+		closed        bool
+	}
+)
+
+// SeedHistory installs synthetic history entries of key after existing ones, bypassing chaincode handlers.
+// Timestamps must be monotonic, current state is set to value of last version or deleted, if last version is delete
+func (stub *MockStub) SeedHistory(key string, versions []SeedVersion) error {
+	var last *timestamp.Timestamp
+	if history := stub.keyHistory[key]; len(history) > 0 {
+		last = history[len(history)-1].Timestamp
+	}
+
+	for i, version := range versions {
+		if version.Timestamp == nil {
+			return fmt.Errorf(`%w: key %s, version %d: timestamp not set`, ErrSeedHistoryInvalid, key, i)
+		}
+		if last != nil && timestampBefore(version.Timestamp, last) {
+			return fmt.Errorf(`%w: key %s, version %d: timestamp is before previous version`,
+				ErrSeedHistoryInvalid, key, i)
+		}
+		last = version.Timestamp
+	}
+
+	for _, version := range versions {
+		txID := version.TxID
+		if txID == `` {
+			txID = stub.generateTxUID()
+		}
+
+		var value []byte
+		if !version.IsDelete {
+			value = version.Value
+		}
+
+		stub.appendKeyModification(key, &queryresult.KeyModification{
+			TxId:      txID,
+			Value:     value,
+			Timestamp: version.Timestamp,
+			IsDelete:  version.IsDelete,
+		})
+	}
+
+	if len(versions) == 0 {
+		return nil
+	}
+
+	if current := versions[len(versions)-1]; current.IsDelete {
+		if err := stub.MockStub.DelState(key); err != nil {
+			return err
+		}
+		stub.indexDocType(key, nil, true)
+	} else {
+		stub.commitState(key, current.Value)
+		stub.indexDocType(key, current.Value, false)
+	}
+
+	return nil
+}
+
+// GetHistoryForKey mocked, returns committed and seeded modifications of key, oldest first
+func (stub *MockStub) GetHistoryForKey(key string) (shim.HistoryQueryIteratorInterface, error) {
+	if err := stub.checkInitRestriction(`history query`); err != nil {
+		return nil, err
+	}
+	iter := &historyIterator{modifications: stub.KeyHistory(key)}
+	stub.openResource(ResourceIterator, fmt.Sprintf(`GetHistoryForKey(%q)`, key), &iter.releaser)
+	return iter, nil
+}
+
+func (iter *historyIterator) HasNext() bool {
+	return !iter.closed && iter.current < len(iter.modifications)
+}
+
+func (iter *historyIterator) Next() (*queryresult.KeyModification, error) {
+	if !iter.HasNext() {
+		return nil, fmt.Errorf(`history iterator has no next item: %w`, ErrIteratorExhausted)
+	}
+
+	modification := iter.modifications[iter.current]
+	iter.current++
+	return proto.Clone(modification).(*queryresult.KeyModification), nil
+}
+
+func (iter *historyIterator) Close() error {
+	iter.release()
+	iter.closed = true
+	return nil
+}
+
+func timestampBefore(a, b *timestamp.Timestamp) bool {
+	return a.Seconds < b.Seconds || (a.Seconds == b.Seconds && a.Nanos < b.Nanos)
+}
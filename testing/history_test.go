@@ -0,0 +1,130 @@
+package testing_test
+
+import (
+	"errors"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/router"
+	p "github.com/s7techlab/cckit/router/param"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+func NewHistoryCC() *router.Chaincode {
+	r := router.New(`history`)
+
+	r.Invoke(`put`, func(c router.Context) (interface{}, error) {
+		return nil, c.Stub().PutState(`key`, []byte(c.ParamString(`value`)))
+	}, p.String(`value`)).
+		Invoke(`del`, func(c router.Context) (interface{}, error) {
+			return nil, c.Stub().DelState(`key`)
+		}).
+		Query(`get`, func(c router.Context) (interface{}, error) {
+			return c.Stub().GetState(`key`)
+		}).
+		// summary returns history entries as "value@seconds", deletes as "deleted@seconds"
+		Query(`summary`, func(c router.Context) (interface{}, error) {
+			iter, err := c.Stub().GetHistoryForKey(`key`)
+			if err != nil {
+				return nil, err
+			}
+			defer func() { _ = iter.Close() }()
+
+			summary := []string{}
+			for iter.HasNext() {
+				modification, err := iter.Next()
+				if err != nil {
+					return nil, err
+				}
+				value := string(modification.Value)
+				if modification.IsDelete {
+					value = `deleted`
+				}
+				summary = append(summary, fmt.Sprintf(`%s@%d`, value, modification.Timestamp.Seconds))
+			}
+			return summary, nil
+		})
+
+	return router.NewChaincode(r)
+}
+
+var _ = Describe(`History`, func() {
+
+	It("Allow to seed key history followed by live writes", func() {
+		cc := testcc.NewMockStub(`history`, NewHistoryCC())
+
+		Expect(cc.SeedHistory(`key`, []testcc.SeedVersion{
+			{Value: []byte(`v1`), Timestamp: testcc.MustTime(`2018-01-01T00:00:00Z`)},
+			{IsDelete: true, Timestamp: testcc.MustTime(`2018-06-01T00:00:00Z`)},
+			{Value: []byte(`v3`), TxID: `legacy`, Timestamp: testcc.MustTime(`2019-01-01T00:00:00Z`)},
+		})).To(Succeed())
+		expectcc.PayloadBytes(cc.Query(`get`), []byte(`v3`))
+
+		expectcc.ResponseOk(cc.At(testcc.MustTime(`2020-01-01T00:00:00Z`)).Invoke(`put`, `v4`))
+
+		Expect(expectcc.PayloadIs(cc.Query(`summary`), &[]string{})).To(Equal([]string{
+			`v1@1514764800`, `deleted@1527811200`, `v3@1546300800`, `v4@1577836800`}))
+		Expect(cc.KeyHistory(`key`)[2].TxId).To(Equal(`legacy`))
+	})
+
+	It("Disallow to seed history with non monotonic timestamps", func() {
+		cc := testcc.NewMockStub(`history`, NewHistoryCC())
+
+		err := cc.SeedHistory(`key`, []testcc.SeedVersion{
+			{Value: []byte(`v1`), Timestamp: testcc.MustTime(`2019-01-01T00:00:00Z`)},
+			{Value: []byte(`v2`), Timestamp: testcc.MustTime(`2018-01-01T00:00:00Z`)},
+		})
+		Expect(errors.Is(err, testcc.ErrSeedHistoryInvalid)).To(BeTrue())
+		Expect(cc.KeyHistory(`key`)).To(BeEmpty())
+	})
+
+	It("Allow to seed deleted key", func() {
+		cc := testcc.NewMockStub(`history`, NewHistoryCC())
+
+		Expect(cc.SeedHistory(`key`, []testcc.SeedVersion{
+			{Value: []byte(`v1`), Timestamp: testcc.MustTime(`2018-01-01T00:00:00Z`)},
+			{IsDelete: true, Timestamp: testcc.MustTime(`2018-06-01T00:00:00Z`)},
+		})).To(Succeed())
+		Expect(cc.State).NotTo(HaveKey(`key`))
+	})
+
+	It("Allow to get history of key, written and deleted by txs, in commit order", func() {
+		cc := testcc.NewMockStub(`history`, NewHistoryCC())
+
+		expectcc.ResponseOk(cc.At(testcc.MustTime(`2020-01-01T00:00:00Z`)).Invoke(`put`, `v1`))
+		expectcc.ResponseOk(cc.At(testcc.MustTime(`2020-01-02T00:00:00Z`)).Invoke(`put`, `v2`))
+		expectcc.ResponseOk(cc.At(testcc.MustTime(`2020-01-03T00:00:00Z`)).Invoke(`del`))
+		expectcc.ResponseOk(cc.At(testcc.MustTime(`2020-01-04T00:00:00Z`)).Invoke(`put`, `v4`))
+
+		Expect(expectcc.PayloadIs(cc.Query(`summary`), &[]string{})).To(Equal([]string{
+			`v1@1577836800`, `v2@1577923200`, `deleted@1578009600`, `v4@1578096000`}))
+
+		history := cc.KeyHistory(`key`)
+		txs := cc.Transactions()
+		Expect(history).To(HaveLen(4))
+		for i, modification := range history {
+			Expect(modification.TxId).To(Equal(txs[i].TxID))
+		}
+		Expect(history[2].IsDelete).To(BeTrue())
+		Expect(history[2].Value).To(BeNil())
+	})
+
+	It("Allow to close history iterator more than once", func() {
+		cc := testcc.NewMockStub(`history`, NewHistoryCC())
+		expectcc.ResponseOk(cc.Invoke(`put`, `v1`))
+
+		iter, err := cc.GetHistoryForKey(`key`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(iter.HasNext()).To(BeTrue())
+
+		Expect(iter.Close()).To(Succeed())
+		Expect(iter.Close()).To(Succeed())
+		Expect(iter.HasNext()).To(BeFalse())
+
+		_, err = iter.Next()
+		Expect(errors.Is(err, testcc.ErrIteratorExhausted)).To(BeTrue())
+	})
+})
@@ -0,0 +1,148 @@
+package testing
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strconv"
+	"sync"
+
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/pkg/errors"
+)
+
+// ErrReentrantInvoke occurs when hook, called during invoke, invokes stub. Hooks run while stub is locked,
+// so re-entrant invoke would deadlock or corrupt simulated tx
+var ErrReentrantInvoke = errors.New(`re-entrant stub invoke from hook`)
+
+type (
+	// HookView restricted view of stub, passed to hooks instead of *MockStub.
+	// Hook can read committed state and data of current tx, but can't invoke stub or change tx
+	HookView struct {
+		hook string
+		stub *MockStub
+	}
+
+	// runningHook hook, called during invoke, and goroutine, it runs in
+	runningHook struct {
+		m         sync.Mutex
+		name      string
+		goroutine uint64
+	}
+)
+
+var hookViewType = reflect.TypeOf(&HookView{})
+
+// Hook returns hook name, i.e. `override of GetState`
+func (v *HookView) Hook() string {
+	return v.hook
+}
+
+// TxID returns id of current tx, empty if no tx is simulated
+func (v *HookView) TxID() string {
+	return v.stub.TxID
+}
+
+// Args returns copy of current tx args
+func (v *HookView) Args() [][]byte {
+	args := make([][]byte, len(v.stub._args))
+	for i, arg := range v.stub._args {
+		args[i] = append([]byte(nil), arg...)
+	}
+	return args
+}
+
+// GetCommittedState returns copy of committed value of key, writes of current tx are not visible
+func (v *HookView) GetCommittedState(key string) ([]byte, bool) {
+	value, ok := v.stub.State[key]
+	return append([]byte(nil), value...), ok
+}
+
+// GetCommittedPrivateData returns copy of committed value of private key
+func (v *HookView) GetCommittedPrivateData(collection, key string) ([]byte, bool) {
+	value, ok := v.stub.PvtState[collection][key]
+	return append([]byte(nil), value...), ok
+}
+
+// TxWrites returns copies of public writes of current tx, buffered until commit
+func (v *HookView) TxWrites() []*StateItem {
+	writes := make([]*StateItem, len(v.stub.StateBuffer))
+	for i, item := range v.stub.StateBuffer {
+		writes[i] = &StateItem{Key: item.Key, Value: append([]byte(nil), item.Value...)}
+	}
+	return writes
+}
+
+// TxEvent returns event, set by current tx, nil if not set
+func (v *HookView) TxEvent() *peer.ChaincodeEvent {
+	return v.stub.ChaincodeEvent
+}
+
+// hookFunc wraps hook fn with signature, hook fn can have additional leading *HookView argument.
+// Hook is marked running while fn is called, so re-entrant invoke from fn is detected
+func (stub *MockStub) hookFunc(hook string, signature reflect.Type, fn interface{}) interface{} {
+	fnValue := reflect.ValueOf(fn)
+	withView := fnValue.Type() != signature
+
+	return reflect.MakeFunc(signature, func(args []reflect.Value) []reflect.Value {
+		defer stub.enterHook(hook)()
+		if withView {
+			view := &HookView{hook: hook, stub: stub}
+			args = append([]reflect.Value{reflect.ValueOf(view)}, args...)
+		}
+		return fnValue.Call(args)
+	}).Interface()
+}
+
+// withHookView returns signature with leading *HookView argument
+func withHookView(signature reflect.Type) reflect.Type {
+	in := []reflect.Type{hookViewType}
+	for i := 0; i < signature.NumIn(); i++ {
+		in = append(in, signature.In(i))
+	}
+	out := make([]reflect.Type, signature.NumOut())
+	for i := range out {
+		out[i] = signature.Out(i)
+	}
+	return reflect.FuncOf(in, out, false)
+}
+
+// enterHook marks hook running in current goroutine, returns func restoring previous running hook
+func (stub *MockStub) enterHook(hook string) func() {
+	stub.runningHook.m.Lock()
+	prevName, prevGoroutine := stub.runningHook.name, stub.runningHook.goroutine
+	stub.runningHook.name, stub.runningHook.goroutine = hook, goroutineID()
+	stub.runningHook.m.Unlock()
+
+	return func() {
+		stub.runningHook.m.Lock()
+		stub.runningHook.name, stub.runningHook.goroutine = prevName, prevGoroutine
+		stub.runningHook.m.Unlock()
+	}
+}
+
+// checkReentrant panics with ErrReentrantInvoke, if api is called from hook, running in current goroutine.
+// Invokes from other goroutines wait for stub unlock as usual
+func (stub *MockStub) checkReentrant(api string) {
+	stub.runningHook.m.Lock()
+	name, goroutine := stub.runningHook.name, stub.runningHook.goroutine
+	stub.runningHook.m.Unlock()
+
+	if name != `` && goroutine == goroutineID() {
+		panic(fmt.Errorf(`%w: %s called from %s, use HookView argument to read state`,
+			ErrReentrantInvoke, api, name))
+	}
+}
+
+// goroutineID returns id of current goroutine, parsed from stack header `goroutine 42 [running]:`
+func goroutineID() uint64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	buf = bytes.TrimPrefix(buf, []byte(`goroutine `))
+	if i := bytes.IndexByte(buf, ' '); i > 0 {
+		buf = buf[:i]
+	}
+	id, _ := strconv.ParseUint(string(buf), 10, 64)
+	return id
+}
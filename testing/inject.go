@@ -0,0 +1,116 @@
+package testing
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/s7techlab/cckit/identity"
+)
+
+// ErrInvokerAndCreatorSet occurs when invoke has both injected invoker and tx creator, set with From
+var ErrInvokerAndCreatorSet = errors.New(`injected invoker and tx creator are both set`)
+
+// WithInvoker injects invoker identity of next invoke or query, returned by identity.InvokerFromStub
+// without tx creator serialization, so identity doesn't need certificate, i.e. identity.Entry can be used.
+// Test only: peer stub provides invoker as tx creator. GetCreator returns no creator and checks,
+// parsing creator certificate (i.e. cid attributes), fail. Can't be combined with From in one invoke
+func (stub *MockStub) WithInvoker(invoker identity.Identity) *MockStub {
+	stub.injectedInvoker = invoker
+	return stub
+}
+
+// InjectedInvoker returns invoker identity, injected with WithInvoker, implements identity.InvokerInjector
+func (stub *MockStub) InjectedInvoker() (identity.Identity, bool) {
+	return stub.injectedInvoker, stub.injectedInvoker != nil
+}
+
+// checkInjectedInvoker returns error, if injected invoker and tx creator are both set.
+// Both are cleared on error, if stub clears creator after invoke
+func (stub *MockStub) checkInjectedInvoker() error {
+	if stub.injectedInvoker == nil || len(stub.mockCreator) == 0 {
+		return nil
+	}
+
+	err := fmt.Errorf(`%w: invoker %s, creator set with From`,
+		ErrInvokerAndCreatorSet, stub.injectedInvoker.GetMSPIdentifier())
+	if stub.ClearCreatorAfterInvoke {
+		stub.injectedInvoker = nil
+		stub.mockCreator = nil
+	}
+	return err
+}
+
+const (
+	// OperationGetState GetState call, injected error key is state key
+	OperationGetState = `GetState`
+	// OperationPutState PutState call, injected error key is state key
+	OperationPutState = `PutState`
+	// OperationDelState DelState call, injected error key is state key
+	OperationDelState = `DelState`
+	// OperationInvokeChaincode InvokeChaincode call, injected error key is chaincode name
+	OperationInvokeChaincode = `InvokeChaincode`
+
+	// WarningUnknownInjection emitted when error is injected into not supported operation
+	WarningUnknownInjection = `unknown_injection`
+)
+
+type (
+	injectionKey struct {
+		operation string
+		key       string
+	}
+
+	injectedError struct {
+		err  error
+		once bool
+	}
+)
+
+// InjectError registers error, returned by operation call with key until ClearInjectedErrors.
+// Empty key matches any key. InvokeChaincode returns error response with error message.
+// Injected error is checked before overrides, see Override
+func (stub *MockStub) InjectError(operation, key string, err error) *MockStub {
+	return stub.injectError(operation, key, &injectedError{err: err})
+}
+
+// InjectErrorOnce registers error, returned by next operation call with key only, see InjectError
+func (stub *MockStub) InjectErrorOnce(operation, key string, err error) *MockStub {
+	return stub.injectError(operation, key, &injectedError{err: err, once: true})
+}
+
+// ClearInjectedErrors removes all injected errors
+func (stub *MockStub) ClearInjectedErrors() *MockStub {
+	stub.injectedErrors = nil
+	return stub
+}
+
+func (stub *MockStub) injectError(operation, key string, injected *injectedError) *MockStub {
+	switch operation {
+	case OperationGetState, OperationPutState, OperationDelState, OperationInvokeChaincode:
+	default:
+		stub.Warn(WarningUnknownInjection, key, fmt.Sprintf(`error can't be injected into %s`, operation))
+		return stub
+	}
+
+	if stub.injectedErrors == nil {
+		stub.injectedErrors = make(map[injectionKey]*injectedError)
+	}
+	stub.injectedErrors[injectionKey{operation: operation, key: key}] = injected
+	return stub
+}
+
+// injectedErr returns error, injected into operation call with key or with any key. One-shot error is removed
+func (stub *MockStub) injectedErr(operation, key string) error {
+	for _, k := range []injectionKey{{operation: operation, key: key}, {operation: operation}} {
+		injected, ok := stub.injectedErrors[k]
+		if !ok {
+			continue
+		}
+		if injected.once {
+			delete(stub.injectedErrors, k)
+		}
+		return injected.err
+	}
+	return nil
+}
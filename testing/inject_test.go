@@ -0,0 +1,64 @@
+package testing_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+var _ = Describe(`Error injection`, func() {
+
+	errUnavailable := errors.New(`state db unavailable`)
+
+	It("Allow to inject persistent error into state call with key", func() {
+		cc := testcc.NewMockStub(`accounts`, NewAccountsCC())
+		expectcc.ResponseOk(cc.Invoke(`deposit`, `alice`, 100))
+
+		cc.InjectError(testcc.OperationGetState, `alice`, errUnavailable)
+		expectcc.ResponseError(cc.Invoke(`withdraw`, `alice`, 10), errUnavailable)
+		expectcc.ResponseError(cc.Invoke(`withdraw`, `alice`, 10), errUnavailable)
+
+		// other keys are not affected
+		expectcc.ResponseOk(cc.Invoke(`deposit`, `bob`, 10))
+		expectcc.ResponseOk(cc.Invoke(`withdraw`, `bob`, 10))
+
+		cc.ClearInjectedErrors()
+		expectcc.ResponseOk(cc.Invoke(`withdraw`, `alice`, 10))
+		Expect(cc.State[`alice`]).To(Equal([]byte(`90`)))
+	})
+
+	It("Allow to inject one-shot error into any key", func() {
+		cc := testcc.NewMockStub(`accounts`, NewAccountsCC())
+
+		cc.InjectErrorOnce(testcc.OperationPutState, ``, errUnavailable)
+		expectcc.ResponseError(cc.Invoke(`deposit`, `alice`, 100), errUnavailable)
+		Expect(cc.State).NotTo(HaveKey(`alice`))
+
+		expectcc.ResponseOk(cc.Invoke(`deposit`, `alice`, 100))
+		Expect(cc.State[`alice`]).To(Equal([]byte(`100`)))
+	})
+
+	It("Allow to inject error into invoke of peer chaincode", func() {
+		caller := testcc.NewMockStub(`status-caller`, NewStatusCallerCC())
+		caller.MockPeerChaincode(`status`, testcc.NewMockStub(`status`, StatusCC{}))
+
+		caller.InjectErrorOnce(testcc.OperationInvokeChaincode, `status`, errUnavailable)
+		Expect(expectcc.PayloadIs(caller.Invoke(`call`, `200`), &ObservedResponse{})).To(Equal(
+			ObservedResponse{Status: 500, Message: errUnavailable.Error()}))
+
+		Expect(expectcc.PayloadIs(caller.Invoke(`call`, `200`), &ObservedResponse{}).(ObservedResponse).Status).To(
+			BeEquivalentTo(200))
+	})
+
+	It("Warn on error injection into not supported operation", func() {
+		cc := testcc.NewMockStub(`accounts`, NewAccountsCC())
+		cc.InjectError(`GetHistoryForKey`, `alice`, errUnavailable)
+
+		Expect(cc.Warnings()).To(HaveLen(1))
+		Expect(cc.Warnings()[0].Code).To(Equal(testcc.WarningUnknownInjection))
+	})
+})
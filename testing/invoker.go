@@ -0,0 +1,140 @@
+package testing
+
+import (
+	"context"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric/msp"
+	"github.com/s7techlab/cckit/convert"
+	"github.com/s7techlab/cckit/gateway/service"
+	"google.golang.org/grpc"
+)
+
+type (
+	// Invoker chaincode invoker, implemented by MockStub and GatewayInvoker,
+	// allows to run same test suite in memory and against Fabric network.
+	// State inspection assertions are MockStub only
+	Invoker interface {
+		// As sets tx creator of next invoke or query, use as invoker.As(id).Invoke(...)
+		As(from msp.SigningIdentity) Invoker
+		Invoke(fn string, args ...interface{}) peer.Response
+		Query(fn string, args ...interface{}) peer.Response
+		// EventStream returns chaincode events, stream is closed when ctx is done
+		EventStream(ctx context.Context) (<-chan *peer.ChaincodeEvent, error)
+	}
+
+	// GatewayInvoker invokes chaincode via chaincode gateway service, backed by hlf-sdk-go or mocked peer
+	GatewayInvoker struct {
+		Service   service.Chaincode
+		Channel   string
+		Chaincode string
+		signer    msp.SigningIdentity
+	}
+
+	// eventsStream server stream, passing events from gateway service to channel
+	eventsStream struct {
+		grpc.ServerStream
+		ctx    context.Context
+		events chan *peer.ChaincodeEvent
+	}
+)
+
+// As sets tx creator of next invoke or query, see From
+func (stub *MockStub) As(from msp.SigningIdentity) Invoker {
+	return stub.From(from)
+}
+
+// EventStream returns subscription to committed chaincode events, closed when ctx is done
+func (stub *MockStub) EventStream(ctx context.Context) (<-chan *peer.ChaincodeEvent, error) {
+	events, closer := stub.EventSubscription()
+	go func() {
+		<-ctx.Done()
+		_ = closer()
+	}()
+	return events, nil
+}
+
+// NewGatewayInvoker creates invoker of chaincode via chaincode gateway service
+func NewGatewayInvoker(svc service.Chaincode, channel, chaincode string) *GatewayInvoker {
+	return &GatewayInvoker{
+		Service:   svc,
+		Channel:   channel,
+		Chaincode: chaincode,
+	}
+}
+
+// As returns invoker with signer
+func (gi *GatewayInvoker) As(from msp.SigningIdentity) Invoker {
+	return &GatewayInvoker{
+		Service:   gi.Service,
+		Channel:   gi.Channel,
+		Chaincode: gi.Chaincode,
+		signer:    from,
+	}
+}
+
+// Invoke chaincode via gateway service, service error is returned as error response
+func (gi *GatewayInvoker) Invoke(fn string, args ...interface{}) peer.Response {
+	return gi.exec(gi.Service.Invoke, fn, args)
+}
+
+// Query chaincode via gateway service, service error is returned as error response
+func (gi *GatewayInvoker) Query(fn string, args ...interface{}) peer.Response {
+	return gi.exec(gi.Service.Query, fn, args)
+}
+
+// EventStream returns chaincode events from gateway service. Events are streamed asynchronously,
+// so events of tx, committed right after EventStream call, can be missed
+func (gi *GatewayInvoker) EventStream(ctx context.Context) (<-chan *peer.ChaincodeEvent, error) {
+	stream := &eventsStream{ctx: ctx, events: make(chan *peer.ChaincodeEvent, EventChannelBufferSize)}
+
+	go func() {
+		defer close(stream.events)
+		_ = gi.Service.Events(&service.ChaincodeLocator{
+			Channel:   gi.Channel,
+			Chaincode: gi.Chaincode,
+		}, &service.ChaincodeEventsServer{ServerStream: stream})
+	}()
+
+	return stream.events, nil
+}
+
+func (s *eventsStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *eventsStream) SendMsg(m interface{}) error {
+	select {
+	case <-s.ctx.Done():
+		return s.ctx.Err()
+	case s.events <- m.(*peer.ChaincodeEvent):
+		return nil
+	}
+}
+
+func (gi *GatewayInvoker) exec(
+	method func(context.Context, *service.ChaincodeInput) (*peer.ProposalResponse, error),
+	fn string, args []interface{}) peer.Response {
+
+	argsBytes, err := convert.ArgsToBytes(args...)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	ctx := context.Background()
+	if gi.signer != nil {
+		ctx = service.ContextWithSigner(ctx, gi.signer)
+	}
+
+	proposalResponse, err := method(ctx, &service.ChaincodeInput{
+		Channel:   gi.Channel,
+		Chaincode: gi.Chaincode,
+		Args:      append([][]byte{[]byte(fn)}, argsBytes...),
+	})
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return *proposalResponse.Response
+}
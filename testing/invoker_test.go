@@ -0,0 +1,78 @@
+package testing_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/examples/cars"
+	"github.com/s7techlab/cckit/extensions/owner"
+	"github.com/s7techlab/cckit/gateway/service/mock"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+// carsSmokeSuite runs against any invoker of initialized cars chaincode,
+// tx creator is set before each call as gateway requires signer
+func carsSmokeSuite(newInvoker func() testcc.Invoker) {
+
+	It("Allow owner to register car", func() {
+		invoker := newInvoker()
+		expectcc.ResponseOk(invoker.As(Authority).Invoke(`carRegister`, cars.Payloads[0]))
+
+		car := expectcc.PayloadIs(invoker.As(Authority).Query(`carGet`, cars.Payloads[0].Id), &cars.Car{}).(cars.Car)
+		Expect(car.Title).To(Equal(cars.Payloads[0].Title))
+		Expect(expectcc.PayloadIs(invoker.As(Authority).Query(`carList`), &[]cars.Car{})).To(HaveLen(1))
+	})
+
+	It("Disallow non owner to register car", func() {
+		expectcc.ResponseError(newInvoker().As(ids[1]).Invoke(`carRegister`, cars.Payloads[0]), owner.ErrOwnerOnly)
+	})
+}
+
+func newCarsStub() *testcc.MockStub {
+	cc := testcc.NewMockStub(ChaincodeName, cars.New())
+	expectcc.ResponseOk(cc.From(Authority).Init())
+	return cc
+}
+
+var _ = Describe(`Invoker`, func() {
+
+	Describe(`MockStub`, func() {
+		carsSmokeSuite(func() testcc.Invoker {
+			return newCarsStub()
+		})
+	})
+
+	Describe(`Gateway`, func() {
+		carsSmokeSuite(func() testcc.Invoker {
+			return testcc.NewGatewayInvoker(
+				mock.New(testcc.NewPeer().WithChannel(Channel, newCarsStub())), Channel, ChaincodeName)
+		})
+
+		It("Allow to stream events via gateway", func() {
+			cc := newCarsStub()
+			invoker := testcc.NewGatewayInvoker(mock.New(testcc.NewPeer().WithChannel(Channel, cc)), Channel, ChaincodeName)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			events, err := invoker.EventStream(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			// events are streamed asynchronously, so wait for subscription to be established
+			registered := 0
+			Eventually(func() int {
+				if registered < len(cars.Payloads) {
+					expectcc.ResponseOk(invoker.As(Authority).Invoke(`carRegister`, cars.Payloads[registered]))
+					registered++
+				}
+				return len(events)
+			}).Should(BeNumerically(`>`, 0))
+
+			Expect((<-events).EventName).To(Equal(cars.CarRegisteredEvent))
+
+			cancel()
+			Eventually(events).Should(BeClosed())
+		})
+	})
+})
@@ -0,0 +1,226 @@
+package testing
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sync/atomic"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/pkg/errors"
+)
+
+// ErrLateStubAccess occurs when chaincode calls stub of already ended tx
+var ErrLateStubAccess = errors.New(`stub accessed after tx end`)
+
+// TxStub stub of single tx, passed to chaincode with late access detection.
+// Calls of state, private data, event and chaincode invocation APIs after tx end are not performed,
+// they return ErrLateStubAccess and emit WarningLateStubAccess with stack of calling goroutine
+type TxStub struct {
+	*MockStub
+	txID  string
+	ended int32
+}
+
+// WithLateAccessDetection enables detection of stub calls after tx end, i.e. from goroutines, spawned by handler.
+// Chaincode receives TxStub instead of MockStub, by default stub calls after tx end are not detected
+// and operate on data of the next tx
+func WithLateAccessDetection() MockStubOpt {
+	return func(stub *MockStub) {
+		stub.lateAccessDetection = true
+	}
+}
+
+// TxID returns id of tx, stub is created for
+func (tx *TxStub) TxID() string {
+	return tx.txID
+}
+
+// Ended returns true if tx of stub is ended
+func (tx *TxStub) Ended() bool {
+	return atomic.LoadInt32(&tx.ended) == 1
+}
+
+// txStub returns stub, passed to chaincode in current tx
+func (stub *MockStub) txStub() shim.ChaincodeStubInterface {
+	if !stub.lateAccessDetection {
+		return stub
+	}
+	stub.currentTxStub = &TxStub{MockStub: stub, txID: stub.TxID}
+	return stub.currentTxStub
+}
+
+// invalidateTxStub marks stub of current tx ended
+func (stub *MockStub) invalidateTxStub() {
+	if stub.currentTxStub != nil {
+		atomic.StoreInt32(&stub.currentTxStub.ended, 1)
+		stub.currentTxStub = nil
+	}
+}
+
+// checkLate returns error and emits warning if tx of stub is ended
+func (tx *TxStub) checkLate(api string) error {
+	if !tx.Ended() {
+		return nil
+	}
+
+	tx.addWarning(Warning{
+		Code:    WarningLateStubAccess,
+		TxID:    tx.txID,
+		Message: fmt.Sprintf(`%s called after tx end`, api),
+		Stack:   string(debug.Stack()),
+	})
+	return fmt.Errorf(`%w: %s, tx %s`, ErrLateStubAccess, api, tx.txID)
+}
+
+func (tx *TxStub) GetState(key string) ([]byte, error) {
+	if err := tx.checkLate(`GetState`); err != nil {
+		return nil, err
+	}
+	return tx.MockStub.GetState(key)
+}
+
+func (tx *TxStub) PutState(key string, value []byte) error {
+	if err := tx.checkLate(`PutState`); err != nil {
+		return err
+	}
+	return tx.MockStub.PutState(key, value)
+}
+
+func (tx *TxStub) DelState(key string) error {
+	if err := tx.checkLate(`DelState`); err != nil {
+		return err
+	}
+	return tx.MockStub.DelState(key)
+}
+
+func (tx *TxStub) SetStateValidationParameter(key string, ep []byte) error {
+	if err := tx.checkLate(`SetStateValidationParameter`); err != nil {
+		return err
+	}
+	return tx.MockStub.SetStateValidationParameter(key, ep)
+}
+
+func (tx *TxStub) GetStateValidationParameter(key string) ([]byte, error) {
+	if err := tx.checkLate(`GetStateValidationParameter`); err != nil {
+		return nil, err
+	}
+	return tx.MockStub.GetStateValidationParameter(key)
+}
+
+func (tx *TxStub) GetStateByRange(startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+	if err := tx.checkLate(`GetStateByRange`); err != nil {
+		return nil, err
+	}
+	return tx.MockStub.GetStateByRange(startKey, endKey)
+}
+
+func (tx *TxStub) GetStateByRangeWithPagination(startKey, endKey string, pageSize int32,
+	bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	if err := tx.checkLate(`GetStateByRangeWithPagination`); err != nil {
+		return nil, nil, err
+	}
+	return tx.MockStub.GetStateByRangeWithPagination(startKey, endKey, pageSize, bookmark)
+}
+
+func (tx *TxStub) GetStateByPartialCompositeKey(objectType string, keys []string) (
+	shim.StateQueryIteratorInterface, error) {
+	if err := tx.checkLate(`GetStateByPartialCompositeKey`); err != nil {
+		return nil, err
+	}
+	return tx.MockStub.GetStateByPartialCompositeKey(objectType, keys)
+}
+
+func (tx *TxStub) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string,
+	pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	if err := tx.checkLate(`GetStateByPartialCompositeKeyWithPagination`); err != nil {
+		return nil, nil, err
+	}
+	return tx.MockStub.GetStateByPartialCompositeKeyWithPagination(objectType, keys, pageSize, bookmark)
+}
+
+func (tx *TxStub) GetQueryResult(query string) (shim.StateQueryIteratorInterface, error) {
+	if err := tx.checkLate(`GetQueryResult`); err != nil {
+		return nil, err
+	}
+	return tx.MockStub.GetQueryResult(query)
+}
+
+func (tx *TxStub) GetQueryResultWithPagination(query string, pageSize int32,
+	bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	if err := tx.checkLate(`GetQueryResultWithPagination`); err != nil {
+		return nil, nil, err
+	}
+	return tx.MockStub.GetQueryResultWithPagination(query, pageSize, bookmark)
+}
+
+func (tx *TxStub) GetHistoryForKey(key string) (shim.HistoryQueryIteratorInterface, error) {
+	if err := tx.checkLate(`GetHistoryForKey`); err != nil {
+		return nil, err
+	}
+	return tx.MockStub.GetHistoryForKey(key)
+}
+
+func (tx *TxStub) GetPrivateData(collection, key string) ([]byte, error) {
+	if err := tx.checkLate(`GetPrivateData`); err != nil {
+		return nil, err
+	}
+	return tx.MockStub.GetPrivateData(collection, key)
+}
+
+func (tx *TxStub) GetPrivateDataHash(collection, key string) ([]byte, error) {
+	if err := tx.checkLate(`GetPrivateDataHash`); err != nil {
+		return nil, err
+	}
+	return tx.MockStub.GetPrivateDataHash(collection, key)
+}
+
+func (tx *TxStub) PutPrivateData(collection, key string, value []byte) error {
+	if err := tx.checkLate(`PutPrivateData`); err != nil {
+		return err
+	}
+	return tx.MockStub.PutPrivateData(collection, key, value)
+}
+
+func (tx *TxStub) DelPrivateData(collection, key string) error {
+	if err := tx.checkLate(`DelPrivateData`); err != nil {
+		return err
+	}
+	return tx.MockStub.DelPrivateData(collection, key)
+}
+
+func (tx *TxStub) GetPrivateDataByRange(collection, startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+	if err := tx.checkLate(`GetPrivateDataByRange`); err != nil {
+		return nil, err
+	}
+	return tx.MockStub.GetPrivateDataByRange(collection, startKey, endKey)
+}
+
+func (tx *TxStub) GetPrivateDataByPartialCompositeKey(collection, objectType string, keys []string) (
+	shim.StateQueryIteratorInterface, error) {
+	if err := tx.checkLate(`GetPrivateDataByPartialCompositeKey`); err != nil {
+		return nil, err
+	}
+	return tx.MockStub.GetPrivateDataByPartialCompositeKey(collection, objectType, keys)
+}
+
+func (tx *TxStub) GetPrivateDataQueryResult(collection, query string) (shim.StateQueryIteratorInterface, error) {
+	if err := tx.checkLate(`GetPrivateDataQueryResult`); err != nil {
+		return nil, err
+	}
+	return tx.MockStub.GetPrivateDataQueryResult(collection, query)
+}
+
+func (tx *TxStub) SetEvent(name string, payload []byte) error {
+	if err := tx.checkLate(`SetEvent`); err != nil {
+		return err
+	}
+	return tx.MockStub.SetEvent(name, payload)
+}
+
+func (tx *TxStub) InvokeChaincode(chaincodeName string, args [][]byte, channel string) peer.Response {
+	if err := tx.checkLate(`InvokeChaincode`); err != nil {
+		return shim.Error(err.Error())
+	}
+	return tx.MockStub.InvokeChaincode(chaincodeName, args, channel)
+}
@@ -0,0 +1,74 @@
+package testing_test
+
+import (
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+// SpawnCC handler of `spawn` starts goroutine, writing to state when released by `release` handler,
+// which waits until the write is finished
+type SpawnCC struct {
+	release chan struct{}
+	done    chan error
+}
+
+func NewSpawnCC() *SpawnCC {
+	return &SpawnCC{release: make(chan struct{}), done: make(chan error, 1)}
+}
+
+func (cc *SpawnCC) Init(shim.ChaincodeStubInterface) peer.Response {
+	return shim.Success(nil)
+}
+
+func (cc *SpawnCC) Invoke(stub shim.ChaincodeStubInterface) peer.Response {
+	switch fn, _ := stub.GetFunctionAndParameters(); fn {
+	case `spawn`:
+		go func() {
+			<-cc.release
+			cc.done <- stub.PutState(`late`, []byte(`value`))
+		}()
+	case `release`:
+		close(cc.release)
+		if err := <-cc.done; err != nil {
+			return shim.Error(err.Error())
+		}
+	}
+	return shim.Success(nil)
+}
+
+var _ = Describe(`Late stub access`, func() {
+
+	It("Allow to detect stub access from goroutine after tx end", func() {
+		cc := testcc.NewMockStub(`spawn`, NewSpawnCC(), testcc.WithLateAccessDetection())
+
+		expectcc.ResponseOk(cc.Invoke(`spawn`))
+		spawnTx := cc.Transactions()[0].TxID
+
+		// write, delayed to the next tx, is rejected
+		expectcc.ResponseError(cc.Invoke(`release`), testcc.ErrLateStubAccess)
+		Expect(cc.State).NotTo(HaveKey(`late`))
+
+		warnings := cc.Warnings()
+		Expect(warnings).To(HaveLen(1))
+		Expect(warnings[0].Code).To(Equal(testcc.WarningLateStubAccess))
+		Expect(warnings[0].TxID).To(Equal(spawnTx))
+		Expect(warnings[0].Message).To(Equal(`PutState called after tx end`))
+		Expect(warnings[0].Stack).To(ContainSubstring(`late_test.go`))
+	})
+
+	It("Allow stub access after tx end by default", func() {
+		cc := testcc.NewMockStub(`spawn`, NewSpawnCC())
+
+		expectcc.ResponseOk(cc.Invoke(`spawn`))
+		expectcc.ResponseOk(cc.Invoke(`release`))
+
+		// write lands in the next tx
+		Expect(cc.State).To(HaveKey(`late`))
+		Expect(cc.Warnings()).To(BeEmpty())
+	})
+})
@@ -0,0 +1,72 @@
+package testing
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// DefaultPrivateLeakMinSize private values shorter than this size are not checked for leaks
+const DefaultPrivateLeakMinSize = 4
+
+type (
+	// privateLeakGuard settings of private data leak check and private values of current tx
+	privateLeakGuard struct {
+		minSize  int
+		txValues []privateValue
+	}
+
+	privateValue struct {
+		collection string
+		key        string
+		value      []byte
+	}
+)
+
+// WithPrivateDataLeakGuard enables check of response payload and event payload for private values, written
+// or read in the same tx: public payloads are distributed via ordering service. Each leak emits
+// WarningPrivateDataLeak, escalated to tx failure with FailOnWarnings. Values shorter than minSize
+// (DefaultPrivateLeakMinSize, if 0) are not checked
+func WithPrivateDataLeakGuard(minSize int) MockStubOpt {
+	return func(stub *MockStub) {
+		if minSize <= 0 {
+			minSize = DefaultPrivateLeakMinSize
+		}
+		stub.privateLeakGuard = &privateLeakGuard{minSize: minSize}
+	}
+}
+
+// trackPrivateValue remembers private value, written or read in current tx
+func (stub *MockStub) trackPrivateValue(collection, key string, value []byte) {
+	guard := stub.privateLeakGuard
+	if guard == nil || stub.TxID == `` || len(value) < guard.minSize {
+		return
+	}
+	guard.txValues = append(guard.txValues, privateValue{
+		collection: collection, key: key, value: append([]byte(nil), value...)})
+}
+
+// checkPrivateDataLeaks emits warning for each private value of current tx, contained in response or event payload
+func (stub *MockStub) checkPrivateDataLeaks(payload []byte) {
+	guard := stub.privateLeakGuard
+	if guard == nil {
+		return
+	}
+	defer func() { guard.txValues = nil }()
+
+	var eventPayload []byte
+	if stub.ChaincodeEvent != nil {
+		eventPayload = stub.ChaincodeEvent.Payload
+	}
+
+	for _, pv := range guard.txValues {
+		for _, target := range []struct {
+			name    string
+			payload []byte
+		}{{`response payload`, payload}, {`event payload`, eventPayload}} {
+			if bytes.Contains(target.payload, pv.value) {
+				stub.Warn(WarningPrivateDataLeak, pv.key,
+					fmt.Sprintf(`%s contains private data of collection %s`, target.name, pv.collection))
+			}
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package testing_test
+
+import (
+	"crypto/sha256"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/router"
+	p "github.com/s7techlab/cckit/router/param"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+const leakCollection = `medical`
+
+func NewLeakCC() *router.Chaincode {
+	r := router.New(`leak`)
+
+	r.Invoke(`store`, func(c router.Context) (interface{}, error) {
+		value := []byte(c.ParamString(`value`))
+		if err := c.Stub().PutPrivateData(leakCollection, c.ParamString(`key`), value); err != nil {
+			return nil, err
+		}
+		hash := sha256.Sum256(value)
+		return hash[:], nil
+	}, p.String(`key`), p.String(`value`)).
+		Invoke(`storeEcho`, func(c router.Context) (interface{}, error) {
+			value := []byte(c.ParamString(`value`))
+			return value, c.Stub().PutPrivateData(leakCollection, c.ParamString(`key`), value)
+		}, p.String(`key`), p.String(`value`)).
+		Invoke(`readToEvent`, func(c router.Context) (interface{}, error) {
+			value, err := c.Stub().GetPrivateData(leakCollection, c.ParamString(`key`))
+			if err != nil {
+				return nil, err
+			}
+			return nil, c.Stub().SetEvent(`read`, append([]byte(`diagnosis: `), value...))
+		}, p.String(`key`))
+
+	return router.NewChaincode(r)
+}
+
+var _ = Describe(`Private data leak guard`, func() {
+
+	It("Allow to return hash of private data", func() {
+		cc := testcc.NewMockStub(`leak`, NewLeakCC(), testcc.WithPrivateDataLeakGuard(0))
+
+		expectcc.ResponseOk(cc.Invoke(`store`, `patient1`, `flu`))
+		expectcc.ResponseOk(cc.Invoke(`store`, `patient2`, `hypertension`))
+		Expect(cc.Warnings()).To(BeEmpty())
+	})
+
+	It("Disallow to return private data in response and event payloads", func() {
+		cc := testcc.NewMockStub(`leak`, NewLeakCC(), testcc.WithPrivateDataLeakGuard(0))
+
+		expectcc.ResponseOk(cc.Invoke(`storeEcho`, `patient1`, `hypertension`))
+		expectcc.ResponseOk(cc.Invoke(`readToEvent`, `patient1`))
+
+		warnings := cc.Warnings()
+		Expect(warnings).To(HaveLen(2))
+		for _, w := range warnings {
+			Expect(w.Code).To(Equal(testcc.WarningPrivateDataLeak))
+			Expect(w.Key).To(Equal(`patient1`))
+			Expect(w.Message).To(ContainSubstring(leakCollection))
+		}
+		Expect(warnings[0].Message).To(ContainSubstring(`response payload`))
+		Expect(warnings[1].Message).To(ContainSubstring(`event payload`))
+	})
+
+	It("Allow to fail tx leaking private data", func() {
+		cc := testcc.NewMockStub(`leak`, NewLeakCC(), testcc.WithPrivateDataLeakGuard(0)).
+			FailOnWarnings(testcc.WarningPrivateDataLeak)
+
+		expectcc.ResponseError(cc.Invoke(`storeEcho`, `patient1`, `hypertension`), testcc.ErrWarningEscalated)
+	})
+
+	It("Allow to exempt small private values", func() {
+		cc := testcc.NewMockStub(`leak`, NewLeakCC(), testcc.WithPrivateDataLeakGuard(0))
+
+		expectcc.ResponseOk(cc.Invoke(`storeEcho`, `patient1`, `flu`))
+		Expect(cc.Warnings()).To(BeEmpty())
+	})
+})
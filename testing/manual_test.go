@@ -0,0 +1,82 @@
+package testing_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+var _ = Describe(`Manual transaction`, func() {
+
+	var cc *testcc.MockStub
+
+	manualTx := func(txID string, fn func()) {
+		cc.MockTransactionStart(txID)
+		fn()
+		cc.MockTransactionEnd(txID)
+	}
+
+	BeforeEach(func() {
+		cc = testcc.NewMockStub(`manual`, NewHistoryCC(), testcc.WithDocTypeIndex(``, nil))
+
+		manualTx(`tx1`, func() {
+			Expect(cc.PutState(`key`, []byte(`v1`))).To(Succeed())
+			Expect(cc.PutState(`doc`, []byte(`{"docType":"order"}`))).To(Succeed())
+			Expect(cc.PutPrivateData(`details`, `key`, []byte(`secret`))).To(Succeed())
+			Expect(cc.SetEvent(`created`, []byte(`key`))).To(Succeed())
+		})
+	})
+
+	It("Allow to commit puts, private writes and event of manual tx", func() {
+		Expect(cc.State[`key`]).To(Equal([]byte(`v1`)))
+		Expect(cc.PvtState[`details`][`key`]).To(Equal([]byte(`secret`)))
+		Expect(cc.DocTypeKeys(`order`)).To(Equal([]string{`doc`}))
+
+		events := cc.ChaincodeEvents()
+		Expect(events).To(HaveLen(1))
+		Expect(events[0].EventName).To(Equal(`created`))
+		Expect(events[0].Payload).To(Equal([]byte(`key`)))
+	})
+
+	It("Allow to commit deletes of manual tx", func() {
+		manualTx(`tx2`, func() {
+			Expect(cc.DelState(`key`)).To(Succeed())
+			Expect(cc.DelState(`doc`)).To(Succeed())
+		})
+
+		Expect(cc.State).NotTo(HaveKey(`key`))
+		Expect(cc.DocTypeKeys(`order`)).To(BeEmpty())
+		Expect(expectcc.PayloadIs(cc.Query(`summary`), &[]string{})).To(HaveLen(2))
+	})
+
+	It("Allow to mix manual txs and chaincode invokes in key history", func() {
+		expectcc.ResponseOk(cc.Invoke(`put`, `v2`))
+		manualTx(`tx3`, func() {
+			Expect(cc.PutState(`key`, []byte(`v3`))).To(Succeed())
+		})
+
+		Expect(expectcc.PayloadIs(cc.Query(`get`), []byte{})).To(Equal([]byte(`v3`)))
+		Expect(expectcc.PayloadIs(cc.Query(`summary`), &[]string{})).To(HaveLen(3))
+	})
+
+	It("Allow to distinguish manual txs in invocation log", func() {
+		expectcc.ResponseOk(cc.Invoke(`put`, `v2`))
+
+		txs := cc.Transactions()
+		Expect(txs).To(HaveLen(2))
+		Expect(txs[0].TxID).To(Equal(`tx1`))
+		Expect(txs[0].Manual).To(BeTrue())
+		Expect(txs[0].Failed).To(BeFalse())
+		Expect(txs[1].Manual).To(BeFalse())
+	})
+
+	It("Allow to seed state with manual tx", func() {
+		Expect(cc.SeedState(map[string][]byte{`seeded`: []byte(`value`)})).To(Succeed())
+
+		txs := cc.Transactions()
+		Expect(txs[len(txs)-1].Manual).To(BeTrue())
+		Expect(cc.State[`seeded`]).To(Equal([]byte(`value`)))
+	})
+})
@@ -25,9 +25,10 @@ type (
 	}
 
 	EventSubscription struct {
-		events chan *peer.ChaincodeEvent
-		errors chan error
-		closer sync.Once
+		events      chan *peer.ChaincodeEvent
+		errors      chan error
+		unsubscribe func() error
+		closer      sync.Once
 	}
 )
 
@@ -74,6 +75,9 @@ func (mi *MockedPeer) Invoke(
 	response := mockStub.From(from).WithTransient(transArgs).InvokeBytes(append([][]byte{[]byte(fn)}, args...)...)
 	if response.Status == shim.ERROR {
 		err = errors.New(response.Message)
+	} else if mockStub.LastValidationError != nil {
+		// endorsed tx failed validation on commit
+		err = mockStub.LastValidationError
 	}
 
 	return &response, api.ChaincodeTx(mockStub.TxID), err
@@ -103,15 +107,16 @@ func (mi *MockedPeer) Subscribe(
 		return nil, err
 	}
 
+	events, unsubscribe := mockStub.EventSubscription()
 	sub := &EventSubscription{
-		events: mockStub.EventSubscription(),
-		errors: make(chan error),
+		events:      events,
+		errors:      make(chan error),
+		unsubscribe: unsubscribe,
 	}
 
 	go func() {
 		<-ctx.Done()
-		close(sub.events)
-		close(sub.errors)
+		_ = sub.Close()
 	}()
 
 	return sub, nil
@@ -120,7 +125,7 @@ func (mi *MockedPeer) Subscribe(
 func (mi *MockedPeer) Chaincode(channel string, chaincode string) (*MockStub, error) {
 	ms, exists := mi.ChannelCC[channel][chaincode]
 	if !exists {
-		return nil, fmt.Errorf(`%s: channell=%s, chaincode=%s`, ErrChaincodeNotExists, channel, chaincode)
+		return nil, fmt.Errorf(`%w: channell=%s, chaincode=%s`, ErrChaincodeNotExists, channel, chaincode)
 	}
 
 	return ms, nil
@@ -135,9 +140,11 @@ func (es *EventSubscription) Errors() chan error {
 }
 
 func (es *EventSubscription) Close() error {
+	var err error
 	es.closer.Do(func() {
-		close(es.events)
+		// events channel is closed by unsubscribe
+		err = es.unsubscribe()
 		close(es.errors)
 	})
-	return nil
+	return err
 }
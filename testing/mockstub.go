@@ -4,17 +4,23 @@ import (
 	"container/list"
 	"crypto/rand"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"unicode/utf8"
 
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-chaincode-go/shimtest"
 	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	pmsp "github.com/hyperledger/fabric-protos-go/msp"
 	"github.com/hyperledger/fabric-protos-go/peer"
 	"github.com/hyperledger/fabric/msp"
 	"github.com/pkg/errors"
 	"github.com/s7techlab/cckit/convert"
+	"github.com/s7techlab/cckit/identity"
+	"github.com/s7techlab/cckit/router"
 )
 
 const EventChannelBufferSize = 100
@@ -26,6 +32,8 @@ var (
 	ErrUnknownFromArgsType = errors.New(`unknown args type to cckit.MockStub.From func`)
 	// ErrKeyAlreadyExistsInTransientMap occurs when attempting to set existing key in transient map
 	ErrKeyAlreadyExistsInTransientMap = errors.New(`key already exists in transient map`)
+	// ErrPeerChaincodeRelinked occurs when mocked peer chaincode name, already invoked, is linked to another stub
+	ErrPeerChaincodeRelinked = errors.New(`peer chaincode already invoked, relinking not allowed`)
 )
 
 type StateItem struct {
@@ -43,42 +51,177 @@ type MockStub struct {
 	transient                   map[string][]byte
 	ClearCreatorAfterInvoke     bool
 	_args                       [][]byte
-	InvokablesFull              map[string]*MockStub        // invokable this version of MockStub
+	InvokablesFull              map[string]*MockStub        // invokable this version of MockStub, guarded by invokablesM
 	creatorTransformer          CreatorTransformer          // transformer for tx creator data, used in From func
+	creatorSerializer           CreatorSerializer           // serializer of tx creator, used in MockCreator
+	creatorParser               identity.CreatorParser      // parser of tx creator, used in mocked access checks
 	ChaincodeEvent              *peer.ChaincodeEvent        // event in last tx
 	chaincodeEventSubscriptions []chan *peer.ChaincodeEvent // multiple event subscriptions
 	PrivateKeys                 map[string]*list.List
-}
-
-type CreatorTransformer func(...interface{}) (mspID string, certPEM []byte, err error)
+	chaincodeID                 *peer.ChaincodeID      // mocked installed chaincode name and version
+	keyEndorsementValidation    bool                   // validate key level endorsement policies on tx end
+	LastValidationError         error                  // validation error of last tx, tx writes are not applied
+	determinismRuns             int                    // if set, each invoke is checked for determinism
+	txTimestamp                 *timestamp.Timestamp   // mocked timestamp of next tx
+	chaincodeEvents             []*peer.ChaincodeEvent // history of committed events
+	subscriptionsM              sync.Mutex
+	retention                   RetentionLimits
+	invocationLog               []*Invocation
+	keyHistory                  map[string][]*queryresult.KeyModification
+	eventsEvicted               int
+	invocationsEvicted          int
+	keyHistoryEvicted           int
+	docTypes                    *docTypeIndex // optional index of committed documents by type
+	sequentialTxIDs             bool
+	txIDPrefix                  string
+	txSeq                       int
+	txDeletes                   []string               // keys deleted in current tx
+	txPrivateWrites             []*privateWrite        // private data writes and deletes of current tx
+	txSnapshot                  *snapshot              // state before current tx, taken if key endorsements are validated
+	lastProposalResponse        *peer.ProposalResponse // simulation results of last invoke
+	reservedKeys                *ReservedKeys          // if set, application writes to reserved keys are rejected
+	reservedKeysAllowed         int
+	dispatcher                  *eventDispatcher // delivers events to subscriptions with full buffers
+	declaredAccess              *AccessSet       // keys, next invoke is allowed to access
+	txDeclaredAccess            *AccessSet
+	txAccess                    AccessSet
+	txAccessError               error
+	LastAccessError             error // first undeclared state access of last tx
+	accessReporter              ErrorReporter
+	collections                 map[string][]string // private data collection member orgs
+	warnings                    warnings
+	includeTransient            bool // transient values are not redacted in recordings
+	counters                    txCounters
+	blockToLive                 map[string]uint64            // private data collections block to live
+	clock                       Clock                        // source of tx timestamps and block height
+	privateWriteBlocks          map[string]map[string]uint64 // write blocks of private keys, expiring by block to live
+	purgedHashes                map[string]map[string][]byte // hashes of purged private data
+	eventsAggregators           []*EventsAggregator          // guarded by subscriptionsM
+	noDefensiveCopies           bool                         // values are not copied on put and get
+	queryCompositeKeys          bool                         // rich queries evaluate composite keyed entries
+	queryModels                 []*queryModel                // rich query models, registered with RegisterQueryModel
+	strictQueryModels           bool                         // rich queries fail on keys without registered model
+	endedTx                     txOutcome                    // outcome of last ended tx
+	overrides                   map[string]interface{}       // per test overrides of stub methods
+	privateLeakGuard            *privateLeakGuard            // if set, public payloads are checked for private values
+	couchBinaryPolicy           CouchBinaryPolicy            // handling of non JSON object values in CouchDB export
+	phase                       InvocationPhase              // phase of currently simulated tx
+	initRestrictions            bool                         // APIs, rejected by peer in Init, return error
+	txEventNames                []string                     // names of events, set in current tx, in call order
+	eventLimits                 eventLimits                  // event name and payload validation, see WithEventValidation
+	eventsPaused                bool                         // guarded by subscriptionsM
+	pausedEvents                []channelEvent               // committed events, buffered while delivery is paused
+	lateAccessDetection         bool                         // chaincode receives TxStub, invalidated on tx end
+	currentTxStub               *TxStub                      // stub of currently simulated tx
+	traceSink                   *TraceSink                   // if set, router spans are attached to invocations
+	invokablesM                 sync.RWMutex
+	invokedPeers                map[string]struct{} // names of invoked peer chaincodes, guarded by invokablesM
+	resourcesM                  sync.Mutex
+	resources                   map[uint64]*OpenResource // open iterators and subscriptions, guarded by resourcesM
+	resourcesSeq                uint64
+	eventReferenceRules         []EventReferenceRule            // rules of keys, referenced by events and written in the same tx
+	injectedInvoker             identity.Identity               // invoker of next tx, injected without creator serialization
+	injectedErrors              map[injectionKey]*injectedError // errors of stub api calls, see InjectError
+	manualTx                    bool                            // current tx is started with MockTransactionStart
+	runningHook                 runningHook                     // hook, called during invoke, see checkReentrant
+}
+
+type (
+	CreatorTransformer func(...interface{}) (mspID string, certPEM []byte, err error)
+
+	// CreatorSerializer serializes tx creator, returned by GetCreator
+	CreatorSerializer func(mspID string, cert []byte) ([]byte, error)
+
+	// MockStubOpt option of MockStub
+	MockStubOpt func(*MockStub)
+
+	// PeerChaincodeLink mocked chaincode, available for invoke, see MockPeerChaincode
+	PeerChaincodeLink struct {
+		Chaincode string
+		Channel   string
+	}
+)
 
 // NewMockStub creates chaincode imitation
-func NewMockStub(name string, cc shim.Chaincode) *MockStub {
-	return &MockStub{
+func NewMockStub(name string, cc shim.Chaincode, opts ...MockStubOpt) *MockStub {
+	stub := &MockStub{
 		MockStub: *shimtest.NewMockStub(name, cc),
 		cc:       cc,
 		// by default tx creator data and transient map are cleared after each cc method query/invoke
 		ClearCreatorAfterInvoke: true,
 		InvokablesFull:          make(map[string]*MockStub),
 		PrivateKeys:             make(map[string]*list.List),
+		retention:               DefaultRetentionLimits(),
+		clock:                   &systemClock{},
 	}
+
+	for _, o := range opts {
+		o(stub)
+	}
+
+	return stub
 }
 
 // PutState wrapped functions puts state items in queue and dumps
 // to state after invocation
 func (stub *MockStub) PutState(key string, value []byte) error {
+	if err := stub.injectedErr(OperationPutState, key); err != nil {
+		return err
+	}
+	if fn, ok := stub.overrides[`PutState`].(func(string, []byte) error); ok {
+		return fn(key, value)
+	}
 	if stub.TxID == "" {
-		return errors.New("cannot PutState without a transactions - call stub.MockTransactionStart()?")
+		return fmt.Errorf(`cannot PutState without a transactions - call stub.MockTransactionStart()?: %w`,
+			ErrNoOpenTransaction)
 	}
 
+	if key == "" {
+		stub.Warn(WarningEmptyKey, key, `write to empty key`)
+		return ErrKeyEmpty
+	}
+	if err := stub.checkReservedKey(key); err != nil {
+		return err
+	}
+	stub.recordAccess(AccessWrite, key)
+
 	stub.StateBuffer = append(stub.StateBuffer, &StateItem{
 		Key:   key,
-		Value: value,
+		Value: stub.copyValue(value),
 	})
 
 	return nil
 }
 
+// DelState mocked, deletion is stored in key history
+func (stub *MockStub) DelState(key string) error {
+	if err := stub.injectedErr(OperationDelState, key); err != nil {
+		return err
+	}
+	if fn, ok := stub.overrides[`DelState`].(func(string) error); ok {
+		return fn(key)
+	}
+	if key == "" {
+		stub.Warn(WarningEmptyKey, key, `write to empty key`)
+		return ErrKeyEmpty
+	}
+	if err := stub.checkReservedKey(key); err != nil {
+		return err
+	}
+	stub.recordAccess(AccessWrite, key)
+
+	if err := stub.MockStub.DelState(key); err != nil {
+		return err
+	}
+
+	if stub.TxID != "" {
+		stub.txDeletes = append(stub.txDeletes, key)
+		stub.addKeyModification(key, nil, true)
+	}
+	stub.indexDocType(key, nil, true)
+	return nil
+}
+
 // GetArgs mocked args
 func (stub *MockStub) GetArgs() [][]byte {
 	return stub._args
@@ -92,17 +235,54 @@ func (stub *MockStub) SetArgs(args [][]byte) {
 // SetEvent sets chaincode event
 func (stub *MockStub) SetEvent(name string, payload []byte) error {
 	if name == "" {
-		return errors.New("event name can not be nil string")
+		return fmt.Errorf(`event name can not be nil string: %w`, ErrEventNameEmpty)
+	}
+	if err := stub.checkEvent(name, payload); err != nil {
+		return err
 	}
 
 	stub.ChaincodeEvent = &peer.ChaincodeEvent{EventName: name, Payload: payload}
+	stub.txEventNames = append(stub.txEventNames, name)
 	return nil
 }
 
-func (stub *MockStub) EventSubscription() chan *peer.ChaincodeEvent {
-	subscription := make(chan *peer.ChaincodeEvent, EventChannelBufferSize)
-	stub.chaincodeEventSubscriptions = append(stub.chaincodeEventSubscriptions, subscription)
-	return subscription
+// EventSubscription returns channel with events, committed after subscription, and subscription closer.
+// If from is set, committed events starting with this position are replayed to channel first.
+// Events not fitting channel buffer are queued to dispatcher goroutine, stopped by Close
+// (registered on test cleanup by NewMockStubT)
+func (stub *MockStub) EventSubscription(from ...int64) (events chan *peer.ChaincodeEvent, closer func() error) {
+	stub.subscriptionsM.Lock()
+	defer stub.subscriptionsM.Unlock()
+
+	var history []*peer.ChaincodeEvent
+	if len(from) > 0 && from[0] >= 0 && from[0] < int64(len(stub.chaincodeEvents)) {
+		history = stub.chaincodeEvents[from[0]:]
+	}
+
+	events = make(chan *peer.ChaincodeEvent, EventChannelBufferSize+len(history))
+	for _, e := range history {
+		events <- e
+	}
+
+	stub.chaincodeEventSubscriptions = append(stub.chaincodeEventSubscriptions, events)
+	subscription := &releaser{}
+	stub.openResource(ResourceSubscription, `EventSubscription`, subscription)
+
+	return events, func() error {
+		stub.subscriptionsM.Lock()
+		defer stub.subscriptionsM.Unlock()
+		subscription.release()
+
+		for i, sub := range stub.chaincodeEventSubscriptions {
+			if sub == events {
+				stub.chaincodeEventSubscriptions = append(
+					stub.chaincodeEventSubscriptions[:i], stub.chaincodeEventSubscriptions[i+1:]...)
+				stub.closeSubscription(events)
+				break
+			}
+		}
+		return nil
+	}
 }
 
 // ClearEvents clears chaincode events channel
@@ -122,36 +302,153 @@ func (stub *MockStub) GetStringArgs() []string {
 	return strargs
 }
 
-// MockPeerChaincode link to another MockStub
-func (stub *MockStub) MockPeerChaincode(invokableChaincodeName string, otherStub *MockStub) {
+// MockPeerChaincode link to another MockStub, safe for concurrent use with InvokeChaincode.
+// Name, already invoked, can't be linked to another stub
+func (stub *MockStub) MockPeerChaincode(invokableChaincodeName string, otherStub *MockStub) error {
+	stub.invokablesM.Lock()
+	defer stub.invokablesM.Unlock()
+
+	if linked, ok := stub.InvokablesFull[invokableChaincodeName]; ok && linked != otherStub {
+		if _, invoked := stub.invokedPeers[invokableChaincodeName]; invoked {
+			return fmt.Errorf(`%w: %s`, ErrPeerChaincodeRelinked, invokableChaincodeName)
+		}
+	}
 	stub.InvokablesFull[invokableChaincodeName] = otherStub
+	return nil
 }
 
-// MockedPeerChaincodes returns names of mocked chaincodes, available for invoke from current stub
+// MockedPeerChaincodes returns sorted names of mocked chaincodes, available for invoke from current stub
 func (stub *MockStub) MockedPeerChaincodes() []string {
-	keys := make([]string, 0)
+	stub.invokablesM.RLock()
+	defer stub.invokablesM.RUnlock()
+
+	keys := make([]string, 0, len(stub.InvokablesFull))
 	for k := range stub.InvokablesFull {
 		keys = append(keys, k)
 	}
+	sort.Strings(keys)
 	return keys
 }
 
+// MockedPeerChaincodeLinks returns mocked chaincodes, available for invoke from current stub,
+// with names split to chaincode and channel, sorted by chaincode, then by channel.
+// Channel is empty for chaincodes, linked without channel
+func (stub *MockStub) MockedPeerChaincodeLinks() []PeerChaincodeLink {
+	names := stub.MockedPeerChaincodes()
+	links := make([]PeerChaincodeLink, len(names))
+	for i, name := range names {
+		links[i] = NewPeerChaincodeLink(name)
+	}
+	sort.SliceStable(links, func(i, j int) bool {
+		if links[i].Chaincode != links[j].Chaincode {
+			return links[i].Chaincode < links[j].Chaincode
+		}
+		return links[i].Channel < links[j].Channel
+	})
+	return links
+}
+
+// NewPeerChaincodeLink parses mocked chaincode name `chaincode` or `chaincode/channel`
+func NewPeerChaincodeLink(name string) PeerChaincodeLink {
+	if i := strings.LastIndex(name, `/`); i >= 0 {
+		return PeerChaincodeLink{Chaincode: name[:i], Channel: name[i+1:]}
+	}
+	return PeerChaincodeLink{Chaincode: name}
+}
+
+// String returns mocked chaincode name, see MockPeerChaincode
+func (l PeerChaincodeLink) String() string {
+	if l.Channel == `` {
+		return l.Chaincode
+	}
+	return l.Chaincode + `/` + l.Channel
+}
+
+// peerChaincodeNotExists describes missing link of chaincode in channel: whether chaincode is linked
+// in other channels and how to link it
+func (stub *MockStub) peerChaincodeNotExists(chaincode, channel string) error {
+	links := stub.MockedPeerChaincodeLinks()
+	var available, otherChannels []string
+	for _, link := range links {
+		available = append(available, link.String())
+		if link.Chaincode == chaincode {
+			otherChannels = append(otherChannels, fmt.Sprintf(`%q`, link.Channel))
+		}
+	}
+
+	requested := PeerChaincodeLink{Chaincode: chaincode, Channel: channel}
+	var reason string
+	if len(otherChannels) > 0 {
+		reason = fmt.Sprintf(`chaincode %q is not mocked in channel %q, but is mocked in channels %s: `+
+			`link it with MockPeerChaincode(%q, stub) or invoke it in mocked channel`,
+			chaincode, channel, strings.Join(otherChannels, `, `), requested.String())
+	} else {
+		reason = fmt.Sprintf(`chaincode %q is not mocked in any channel: link it with MockPeerChaincode(%q, stub)`,
+			chaincode, requested.String())
+	}
+	return fmt.Errorf(`%w: %s. Available mocked chaincodes: [%s]`,
+		ErrChaincodeNotExists, reason, strings.Join(available, `, `))
+}
+
+// peerChaincode returns linked stub and marks name as invoked
+func (stub *MockStub) peerChaincode(name string) (*MockStub, bool) {
+	stub.invokablesM.Lock()
+	defer stub.invokablesM.Unlock()
+
+	otherStub, exists := stub.InvokablesFull[name]
+	if exists {
+		if stub.invokedPeers == nil {
+			stub.invokedPeers = make(map[string]struct{})
+		}
+		stub.invokedPeers[name] = struct{}{}
+	}
+	return otherStub, exists
+}
+
 // InvokeChaincode using another MockStub
 func (stub *MockStub) InvokeChaincode(chaincodeName string, args [][]byte, channel string) peer.Response {
+	if err := stub.injectedErr(OperationInvokeChaincode, chaincodeName); err != nil {
+		return shim.Error(err.Error())
+	}
+
 	// Internally we use chaincode name as a composite name
 	ccName := chaincodeName
 	if channel != "" {
 		chaincodeName = chaincodeName + "/" + channel
 	}
 
-	otherStub, exists := stub.InvokablesFull[chaincodeName]
+	otherStub, exists := stub.peerChaincode(chaincodeName)
 	if !exists {
-		return shim.Error(fmt.Sprintf(
-			`%s	: try to invoke chaincode "%s" in channel "%s" (%s). Available mocked chaincodes are: %s`,
-			ErrChaincodeNotExists, ccName, channel, chaincodeName, stub.MockedPeerChaincodes()))
+		return shim.Error(stub.peerChaincodeNotExists(ccName, channel).Error())
 	}
 
+	// callee observes channel of invocation, by default - channel of caller
+	calleeChannel := channel
+	if calleeChannel == "" {
+		calleeChannel = stub.ChannelID
+	}
+	prevChannel := otherStub.ChannelID
+	otherStub.ChannelID = calleeChannel
+	defer func() { otherStub.ChannelID = prevChannel }()
+
+	// callee observes creator of caller proposal
+	prevCreator := otherStub.mockCreator
+	otherStub.mockCreator = stub.mockCreator
+	defer func() { otherStub.mockCreator = prevCreator }()
+
+	// peer passes callee response to caller shim as is, marshaled in COMPLETED message, regardless of status:
+	// caller observes status, message and payload set by callee, statuses >= shim.ERRORTHRESHOLD are not rewritten.
+	// Only invocation failures (i.e. callee not found) are returned as shim.ERROR with failure message
 	res := otherStub.MockInvoke(stub.TxID, args)
+	return unmarshalResponse(MustProtoMarshal(&res))
+}
+
+// unmarshalResponse mimics transfer of callee response to caller shim
+func unmarshalResponse(bb []byte) peer.Response {
+	res := peer.Response{}
+	if err := proto.Unmarshal(bb, &res); err != nil {
+		return shim.Error(err.Error())
+	}
 	return res
 }
 
@@ -173,12 +470,42 @@ func (stub *MockStub) RegisterCreatorTransformer(creatorTransformer CreatorTrans
 	return stub
 }
 
+// RegisterCreatorSerializer sets serializer of tx creator, msp.NewSerializedIdentity is used by default.
+// Parser is used by mocked access checks (collections membership, key endorsement), if nil - default one
+func (stub *MockStub) RegisterCreatorSerializer(serializer CreatorSerializer, parser identity.CreatorParser) *MockStub {
+	stub.creatorSerializer = serializer
+	stub.creatorParser = parser
+	return stub
+}
+
 // MockCreator of tx
 func (stub *MockStub) MockCreator(mspID string, certPEM []byte) {
-	stub.mockCreator, _ = msp.NewSerializedIdentity(mspID, certPEM)
+	if stub.creatorSerializer == nil {
+		stub.mockCreator, _ = msp.NewSerializedIdentity(mspID, certPEM)
+		return
+	}
+
+	creator, err := stub.creatorSerializer(mspID, certPEM)
+	if err != nil {
+		panic(err)
+	}
+	stub.mockCreator = creator
+}
+
+// creatorIdentity parses mocked tx creator with registered creator parser
+func (stub *MockStub) creatorIdentity() (*pmsp.SerializedIdentity, error) {
+	if stub.creatorParser == nil {
+		return identity.ParseSerializedIdentity(stub.mockCreator)
+	}
+	return stub.creatorParser(stub.mockCreator)
 }
 
 func (stub *MockStub) generateTxUID() string {
+	if stub.sequentialTxIDs {
+		stub.txSeq++
+		return fmt.Sprintf("%s%d", stub.txIDPrefix, stub.txSeq)
+	}
+
 	id := make([]byte, 32)
 	if _, err := rand.Read(id); err != nil {
 		panic(err)
@@ -203,12 +530,23 @@ func (stub *MockStub) InitBytes(args ...[]byte) peer.Response {
 
 // MockInit mocked init function
 func (stub *MockStub) MockInit(uuid string, args [][]byte) peer.Response {
+	stub.checkReentrant(`MockInit`)
+	stub.m.Lock()
+	defer stub.m.Unlock()
+	if err := stub.checkInjectedInvoker(); err != nil {
+		return shim.Error(err.Error())
+	}
+	defer stub.clearDeclaredAccess()
+	defer stub.enterPhase(PhaseInit)()
 
 	stub.SetArgs(args)
 
-	stub.MockTransactionStart(uuid)
-	res := stub.cc.Init(stub)
+	stub.startTx(uuid)
+	res := stub.cc.Init(stub.txStub())
+	res = stub.endorse(res)
+	stub.logInvocation(uuid, args, res)
 	stub.MockTransactionEnd(uuid)
+	stub.countTx(args, res)
 
 	return res
 }
@@ -217,60 +555,162 @@ func (stub *MockStub) DumpStateBuffer() {
 	// dump state buffer to state
 	for i := range stub.StateBuffer {
 		s := stub.StateBuffer[i]
-		_ = stub.MockStub.PutState(s.Key, s.Value)
+		stub.commitState(s.Key, s.Value)
+		stub.addKeyModification(s.Key, s.Value, false)
+		stub.indexDocType(s.Key, s.Value, false)
 	}
 	stub.StateBuffer = nil
 
 	if stub.ChaincodeEvent != nil {
+		stub.subscriptionsM.Lock()
+		stub.addEventToHistory(stub.ChaincodeEvent)
 		// send only last event
-		for _, sub := range stub.chaincodeEventSubscriptions {
-			sub <- stub.ChaincodeEvent
-		}
+		stub.publishEvent(stub.ChannelID, stub.ChaincodeEvent)
+		stub.subscriptionsM.Unlock()
 
 		// actually no chances to have error here
 		_ = stub.MockStub.SetEvent(stub.ChaincodeEvent.EventName, stub.ChaincodeEvent.Payload)
 	}
 }
 
+// commitState puts value to state, keys greater than last sorted key are appended without keys list scan
+func (stub *MockStub) commitState(key string, value []byte) {
+	if last := stub.Keys.Back(); key != `` && (last == nil || last.Value.(string) < key) {
+		stub.State[key] = value
+		stub.Keys.PushBack(key)
+		return
+	}
+
+	_ = stub.MockStub.PutState(key, value)
+}
+
+// endorse checks tx warnings and stores simulation results of current tx
+func (stub *MockStub) endorse(response peer.Response) peer.Response {
+	response = stub.checkResponseWarnings(response)
+	stub.lastProposalResponse = stub.simulationResults(response)
+	return response
+}
+
 // MockQuery
 func (stub *MockStub) MockQuery(uuid string, args [][]byte) peer.Response {
 	return stub.MockInvoke(uuid, args)
 }
 
+// MockTransactionStart starts manual tx: test can call stub methods directly, bypassing chaincode.
+// Manual tx is committed on MockTransactionEnd unconditionally and marked as manual in invocation log
 func (stub *MockStub) MockTransactionStart(uuid string) {
+	stub.checkReentrant(`MockTransactionStart`)
+	stub.startTx(uuid)
+	stub.manualTx = true
+}
+
+// startTx starts tx, simulating chaincode invoke
+func (stub *MockStub) startTx(uuid string) {
+	stub.manualTx = false
 	//empty event
 	stub.ChaincodeEvent = nil
+	stub.LastValidationError = nil
 
 	// empty state buffer
 	stub.StateBuffer = nil
+	stub.txDeletes = nil
+	stub.txPrivateWrites = nil
+	stub.txEventNames = nil
+	stub.warnings.txFailure = nil
+	if stub.privateLeakGuard != nil {
+		stub.privateLeakGuard.txValues = nil
+	}
 
 	stub.MockStub.MockTransactionStart(uuid)
+	stub.startAccessCheck()
+
+	stub.TxTimestamp = stub.clockTimestamp()
+	// clock can be advanced between txs
+	stub.purgeExpiredPrivateData()
+
+	// deletes and private writes are applied immediately, so tx, failed validation, is rolled back to snapshot
+	stub.txSnapshot = nil
+	if stub.keyEndorsementValidation {
+		stub.txSnapshot = stub.snapshot()
+	}
 }
 
+// MockTransactionEnd commits tx, started with MockTransactionStart
 func (stub *MockStub) MockTransactionEnd(uuid string) {
+	if stub.manualTx {
+		stub.manualTx = false
+		stub.logInvocation(uuid, nil, shim.Success(nil))
+		if last := len(stub.invocationLog) - 1; last >= 0 {
+			stub.invocationLog[last].Manual = true
+		}
+	}
+	stub.endedTx = stub.txOutcome()
 
+	if stub.keyEndorsementValidation {
+		stub.LastValidationError = stub.validateKeyEndorsements()
+	}
+
+	if last := len(stub.invocationLog) - 1; last >= 0 && stub.invocationLog[last].TxID == uuid {
+		stub.invocationLog[last].ValidationError = stub.LastValidationError
+	}
+
+	if stub.LastValidationError != nil {
+		// invalid tx writes, deletes and event are not committed
+		if stub.txSnapshot != nil {
+			stub.restore(stub.txSnapshot)
+		}
+		stub.StateBuffer = nil
+		stub.ChaincodeEvent = nil
+	}
+	stub.txSnapshot = nil
+
+	stub.finishAccessCheck()
 	stub.DumpStateBuffer()
 
 	stub.MockStub.MockTransactionEnd(uuid)
+	stub.invalidateTxStub()
+	stub.clock.SetBlock(stub.clock.BlockHeight() + 1)
+	stub.purgeExpiredPrivateData()
 
 	if stub.ClearCreatorAfterInvoke {
 		stub.mockCreator = nil
+		stub.injectedInvoker = nil
 		stub.transient = nil
+		stub.txTimestamp = nil
 	}
 }
 
 // MockInvoke
 func (stub *MockStub) MockInvoke(uuid string, args [][]byte) peer.Response {
+	stub.checkReentrant(`MockInvoke`)
 	stub.m.Lock()
 	defer stub.m.Unlock()
+	if err := stub.checkInjectedInvoker(); err != nil {
+		return shim.Error(err.Error())
+	}
+	defer stub.clearDeclaredAccess()
+	defer stub.enterPhase(PhaseInvoke)()
+
+	if stub.determinismRuns > 0 {
+		res, err := stub.checkDeterminism(stub.determinismRuns, uuid, args)
+		if err != nil {
+			res = shim.Error(err.Error())
+		}
+		stub.logInvocation(uuid, args, res)
+		stub.countTx(args, res)
+		return res
+	}
 
 	// this is a hack here to set MockStub.args, because its not accessible otherwise
 	stub.SetArgs(args)
 
 	// now do the invoke with the correct stub
-	stub.MockTransactionStart(uuid)
-	res := stub.cc.Invoke(stub)
+	stub.startTx(uuid)
+	res := stub.cc.Invoke(stub.txStub())
+	res = stub.endorse(res)
+	stub.logInvocation(uuid, args, res)
 	stub.MockTransactionEnd(uuid)
+	stub.countTx(args, res)
 
 	return res
 }
@@ -301,6 +741,9 @@ func (stub *MockStub) Query(funcName string, iargs ...interface{}) peer.Response
 
 // GetCreator mocked
 func (stub *MockStub) GetCreator() ([]byte, error) {
+	if fn, ok := stub.overrides[`GetCreator`].(func() ([]byte, error)); ok {
+		return fn()
+	}
 	return stub.mockCreator, nil
 }
 
@@ -324,8 +767,17 @@ func (stub *MockStub) From(txCreator ...interface{}) *MockStub {
 	return stub
 }
 
+// GetTransient mocked, returns copy of transient map, so chaincode can't change transient map of stub
 func (stub *MockStub) GetTransient() (map[string][]byte, error) {
-	return stub.transient, nil
+	if stub.transient == nil {
+		return nil, nil
+	}
+
+	transient := make(map[string][]byte, len(stub.transient))
+	for key, value := range stub.transient {
+		transient[key] = append([]byte(nil), value...)
+	}
+	return transient, nil
 }
 
 // WithTransient sets transient map
@@ -348,33 +800,43 @@ func (stub *MockStub) AddTransient(transient map[string][]byte) *MockStub {
 	return stub
 }
 
-// At mock tx timestamp
-//func (stub *MockStub) At(txTimestamp *timestamp.Timestamp) *MockStub {
-//	stub.TxTimestamp = txTimestamp
-//	return stub
-//}
+// WithFormat requests format of proto message payloads with router.FormatKey transient key, see router.ResponseFormat
+func (stub *MockStub) WithFormat(format string) *MockStub {
+	if stub.transient == nil {
+		stub.transient = make(map[string][]byte)
+	}
+	stub.transient[router.FormatKey] = []byte(format)
+	return stub
+}
 
 // DelPrivateData mocked
 func (stub *MockStub) DelPrivateData(collection string, key string) error {
+	if err := stub.checkPrivateDataInit(); err != nil {
+		return err
+	}
 	m, in := stub.PvtState[collection]
 	if !in {
-		return errors.Errorf("Collection %s not found.", collection)
+		return fmt.Errorf(`Collection %s not found: %w`, collection, ErrCollectionNotFound)
 	}
 
 	if _, ok := m[key]; !ok {
-		return errors.Errorf("Key %s not found.", key)
+		return fmt.Errorf(`Key %s not found: %w`, key, ErrKeyNotFound)
 	}
 	delete(m, key)
+	stub.txPrivateWrites = append(stub.txPrivateWrites, &privateWrite{Collection: collection, Key: key, IsDelete: true})
 
 	for elem := stub.PrivateKeys[collection].Front(); elem != nil; elem = elem.Next() {
 		if strings.Compare(key, elem.Value.(string)) == 0 {
 			stub.PrivateKeys[collection].Remove(elem)
 		}
 	}
+	delete(stub.privateWriteBlocks[collection], key)
+	delete(stub.purgedHashes[collection], key)
 	return nil
 }
 
 type PrivateMockStateRangeQueryIterator struct {
+	releaser
 	Closed     bool
 	Stub       *MockStub
 	StartKey   string
@@ -395,64 +857,52 @@ func (iter *PrivateMockStateRangeQueryIterator) HasNext() bool {
 		return false
 	}
 
-	current := iter.Current
-	for current != nil {
-		// if this is an open-ended query for all keys, return true
-		if iter.StartKey == "" && iter.EndKey == "" {
-			return true
+	// keys are sorted, first key after start key is the only candidate
+	for current := iter.Current; current != nil; current = current.Next() {
+		if key := current.Value.(string); key >= iter.StartKey {
+			return iter.inRange(key)
 		}
-		comp1 := strings.Compare(current.Value.(string), iter.StartKey)
-		comp2 := strings.Compare(current.Value.(string), iter.EndKey)
-		if comp1 >= 0 {
-			if comp2 < 0 {
-				return true
-			} else {
-				return false
-
-			}
-		}
-		current = current.Next()
 	}
 
 	// we've reached the end of the underlying values
 	return false
 }
 
+// inRange returns true if key is in half-open range [StartKey, EndKey), empty EndKey means end of key space
+func (iter *PrivateMockStateRangeQueryIterator) inRange(key string) bool {
+	return key >= iter.StartKey && (iter.EndKey == "" || key < iter.EndKey)
+}
+
 // Next returns the next key and value in the range query iterator.
 func (iter *PrivateMockStateRangeQueryIterator) Next() (*queryresult.KV, error) {
 	if iter.Closed {
-		err := errors.New("PrivateMockStateRangeQueryIterator.Next() called after Close()")
-		return nil, err
+		return nil, fmt.Errorf(`PrivateMockStateRangeQueryIterator.Next() called after Close(): %w`, ErrIteratorClosed)
 	}
 
 	if !iter.HasNext() {
-		err := errors.New("PrivateMockStateRangeQueryIterator.Next() called when it does not HaveNext()")
-		return nil, err
+		return nil, fmt.Errorf(`PrivateMockStateRangeQueryIterator.Next() called when it does not HaveNext(): %w`,
+			ErrIteratorExhausted)
 	}
 
 	for iter.Current != nil {
-		comp1 := strings.Compare(iter.Current.Value.(string), iter.StartKey)
-		comp2 := strings.Compare(iter.Current.Value.(string), iter.EndKey)
-		// compare to start and end keys. or, if this is an open-ended query for
-		// all keys, it should always return the key and value
-		if (comp1 >= 0 && comp2 < 0) || (iter.StartKey == "" && iter.EndKey == "") {
-			key := iter.Current.Value.(string)
+		if key := iter.Current.Value.(string); iter.inRange(key) {
 			value, err := iter.Stub.GetPrivateData(iter.Collection, key)
 			iter.Current = iter.Current.Next()
 			return &queryresult.KV{Key: key, Value: value}, err
 		}
 		iter.Current = iter.Current.Next()
 	}
-	return nil, errors.New("PrivateMockStateRangeQueryIterator.Next() went past end of range")
+	return nil, fmt.Errorf(`PrivateMockStateRangeQueryIterator.Next() went past end of range: %w`, ErrIteratorExhausted)
 }
 
 // Close closes the range query iterator. This should be called when done
 // reading from the iterator to free up resources.
 func (iter *PrivateMockStateRangeQueryIterator) Close() error {
 	if iter.Closed {
-		return errors.New("PrivateMockStateRangeQueryIterator.Close() called after Close()")
+		return fmt.Errorf(`PrivateMockStateRangeQueryIterator.Close() called after Close(): %w`, ErrIteratorClosed)
 	}
 
+	iter.release()
 	iter.Closed = true
 	return nil
 }
@@ -475,10 +925,17 @@ func NewPrivateMockStateRangeQueryIterator(stub *MockStub, collection string, st
 
 // PutPrivateData mocked
 func (stub *MockStub) PutPrivateData(collection string, key string, value []byte) error {
+	if err := stub.checkPrivateDataInit(); err != nil {
+		return err
+	}
 	if _, in := stub.PvtState[collection]; !in {
 		stub.PvtState[collection] = make(map[string][]byte)
 	}
-	stub.PvtState[collection][key] = value
+	stub.PvtState[collection][key] = stub.copyValue(value)
+	stub.txPrivateWrites = append(stub.txPrivateWrites,
+		&privateWrite{Collection: collection, Key: key, Value: stub.copyValue(value)})
+	stub.trackPrivateWrite(collection, key)
+	stub.trackPrivateValue(collection, key, value)
 
 	if _, ok := stub.PrivateKeys[collection]; !ok {
 		stub.PrivateKeys[collection] = list.New()
@@ -515,9 +972,17 @@ const maxUnicodeRuneValue = utf8.MaxRune
 
 // GetPrivateDataByPartialCompositeKey mocked
 func (stub *MockStub) GetPrivateDataByPartialCompositeKey(collection, objectType string, attributes []string) (shim.StateQueryIteratorInterface, error) {
+	if err := stub.checkPrivateDataInit(); err != nil {
+		return nil, err
+	}
+	if err := stub.checkCollectionRead(collection); err != nil {
+		return nil, err
+	}
 	partialCompositeKey, err := stub.CreateCompositeKey(objectType, attributes)
 	if err != nil {
 		return nil, err
 	}
-	return NewPrivateMockStateRangeQueryIterator(stub, collection, partialCompositeKey, partialCompositeKey+string(maxUnicodeRuneValue)), nil
+	return stub.trackIterator(NewPrivateMockStateRangeQueryIterator(stub, collection, partialCompositeKey,
+		partialCompositeKey+string(maxUnicodeRuneValue)), nil,
+		fmt.Sprintf(`GetPrivateDataByPartialCompositeKey(%q, %q, %q)`, collection, objectType, attributes))
 }
@@ -80,8 +80,8 @@ var _ = Describe(`Testing`, func() {
 		It("Allow to use multiple events subscriptions", func(done Done) {
 			Expect(len(cc.ChaincodeEventsChannel)).To(Equal(0))
 
-			sub1 := cc.EventSubscription()
-			sub2 := cc.EventSubscription()
+			sub1, _ := cc.EventSubscription()
+			sub2, _ := cc.EventSubscription()
 
 			Expect(len(sub1)).To(Equal(0))
 			Expect(len(sub2)).To(Equal(0))
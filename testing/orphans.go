@@ -0,0 +1,65 @@
+package testing
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// ErrOrphanedPrivateData occurs when private data key has no corresponding key in public state
+var ErrOrphanedPrivateData = errors.New(`orphaned private data`)
+
+// PrivateDataRule checks private data key of collection has corresponding key in public state
+type PrivateDataRule func(publicKeys map[string]struct{}, collection, privateKey string) bool
+
+// SameKeyRule private data is referenced by public state entry with identical key
+func SameKeyRule(publicKeys map[string]struct{}, _, privateKey string) bool {
+	_, ok := publicKeys[privateKey]
+	return ok
+}
+
+// AssertNoOrphanedPrivateData reports to t collection and key of each private data entry, which public key,
+// defined by rule, does not exist in committed state. If rule is nil, SameKeyRule is used.
+// Returns true if no orphaned private data found
+func AssertNoOrphanedPrivateData(t ErrorReporter, stub *MockStub, rule PrivateDataRule) bool {
+	orphaned := stub.OrphanedPrivateData(rule)
+
+	collections := make([]string, 0, len(orphaned))
+	for collection := range orphaned {
+		collections = append(collections, collection)
+	}
+	sort.Strings(collections)
+
+	for _, collection := range collections {
+		for _, key := range orphaned[collection] {
+			t.Errorf(`%s: collection %s, key %q`, ErrOrphanedPrivateData, collection, key)
+		}
+	}
+	return len(orphaned) == 0
+}
+
+// OrphanedPrivateData returns sorted private data keys by collection, which public key,
+// defined by rule, does not exist in committed state. If rule is nil, SameKeyRule is used.
+// State is read with CommittedView
+func (stub *MockStub) OrphanedPrivateData(rule PrivateDataRule) map[string][]string {
+	if rule == nil {
+		rule = SameKeyRule
+	}
+
+	orphaned := make(map[string][]string)
+	stub.CommittedView().read(`OrphanedPrivateData`, func() {
+		publicKeys := make(map[string]struct{}, len(stub.State))
+		for key := range stub.State {
+			publicKeys[key] = struct{}{}
+		}
+
+		for _, collection := range stub.PrivateCollections() {
+			for _, key := range stub.PrivateKeysOf(collection) {
+				if !rule(publicKeys, collection, key) {
+					orphaned[collection] = append(orphaned[collection], key)
+				}
+			}
+		}
+	})
+	return orphaned
+}
@@ -0,0 +1,76 @@
+package testing_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/router"
+	p "github.com/s7techlab/cckit/router/param"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+const secretsCollection = `secrets`
+
+func NewSecretsCC() *router.Chaincode {
+	r := router.New(`secrets`)
+
+	r.Invoke(`create`, func(c router.Context) (interface{}, error) {
+		id := c.ParamString(`id`)
+		if err := c.Stub().PutState(id, []byte(`public`)); err != nil {
+			return nil, err
+		}
+		return nil, c.Stub().PutPrivateData(secretsCollection, id, []byte(`secret`))
+	}, p.String(`id`)).
+		Invoke(`delete`, func(c router.Context) (interface{}, error) {
+			id := c.ParamString(`id`)
+			if err := c.Stub().DelState(id); err != nil {
+				return nil, err
+			}
+			return nil, c.Stub().DelPrivateData(secretsCollection, id)
+		}, p.String(`id`)).
+		// deleteLeaky forgets to delete private data
+		Invoke(`deleteLeaky`, func(c router.Context) (interface{}, error) {
+			return nil, c.Stub().DelState(c.ParamString(`id`))
+		}, p.String(`id`))
+
+	return router.NewChaincode(r)
+}
+
+var _ = Describe(`Orphaned private data`, func() {
+
+	It("Allow to pass check after deleting public and private data", func() {
+		cc := testcc.NewMockStub(`secrets`, NewSecretsCC())
+		expectcc.ResponseOk(cc.Invoke(`create`, `a`))
+		expectcc.ResponseOk(cc.Invoke(`create`, `b`))
+		expectcc.ResponseOk(cc.Invoke(`delete`, `a`))
+
+		var errs errorsCollector
+		Expect(testcc.AssertNoOrphanedPrivateData(&errs, cc, nil)).To(BeTrue())
+		Expect(errs).To(BeEmpty())
+	})
+
+	It("Disallow to leave private data after public data delete", func() {
+		cc := testcc.NewMockStub(`secrets`, NewSecretsCC())
+		expectcc.ResponseOk(cc.Invoke(`create`, `a`))
+		expectcc.ResponseOk(cc.Invoke(`create`, `b`))
+		expectcc.ResponseOk(cc.Invoke(`deleteLeaky`, `a`))
+
+		var errs errorsCollector
+		Expect(testcc.AssertNoOrphanedPrivateData(&errs, cc, testcc.SameKeyRule)).To(BeFalse())
+		Expect(errs).To(HaveLen(1))
+		Expect(errs[0]).To(ContainSubstring(testcc.ErrOrphanedPrivateData.Error()))
+		Expect(errs[0]).To(ContainSubstring(secretsCollection))
+		Expect(errs[0]).To(ContainSubstring(`"a"`))
+	})
+
+	It("Allow to check private data with custom rule", func() {
+		cc := testcc.NewMockStub(`secrets`, NewSecretsCC())
+		expectcc.ResponseOk(cc.Invoke(`create`, `a`))
+		expectcc.ResponseOk(cc.Invoke(`deleteLeaky`, `a`))
+
+		keepAll := func(map[string]struct{}, string, string) bool { return true }
+		Expect(testcc.AssertNoOrphanedPrivateData(&errorsCollector{}, cc, keepAll)).To(BeTrue())
+		Expect(cc.OrphanedPrivateData(nil)).To(Equal(map[string][]string{secretsCollection: {`a`}}))
+	})
+})
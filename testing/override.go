@@ -0,0 +1,66 @@
+package testing
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// WarningUnknownOverride emitted when override of not overridable method or with wrong signature is registered
+const WarningUnknownOverride = `unknown_override`
+
+// overridable methods and signatures of their overrides
+var overridable = map[string]reflect.Type{
+	`GetState`:        reflect.TypeOf(func(key string) ([]byte, error) { return nil, nil }),
+	`PutState`:        reflect.TypeOf(func(key string, value []byte) error { return nil }),
+	`DelState`:        reflect.TypeOf(func(key string) error { return nil }),
+	`GetPrivateData`:  reflect.TypeOf(func(collection, key string) ([]byte, error) { return nil, nil }),
+	`GetStateByRange`: reflect.TypeOf(func(startKey, endKey string) (shim.StateQueryIteratorInterface, error) { return nil, nil }),
+	`GetTxTimestamp`:  reflect.TypeOf(func() (*timestamp.Timestamp, error) { return nil, nil }),
+	`GetCreator`:      reflect.TypeOf(func() ([]byte, error) { return nil, nil }),
+}
+
+// Override registers fn, called instead of stub method with name until ClearOverride.
+// Overridable methods are GetState, PutState, DelState, GetPrivateData, GetStateByRange, GetTxTimestamp
+// and GetCreator, fn must have method signature. Other overrides are ignored with WarningUnknownOverride.
+// Override is a hook, running while stub is locked: fn can have additional leading *HookView argument
+// to read committed state and current tx data, invoking stub from fn panics with ErrReentrantInvoke
+func (stub *MockStub) Override(name string, fn interface{}) *MockStub {
+	signature, ok := overridable[name]
+	if !ok {
+		stub.Warn(WarningUnknownOverride, ``, fmt.Sprintf(`method %s is not overridable`, name))
+		return stub
+	}
+	if fnType := reflect.TypeOf(fn); fnType != signature && fnType != withHookView(signature) {
+		stub.Warn(WarningUnknownOverride, ``, fmt.Sprintf(`override of %s must be %s, got %T`, name, signature, fn))
+		return stub
+	}
+
+	if stub.overrides == nil {
+		stub.overrides = make(map[string]interface{})
+	}
+	stub.overrides[name] = stub.hookFunc(`override of `+name, signature, fn)
+	return stub
+}
+
+// ClearOverride removes override of method with name
+func (stub *MockStub) ClearOverride(name string) *MockStub {
+	delete(stub.overrides, name)
+	return stub
+}
+
+// ClearOverrides removes all overrides
+func (stub *MockStub) ClearOverrides() *MockStub {
+	stub.overrides = nil
+	return stub
+}
+
+// GetTxTimestamp mocked, can be overridden
+func (stub *MockStub) GetTxTimestamp() (*timestamp.Timestamp, error) {
+	if fn, ok := stub.overrides[`GetTxTimestamp`].(func() (*timestamp.Timestamp, error)); ok {
+		return fn()
+	}
+	return stub.MockStub.GetTxTimestamp()
+}
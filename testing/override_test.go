@@ -0,0 +1,107 @@
+package testing_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/router"
+	p "github.com/s7techlab/cckit/router/param"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+type OverrideCounter struct {
+	Value int
+}
+
+func NewOverrideCC() *router.Chaincode {
+	r := router.New(`counter`)
+
+	r.Invoke(`set`, func(c router.Context) (interface{}, error) {
+		return nil, c.State().Put(`counter`, OverrideCounter{Value: c.ParamInt(`value`)})
+	}, p.Int(`value`)).
+		Query(`get`, func(c router.Context) (interface{}, error) {
+			return c.State().Get(`counter`, &OverrideCounter{})
+		})
+
+	return router.NewChaincode(r)
+}
+
+var _ = Describe(`Override`, func() {
+
+	It("Allow to override GetState and clear override", func() {
+		cc := testcc.NewMockStub(`counter`, NewOverrideCC()).FailOnWarnings()
+		expectcc.ResponseOk(cc.Invoke(`set`, 1))
+
+		cc.Override(`GetState`, func(key string) ([]byte, error) {
+			return []byte(`{corrupted`), nil
+		})
+		expectcc.ResponseError(cc.Query(`get`))
+
+		cc.Override(`GetState`, func(key string) ([]byte, error) {
+			return nil, errors.New(`state unavailable`)
+		})
+		expectcc.ResponseError(cc.Query(`get`), `state unavailable`)
+
+		cc.ClearOverride(`GetState`)
+		Expect(expectcc.PayloadIs(cc.Query(`get`), &OverrideCounter{})).To(Equal(OverrideCounter{Value: 1}))
+	})
+
+	It("Allow to ignore unknown overrides with warning", func() {
+		cc := testcc.NewMockStub(`counter`, NewOverrideCC())
+		cc.Override(`GetHistoryForKey`, func() {})
+		cc.Override(`GetState`, func() {})
+
+		Expect(cc.Warnings()).To(HaveLen(2))
+		Expect(cc.Warnings()[0].Code).To(Equal(testcc.WarningUnknownOverride))
+
+		expectcc.ResponseOk(cc.Invoke(`set`, 2))
+		Expect(expectcc.PayloadIs(cc.Query(`get`), &OverrideCounter{})).To(Equal(OverrideCounter{Value: 2}))
+	})
+
+	It("Allow override hook to read committed state and current tx with hook view", func() {
+		cc := testcc.NewMockStub(`counter`, NewOverrideCC())
+		expectcc.ResponseOk(cc.Invoke(`set`, 1))
+
+		var hooks, args []string
+		cc.Override(`GetState`, func(view *testcc.HookView, key string) ([]byte, error) {
+			hooks = append(hooks, view.Hook())
+			args = append(args, string(view.Args()[0]))
+			Expect(view.TxID()).NotTo(BeEmpty())
+
+			value, _ := view.GetCommittedState(key)
+			return value, nil
+		})
+
+		Expect(expectcc.PayloadIs(cc.Query(`get`), &OverrideCounter{})).To(Equal(OverrideCounter{Value: 1}))
+		Expect(hooks).To(Equal([]string{`override of GetState`}))
+		Expect(args).To(Equal([]string{`get`}))
+	})
+
+	It("Disallow override hook to invoke stub with error, naming hook", func() {
+		cc := testcc.NewMockStub(`counter`, NewOverrideCC())
+		expectcc.ResponseOk(cc.Invoke(`set`, 1))
+
+		cc.Override(`GetState`, func(key string) ([]byte, error) {
+			cc.Invoke(`set`, 2)
+			return nil, nil
+		})
+
+		var recovered interface{}
+		func() {
+			defer func() { recovered = recover() }()
+			cc.Query(`get`)
+		}()
+
+		err, ok := recovered.(error)
+		Expect(ok).To(BeTrue())
+		Expect(errors.Is(err, testcc.ErrReentrantInvoke)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring(`MockInvoke called from override of GetState`))
+
+		// stub is unlocked and usable after hook failure
+		cc.ClearOverrides()
+		Expect(expectcc.PayloadIs(cc.Query(`get`), &OverrideCounter{})).To(Equal(OverrideCounter{Value: 1}))
+	})
+})
@@ -0,0 +1,98 @@
+package testing
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// GetStateByRangeWithPagination mocked, range semantics are the same as of GetStateByRange.
+// Bookmark is the first key of next page, it's empty when there are no more results
+func (stub *MockStub) GetStateByRangeWithPagination(startKey, endKey string, pageSize int32,
+	bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	stub.recordRangeAccess(KeyRange{Start: startKey, End: endKey})
+	query := fmt.Sprintf(`range [%q, %q)`, startKey, endKey)
+
+	if bookmark != `` && bookmark > startKey {
+		startKey = bookmark
+	}
+
+	page, metadata := paginate(stub.rangeEntries(startKey, endKey), pageSize)
+	iter, err := stub.trackIterator(NewQueryResultIterator(query, page), nil,
+		fmt.Sprintf(`GetStateByRangeWithPagination(%q, %q, %d, %q)`, startKey, endKey, pageSize, bookmark))
+	return iter, metadata, err
+}
+
+// GetStateByPartialCompositeKeyWithPagination mocked, see GetStateByRangeWithPagination
+func (stub *MockStub) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string,
+	pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	partialKey, err := stub.MockStub.CreateCompositeKey(objectType, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return stub.GetStateByRangeWithPagination(partialKey, partialKey+string(maxUnicodeRuneValue), pageSize, bookmark)
+}
+
+// GetQueryResultWithPagination mocked rich query, bookmark is the key of document to start next page from
+func (stub *MockStub) GetQueryResultWithPagination(query string, pageSize int32,
+	bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	iter, err := stub.GetQueryResult(query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return stub.paginateIterator(iter.(*MockStateQueryResultIterator), pageSize, bookmark,
+		fmt.Sprintf(`GetQueryResultWithPagination(%s, %d, %q)`, query, pageSize, bookmark))
+}
+
+// PrivateQueryWithPagination rich query over private data collection with the same pagination semantics
+// as GetQueryResultWithPagination. Shim has no paginated private data query, helper is intended for testing
+// chaincode manual pagination, see state.Paginate
+func (stub *MockStub) PrivateQueryWithPagination(collection, query string, pageSize int32,
+	bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	iter, err := stub.GetPrivateDataQueryResult(collection, query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return stub.paginateIterator(iter.(*MockStateQueryResultIterator), pageSize, bookmark,
+		fmt.Sprintf(`PrivateQueryWithPagination(%q, %s, %d, %q)`, collection, query, pageSize, bookmark))
+}
+
+// paginateIterator returns iterator over page of query results, starting from bookmark key. Source iterator is closed
+func (stub *MockStub) paginateIterator(iter *MockStateQueryResultIterator, pageSize int32, bookmark string,
+	description string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	page, metadata := paginate(fromBookmark(iter.entries, bookmark), pageSize)
+	if err := iter.Close(); err != nil {
+		return nil, nil, err
+	}
+
+	pageIter, err := stub.trackIterator(NewQueryResultIterator(iter.Query, page), nil, description)
+	return pageIter, metadata, err
+}
+
+// fromBookmark returns entries, starting from bookmark key
+func fromBookmark(entries []*QueryResultEntry, bookmark string) []*QueryResultEntry {
+	for i, entry := range entries {
+		if entry.Key >= bookmark {
+			return entries[i:]
+		}
+	}
+	return nil
+}
+
+// paginate returns first page of entries, page size 0 means all entries
+func paginate(entries []*QueryResultEntry, pageSize int32) ([]*QueryResultEntry, *peer.QueryResponseMetadata) {
+	var bookmark string
+	if pageSize > 0 && len(entries) > int(pageSize) {
+		bookmark = entries[pageSize].Key
+		entries = entries[:pageSize]
+	}
+
+	return entries, &peer.QueryResponseMetadata{
+		FetchedRecordsCount: int32(len(entries)),
+		Bookmark:            bookmark,
+	}
+}
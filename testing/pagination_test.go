@@ -0,0 +1,186 @@
+package testing_test
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	testcc "github.com/s7techlab/cckit/testing"
+)
+
+const (
+	paginatedDocsCount  = 30
+	paginatedCollection = `collection`
+	paginatedQuery      = `{"selector":{"docType":"car"}}`
+)
+
+type pageQuery func(stub *testcc.MockStub, pageSize int32, bookmark string) (
+	shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error)
+
+// paginatedStub returns stub with the same documents in public state and in private data collection,
+// every third document is not selected by paginatedQuery
+func paginatedStub() *testcc.MockStub {
+	stub := testcc.NewMockStub(`pagination`, nil)
+
+	stub.MockTransactionStart(`seed`)
+	for i := 0; i < paginatedDocsCount*3/2; i++ {
+		docType := `car`
+		if i%3 == 2 {
+			docType = `owner`
+		}
+		key, value := fmt.Sprintf(`DOC%03d`, i), []byte(fmt.Sprintf(`{"docType":"%s","n":%d}`, docType, i))
+		Expect(stub.PutState(key, value)).To(Succeed())
+		Expect(stub.PutPrivateData(paginatedCollection, key, value)).To(Succeed())
+	}
+	stub.MockTransactionEnd(`seed`)
+	return stub
+}
+
+// walkPages returns keys of each page and bookmarks, returned with pages
+func walkPages(stub *testcc.MockStub, query pageQuery, pageSize int32) (pages [][]string, bookmarks []string) {
+	var bookmark string
+	for {
+		iter, metadata, err := query(stub, pageSize, bookmark)
+		Expect(err).NotTo(HaveOccurred())
+
+		var keys []string
+		for _, kv := range readAll(iter) {
+			keys = append(keys, kv.Key)
+		}
+		Expect(metadata.FetchedRecordsCount).To(BeEquivalentTo(len(keys)))
+
+		pages = append(pages, keys)
+		bookmarks = append(bookmarks, metadata.Bookmark)
+		if bookmark = metadata.Bookmark; bookmark == `` {
+			return pages, bookmarks
+		}
+	}
+}
+
+var publicQuery pageQuery = func(stub *testcc.MockStub, pageSize int32, bookmark string) (
+	shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	return stub.GetQueryResultWithPagination(paginatedQuery, pageSize, bookmark)
+}
+
+var privateQuery pageQuery = func(stub *testcc.MockStub, pageSize int32, bookmark string) (
+	shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	return stub.PrivateQueryWithPagination(paginatedCollection, paginatedQuery, pageSize, bookmark)
+}
+
+var _ = Describe(`Rich query pagination`, func() {
+
+	queries := map[string]pageQuery{`public state`: publicQuery, `private data collection`: privateQuery}
+	for name, query := range queries {
+		query := query
+		It("Allow to page through query results in "+name, func() {
+			pages, bookmarks := walkPages(paginatedStub(), query, 12)
+
+			Expect(pages).To(HaveLen(3))
+			Expect(pages[0]).To(HaveLen(12))
+			Expect(pages[1]).To(HaveLen(12))
+			Expect(pages[2]).To(HaveLen(6))
+			Expect(pages[0][0]).To(Equal(`DOC000`))
+			Expect(bookmarks[0]).To(Equal(pages[1][0]))
+			Expect(bookmarks[1]).To(Equal(pages[2][0]))
+			Expect(bookmarks[2]).To(BeEmpty())
+
+			var all []string
+			for _, page := range pages {
+				all = append(all, page...)
+			}
+			Expect(all).To(HaveLen(paginatedDocsCount))
+		})
+	}
+
+	It("Allow to get the same pages from public state and private data collection", func() {
+		stub := paginatedStub()
+
+		// all on one page, larger than and equal to results, single result and uneven pages
+		for _, pageSize := range []int32{0, 100, paginatedDocsCount, 1, 7} {
+			publicPages, publicBookmarks := walkPages(stub, publicQuery, pageSize)
+			privatePages, privateBookmarks := walkPages(stub, privateQuery, pageSize)
+
+			Expect(privatePages).To(Equal(publicPages), `page size %d`, pageSize)
+			Expect(privateBookmarks).To(Equal(publicBookmarks), `page size %d`, pageSize)
+		}
+	})
+
+	It("Disallow private query of collection without read access", func() {
+		stub := testcc.NewMockStub(`pagination`, nil, testcc.WithCollection(paginatedCollection, `OrgMSP`))
+
+		stub.MockTransactionStart(`tx`)
+		defer stub.MockTransactionEnd(`tx`)
+		_, _, err := stub.PrivateQueryWithPagination(paginatedCollection, paginatedQuery, 12, ``)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("Allow to cap paginated query results with limit", func() {
+		stub := testcc.NewMockStub(`docs`, nil)
+		Expect(stub.SeedState(map[string][]byte{
+			`a`: []byte(`{"n":1}`), `b`: []byte(`{"n":2}`), `c`: []byte(`{"n":3}`),
+			`d`: []byte(`{"n":4}`), `e`: []byte(`{"n":5}`),
+		})).To(Succeed())
+
+		pages, bookmarks := walkPages(stub, func(stub *testcc.MockStub, pageSize int32, bookmark string) (
+			shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+			return stub.GetQueryResultWithPagination(`{"selector":{"n":{"$gt":0}},"limit":3}`, pageSize, bookmark)
+		}, 2)
+
+		Expect(pages).To(Equal([][]string{{`a`, `b`}, {`c`}}))
+		Expect(bookmarks).To(Equal([]string{`c`, ``}))
+	})
+})
+
+var _ = Describe(`State range`, func() {
+
+	var stub *testcc.MockStub
+
+	BeforeEach(func() {
+		stub = testcc.NewMockStub(`range`, nil)
+		stub.MockTransactionStart(`seed`)
+		for _, key := range []string{`d`, `b`, `e`, `a`, `c`} {
+			Expect(stub.PutState(key, []byte(key))).To(Succeed())
+		}
+		stub.MockTransactionEnd(`seed`)
+	})
+
+	rangeKeys := func(startKey, endKey string) []string {
+		iter, err := stub.GetStateByRange(startKey, endKey)
+		Expect(err).NotTo(HaveOccurred())
+		keys := []string{}
+		for _, kv := range readAll(iter) {
+			keys = append(keys, kv.Key)
+		}
+		return keys
+	}
+
+	It("Allow to get keys of half-open range in lexicographic order", func() {
+		Expect(rangeKeys(`b`, `d`)).To(Equal([]string{`b`, `c`}))
+		Expect(rangeKeys(`b`, `b`)).To(BeEmpty())
+		Expect(rangeKeys(`bb`, `x`)).To(Equal([]string{`c`, `d`, `e`}))
+	})
+
+	It("Allow to use empty start or end key as start or end of key space", func() {
+		Expect(rangeKeys(``, `c`)).To(Equal([]string{`a`, `b`}))
+		Expect(rangeKeys(`c`, ``)).To(Equal([]string{`c`, `d`, `e`}))
+		Expect(rangeKeys(``, ``)).To(Equal([]string{`a`, `b`, `c`, `d`, `e`}))
+	})
+
+	It("Disallow to use composite key as range bound", func() {
+		_, err := stub.GetStateByRange("\x00a", ``)
+		Expect(err).To(MatchError(ContainSubstring(`null character`)))
+	})
+
+	It("Allow to get range pages with bookmark equal to first key of next page", func() {
+		pages, bookmarks := walkPages(stub, func(stub *testcc.MockStub, pageSize int32, bookmark string) (
+			shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+			return stub.GetStateByRangeWithPagination(``, `e`, pageSize, bookmark)
+		}, 2)
+
+		Expect(pages).To(Equal([][]string{{`a`, `b`}, {`c`, `d`}}))
+		Expect(bookmarks).To(Equal([]string{`c`, ``}))
+	})
+})
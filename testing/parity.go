@@ -0,0 +1,89 @@
+package testing
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/s7techlab/cckit/convert"
+)
+
+var (
+	// ErrQueryInvokeMismatch occurs when query and invoke of same function against same state differ
+	ErrQueryInvokeMismatch = errors.New(`query and invoke output mismatch`)
+
+	// ErrReadOnlyWrites occurs when invoke of read only function writes to state
+	ErrReadOnlyWrites = errors.New(`read only function writes to state`)
+)
+
+// AssertQueryInvokeParity runs function as query and as invoke against identical state snapshots
+// and reports to t differences in response status, message and payload and writes of invoke.
+// State is not changed by check, returns true if function is read only consistent
+func AssertQueryInvokeParity(t ErrorReporter, stub *MockStub, funcName string, iargs ...interface{}) bool {
+	err := stub.CheckQueryInvokeParity(funcName, iargs...)
+	if err != nil {
+		t.Errorf(`%s: %s`, funcName, err)
+	}
+	return err == nil
+}
+
+// CheckQueryInvokeParity returns first difference between query and invoke outputs of function
+// or error if invoke writes to state
+func (stub *MockStub) CheckQueryInvokeParity(funcName string, iargs ...interface{}) error {
+	fargs, err := convert.ArgsToBytes(iargs...)
+	if err != nil {
+		return err
+	}
+	args := append([][]byte{[]byte(funcName)}, fargs...)
+
+	stub.checkReentrant(`CheckQueryInvokeParity`)
+	stub.m.Lock()
+	defer stub.m.Unlock()
+
+	var (
+		txTimestamp = stub.clockTimestamp()
+		uuid        = stub.generateTxUID()
+		snap        = stub.snapshot()
+		query       = stub.runTx(uuid, args, txTimestamp, false, snap)
+		invoke      *txOutput
+	)
+
+	stub.restore(snap)
+	invoke = stub.runTx(uuid, args, txTimestamp, false, snap)
+	stub.restore(snap)
+
+	if stub.ClearCreatorAfterInvoke {
+		stub.mockCreator = nil
+		stub.injectedInvoker = nil
+		stub.transient = nil
+		stub.txTimestamp = nil
+	}
+
+	for _, part := range []struct {
+		subject       string
+		query, invoke []byte
+	}{
+		{`response status`, []byte(fmt.Sprint(query.response.Status)), []byte(fmt.Sprint(invoke.response.Status))},
+		{`response message`, []byte(query.response.Message), []byte(invoke.response.Message)},
+		{`response payload`, query.response.Payload, invoke.response.Payload},
+	} {
+		if !bytes.Equal(part.query, part.invoke) {
+			return fmt.Errorf(`%w: %s %s`, ErrQueryInvokeMismatch, part.subject, bytesDiff(part.query, part.invoke))
+		}
+	}
+
+	if len(invoke.writes) > 0 {
+		return fmt.Errorf(`%w: keys %q`, ErrReadOnlyWrites, stateItemKeys(invoke.writes))
+	}
+
+	return nil
+}
+
+// bytesDiff describes first difference between expected and actual bytes
+func bytesDiff(expected, actual []byte) string {
+	offset := 0
+	for offset < len(expected) && offset < len(actual) && expected[offset] == actual[offset] {
+		offset++
+	}
+	return fmt.Sprintf(`differs at offset %d: %q != %q`, offset, expected[offset:], actual[offset:])
+}
@@ -0,0 +1,74 @@
+package testing_test
+
+import (
+	"errors"
+	"strconv"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/router"
+	p "github.com/s7techlab/cckit/router/param"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+func NewParityCC() *router.Chaincode {
+	r := router.New(`parity`)
+
+	r.Invoke(`set`, func(c router.Context) (interface{}, error) {
+		return nil, c.Stub().PutState(`value`, []byte(c.ParamString(`value`)))
+	}, p.String(`value`)).
+		Query(`get`, func(c router.Context) (interface{}, error) {
+			return c.Stub().GetState(`value`)
+		}).
+		// square lazily caches computation result into state
+		Query(`square`, func(c router.Context) (interface{}, error) {
+			cached, err := c.Stub().GetState(`square`)
+			if err != nil || cached != nil {
+				return cached, err
+			}
+
+			value, err := c.Stub().GetState(`value`)
+			if err != nil {
+				return nil, err
+			}
+			number, err := strconv.Atoi(string(value))
+			if err != nil {
+				return nil, err
+			}
+
+			square := []byte(strconv.Itoa(number * number))
+			return square, c.Stub().PutState(`square`, square)
+		})
+
+	return router.NewChaincode(r)
+}
+
+var _ = Describe(`Query invoke parity`, func() {
+
+	It("Allow pure read function to pass parity check", func() {
+		var errs errorsCollector
+		cc := testcc.NewMockStub(`parity`, NewParityCC())
+		expectcc.ResponseOk(cc.Invoke(`set`, `3`))
+
+		Expect(testcc.AssertQueryInvokeParity(&errs, cc, `get`)).To(BeTrue())
+		Expect(errs).To(BeEmpty())
+	})
+
+	It("Disallow function, lazily caching computation into state", func() {
+		var errs errorsCollector
+		cc := testcc.NewMockStub(`parity`, NewParityCC())
+		expectcc.ResponseOk(cc.Invoke(`set`, `3`))
+
+		Expect(testcc.AssertQueryInvokeParity(&errs, cc, `square`)).To(BeFalse())
+		Expect(errs).To(HaveLen(1))
+		Expect(errs[0]).To(ContainSubstring(`"square"`))
+
+		err := cc.CheckQueryInvokeParity(`square`)
+		Expect(errors.Is(err, testcc.ErrReadOnlyWrites)).To(BeTrue())
+
+		// parity check does not change state
+		Expect(cc.State).NotTo(HaveKey(`square`))
+	})
+})
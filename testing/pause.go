@@ -0,0 +1,58 @@
+package testing
+
+import (
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// channelEvent committed event with channel of tx
+type channelEvent struct {
+	channel string
+	event   *peer.ChaincodeEvent
+}
+
+// PauseEvents pauses delivery of committed events to subscriptions and events aggregators.
+// Commits are not blocked, events are buffered and delivered in commit order on ResumeEvents
+func (stub *MockStub) PauseEvents() {
+	stub.subscriptionsM.Lock()
+	defer stub.subscriptionsM.Unlock()
+
+	stub.eventsPaused = true
+}
+
+// ResumeEvents resumes delivery of events and delivers events, buffered while paused, in commit order
+func (stub *MockStub) ResumeEvents() {
+	stub.subscriptionsM.Lock()
+	defer stub.subscriptionsM.Unlock()
+
+	stub.eventsPaused = false
+	paused := stub.pausedEvents
+	stub.pausedEvents = nil
+	for _, e := range paused {
+		stub.publishEvent(e.channel, e.event)
+	}
+}
+
+// FlushEvents waits until events, queued for subscriptions with full channel buffers, are delivered.
+// Events, buffered while delivery is paused, are not awaited
+func (stub *MockStub) FlushEvents() {
+	stub.subscriptionsM.Lock()
+	defer stub.subscriptionsM.Unlock()
+
+	for stub.dispatcher != nil && len(stub.dispatcher.queues) > 0 {
+		stub.dispatcher.drained.Wait()
+	}
+}
+
+// publishEvent delivers committed event to subscriptions and aggregators or buffers it, if delivery is paused.
+// Should be called with locked subscriptionsM
+func (stub *MockStub) publishEvent(channel string, event *peer.ChaincodeEvent) {
+	if stub.eventsPaused {
+		stub.pausedEvents = append(stub.pausedEvents, channelEvent{channel: channel, event: event})
+		return
+	}
+
+	stub.deliverEvent(event)
+	for _, aggregator := range stub.eventsAggregators {
+		aggregator.publish(stub.Name, channel, event)
+	}
+}
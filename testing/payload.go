@@ -0,0 +1,37 @@
+package testing
+
+// PayloadSizeReport sizes of response payloads of logged invocations
+type PayloadSizeReport struct {
+	Invocations int
+	Total       int
+	Max         int
+	MaxTxID     string
+	// Oversized number of payloads, exceeding payload warning size
+	Oversized int
+}
+
+// PayloadSizes returns report of response payload sizes of logged invocations, manual txs have no payload
+// and are skipped. Number of invocations is limited by RetentionLimits.MaxInvocationLog
+func (stub *MockStub) PayloadSizes() PayloadSizeReport {
+	warningSize := stub.warnings.payloadSize
+	if warningSize == 0 {
+		warningSize = DefaultPayloadWarningSize
+	}
+
+	report := PayloadSizeReport{}
+	for _, invocation := range stub.invocationLog {
+		if invocation.Manual {
+			continue
+		}
+		report.Invocations++
+		report.Total += invocation.PayloadSize
+		if invocation.PayloadSize > report.Max {
+			report.Max = invocation.PayloadSize
+			report.MaxTxID = invocation.TxID
+		}
+		if invocation.PayloadSize > warningSize {
+			report.Oversized++
+		}
+	}
+	return report
+}
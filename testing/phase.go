@@ -0,0 +1,64 @@
+package testing
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// InvocationPhase phase of chaincode invocation, tx is simulated in
+type InvocationPhase int
+
+const (
+	// PhaseNone no tx is simulated
+	PhaseNone InvocationPhase = iota
+	// PhaseInit tx is simulated by chaincode Init
+	PhaseInit
+	// PhaseInvoke tx is simulated by chaincode Invoke, including invocation from other chaincode
+	PhaseInvoke
+)
+
+// ErrNotAllowedInInit occurs when chaincode Init calls stub API, which peer rejects during Init
+var ErrNotAllowedInInit = errors.New(`not allowed in chaincode Init()`)
+
+func (p InvocationPhase) String() string {
+	switch p {
+	case PhaseInit:
+		return `init`
+	case PhaseInvoke:
+		return `invoke`
+	}
+	return `none`
+}
+
+// WithInitRestrictions rejects history queries and private data APIs during Init, as peer does
+func WithInitRestrictions() MockStubOpt {
+	return func(stub *MockStub) {
+		stub.initRestrictions = true
+	}
+}
+
+// Phase returns invocation phase of currently simulated tx
+func (stub *MockStub) Phase() InvocationPhase {
+	return stub.phase
+}
+
+// enterPhase sets invocation phase, returned func restores previous phase
+func (stub *MockStub) enterPhase(phase InvocationPhase) func() {
+	prev := stub.phase
+	stub.phase = phase
+	return func() { stub.phase = prev }
+}
+
+// checkInitRestriction returns peer equivalent error, if api is called in Init and init restrictions are enabled
+func (stub *MockStub) checkInitRestriction(api string) error {
+	if !stub.initRestrictions || stub.phase != PhaseInit {
+		return nil
+	}
+	return fmt.Errorf(`%s APIs are %w`, api, ErrNotAllowedInInit)
+}
+
+// checkPrivateDataInit checks private data API call is allowed in current invocation phase
+func (stub *MockStub) checkPrivateDataInit() error {
+	return stub.checkInitRestriction(`private data`)
+}
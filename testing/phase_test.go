@@ -0,0 +1,92 @@
+package testing_test
+
+import (
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+// HistoryCC reads key history in Init and Invoke. Init with `call` arg reads history via other chaincode,
+// with `private` arg writes private data
+type HistoryCC struct{}
+
+func (cc HistoryCC) Init(stub shim.ChaincodeStubInterface) peer.Response {
+	args := stub.GetStringArgs()
+	if len(args) == 0 {
+		return cc.history(stub)
+	}
+
+	switch args[0] {
+	case `call`:
+		return stub.InvokeChaincode(`history`, [][]byte{[]byte(`history`)}, ``)
+	case `private`:
+		if err := stub.PutPrivateData(`collection`, `key`, []byte(`value`)); err != nil {
+			return shim.Error(err.Error())
+		}
+	}
+	return shim.Success(nil)
+}
+
+func (cc HistoryCC) Invoke(stub shim.ChaincodeStubInterface) peer.Response {
+	if fn, _ := stub.GetFunctionAndParameters(); fn == `phase` {
+		return shim.Success([]byte(stub.(*testcc.MockStub).Phase().String()))
+	}
+	return cc.history(stub)
+}
+
+func (cc HistoryCC) history(stub shim.ChaincodeStubInterface) peer.Response {
+	iter, err := stub.GetHistoryForKey(`key`)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	_ = iter.Close()
+	return shim.Success(nil)
+}
+
+var _ = Describe(`Invocation phase`, func() {
+
+	It("Disallow history query in Init with Fabric defaults", func() {
+		cc := testcc.NewMockStub(`history`, HistoryCC{}, testcc.WithFabricDefaults())
+
+		res := cc.Init()
+		expectcc.ResponseError(res, testcc.ErrNotAllowedInInit)
+		Expect(res.Message).To(Equal(`history query APIs are not allowed in chaincode Init()`))
+	})
+
+	It("Allow history query in Invoke with Fabric defaults", func() {
+		cc := testcc.NewMockStub(`history`, HistoryCC{}, testcc.WithFabricDefaults())
+
+		expectcc.ResponseOk(cc.Invoke(`history`))
+	})
+
+	It("Allow history query in Init without init restrictions", func() {
+		expectcc.ResponseOk(testcc.NewMockStub(`history`, HistoryCC{}).Init())
+	})
+
+	It("Disallow private data APIs in Init with init restrictions", func() {
+		cc := testcc.NewMockStub(`history`, HistoryCC{}, testcc.WithInitRestrictions())
+
+		res := cc.Init(`private`)
+		expectcc.ResponseError(res, `private data APIs are not allowed in chaincode Init()`)
+		Expect(cc.PvtState).To(BeEmpty())
+	})
+
+	It("Allow history query in chaincode invoked from Init", func() {
+		caller := testcc.NewMockStub(`caller`, HistoryCC{}, testcc.WithFabricDefaults())
+		caller.MockPeerChaincode(`history`, testcc.NewMockStub(`history`, HistoryCC{}, testcc.WithFabricDefaults()))
+
+		expectcc.ResponseOk(caller.Init(`call`))
+	})
+
+	It("Allow to get invocation phase", func() {
+		cc := testcc.NewMockStub(`history`, HistoryCC{})
+
+		Expect(cc.Phase()).To(Equal(testcc.PhaseNone))
+		Expect(string(cc.Invoke(`phase`).Payload)).To(Equal(`invoke`))
+		Expect(cc.Phase()).To(Equal(testcc.PhaseNone))
+	})
+})
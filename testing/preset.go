@@ -0,0 +1,21 @@
+package testing
+
+import (
+	"github.com/s7techlab/cckit/state"
+)
+
+// WithFabricDefaults enables checks, which are off by default for permissive tests,
+// making MockStub behaviour closer to Fabric peer:
+// reserved keys guard with default reserved keys, history and private data APIs rejected in Init,
+// event names and payload size validated with default limits
+func WithFabricDefaults() MockStubOpt {
+	return func(stub *MockStub) {
+		for _, o := range []MockStubOpt{
+			WithReservedKeys(DefaultReservedKeys()),
+			WithInitRestrictions(),
+			WithEventValidation(state.DefaultEventNameMaxLength, DefaultEventPayloadMaxSize),
+		} {
+			o(stub)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package testing
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"sort"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// DefaultChaincodeVersion version of mocked chaincode if not set via WithChaincodeID
+const DefaultChaincodeVersion = `0`
+
+// WithChaincodeID sets mocked installed chaincode name and version
+func (stub *MockStub) WithChaincodeID(name, version string) *MockStub {
+	stub.chaincodeID = &peer.ChaincodeID{Name: name, Version: version}
+	return stub
+}
+
+// ChaincodeID returns mocked installed chaincode name and version
+func (stub *MockStub) ChaincodeID() *peer.ChaincodeID {
+	if stub.chaincodeID == nil {
+		return &peer.ChaincodeID{Name: stub.Name, Version: DefaultChaincodeVersion}
+	}
+	return &peer.ChaincodeID{Name: stub.chaincodeID.Name, Version: stub.chaincodeID.Version}
+}
+
+// GetSignedProposal mocked, contains chaincode spec with chaincode id, current args and transient map
+func (stub *MockStub) GetSignedProposal() (*peer.SignedProposal, error) {
+	proposal, err := stub.proposal(true)
+	if err != nil {
+		return nil, err
+	}
+
+	proposalBytes, err := proto.Marshal(proposal)
+	if err != nil {
+		return nil, err
+	}
+
+	return &peer.SignedProposal{ProposalBytes: proposalBytes}, nil
+}
+
+// proposal creates proposal of current tx, transient map is excluded from proposal hash as in Fabric
+func (stub *MockStub) proposal(includeTransient bool) (*peer.Proposal, error) {
+	chaincodeID := stub.ChaincodeID()
+
+	extension, err := proto.Marshal(&peer.ChaincodeHeaderExtension{ChaincodeId: chaincodeID})
+	if err != nil {
+		return nil, err
+	}
+
+	channelHeader, err := proto.Marshal(&common.ChannelHeader{
+		Type:      int32(common.HeaderType_ENDORSER_TRANSACTION),
+		ChannelId: stub.ChannelID,
+		TxId:      stub.TxID,
+		Timestamp: stub.TxTimestamp,
+		Extension: extension,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	signatureHeader, err := proto.Marshal(&common.SignatureHeader{Creator: stub.mockCreator})
+	if err != nil {
+		return nil, err
+	}
+
+	header, err := proto.Marshal(&common.Header{
+		ChannelHeader:   channelHeader,
+		SignatureHeader: signatureHeader,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	input, err := proto.Marshal(&peer.ChaincodeInvocationSpec{
+		ChaincodeSpec: &peer.ChaincodeSpec{
+			Type:        peer.ChaincodeSpec_GOLANG,
+			ChaincodeId: chaincodeID,
+			Input:       &peer.ChaincodeInput{Args: stub.GetArgs()},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	proposalPayload := &peer.ChaincodeProposalPayload{Input: input}
+	if includeTransient {
+		proposalPayload.TransientMap = stub.transient
+	}
+
+	payload, err := proto.Marshal(proposalPayload)
+	if err != nil {
+		return nil, err
+	}
+
+	return &peer.Proposal{Header: header, Payload: payload}, nil
+}
+
+// LastProposalResponse returns simplified proposal response of last invoke. Payload contains
+// ProposalResponsePayload with proposal hash and ChaincodeAction extension, ChaincodeAction.Results
+// is a hash over collapsed write set instead of read write set, Events is marshaled chaincode event.
+// Endorsement is not set
+func (stub *MockStub) LastProposalResponse() *peer.ProposalResponse {
+	return stub.lastProposalResponse
+}
+
+// ProposalResponseAction extracts chaincode action from proposal response payload
+func ProposalResponseAction(proposalResponse *peer.ProposalResponse) (*peer.ChaincodeAction, error) {
+	payload := &peer.ProposalResponsePayload{}
+	if err := proto.Unmarshal(proposalResponse.Payload, payload); err != nil {
+		return nil, err
+	}
+
+	action := &peer.ChaincodeAction{}
+	if err := proto.Unmarshal(payload.Extension, action); err != nil {
+		return nil, err
+	}
+	return action, nil
+}
+
+// simulationResults creates proposal response for current tx, called before tx end
+func (stub *MockStub) simulationResults(response peer.Response) *peer.ProposalResponse {
+	proposal, err := stub.proposal(false)
+	PanicIfError(err)
+	proposalHash := sha256.Sum256(MustProtoMarshal(proposal))
+
+	var events []byte
+	if stub.ChaincodeEvent != nil {
+		// event is delivered to subscribers and compared in tests, so marshaling must not touch its size cache
+		events = MustProtoMarshal(proto.Clone(stub.ChaincodeEvent))
+	}
+
+	return &peer.ProposalResponse{
+		Version:   1,
+		Timestamp: stub.TxTimestamp,
+		Response:  &response,
+		Payload: MustProtoMarshal(&peer.ProposalResponsePayload{
+			ProposalHash: proposalHash[:],
+			Extension: MustProtoMarshal(&peer.ChaincodeAction{
+				Results:     stub.writeSetHash(),
+				Events:      events,
+				Response:    &response,
+				ChaincodeId: stub.ChaincodeID(),
+			}),
+		}),
+	}
+}
+
+// writeSetHash returns hash over tx writes collapsed by key, as they will be committed:
+// deletes are applied immediately, buffered puts on tx end
+func (stub *MockStub) writeSetHash() []byte {
+	writes := make(map[string][]byte)
+	for _, key := range stub.txDeletes {
+		writes[key] = nil
+	}
+	for _, item := range stub.StateBuffer {
+		writes[item.Key] = item.Value
+	}
+
+	keys := make([]string, 0, len(writes))
+	for key := range writes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	hash := sha256.New()
+	for _, key := range keys {
+		writeHashPart(hash.Write, []byte(key))
+		if value := writes[key]; value == nil {
+			_, _ = hash.Write([]byte{1}) // delete marker
+		} else {
+			_, _ = hash.Write([]byte{0})
+			writeHashPart(hash.Write, value)
+		}
+	}
+	return hash.Sum(nil)
+}
+
+func writeHashPart(write func([]byte) (int, error), part []byte) {
+	size := make([]byte, 8)
+	binary.BigEndian.PutUint64(size, uint64(len(part)))
+	_, _ = write(size)
+	_, _ = write(part)
+}
@@ -0,0 +1,46 @@
+package testing_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/hyperledger/fabric-protos-go/peer"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+	"github.com/s7techlab/cckit/testing/testdata"
+)
+
+func createEntityAction(cc *testcc.MockStub, entity testdata.Entity) *peer.ChaincodeAction {
+	expectcc.ResponseOk(cc.Invoke(`entityCreate`, entity))
+
+	action, err := testcc.ProposalResponseAction(cc.LastProposalResponse())
+	Expect(err).NotTo(HaveOccurred())
+	return action
+}
+
+var _ = Describe(`Proposal response`, func() {
+
+	It("Allow to get simulation results of last invoke", func() {
+		cc := testcc.NewMockStub(`entities`, testdata.NewEntitiesCC())
+		action := createEntityAction(cc, testdata.Entity{Id: `a`, Owner: `owner1`})
+
+		Expect(cc.LastProposalResponse().Response.Status).To(BeNumerically(`==`, 200))
+		Expect(action.ChaincodeId.Name).To(Equal(`entities`))
+		Expect(action.Results).To(HaveLen(32))
+
+		event := testcc.MustProtoUnmarshal(action.Events, &peer.ChaincodeEvent{}).(*peer.ChaincodeEvent)
+		Expect(event.EventName).To(Equal(testdata.EntityCreatedEvent))
+	})
+
+	It("Allow to get stable results hash for identical writes and different for different", func() {
+		action1 := createEntityAction(
+			testcc.NewMockStub(`entities`, testdata.NewEntitiesCC()), testdata.Entity{Id: `a`, Owner: `owner1`})
+		action2 := createEntityAction(
+			testcc.NewMockStub(`entities`, testdata.NewEntitiesCC()), testdata.Entity{Id: `a`, Owner: `owner1`})
+		action3 := createEntityAction(
+			testcc.NewMockStub(`entities`, testdata.NewEntitiesCC()), testdata.Entity{Id: `a`, Owner: `owner2`})
+
+		Expect(action1.Results).To(Equal(action2.Results))
+		Expect(action1.Results).NotTo(Equal(action3.Results))
+	})
+})
@@ -0,0 +1,801 @@
+package testing
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/pkg/errors"
+
+	"github.com/s7techlab/cckit/state/selector"
+)
+
+var (
+	// ErrQueryInvalid occurs when rich query can't be parsed
+	ErrQueryInvalid = errors.New(`invalid rich query`)
+	// ErrSelectorOperatorNotSupported occurs when selector contains operator, not supported by mocked query engine
+	ErrSelectorOperatorNotSupported = errors.New(`selector operator not supported`)
+	// ErrSelectorTypeMismatch occurs when comparison operator argument and property value types differ
+	ErrSelectorTypeMismatch = errors.New(`selector operator argument and property type mismatch`)
+)
+
+type (
+	// RichQuery CouchDB style query, selector and sort are evaluated by mocked query engine
+	// or by models, registered with RegisterQueryModel.
+	// Skip and limit are applied after sort, zero limit means unlimited. Fields projects matched documents
+	RichQuery struct {
+		Selector map[string]interface{} `json:"selector"`
+		Sort     []map[string]string    `json:"sort,omitempty"`
+		Limit    int                    `json:"limit,omitempty"`
+		Skip     int                    `json:"skip,omitempty"`
+		Fields   []string               `json:"fields,omitempty"`
+	}
+
+	// QueryResultEntry entry of query result
+	QueryResultEntry struct {
+		Key   string
+		Value []byte
+		// Doc parsed JSON document of rich query result, nil for range query entries
+		// and entries, evaluated with registered model
+		Doc map[string]interface{}
+
+		model ModelMock
+	}
+
+	// QueryResultError describes malformed entry of query result
+	QueryResultError struct {
+		Query  string
+		Index  int
+		Reason string
+	}
+
+	// MockStateQueryResultIterator iterator over query results
+	MockStateQueryResultIterator struct {
+		releaser
+		Closed bool
+		// Query description of query, produced iterator, used in errors
+		Query   string
+		entries []*QueryResultEntry
+		pos     int
+	}
+)
+
+func (e *QueryResultError) Error() string {
+	return fmt.Sprintf(`%s: query %s, entry %d: %s`, ErrQueryResultMalformed, e.Query, e.Index, e.Reason)
+}
+
+func (e *QueryResultError) Unwrap() error {
+	return ErrQueryResultMalformed
+}
+
+// QueryIDField document id field, contains state key
+const QueryIDField = `_id`
+
+// WithIncludeCompositeKeys enables evaluation of rich query selectors against entries with composite keys.
+// By default composite keyed entries are evaluated only if selector targets them via _id field
+func WithIncludeCompositeKeys() MockStubOpt {
+	return func(stub *MockStub) {
+		stub.queryCompositeKeys = true
+	}
+}
+
+// GetQueryResult mocked CouchDB rich query, evaluates selector against JSON documents in committed state.
+// Results are ordered by key, non JSON values are skipped. Document _id field contains state key
+func (stub *MockStub) GetQueryResult(query string) (shim.StateQueryIteratorInterface, error) {
+	q, err := ParseRichQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	iter, err := stub.richQueryResult(query, q)
+	return stub.trackIterator(iter, err, `GetQueryResult(`+query+`)`)
+}
+
+// GetSelectorQueryResult mocked rich query, built with selector package, see GetQueryResult.
+// Sort and limit of built query are applied as in query JSON
+func (stub *MockStub) GetSelectorQueryResult(query *selector.Query) (shim.StateQueryIteratorInterface, error) {
+	built, err := query.Build()
+	if err != nil {
+		return nil, fmt.Errorf(`%w: %s`, ErrQueryInvalid, err)
+	}
+	q, err := ParseRichQuery(built)
+	if err != nil {
+		return nil, err
+	}
+	iter, err := stub.richQueryResult(built, q)
+	return stub.trackIterator(iter, err, `GetSelectorQueryResult(`+built+`)`)
+}
+
+func (stub *MockStub) richQueryResult(query string, q *RichQuery) (shim.StateQueryIteratorInterface, error) {
+	stub.recordRangeAccess(KeyRange{Query: true})
+
+	entries, err := stub.queryDocuments(q, stub.queryCandidateKeys(q.Selector), stub.State)
+	if err != nil {
+		return nil, err
+	}
+	return NewQueryResultIterator(query, entries), nil
+}
+
+// GetPrivateDataQueryResult mocked rich query over private data collection, see GetQueryResult
+func (stub *MockStub) GetPrivateDataQueryResult(collection, query string) (shim.StateQueryIteratorInterface, error) {
+	if err := checkCollectionName(collection); err != nil {
+		return nil, err
+	}
+	if err := stub.checkPrivateDataInit(); err != nil {
+		return nil, err
+	}
+	if err := stub.checkCollectionRead(collection); err != nil {
+		return nil, err
+	}
+	q, err := ParseRichQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	values := stub.PvtState[collection]
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	entries, err := stub.queryDocuments(q, keys, values)
+	if err != nil {
+		return nil, err
+	}
+	return stub.trackIterator(NewQueryResultIterator(collection+`: `+query, entries), nil,
+		fmt.Sprintf(`GetPrivateDataQueryResult(%q, %s)`, collection, query))
+}
+
+// queryDocuments evaluates query selector against registered models or JSON documents with keys,
+// in keys order or sort order of models
+func (stub *MockStub) queryDocuments(q *RichQuery, keys []string, values map[string][]byte) (
+	[]*QueryResultEntry, error) {
+	_, selectsID := q.Selector[QueryIDField]
+	var entries []*QueryResultEntry
+	for _, key := range keys {
+		// composite keys are excluded before model lookup, so strict models aren't required for them
+		if strings.HasPrefix(key, compositeKeyNamespace) && !stub.queryCompositeKeys && !selectsID {
+			continue
+		}
+
+		value := values[key]
+		model, ok, err := stub.queryModel(key, value)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matched, err := model.Query(q.Selector)
+			if err != nil {
+				return nil, err
+			}
+			if matched {
+				entries = append(entries, &QueryResultEntry{Key: key, Value: stub.copyValue(value), model: model})
+			}
+			continue
+		}
+
+		var doc map[string]interface{}
+		if json.Unmarshal(value, &doc) != nil || doc == nil {
+			continue
+		}
+		if _, ok := doc[QueryIDField]; !ok {
+			doc[QueryIDField] = key
+		}
+
+		matched, err := MatchSelector(doc, q.Selector)
+		if err != nil {
+			return nil, err
+		}
+
+		if matched {
+			entries = append(entries, &QueryResultEntry{Key: key, Value: stub.copyValue(value), Doc: doc})
+		}
+	}
+
+	sortEntries(entries, q.Sort)
+	entries = limitEntries(entries, q.Skip, q.Limit)
+	if len(q.Fields) > 0 {
+		for _, entry := range entries {
+			projectEntry(entry, q.Fields)
+		}
+	}
+	return entries, nil
+}
+
+// limitEntries returns entries after skip, at most limit, zero limit means unlimited
+func limitEntries(entries []*QueryResultEntry, skip, limit int) []*QueryResultEntry {
+	if skip > 0 {
+		if skip >= len(entries) {
+			return nil
+		}
+		entries = entries[skip:]
+	}
+	if limit > 0 && limit < len(entries) {
+		entries = entries[:limit]
+	}
+	return entries
+}
+
+// projectEntry keeps only fields and _id field in entry document, fields can be dot-notation paths.
+// Value of entry, evaluated with model, is projected if it's JSON object
+func projectEntry(entry *QueryResultEntry, fields []string) {
+	doc := entry.Doc
+	if doc == nil {
+		if json.Unmarshal(entry.Value, &doc) != nil || doc == nil {
+			return
+		}
+	}
+
+	projected := map[string]interface{}{QueryIDField: entry.Key}
+	if id, ok := doc[QueryIDField]; ok {
+		projected[QueryIDField] = id
+	}
+	for _, field := range fields {
+		// projected fields are nested objects, array elements are not projected
+		value, ok := lookupField(doc, field, false)
+		if !ok {
+			continue
+		}
+		names := strings.Split(field, `.`)
+		object := projected
+		for _, name := range names[:len(names)-1] {
+			nested, ok := object[name].(map[string]interface{})
+			if !ok {
+				nested = make(map[string]interface{})
+				object[name] = nested
+			}
+			object = nested
+		}
+		object[names[len(names)-1]] = value
+	}
+
+	entry.Value, _ = json.Marshal(projected)
+	if entry.Doc != nil {
+		entry.Doc = projected
+	}
+}
+
+// queryCandidateKeys returns sorted keys of documents to evaluate selector against
+func (stub *MockStub) queryCandidateKeys(selector map[string]interface{}) []string {
+	if keys, ok := stub.docTypeCandidateKeys(selector); ok {
+		return keys
+	}
+
+	keys := make([]string, 0, len(stub.State))
+	for key := range stub.State {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ParseRichQuery parses CouchDB style query string
+func ParseRichQuery(query string) (*RichQuery, error) {
+	q := &RichQuery{}
+	if err := json.Unmarshal([]byte(query), q); err != nil {
+		return nil, fmt.Errorf(`%w: %s`, ErrQueryInvalid, err)
+	}
+
+	if q.Selector == nil {
+		return nil, fmt.Errorf(`%w: selector required`, ErrQueryInvalid)
+	}
+	return q, nil
+}
+
+// MatchSelector checks all top level selector conditions against document properties (implicit $and).
+// Property of nested object can be selected with dot-notation path, i.e. `owner.mspID`,
+// array element with index segment, i.e. `owners.0.mspID`.
+// Documents without selected property are matched only by $exists: false. Combination operators $and, $or, $nor
+// with array of sub selectors and $not with sub selector can be nested at any depth
+func MatchSelector(doc map[string]interface{}, selector map[string]interface{}) (bool, error) {
+	fields := make([]string, 0, len(selector))
+	for field := range selector {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		if isCombinationOperator(field) {
+			matched, err := combine(field, selector[field], func(sub interface{}) (bool, error) {
+				subSelector, ok := sub.(map[string]interface{})
+				if !ok {
+					return false, fmt.Errorf(`%w: %s argument must be a selector`, ErrQueryInvalid, field)
+				}
+				return MatchSelector(doc, subSelector)
+			})
+			if err != nil || !matched {
+				return false, err
+			}
+			continue
+		}
+
+		value, exists := lookupField(doc, field, true)
+		matched, err := validateField(value, exists, selector[field])
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// validateField checks property condition, absent property matches only $exists: false condition
+func validateField(value interface{}, exists bool, condition interface{}) (bool, error) {
+	if exists {
+		return ValidateProperty(value, condition)
+	}
+	return matchAbsent(condition)
+}
+
+// matchAbsent checks condition against absent property: $exists: false matches, $and, $or and $nor
+// combine conditions, other conditions, including $not and $nin, don't match like in CouchDB
+func matchAbsent(condition interface{}) (bool, error) {
+	operators, ok := condition.(map[string]interface{})
+	if !ok || !isOperatorsObject(operators) {
+		return false, nil
+	}
+
+	ops := make([]string, 0, len(operators))
+	for op := range operators {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+
+	for _, op := range ops {
+		var (
+			matched bool
+			err     error
+		)
+		switch op {
+		case `$exists`:
+			var exists bool
+			if exists, err = existsArg(operators[op]); err == nil {
+				matched = !exists
+			}
+		case `$and`, `$or`, `$nor`:
+			matched, err = combine(op, operators[op], matchAbsent)
+		}
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func existsArg(arg interface{}) (bool, error) {
+	exists, ok := arg.(bool)
+	if !ok {
+		return false, fmt.Errorf(`%w: $exists argument must be a boolean`, ErrQueryInvalid)
+	}
+	return exists, nil
+}
+
+// jsonType returns CouchDB type name of JSON value: null, boolean, number, string, array or object
+func jsonType(value interface{}) string {
+	if _, ok := toFloat64(value); ok {
+		return `number`
+	}
+	switch value.(type) {
+	case nil:
+		return `null`
+	case bool:
+		return `boolean`
+	case string:
+		return `string`
+	case []interface{}:
+		return `array`
+	case map[string]interface{}:
+		return `object`
+	}
+	return reflect.TypeOf(value).String()
+}
+
+// ValidateProperty checks present property value against selector condition.
+// Condition can be plain value (equality) or object with $eq, $ne, $in, $nin, $all, $regex, $elemMatch,
+// $exists, $type, $size and $gt, $gte, $lt, $lte operators. Operators of one condition object are combined with $and,
+// conditions can be combined with $and, $or, $nor and $not operators.
+// Numbers in $in, $nin and $all arrays are compared numerically, empty $all matches any value
+func ValidateProperty(value interface{}, condition interface{}) (bool, error) {
+	operators, ok := condition.(map[string]interface{})
+	if !ok || !isOperatorsObject(operators) {
+		return reflect.DeepEqual(value, condition), nil
+	}
+
+	ops := make([]string, 0, len(operators))
+	for op := range operators {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+
+	for _, op := range ops {
+		matched, err := validateOperator(value, op, operators[op])
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+func validateOperator(value interface{}, op string, arg interface{}) (bool, error) {
+	switch op {
+	case `$eq`:
+		return reflect.DeepEqual(value, arg), nil
+
+	case `$ne`:
+		return !reflect.DeepEqual(value, arg), nil
+
+	case `$in`, `$nin`:
+		values, ok := arg.([]interface{})
+		if !ok {
+			return false, fmt.Errorf(`%w: %s argument must be an array`, ErrQueryInvalid, op)
+		}
+		return containsValue(values, value) == (op == `$in`), nil
+
+	case `$all`:
+		values, ok := arg.([]interface{})
+		if !ok {
+			return false, fmt.Errorf(`%w: $all argument must be an array`, ErrQueryInvalid)
+		}
+		if len(values) == 0 {
+			return true, nil
+		}
+		elems, ok := value.([]interface{})
+		if !ok {
+			return false, nil
+		}
+		for _, v := range values {
+			if !containsValue(elems, v) {
+				return false, nil
+			}
+		}
+		return true, nil
+
+	case `$regex`:
+		pattern, ok := arg.(string)
+		if !ok {
+			return false, fmt.Errorf(`%w: $regex argument must be a string`, ErrQueryInvalid)
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return false, fmt.Errorf(`%w: %s`, ErrQueryInvalid, err)
+		}
+		str, ok := value.(string)
+		return ok && re.MatchString(str), nil
+
+	case `$gt`, `$gte`, `$lt`, `$lte`:
+		return compareValues(value, op, arg)
+
+	case `$and`, `$or`, `$nor`, `$not`:
+		return combine(op, arg, func(condition interface{}) (bool, error) {
+			return ValidateProperty(value, condition)
+		})
+
+	case `$exists`:
+		return existsArg(arg)
+
+	case `$type`:
+		typeName, ok := arg.(string)
+		if !ok {
+			return false, fmt.Errorf(`%w: $type argument must be a string`, ErrQueryInvalid)
+		}
+		switch typeName {
+		case `null`, `boolean`, `number`, `string`, `array`, `object`:
+		default:
+			return false, fmt.Errorf(`%w: $type argument %s is not a JSON type`, ErrQueryInvalid, typeName)
+		}
+		return jsonType(value) == typeName, nil
+
+	case `$size`:
+		size, ok := toFloat64(arg)
+		if !ok || size < 0 || size != float64(int(size)) {
+			return false, fmt.Errorf(`%w: $size argument must be a non negative integer`, ErrQueryInvalid)
+		}
+		elems, ok := value.([]interface{})
+		return ok && len(elems) == int(size), nil
+
+	case `$elemMatch`:
+		elems, ok := value.([]interface{})
+		if !ok {
+			return false, nil
+		}
+		for _, elem := range elems {
+			matched, err := matchElem(elem, arg)
+			if err != nil {
+				return false, err
+			}
+			if matched {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	return false, fmt.Errorf(`%w: %s`, ErrSelectorOperatorNotSupported, op)
+}
+
+// containsValue checks values contain value, numbers are compared numerically
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if equalValues(value, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// equalValues checks values are equal, numbers of any type are compared as float64
+func equalValues(a, b interface{}) bool {
+	if x, ok := toFloat64(a); ok {
+		y, ok := toFloat64(b)
+		return ok && x == y
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// compareValues compares property value with operator argument: numbers numerically, strings lexically.
+// JSON numbers are decoded as float64, so int and float values of any width are compared as float64.
+// Value of other type than argument is ErrSelectorTypeMismatch
+func compareValues(value interface{}, op string, arg interface{}) (bool, error) {
+	var cmp int
+	if limit, ok := toFloat64(arg); ok {
+		number, ok := toFloat64(value)
+		if !ok {
+			return false, fmt.Errorf(`%w: %s %v, property %T`, ErrSelectorTypeMismatch, op, arg, value)
+		}
+		switch {
+		case number < limit:
+			cmp = -1
+		case number > limit:
+			cmp = 1
+		}
+	} else if limit, ok := arg.(string); ok {
+		str, ok := value.(string)
+		if !ok {
+			return false, fmt.Errorf(`%w: %s %q, property %T`, ErrSelectorTypeMismatch, op, arg, value)
+		}
+		cmp = strings.Compare(str, limit)
+	} else {
+		return false, fmt.Errorf(`%w: %s argument must be a number or a string`, ErrQueryInvalid, op)
+	}
+
+	switch op {
+	case `$gt`:
+		return cmp > 0, nil
+	case `$gte`:
+		return cmp >= 0, nil
+	case `$lt`:
+		return cmp < 0, nil
+	default:
+		return cmp <= 0, nil
+	}
+}
+
+// lessDocs reports whether document is ordered before other document by sort fields
+func lessDocs(doc, other map[string]interface{}, fields []map[string]string) bool {
+	for _, s := range fields {
+		for field, direction := range s {
+			a, aExists := lookupField(doc, field, true)
+			b, bExists := lookupField(other, field, true)
+			if cmp := collateValues(a, aExists, b, bExists); cmp != 0 {
+				return cmp < 0 != (direction == selector.SortDesc)
+			}
+		}
+	}
+	return false
+}
+
+// collateValues compares JSON values in CouchDB collation order of types: null, boolean, number, string,
+// array, object. Absent values are ordered first, arrays and objects are not compared by content
+func collateValues(a interface{}, aExists bool, b interface{}, bExists bool) int {
+	rank := func(value interface{}, exists bool) int {
+		if !exists {
+			return -1
+		}
+		switch jsonType(value) {
+		case `null`:
+			return 0
+		case `boolean`:
+			return 1
+		case `number`:
+			return 2
+		case `string`:
+			return 3
+		case `array`:
+			return 4
+		}
+		return 5
+	}
+
+	aRank, bRank := rank(a, aExists), rank(b, bExists)
+	switch {
+	case aRank != bRank:
+		return aRank - bRank
+	case aRank == 1:
+		if a == b {
+			return 0
+		}
+		if b.(bool) {
+			return -1
+		}
+		return 1
+	case aRank == 2:
+		af, _ := toFloat64(a)
+		bf, _ := toFloat64(b)
+		switch {
+		case af < bf:
+			return -1
+		case af > bf:
+			return 1
+		}
+	case aRank == 3:
+		return strings.Compare(a.(string), b.(string))
+	}
+	return 0
+}
+
+func toFloat64(value interface{}) (float64, bool) {
+	if number, ok := value.(json.Number); ok {
+		f, err := number.Float64()
+		return f, err == nil
+	}
+
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	}
+	return 0, false
+}
+
+// lookupField returns document property by field name or dot-notation path of nested objects.
+// If arrayIndexes is set, path segment after array is element index, like in CouchDB: `owners.0.mspID`.
+// Path through missing property, out of range index or scalar value is not found
+func lookupField(doc map[string]interface{}, field string, arrayIndexes bool) (interface{}, bool) {
+	var value interface{} = doc
+	for _, name := range strings.Split(field, `.`) {
+		if elems, ok := value.([]interface{}); ok && arrayIndexes {
+			i, err := strconv.Atoi(name)
+			if err != nil || i < 0 || i >= len(elems) || name != strconv.Itoa(i) {
+				return nil, false
+			}
+			value = elems[i]
+			continue
+		}
+
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = object[name]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// combine evaluates combination operator: $and, $or, $nor over array of sub selectors or conditions,
+// $not over single one. Empty $and and $nor match everything, empty $or matches nothing
+func combine(op string, arg interface{}, match func(interface{}) (bool, error)) (bool, error) {
+	if op == `$not` {
+		matched, err := match(arg)
+		return err == nil && !matched, err
+	}
+
+	subs, ok := arg.([]interface{})
+	if !ok {
+		return false, fmt.Errorf(`%w: %s argument must be an array`, ErrQueryInvalid, op)
+	}
+	for _, sub := range subs {
+		matched, err := match(sub)
+		switch {
+		case err != nil:
+			return false, err
+		case op == `$and` && !matched, op == `$nor` && matched:
+			return false, nil
+		case op == `$or` && matched:
+			return true, nil
+		}
+	}
+	return op != `$or`, nil
+}
+
+func isCombinationOperator(op string) bool {
+	switch op {
+	case `$and`, `$or`, `$nor`, `$not`:
+		return true
+	}
+	return false
+}
+
+// matchElem matches array element against $elemMatch argument,
+// which can be field selector for object elements or operators object
+func matchElem(elem interface{}, arg interface{}) (bool, error) {
+	if selector, ok := arg.(map[string]interface{}); ok && !isOperatorsObject(selector) {
+		doc, ok := elem.(map[string]interface{})
+		if !ok {
+			return false, nil
+		}
+		return MatchSelector(doc, selector)
+	}
+	return ValidateProperty(elem, arg)
+}
+
+func isOperatorsObject(m map[string]interface{}) bool {
+	if len(m) == 0 {
+		return false
+	}
+	for key := range m {
+		if len(key) == 0 || key[0] != '$' {
+			return false
+		}
+	}
+	return true
+}
+
+// NewMockStateQueryResultIterator creates iterator over query result items
+func NewMockStateQueryResultIterator(items []*queryresult.KV) *MockStateQueryResultIterator {
+	entries := make([]*QueryResultEntry, len(items))
+	for i, item := range items {
+		if item != nil {
+			entries[i] = &QueryResultEntry{Key: item.Key, Value: item.Value}
+		}
+	}
+	return NewQueryResultIterator(``, entries)
+}
+
+// NewQueryResultIterator creates iterator over entries of query, query is used in errors of malformed entries
+func NewQueryResultIterator(query string, entries []*QueryResultEntry) *MockStateQueryResultIterator {
+	return &MockStateQueryResultIterator{Query: query, entries: entries}
+}
+
+// HasNext returns true if the range query iterator contains additional keys and values
+func (iter *MockStateQueryResultIterator) HasNext() bool {
+	return !iter.Closed && iter.pos < len(iter.entries)
+}
+
+// Next returns the next key and value in the query result iterator
+func (iter *MockStateQueryResultIterator) Next() (*queryresult.KV, error) {
+	if iter.Closed {
+		return nil, fmt.Errorf(`MockStateQueryResultIterator.Next() called after Close(): %w`, ErrIteratorClosed)
+	}
+
+	if iter.pos >= len(iter.entries) {
+		return nil, fmt.Errorf(`MockStateQueryResultIterator.Next() called when it does not HaveNext(): %w`,
+			ErrIteratorExhausted)
+	}
+
+	index, entry := iter.pos, iter.entries[iter.pos]
+	iter.pos++
+
+	switch {
+	case entry == nil:
+		return nil, &QueryResultError{Query: iter.Query, Index: index, Reason: `nil entry`}
+	case entry.Key == ``:
+		return nil, &QueryResultError{Query: iter.Query, Index: index, Reason: `empty key`}
+	case entry.Value == nil:
+		return nil, &QueryResultError{Query: iter.Query, Index: index, Reason: `nil value of key ` + entry.Key}
+	}
+	return &queryresult.KV{Key: entry.Key, Value: entry.Value}, nil
+}
+
+// Close closes the query result iterator
+func (iter *MockStateQueryResultIterator) Close() error {
+	if iter.Closed {
+		return fmt.Errorf(`MockStateQueryResultIterator.Close() called after Close(): %w`, ErrIteratorClosed)
+	}
+
+	iter.release()
+	iter.Closed = true
+	return nil
+}
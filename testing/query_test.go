@@ -0,0 +1,577 @@
+package testing_test
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+
+	"github.com/s7techlab/cckit/state"
+	testcc "github.com/s7techlab/cckit/testing"
+)
+
+var docTypes = []string{`car`, `owner`, `dealer`, `insurance`, `service`}
+
+func docs(count int, seed int64) map[string][]byte {
+	rnd := rand.New(rand.NewSource(seed))
+	state := make(map[string][]byte, count)
+	for i := 0; i < count; i++ {
+		state[fmt.Sprintf(`DOC%06d`, i)] = []byte(fmt.Sprintf(`{"docType":"%s","n":%d,"tags":["t%d"]}`,
+			docTypes[rnd.Intn(len(docTypes))], rnd.Intn(10), rnd.Intn(3)))
+	}
+	return state
+}
+
+func queryAll(stub *testcc.MockStub, query string) []*queryresult.KV {
+	iter, err := stub.GetQueryResult(query)
+	Expect(err).NotTo(HaveOccurred())
+
+	return readAll(iter)
+}
+
+func queryKeys(stub *testcc.MockStub, query string) []string {
+	var keys []string
+	for _, kv := range queryAll(stub, query) {
+		keys = append(keys, kv.Key)
+	}
+	return keys
+}
+
+func readAll(iter shim.StateQueryIteratorInterface) []*queryresult.KV {
+	var items []*queryresult.KV
+	Expect(state.IterateKV(iter, func(kv *queryresult.KV) (bool, error) {
+		items = append(items, kv)
+		return false, nil
+	})).To(Succeed())
+	return items
+}
+
+var _ = Describe(`Rich query`, func() {
+
+	queries := []string{
+		`{"selector":{"docType":"car"}}`,
+		`{"selector":{"docType":{"$eq":"owner"},"n":{"$in":[1,2,3]}}}`,
+		`{"selector":{"docType":"dealer","tags":{"$elemMatch":{"$eq":"t1"}}}}`,
+		`{"selector":{"docType":{"$regex":"^s"}}}`,
+		`{"selector":{"docType":"unknown"}}`,
+		`{"selector":{"n":5}}`,
+	}
+
+	It("Allow to query documents with selector", func() {
+		stub := testcc.NewMockStub(`docs`, nil)
+		Expect(stub.SeedState(map[string][]byte{
+			`a`: []byte(`{"docType":"car","make":"Audi","tags":["red","fast"]}`),
+			`b`: []byte(`{"docType":"car","make":"BMW","tags":["blue"]}`),
+			`c`: []byte(`{"docType":"owner","make":"Audi"}`),
+			`d`: []byte(`not json`),
+		})).To(Succeed())
+
+		Expect(queryAll(stub, `{"selector":{"docType":"car"}}`)).To(HaveLen(2))
+		Expect(queryAll(stub, `{"selector":{"make":{"$regex":"^A"}}}`)).To(HaveLen(2))
+		Expect(queryAll(stub, `{"selector":{"make":{"$in":["BMW","Opel"]}}}`)[0].Key).To(Equal(`b`))
+		Expect(queryAll(stub, `{"selector":{"tags":{"$elemMatch":{"$eq":"fast"}}}}`)[0].Key).To(Equal(`a`))
+		Expect(queryAll(stub, `{"selector":{"docType":"car","make":"Opel"}}`)).To(BeEmpty())
+
+		_, err := stub.GetQueryResult(`{"selector":{"make":{"$mod":[2,0]}}}`)
+		Expect(err).To(MatchError(ContainSubstring(testcc.ErrSelectorOperatorNotSupported.Error())))
+
+		_, err = stub.GetQueryResult(`{}`)
+		Expect(err).To(MatchError(ContainSubstring(testcc.ErrQueryInvalid.Error())))
+	})
+
+	It("Allow to query numeric ranges of integer and decimal values", func() {
+		stub := testcc.NewMockStub(`docs`, nil)
+		Expect(stub.SeedState(map[string][]byte{
+			`a`: []byte(`{"amount":50}`),
+			`b`: []byte(`{"amount":100}`),
+			`c`: []byte(`{"amount":100.5}`),
+		})).To(Succeed())
+
+		keys := func(query string) []string {
+			var keys []string
+			for _, kv := range queryAll(stub, query) {
+				keys = append(keys, kv.Key)
+			}
+			return keys
+		}
+
+		Expect(keys(`{"selector":{"amount":{"$gt":100}}}`)).To(Equal([]string{`c`}))
+		Expect(keys(`{"selector":{"amount":{"$gte":100}}}`)).To(Equal([]string{`b`, `c`}))
+		Expect(keys(`{"selector":{"amount":{"$lt":100.5}}}`)).To(Equal([]string{`a`, `b`}))
+		Expect(keys(`{"selector":{"amount":{"$lte":50}}}`)).To(Equal([]string{`a`}))
+		Expect(keys(`{"selector":{"amount":{"$gt":50.25,"$lt":100.75}}}`)).To(Equal([]string{`b`, `c`}))
+
+		// int values and arguments, i.e. of built selector, are compared with decoded JSON numbers
+		Expect(testcc.ValidateProperty(float64(100), map[string]interface{}{`$gte`: 100})).To(BeTrue())
+		Expect(testcc.ValidateProperty(int32(7), map[string]interface{}{`$lt`: 7.5})).To(BeTrue())
+		Expect(testcc.ValidateProperty(uint(7), map[string]interface{}{`$gt`: int64(7)})).To(BeFalse())
+
+		_, err := stub.GetQueryResult(`{"selector":{"amount":{"$gt":true}}}`)
+		Expect(err).To(MatchError(ContainSubstring(testcc.ErrQueryInvalid.Error())))
+	})
+
+	It("Allow to query string ranges and disallow type mismatch with error", func() {
+		stub := testcc.NewMockStub(`docs`, nil)
+		Expect(stub.SeedState(map[string][]byte{
+			`a`: []byte(`{"name":"Audi","level":1}`),
+			`b`: []byte(`{"name":"BMW","level":2}`),
+			`c`: []byte(`{"name":"Citroen","level":4.5}`),
+			`d`: []byte(`{"name":"Dacia","level":5}`),
+		})).To(Succeed())
+
+		keys := func(query string) []string {
+			var keys []string
+			for _, kv := range queryAll(stub, query) {
+				keys = append(keys, kv.Key)
+			}
+			return keys
+		}
+
+		Expect(keys(`{"selector":{"name":{"$gte":"B","$lt":"D"}}}`)).To(Equal([]string{`b`, `c`}))
+		Expect(keys(`{"selector":{"name":{"$gt":"BMW"}}}`)).To(Equal([]string{`c`, `d`}))
+		Expect(keys(`{"selector":{"level":{"$gte":2,"$lt":5}}}`)).To(Equal([]string{`b`, `c`}))
+		Expect(keys(`{"selector":{"level":{"$gte":2},"name":{"$lte":"C"}}}`)).To(Equal([]string{`b`}))
+
+		_, err := stub.GetQueryResult(`{"selector":{"name":{"$gt":1}}}`)
+		Expect(errors.Is(err, testcc.ErrSelectorTypeMismatch)).To(BeTrue())
+
+		_, err = stub.GetQueryResult(`{"selector":{"level":{"$lt":"5"}}}`)
+		Expect(errors.Is(err, testcc.ErrSelectorTypeMismatch)).To(BeTrue())
+	})
+
+	It("Allow to query arbitrary JSON documents by nested properties", func() {
+		stub := testcc.NewMockStub(`docs`, nil)
+		stub.MockTransactionStart(`put`)
+		for key, doc := range map[string]string{
+			`a`: `{"asset":{"id":"A1","owner":{"mspID":"Org1MSP","name":"alice"}},"amount":10}`,
+			`b`: `{"asset":{"id":"B1","owner":{"mspID":"Org2MSP","name":"bob"}},"amount":20}`,
+			`c`: `{"asset":{"id":"C1"},"amount":30}`,
+			`d`: `{"asset":"D1","amount":40}`,
+		} {
+			Expect(stub.PutState(key, []byte(doc))).To(Succeed())
+		}
+		stub.MockTransactionEnd(`put`)
+
+		Expect(queryKeys(stub, `{"selector":{"amount":{"$gte":20}}}`)).To(Equal([]string{`b`, `c`, `d`}))
+		Expect(queryKeys(stub, `{"selector":{"asset.id":"C1"}}`)).To(Equal([]string{`c`}))
+		Expect(queryKeys(stub, `{"selector":{"asset.owner.mspID":"Org1MSP"}}`)).To(Equal([]string{`a`}))
+		Expect(queryKeys(stub, `{"selector":{"asset.owner.name":{"$regex":"^b"},"amount":20}}`)).To(
+			Equal([]string{`b`}))
+
+		// missing intermediate object and not object value on path are not matched
+		Expect(queryKeys(stub, `{"selector":{"asset.owner.mspID":{"$gt":""}}}`)).To(Equal([]string{`a`, `b`}))
+		Expect(queryKeys(stub, `{"selector":{"asset.id.value":"D1"}}`)).To(BeEmpty())
+	})
+
+	It("Allow to query nested properties through arrays with element index", func() {
+		stub := testcc.NewMockStub(`docs`, nil)
+		Expect(stub.SeedState(map[string][]byte{
+			`a`: []byte(`{"asset":{"owners":[{"org":{"mspID":"Org1MSP"}},{"org":{"mspID":"Org2MSP"}}]}}`),
+			`b`: []byte(`{"asset":{"owners":[{"org":{"mspID":"Org2MSP"}}]}}`),
+			`c`: []byte(`{"asset":{"owners":[["Org1MSP"]]}}`),
+			`d`: []byte(`{"asset":{"owners":{"0":{"org":{"mspID":"Org1MSP"}}}}}`),
+		})).To(Succeed())
+
+		Expect(queryKeys(stub, `{"selector":{"asset.owners.0.org.mspID":"Org1MSP"}}`)).To(Equal([]string{`a`, `d`}))
+		Expect(queryKeys(stub, `{"selector":{"asset.owners.1.org.mspID":"Org2MSP"}}`)).To(Equal([]string{`a`}))
+		Expect(queryKeys(stub, `{"selector":{"asset.owners.0.0":"Org1MSP"}}`)).To(Equal([]string{`c`}))
+
+		// out of range, negative or not numeric index is not matched
+		Expect(queryKeys(stub, `{"selector":{"asset.owners.2.org.mspID":{"$exists":true}}}`)).To(BeEmpty())
+		Expect(queryKeys(stub, `{"selector":{"asset.owners.-1.org.mspID":{"$exists":true}}}`)).To(BeEmpty())
+		Expect(queryKeys(stub, `{"selector":{"asset.owners.01.org.mspID":{"$exists":true}}}`)).To(BeEmpty())
+		Expect(queryKeys(stub, `{"selector":{"asset.owners.1.org.mspID":{"$exists":false}}}`)).To(
+			Equal([]string{`b`, `c`, `d`}))
+	})
+
+	It("Allow to query nested properties of array elements with $elemMatch", func() {
+		stub := testcc.NewMockStub(`docs`, nil)
+		Expect(stub.SeedState(map[string][]byte{
+			`a`: []byte(`{"asset":{"owners":[{"org":{"mspID":"Org1MSP"},"share":60},{"org":{"mspID":"Org2MSP"},"share":40}]}}`),
+			`b`: []byte(`{"asset":{"owners":[{"org":{"mspID":"Org2MSP"},"share":100}]}}`),
+			`c`: []byte(`{"asset":{"owners":[]}}`),
+			`d`: []byte(`{"asset":null}`),
+			`e`: []byte(`{"asset":{"owners":{"org":{"mspID":"Org1MSP"}}}}`),
+		})).To(Succeed())
+
+		Expect(queryKeys(stub, `{"selector":{"asset.owners":{"$elemMatch":{"org.mspID":"Org1MSP"}}}}`)).To(
+			Equal([]string{`a`}))
+		Expect(queryKeys(stub, `{"selector":{"asset.owners":{"$elemMatch":{"org.mspID":"Org2MSP","share":{"$gt":50}}}}}`)).To(
+			Equal([]string{`b`}))
+
+		// array is not walked without $elemMatch, null intermediate value is not matched
+		Expect(queryKeys(stub, `{"selector":{"asset.owners.org.mspID":"Org1MSP"}}`)).To(Equal([]string{`e`}))
+		Expect(queryKeys(stub, `{"selector":{"asset.owners":{"$elemMatch":{"share":{"$gte":0}}}}}`)).To(
+			Equal([]string{`a`, `b`}))
+	})
+
+	It("Allow to limit, skip and project query results", func() {
+		stub := testcc.NewMockStub(`docs`, nil)
+		Expect(stub.SeedState(map[string][]byte{
+			`a`: []byte(`{"docType":"car","title":"A","owner":{"name":"alice","mspID":"Org1MSP"}}`),
+			`b`: []byte(`{"docType":"car","title":"B","owner":{"name":"bob","mspID":"Org2MSP"}}`),
+			`c`: []byte(`{"docType":"car","title":"C"}`),
+			`d`: []byte(`{"docType":"owner","title":"D"}`),
+		})).To(Succeed())
+
+		Expect(queryKeys(stub, `{"selector":{"docType":"car"},"limit":2}`)).To(Equal([]string{`a`, `b`}))
+		Expect(queryKeys(stub, `{"selector":{"docType":"car"},"skip":1,"limit":1}`)).To(Equal([]string{`b`}))
+		Expect(queryKeys(stub, `{"selector":{"docType":"car"},"skip":1,"limit":0}`)).To(Equal([]string{`b`, `c`}))
+		Expect(queryKeys(stub, `{"selector":{"docType":"car"},"skip":3}`)).To(BeEmpty())
+
+		var values []string
+		for _, kv := range queryAll(stub, `{"selector":{"docType":"car"},"fields":["title","owner.name"],"limit":2}`) {
+			values = append(values, string(kv.Value))
+		}
+		Expect(values).To(Equal([]string{
+			`{"_id":"a","owner":{"name":"alice"},"title":"A"}`,
+			`{"_id":"b","owner":{"name":"bob"},"title":"B"}`,
+		}))
+	})
+
+	It("Allow to sort query results of generic documents by fields", func() {
+		stub := testcc.NewMockStub(`docs`, nil)
+		Expect(stub.SeedState(map[string][]byte{
+			`a`: []byte(`{"docType":"car","year":2018,"make":"bmw"}`),
+			`b`: []byte(`{"docType":"car","year":2016,"make":"audi"}`),
+			`c`: []byte(`{"docType":"car","year":2018,"make":"audi"}`),
+			`d`: []byte(`{"docType":"car","make":"fiat"}`),
+		})).To(Succeed())
+
+		Expect(queryKeys(stub, `{"selector":{"docType":"car"},"sort":[{"year":"asc"}]}`)).To(
+			Equal([]string{`d`, `b`, `a`, `c`}))
+		Expect(queryKeys(stub, `{"selector":{"docType":"car"},"sort":[{"year":"desc"},{"make":"asc"}]}`)).To(
+			Equal([]string{`c`, `a`, `b`, `d`}))
+		Expect(queryKeys(stub, `{"selector":{"docType":"car"},"sort":[{"make":"desc"}],"limit":2}`)).To(
+			Equal([]string{`d`, `a`}))
+	})
+
+	It("Allow to query by property presence, type and array size", func() {
+		stub := testcc.NewMockStub(`docs`, nil)
+		Expect(stub.SeedState(map[string][]byte{
+			`a`: []byte(`{"title":"a","deletedAt":"2020-01-01","receivers":["r1","r2"]}`),
+			`b`: []byte(`{"title":"b","deletedAt":null,"receivers":[]}`),
+			`c`: []byte(`{"title":"c","receivers":"r1","meta":{"deletedAt":1}}`),
+			`d`: []byte(`{"title":4,"receivers":[{"id":"r1"},{"id":"r2"}],"meta":null}`),
+		})).To(Succeed())
+
+		// null property is present, missing one is absent
+		Expect(queryKeys(stub, `{"selector":{"deletedAt":{"$exists":true}}}`)).To(Equal([]string{`a`, `b`}))
+		Expect(queryKeys(stub, `{"selector":{"deletedAt":{"$exists":false}}}`)).To(Equal([]string{`c`, `d`}))
+		Expect(queryKeys(stub, `{"selector":{"deletedAt":null}}`)).To(Equal([]string{`b`}))
+		Expect(queryKeys(stub, `{"selector":{"meta.deletedAt":{"$exists":false}}}`)).To(Equal([]string{`a`, `b`, `d`}))
+		Expect(queryKeys(stub, `{"selector":{"deletedAt":{"$or":[{"$exists":false},{"$type":"null"}]}}}`)).To(
+			Equal([]string{`b`, `c`, `d`}))
+		// negated field condition doesn't match absent property
+		Expect(queryKeys(stub, `{"selector":{"deletedAt":{"$not":{"$type":"string"}}}}`)).To(Equal([]string{`b`}))
+
+		Expect(queryKeys(stub, `{"selector":{"deletedAt":{"$type":"null"}}}`)).To(Equal([]string{`b`}))
+		Expect(queryKeys(stub, `{"selector":{"title":{"$type":"number"}}}`)).To(Equal([]string{`d`}))
+		Expect(queryKeys(stub, `{"selector":{"receivers":{"$type":"array"}}}`)).To(Equal([]string{`a`, `b`, `d`}))
+		Expect(queryKeys(stub, `{"selector":{"meta":{"$type":"object"}}}`)).To(Equal([]string{`c`}))
+
+		Expect(queryKeys(stub, `{"selector":{"receivers":{"$size":2}}}`)).To(Equal([]string{`a`, `d`}))
+		Expect(queryKeys(stub, `{"selector":{"receivers":{"$size":0}}}`)).To(Equal([]string{`b`}))
+
+		for _, query := range []string{
+			`{"selector":{"deletedAt":{"$exists":"yes"}}}`,
+			`{"selector":{"deletedAt":{"$exists":1}}}`,
+			`{"selector":{"title":{"$type":"integer"}}}`,
+			`{"selector":{"receivers":{"$size":1.5}}}`,
+		} {
+			_, err := stub.GetQueryResult(query)
+			Expect(errors.Is(err, testcc.ErrQueryInvalid)).To(BeTrue(), query)
+		}
+	})
+
+	It("Allow to exclude documents with $ne and negated operators", func() {
+		stub := testcc.NewMockStub(`docs`, nil)
+		Expect(stub.SeedState(map[string][]byte{
+			`a`: []byte(`{"status":"active","level":1,"verified":true}`),
+			`b`: []byte(`{"status":"deleted","level":2,"verified":false}`),
+			`c`: []byte(`{"status":"archived","level":3,"verified":true}`),
+			`d`: []byte(`{"level":4}`),
+		})).To(Succeed())
+
+		// document without property is not matched by $ne, like in CouchDB
+		Expect(queryKeys(stub, `{"selector":{"status":{"$ne":"deleted"}}}`)).To(Equal([]string{`a`, `c`}))
+		Expect(queryKeys(stub, `{"selector":{"level":{"$ne":2}}}`)).To(Equal([]string{`a`, `c`, `d`}))
+		Expect(queryKeys(stub, `{"selector":{"verified":{"$ne":true}}}`)).To(Equal([]string{`b`}))
+
+		Expect(queryKeys(stub, `{"selector":{"status":{"$not":{"$in":["active","deleted"]}}}}`)).To(
+			Equal([]string{`c`}))
+		Expect(queryKeys(stub, `{"selector":{"level":{"$not":{"$gte":2,"$lt":4}}}}`)).To(Equal([]string{`a`, `d`}))
+		Expect(queryKeys(stub, `{"selector":{"verified":{"$not":{"$eq":false}}}}`)).To(Equal([]string{`a`, `c`}))
+		Expect(queryKeys(stub, `{"selector":{"status":{"$not":{"$regex":"^a"}},"level":{"$ne":1}}}`)).To(
+			Equal([]string{`b`}))
+
+		_, err := stub.GetQueryResult(`{"selector":{"level":{"$not":{"$mod":[2,0]}}}}`)
+		Expect(errors.Is(err, testcc.ErrSelectorOperatorNotSupported)).To(BeTrue())
+	})
+
+	It("Allow to query by allowed, not allowed and all contained values", func() {
+		stub := testcc.NewMockStub(`docs`, nil)
+		Expect(stub.SeedState(map[string][]byte{
+			`a`: []byte(`{"owner":"alice","level":1,"tags":["red","green"],"codes":[1,2,3]}`),
+			`b`: []byte(`{"owner":"bob","level":2.5,"tags":["red"],"codes":[2]}`),
+			`c`: []byte(`{"owner":"carol","level":3,"tags":"red"}`),
+			`d`: []byte(`{"level":4,"tags":[]}`),
+		})).To(Succeed())
+
+		Expect(queryKeys(stub, `{"selector":{"level":{"$in":[1,2.5]}}}`)).To(Equal([]string{`a`, `b`}))
+		Expect(queryKeys(stub, `{"selector":{"level":{"$nin":[1,2.5]}}}`)).To(Equal([]string{`c`, `d`}))
+		// document without property is not matched by $nin, like in CouchDB
+		Expect(queryKeys(stub, `{"selector":{"owner":{"$nin":["alice","bob"]}}}`)).To(Equal([]string{`c`}))
+		Expect(queryKeys(stub, `{"selector":{"owner":{"$nin":[]}}}`)).To(Equal([]string{`a`, `b`, `c`}))
+
+		Expect(queryKeys(stub, `{"selector":{"tags":{"$all":["red"]}}}`)).To(Equal([]string{`a`, `b`}))
+		Expect(queryKeys(stub, `{"selector":{"tags":{"$all":["green","red"]}}}`)).To(Equal([]string{`a`}))
+		Expect(queryKeys(stub, `{"selector":{"codes":{"$all":[3,1]}}}`)).To(Equal([]string{`a`}))
+		Expect(queryKeys(stub, `{"selector":{"codes":{"$all":[2.0]}}}`)).To(Equal([]string{`a`, `b`}))
+		// empty $all matches any present property
+		Expect(queryKeys(stub, `{"selector":{"tags":{"$all":[]}}}`)).To(Equal([]string{`a`, `b`, `c`, `d`}))
+		Expect(queryKeys(stub, `{"selector":{"codes":{"$all":[]}}}`)).To(Equal([]string{`a`, `b`}))
+
+		// numbers of different types are compared numerically
+		Expect(testcc.ValidateProperty(float64(2), map[string]interface{}{`$in`: []interface{}{1, 2}})).To(BeTrue())
+		Expect(testcc.ValidateProperty([]interface{}{float64(1)},
+			map[string]interface{}{`$all`: []interface{}{int64(1)}})).To(BeTrue())
+
+		for _, query := range []string{
+			`{"selector":{"owner":{"$nin":"alice"}}}`,
+			`{"selector":{"tags":{"$all":"red"}}}`,
+		} {
+			_, err := stub.GetQueryResult(query)
+			Expect(errors.Is(err, testcc.ErrQueryInvalid)).To(BeTrue(), query)
+		}
+	})
+
+	It("Allow to get identical results with and without doc type index", func() {
+		plain := testcc.NewMockStub(`docs`, nil)
+		indexed := testcc.NewMockStub(`docs`, nil, testcc.WithDocTypeIndex(``, nil))
+
+		state := docs(1000, 1)
+		for _, stub := range []*testcc.MockStub{plain, indexed} {
+			Expect(stub.SeedState(state)).To(Succeed())
+			// overwrite with another doc type and delete
+			Expect(stub.SeedState(docs(300, 2))).To(Succeed())
+			stub.MockTransactionStart(`delete`)
+			for i := 0; i < 1000; i += 7 {
+				Expect(stub.DelState(fmt.Sprintf(`DOC%06d`, i))).To(Succeed())
+			}
+			stub.MockTransactionEnd(`delete`)
+		}
+
+		for _, q := range queries {
+			Expect(queryAll(indexed, q)).To(Equal(queryAll(plain, q)), q)
+		}
+
+		Expect(indexed.DocTypeKeys(`car`)).To(Equal(
+			func() []string {
+				var keys []string
+				for _, kv := range queryAll(plain, `{"selector":{"docType":"car"}}`) {
+					keys = append(keys, kv.Key)
+				}
+				return keys
+			}()))
+	})
+
+	It("Allow to invalidate doc type index on overwrite and delete", func() {
+		stub := testcc.NewMockStub(`docs`, nil, testcc.WithDocTypeIndex(``, nil))
+		Expect(stub.SeedState(map[string][]byte{`a`: []byte(`{"docType":"car"}`)})).To(Succeed())
+		Expect(stub.DocTypeKeys(`car`)).To(Equal([]string{`a`}))
+
+		Expect(stub.SeedState(map[string][]byte{`a`: []byte(`{"docType":"owner"}`)})).To(Succeed())
+		Expect(stub.DocTypeKeys(`car`)).To(BeEmpty())
+		Expect(stub.DocTypeKeys(`owner`)).To(Equal([]string{`a`}))
+
+		Expect(stub.DelState(`a`)).To(Succeed())
+		Expect(stub.DocTypeKeys(`owner`)).To(BeEmpty())
+		Expect(queryAll(stub, `{"selector":{"docType":"owner"}}`)).To(BeEmpty())
+	})
+})
+
+func benchmarkDocTypeQuery(b *testing.B, opts ...testcc.MockStubOpt) {
+	stub := testcc.NewMockStub(`docs`, nil, opts...)
+	if err := stub.SeedState(docs(50000, 1)); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		iter, err := stub.GetQueryResult(`{"selector":{"docType":"car","n":{"$in":[1,2]}}}`)
+		if err != nil {
+			b.Fatal(err)
+		}
+		_ = iter.Close()
+	}
+}
+
+func BenchmarkQueryWithoutDocTypeIndex(b *testing.B) {
+	benchmarkDocTypeQuery(b)
+}
+
+func BenchmarkQueryWithDocTypeIndex(b *testing.B) {
+	benchmarkDocTypeQuery(b, testcc.WithDocTypeIndex(``, nil))
+}
+
+var _ = Describe(`Rich query combination operators`, func() {
+
+	var stub *testcc.MockStub
+
+	BeforeEach(func() {
+		stub = testcc.NewMockStub(`docs`, nil)
+		Expect(stub.SeedState(map[string][]byte{
+			`a`: []byte(`{"docType":"affiliate","level":1,"tags":["gold"]}`),
+			`b`: []byte(`{"docType":"affiliate","level":3}`),
+			`c`: []byte(`{"docType":"transaction","level":2,"tags":["gold","fast"]}`),
+			`d`: []byte(`{"docType":"transaction","level":5}`),
+			`e`: []byte(`{"docType":"report"}`),
+		})).To(Succeed())
+	})
+
+	selectors := []struct {
+		selector string
+		keys     []string
+	}{
+		{`{"$or":[{"docType":"affiliate"},{"docType":"transaction"}]}`, []string{`a`, `b`, `c`, `d`}},
+		{`{"$and":[{"docType":"affiliate"},{"level":{"$gt":1}}]}`, []string{`b`}},
+		{`{"$nor":[{"docType":"affiliate"},{"docType":"transaction"}]}`, []string{`e`}},
+		// document without property matches negated condition on it
+		{`{"$not":{"level":{"$gte":2}}}`, []string{`a`, `e`}},
+		{`{"$and":[{"$or":[{"level":1},{"level":5}]},{"docType":"transaction"}]}`, []string{`d`}},
+		{`{"$or":[{"$and":[{"docType":"affiliate"},{"level":3}]},{"$and":[{"docType":"transaction"},{"level":2}]}]}`,
+			[]string{`b`, `c`}},
+		{`{"docType":"transaction","$or":[{"level":{"$lt":3}},{"tags":{"$elemMatch":{"$eq":"fast"}}}]}`, []string{`c`}},
+		{`{"$not":{"$or":[{"docType":"report"},{"level":{"$gt":2}}]}}`, []string{`a`, `c`}},
+		{`{"$or":[{"$not":{"docType":"affiliate"}},{"$nor":[{"level":1}]}]}`, []string{`b`, `c`, `d`, `e`}},
+		{`{"level":{"$or":[{"$lt":2},{"$gt":4}]}}`, []string{`a`, `d`}},
+		{`{"level":{"$not":{"$in":[1,2]}}}`, []string{`b`, `d`}},
+		{`{"level":{"$and":[{"$gt":1},{"$not":{"$eq":3}}]}}`, []string{`c`, `d`}},
+		{`{"$and":[{"$and":[{"$or":[{"docType":"affiliate"},{"docType":"report"}]}]},{"$not":{"level":1}}]}`,
+			[]string{`b`, `e`}},
+		// empty $and matches everything, empty $or matches nothing
+		{`{"$and":[]}`, []string{`a`, `b`, `c`, `d`, `e`}},
+		{`{"$or":[]}`, nil},
+		{`{"$nor":[]}`, []string{`a`, `b`, `c`, `d`, `e`}},
+	}
+
+	for _, s := range selectors {
+		s := s
+		It(fmt.Sprintf("Allow to query with combined selector %s", s.selector), func() {
+			Expect(queryKeys(stub, `{"selector":`+s.selector+`}`)).To(Equal(s.keys))
+		})
+	}
+
+	It("Allow to query with three level nested $and and $or selectors", func() {
+		query := `{"selector":{"$or":[` +
+			`{"$and":[{"docType":"affiliate"},{"$or":[{"level":1},{"tags":{"$elemMatch":{"$eq":"silver"}}}]}]},` +
+			`{"$and":[{"docType":"transaction"},{"$or":[{"level":{"$gt":4}},{"$and":[{"level":2},{"tags":{"$elemMatch":{"$eq":"fast"}}}]}]}]}` +
+			`]}}`
+		Expect(queryKeys(stub, query)).To(Equal([]string{`a`, `c`, `d`}))
+
+		// explicit $and is equal to implicit $and of plain selector
+		Expect(queryKeys(stub, `{"selector":{"$and":[{"docType":"transaction"},{"level":{"$lt":3}}]}}`)).To(
+			Equal(queryKeys(stub, `{"selector":{"docType":"transaction","level":{"$lt":3}}}`)))
+	})
+
+	It("Disallow malformed combination operator arguments", func() {
+		for _, selector := range []string{
+			`{"$or":{"docType":"affiliate"}}`,
+			`{"$and":["affiliate"]}`,
+			`{"$not":[{"docType":"affiliate"}]}`,
+			`{"level":{"$or":1}}`,
+		} {
+			_, err := stub.GetQueryResult(`{"selector":` + selector + `}`)
+			Expect(errors.Is(err, testcc.ErrQueryInvalid)).To(BeTrue(), selector)
+		}
+	})
+})
+
+var _ = Describe(`Rich query over composite keys`, func() {
+
+	state := func() map[string][]byte {
+		compositeKey, _ := shim.CreateCompositeKey(`CAR`, []string{`A1`})
+		return map[string][]byte{
+			`car-a2`:     []byte(`{"docType":"car","n":2}`),
+			`car-a3`:     []byte(`{"docType":"car","n":3}`),
+			compositeKey: []byte(`{"docType":"car","n":1}`),
+		}
+	}
+
+	keys := func(items []*queryresult.KV) []string {
+		var kk []string
+		for _, item := range items {
+			kk = append(kk, item.Key)
+		}
+		return kk
+	}
+
+	It("Allow to evaluate selector only against plain keys by default", func() {
+		stub := testcc.NewMockStub(`query`, nil)
+		Expect(stub.SeedState(state())).To(Succeed())
+
+		Expect(keys(queryAll(stub, `{"selector":{"docType":"car"}}`))).To(Equal([]string{`car-a2`, `car-a3`}))
+	})
+
+	It("Allow to include composite keys with option", func() {
+		stub := testcc.NewMockStub(`query`, nil, testcc.WithIncludeCompositeKeys())
+		Expect(stub.SeedState(state())).To(Succeed())
+
+		Expect(queryAll(stub, `{"selector":{"docType":"car"}}`)).To(HaveLen(3))
+	})
+
+	It("Allow to target composite key via _id selector", func() {
+		stub := testcc.NewMockStub(`query`, nil)
+		Expect(stub.SeedState(state())).To(Succeed())
+		compositeKey, _ := shim.CreateCompositeKey(`CAR`, []string{`A1`})
+
+		Expect(keys(queryAll(stub, `{"selector":{"_id":"\u0000CAR\u0000A1\u0000"}}`))).To(
+			Equal([]string{compositeKey}))
+		Expect(keys(queryAll(stub, `{"selector":{"_id":{"$regex":"^car-"},"n":3}}`))).To(
+			Equal([]string{`car-a3`}))
+	})
+})
+
+var _ = Describe(`Query result iterator`, func() {
+
+	It("Allow to iterate query results with parsed documents", func() {
+		stub := testcc.NewMockStub(`query`, nil)
+		Expect(stub.SeedState(map[string][]byte{`a`: []byte(`{"n":1}`), `b`: []byte(`{"n":2}`)})).To(Succeed())
+
+		iter, err := stub.GetQueryResult(`{"selector":{"n":2}}`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(iter.(*testcc.MockStateQueryResultIterator).Query).To(Equal(`{"selector":{"n":2}}`))
+
+		kv, err := iter.Next()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(kv.Key).To(Equal(`b`))
+	})
+
+	It("Disallow malformed entries with error, naming query and entry index", func() {
+		iter := testcc.NewQueryResultIterator(`{"selector":{"n":1}}`, []*testcc.QueryResultEntry{
+			{Key: `a`, Value: []byte(`{"n":1}`)}, nil, {Value: []byte(`{}`)}, {Key: `d`},
+		})
+
+		_, err := iter.Next()
+		Expect(err).NotTo(HaveOccurred())
+
+		for i, reason := range []string{`nil entry`, `empty key`, `nil value of key d`} {
+			Expect(iter.HasNext()).To(BeTrue())
+			_, err = iter.Next()
+
+			var resultErr *testcc.QueryResultError
+			Expect(errors.As(err, &resultErr)).To(BeTrue())
+			Expect(resultErr.Index).To(Equal(i + 1))
+			Expect(err.Error()).To(Equal(fmt.Sprintf(
+				`query result entry malformed: query {"selector":{"n":1}}, entry %d: %s`, i+1, reason)))
+		}
+		Expect(iter.HasNext()).To(BeFalse())
+	})
+})
@@ -0,0 +1,101 @@
+package testing
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrQueryModelNotFound occurs when rich query evaluates key, not matched by registered model patterns,
+// and strict query models are enabled with WithStrictQueryModels
+var ErrQueryModelNotFound = errors.New(`query model not found`)
+
+type (
+	// ModelMock typed document of rich query, created by factory, registered with RegisterQueryModel
+	ModelMock interface {
+		// Query checks query selector against model
+		Query(selector map[string]interface{}) (bool, error)
+		// Less reports whether model is ordered before other model of the same factory by query sort fields
+		Less(other ModelMock, sort []map[string]string) bool
+	}
+
+	// ModelMockFactory creates model from state entry
+	ModelMockFactory func(key string, value []byte) (ModelMock, error)
+
+	queryModel struct {
+		pattern string
+		prefix  string
+		re      *regexp.Regexp
+		factory ModelMockFactory
+	}
+)
+
+// RegisterQueryModel registers model factory for state keys, matched with key pattern. Pattern is regexp
+// or key prefix with `*` suffix. Rich queries evaluate selector and sort with model of first registered
+// pattern, matched by key, instead of generic JSON document evaluation. Models are registered per stub
+func (stub *MockStub) RegisterQueryModel(keyPattern string, factory ModelMockFactory) error {
+	model := &queryModel{pattern: keyPattern, factory: factory}
+	if strings.HasSuffix(keyPattern, `*`) {
+		model.prefix = strings.TrimSuffix(keyPattern, `*`)
+	} else {
+		re, err := regexp.Compile(keyPattern)
+		if err != nil {
+			return fmt.Errorf(`%w: query model key pattern: %s`, ErrQueryInvalid, err)
+		}
+		model.re = re
+	}
+
+	stub.queryModels = append(stub.queryModels, model)
+	return nil
+}
+
+// WithStrictQueryModels disables generic JSON evaluation of rich queries: each key, evaluated by query,
+// must be matched by pattern of model, registered with RegisterQueryModel, otherwise query fails
+// with ErrQueryModelNotFound. Composite keys, not evaluated by query (see WithIncludeCompositeKeys), are not checked
+func WithStrictQueryModels() MockStubOpt {
+	return func(stub *MockStub) {
+		stub.strictQueryModels = true
+	}
+}
+
+// queryModel returns model of key, created by factory of first matched pattern
+func (stub *MockStub) queryModel(key string, value []byte) (ModelMock, bool, error) {
+	for _, m := range stub.queryModels {
+		if m.re != nil && !m.re.MatchString(key) || m.re == nil && !strings.HasPrefix(key, m.prefix) {
+			continue
+		}
+		model, err := m.factory(key, value)
+		if err != nil {
+			return nil, true, fmt.Errorf(`query model %s, key %s: %w`, m.pattern, key, err)
+		}
+		return model, true, nil
+	}
+
+	if stub.strictQueryModels {
+		return nil, false, fmt.Errorf(`%w: key %s`, ErrQueryModelNotFound, key)
+	}
+	return nil, false, nil
+}
+
+// sortEntries orders query result entries by query sort fields: entries with models are compared by model,
+// generic JSON documents by collation of sort field values. Entries with model are ordered before documents
+func sortEntries(entries []*QueryResultEntry, fields []map[string]string) {
+	if len(fields) == 0 {
+		return
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		switch {
+		case a.model != nil && b.model != nil:
+			return a.model.Less(b.model, fields)
+		case a.model != nil:
+			return true
+		case b.model != nil:
+			return false
+		}
+		return lessDocs(a.Doc, b.Doc, fields)
+	})
+}
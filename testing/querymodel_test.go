@@ -0,0 +1,146 @@
+package testing_test
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/extensions/owner"
+	testcc "github.com/s7techlab/cckit/testing"
+)
+
+// carModel typed car, stored as `make|year` text instead of JSON
+type carModel struct {
+	make string
+	year int
+}
+
+func newCarModel(key string, value []byte) (testcc.ModelMock, error) {
+	parts := strings.Split(string(value), `|`)
+	if len(parts) != 2 {
+		return nil, errors.New(`car value must be make|year`)
+	}
+	year, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return nil, err
+	}
+	return &carModel{make: parts[0], year: year}, nil
+}
+
+// Query supports make equality and year lower bound only
+func (c *carModel) Query(selector map[string]interface{}) (bool, error) {
+	if value, ok := selector[`make`]; ok && value != c.make {
+		return false, nil
+	}
+	if year, ok := selector[`year`].(map[string]interface{}); ok {
+		from, _ := year[`$gte`].(float64)
+		return c.year >= int(from), nil
+	}
+	return true, nil
+}
+
+func (c *carModel) Less(other testcc.ModelMock, sort []map[string]string) bool {
+	if sort[0][`year`] == `desc` {
+		return c.year > other.(*carModel).year
+	}
+	return c.year < other.(*carModel).year
+}
+
+var _ = Describe(`Rich query models`, func() {
+
+	var stub *testcc.MockStub
+
+	BeforeEach(func() {
+		stub = testcc.NewMockStub(`cars`, nil)
+		Expect(stub.SeedState(map[string][]byte{
+			`CAR_1`:   []byte(`Audi|2015`),
+			`CAR_2`:   []byte(`BMW|2019`),
+			`CAR_3`:   []byte(`Audi|2021`),
+			`DEALER1`: []byte(`{"make":"Audi","year":2000}`),
+		})).To(Succeed())
+	})
+
+	It("Allow to query and sort entries with registered model", func() {
+		Expect(stub.RegisterQueryModel(`CAR_*`, newCarModel)).To(Succeed())
+
+		Expect(queryKeys(stub, `{"selector":{"make":"Audi"}}`)).To(Equal([]string{`CAR_1`, `CAR_3`, `DEALER1`}))
+		Expect(queryKeys(stub, `{"selector":{"year":{"$gte":2016}},"sort":[{"year":"desc"}]}`)).To(
+			Equal([]string{`CAR_3`, `CAR_2`}))
+		Expect(queryKeys(stub, `{"selector":{"year":{"$gte":0}},"sort":[{"year":"asc"}]}`)).To(
+			Equal([]string{`CAR_1`, `CAR_2`, `CAR_3`, `DEALER1`}))
+	})
+
+	It("Allow to register model with regexp key pattern, first matched pattern wins", func() {
+		Expect(stub.RegisterQueryModel(`^CAR_[12]$`, newCarModel)).To(Succeed())
+		Expect(stub.RegisterQueryModel(`^CAR_`, func(string, []byte) (testcc.ModelMock, error) {
+			return nil, errors.New(`unexpected car`)
+		})).To(Succeed())
+
+		_, err := stub.GetQueryResult(`{"selector":{"make":"Audi"}}`)
+		Expect(err).To(MatchError(ContainSubstring(`query model ^CAR_, key CAR_3: unexpected car`)))
+
+		Expect(stub.RegisterQueryModel(`(`, newCarModel)).To(MatchError(ContainSubstring(
+			testcc.ErrQueryInvalid.Error())))
+	})
+
+	It("Disallow to query keys without registered model in strict mode", func() {
+		strict := testcc.NewMockStub(`cars`, nil, testcc.WithStrictQueryModels())
+		Expect(strict.SeedState(map[string][]byte{`CAR_1`: []byte(`Audi|2015`)})).To(Succeed())
+		Expect(strict.RegisterQueryModel(`CAR_*`, newCarModel)).To(Succeed())
+		Expect(queryKeys(strict, `{"selector":{"make":"Audi"}}`)).To(Equal([]string{`CAR_1`}))
+
+		Expect(strict.SeedState(map[string][]byte{`DEALER1`: []byte(`{"make":"Audi"}`)})).To(Succeed())
+		_, err := strict.GetQueryResult(`{"selector":{"make":"Audi"}}`)
+		Expect(errors.Is(err, testcc.ErrQueryModelNotFound)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring(`DEALER1`))
+	})
+
+	It("Allow to query in strict mode with extension and index composite keys in state", func() {
+		strict := testcc.NewMockStub(`cars`, nil, testcc.WithStrictQueryModels())
+		ownerKey, _ := shim.CreateCompositeKey(owner.StateKey()[0], owner.StateKey()[1:])
+		indexKey, _ := shim.CreateCompositeKey(`_idx`, []string{`CarByMake`, `Audi`})
+		Expect(strict.SeedState(map[string][]byte{
+			`CAR_1`:  []byte(`Audi|2015`),
+			ownerKey: []byte(`{"mspId":"Org1MSP"}`),
+			indexKey: []byte(`CAR_1`),
+		})).To(Succeed())
+		Expect(strict.RegisterQueryModel(`CAR_*`, newCarModel)).To(Succeed())
+
+		Expect(queryKeys(strict, `{"selector":{"make":"Audi"}}`)).To(Equal([]string{`CAR_1`}))
+
+		// composite key, targeted via _id, is evaluated and requires model
+		_, err := strict.GetQueryResult(`{"selector":{"_id":{"$gt":""}}}`)
+		Expect(errors.Is(err, testcc.ErrQueryModelNotFound)).To(BeTrue())
+	})
+
+	It("Allow registered model to take precedence over generic JSON evaluation", func() {
+		Expect(stub.SeedState(map[string][]byte{
+			`CAR_4`:   []byte(`{"make":"Audi","year":2020}`),
+			`DEALER2`: []byte(`{"make":{"name":"Audi"},"year":2020}`),
+		})).To(Succeed())
+		Expect(stub.RegisterQueryModel(`CAR_*`, func(key string, value []byte) (testcc.ModelMock, error) {
+			if key == `CAR_4` {
+				return &carModel{make: `BMW`, year: 2020}, nil
+			}
+			return newCarModel(key, value)
+		})).To(Succeed())
+
+		// JSON document of CAR_4 is not evaluated, entries without model are evaluated with dot-notation paths
+		Expect(queryKeys(stub, `{"selector":{"make":"Audi","year":{"$gte":2020}}}`)).To(Equal([]string{`CAR_3`}))
+		Expect(queryKeys(stub, `{"selector":{"make.name":"Audi"}}`)).To(ContainElement(`DEALER2`))
+	})
+
+	It("Allow to register models per stub", func() {
+		other := testcc.NewMockStub(`cars`, nil)
+		Expect(other.SeedState(map[string][]byte{`CAR_1`: []byte(`Audi|2015`)})).To(Succeed())
+		Expect(stub.RegisterQueryModel(`CAR_*`, newCarModel)).To(Succeed())
+
+		Expect(queryKeys(stub, `{"selector":{"make":"Audi"}}`)).To(ContainElement(`CAR_1`))
+		// not JSON value is skipped without registered model
+		Expect(queryKeys(other, `{"selector":{"make":"Audi"}}`)).To(BeEmpty())
+	})
+})
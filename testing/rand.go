@@ -0,0 +1,87 @@
+package testing
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/s7techlab/cckit/identity"
+)
+
+// SeedEnv environment variable, overriding seed of test random source for reproducing failures
+const SeedEnv = `CCKIT_TEST_SEED`
+
+type (
+	// Rand explicitly seeded random source for identity pickers, fixture generators and shuffling helpers.
+	// MockStub tx ids are generated with crypto/rand and are not affected, use WithSequentialTxIDs for reproducible tx ids
+	Rand struct {
+		*rand.Rand
+		Seed int64
+	}
+
+	// TB subset of testing.TB, GinkgoT() can be used as well
+	TB interface {
+		Logf(format string, args ...interface{})
+	}
+)
+
+// NewRand creates random source with seed
+func NewRand(seed int64) *Rand {
+	return &Rand{
+		Rand: rand.New(rand.NewSource(seed)),
+		Seed: seed,
+	}
+}
+
+// NewRandTB creates random source with seed from SeedEnv or current time.
+// Seed is logged to tb, so it's printed when test fails
+func NewRandTB(tb TB) *Rand {
+	seed := time.Now().UnixNano()
+	if env := os.Getenv(SeedEnv); env != `` {
+		if envSeed, err := strconv.ParseInt(env, 10, 64); err == nil {
+			seed = envSeed
+		}
+	}
+
+	tb.Logf(`random seed: %d, set %s=%d to reproduce`, seed, SeedEnv, seed)
+	return NewRand(seed)
+}
+
+// String returns seed description
+func (r *Rand) String() string {
+	return fmt.Sprintf(`seed %d`, r.Seed)
+}
+
+// ShuffleSlice shuffles slice in place
+func (r *Rand) ShuffleSlice(slice interface{}) {
+	swap := reflect.Swapper(slice)
+	r.Shuffle(reflect.ValueOf(slice).Len(), swap)
+}
+
+// PickIdentity returns random identity with role, roles are sorted before pick as map order is random
+func (r *Rand) PickIdentity(ids Identities) (string, identity.Identity) {
+	if len(ids) == 0 {
+		return ``, nil
+	}
+
+	roles := make([]string, 0, len(ids))
+	for role := range ids {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+
+	role := roles[r.Intn(len(roles))]
+	return role, ids[role]
+}
+
+// WithSequentialTxIDs replaces crypto/rand tx ids with prefix and sequence number
+func WithSequentialTxIDs(prefix string) MockStubOpt {
+	return func(stub *MockStub) {
+		stub.sequentialTxIDs = true
+		stub.txIDPrefix = prefix
+	}
+}
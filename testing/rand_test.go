@@ -0,0 +1,55 @@
+package testing_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+	"github.com/s7techlab/cckit/testing/testdata"
+)
+
+// scenario picks random identities and generated entities, returns created entities and tx ids
+func randomScenario(rnd *testcc.Rand) ([]testdata.Entity, []string) {
+	cc := testcc.NewMockStub(`entities`, testdata.NewEntitiesCC(), testcc.WithSequentialTxIDs(`tx`))
+	ids := testcc.Identities{`authority`: Authority, `someone`: ids[1], `another`: ids[2]}
+
+	entities := testdata.GenerateEntities(rnd.Rand, 10, `owner1`, `owner2`, `owner3`)
+	rnd.ShuffleSlice(entities)
+
+	for _, e := range entities {
+		_, id := rnd.PickIdentity(ids)
+		expectcc.ResponseOk(cc.From(id).Invoke(`entityCreate`, e))
+	}
+
+	var txIDs []string
+	for _, invocation := range cc.InvocationLog() {
+		txIDs = append(txIDs, invocation.TxID)
+	}
+
+	return expectcc.PayloadIs(cc.Query(`entityList`), &[]testdata.Entity{}).([]testdata.Entity), txIDs
+}
+
+var _ = Describe(`Rand`, func() {
+
+	It("Allow to reproduce scenario with same seed", func() {
+		entities1, txIDs1 := randomScenario(testcc.NewRand(42))
+		entities2, txIDs2 := randomScenario(testcc.NewRand(42))
+
+		Expect(entities1).To(Equal(entities2))
+		Expect(txIDs1).To(Equal(txIDs2))
+		Expect(txIDs1[0]).To(Equal(`tx1`))
+	})
+
+	It("Allow to get different scenario with different seed", func() {
+		entities1, _ := randomScenario(testcc.NewRand(1))
+		entities2, _ := randomScenario(testcc.NewRand(2))
+
+		Expect(entities1).NotTo(Equal(entities2))
+	})
+
+	It("Allow to log seed to TB", func() {
+		rnd := testcc.NewRandTB(GinkgoT())
+		Expect(rnd.String()).To(ContainSubstring(`seed`))
+	})
+})
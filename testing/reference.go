@@ -0,0 +1,80 @@
+package testing
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// WarningEventDanglingReference code of warning, emitted when event references state entry, not written in tx
+const WarningEventDanglingReference = `event_dangling_reference`
+
+// EventReferenceRule returns keys of state entries, referenced by event with JSON object payload
+type EventReferenceRule func(eventName string, payload map[string]interface{}) (keys []string, err error)
+
+// WithEventReferenceCheck enables check of events in successful txs: each key, derived from event payload
+// by rules, must be written in the same tx, otherwise listeners fetch nonexistent entry. Each missing key
+// emits WarningEventDanglingReference, escalated to tx failure with FailOnWarnings.
+// Events with payload, which is not JSON object, are not checked
+func WithEventReferenceCheck(rules ...EventReferenceRule) MockStubOpt {
+	return func(stub *MockStub) {
+		stub.eventReferenceRules = append(stub.eventReferenceRules, rules...)
+	}
+}
+
+// FieldReference returns rule, referencing composite key with object type and string payload field
+// as single attribute. Events without field reference nothing
+func FieldReference(field, objectType string) EventReferenceRule {
+	return func(_ string, payload map[string]interface{}) ([]string, error) {
+		value, ok := payload[field]
+		if !ok {
+			return nil, nil
+		}
+
+		str, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf(`field %s is %T, string expected`, field, value)
+		}
+
+		key, err := shim.CreateCompositeKey(objectType, []string{str})
+		if err != nil {
+			return nil, err
+		}
+		return []string{key}, nil
+	}
+}
+
+// checkEventReferences emits warning for each key, referenced by event of current tx and not written in tx
+func (stub *MockStub) checkEventReferences() {
+	if len(stub.eventReferenceRules) == 0 || stub.ChaincodeEvent == nil {
+		return
+	}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(stub.ChaincodeEvent.Payload, &payload); err != nil {
+		return
+	}
+
+	written := make(map[string]bool, len(stub.StateBuffer))
+	for _, item := range stub.StateBuffer {
+		written[item.Key] = true
+	}
+
+	eventName := stub.ChaincodeEvent.EventName
+	for _, rule := range stub.eventReferenceRules {
+		keys, err := rule(eventName, payload)
+		if err != nil {
+			stub.Warn(WarningEventDanglingReference, ``,
+				fmt.Sprintf(`event %s references are not derived: %s`, eventName, err))
+			continue
+		}
+
+		for _, key := range keys {
+			if !written[key] {
+				stub.Warn(WarningEventDanglingReference, key,
+					fmt.Sprintf(`event %s references key, not written in tx`, eventName))
+			}
+		}
+	}
+}
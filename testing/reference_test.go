@@ -0,0 +1,70 @@
+package testing_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/router"
+	p "github.com/s7techlab/cckit/router/param"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+type OrderCreated struct {
+	ID string `json:"id"`
+}
+
+// NewOrdersCC emits event with order id, renaming handler writes order under different key
+func NewOrdersCC() *router.Chaincode {
+	r := router.New(`orders`)
+
+	r.Invoke(`orderCreate`, func(c router.Context) (interface{}, error) {
+		id := c.ParamString(`id`)
+		if err := c.Event().Set(`OrderCreated`, &OrderCreated{ID: id}); err != nil {
+			return nil, err
+		}
+		return nil, c.State().Put([]string{`ORDER`, id}, id)
+	}, p.String(`id`))
+
+	r.Invoke(`orderCreateRenamed`, func(c router.Context) (interface{}, error) {
+		id := c.ParamString(`id`)
+		if err := c.Event().Set(`OrderCreated`, &OrderCreated{ID: id}); err != nil {
+			return nil, err
+		}
+		return nil, c.State().Put([]string{`ORDER`, id + `-v2`}, id)
+	}, p.String(`id`))
+
+	return router.NewChaincode(r)
+}
+
+var _ = Describe(`Event references`, func() {
+
+	var cc *testcc.MockStub
+
+	BeforeEach(func() {
+		cc = testcc.NewMockStub(`orders`, NewOrdersCC(),
+			testcc.WithEventReferenceCheck(testcc.FieldReference(`id`, `ORDER`)))
+	})
+
+	It("Allow event, referencing key, written in tx", func() {
+		expectcc.ResponseOk(cc.Invoke(`orderCreate`, `1`))
+		Expect(cc.Warnings()).To(BeEmpty())
+	})
+
+	It("Disallow event, referencing key, not written in tx", func() {
+		expectcc.ResponseOk(cc.Invoke(`orderCreateRenamed`, `1`))
+
+		key, _ := cc.CreateCompositeKey(`ORDER`, []string{`1`})
+		warnings := cc.Warnings()
+		Expect(warnings).To(HaveLen(1))
+		Expect(warnings[0].Code).To(Equal(testcc.WarningEventDanglingReference))
+		Expect(warnings[0].Key).To(Equal(key))
+		Expect(warnings[0].Message).To(Equal(`event OrderCreated references key, not written in tx`))
+	})
+
+	It("Allow to fail tx with dangling reference", func() {
+		cc.FailOnWarnings(testcc.WarningEventDanglingReference)
+		expectcc.ResponseError(cc.Invoke(`orderCreateRenamed`, `1`), testcc.ErrWarningEscalated)
+		Expect(cc.State).To(BeEmpty())
+	})
+})
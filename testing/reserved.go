@@ -0,0 +1,110 @@
+package testing
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/s7techlab/cckit/state"
+)
+
+// compositeKeyNamespace first byte of composite key, as in shim
+const compositeKeyNamespace = "\x00"
+
+var (
+	// ErrReservedKey occurs when application code writes to key reserved by extensions
+	ErrReservedKey = errors.New(`write to reserved key`)
+
+	// ErrCompositeKeyAttributesExceeded occurs when composite key is created with more attributes than allowed
+	ErrCompositeKeyAttributesExceeded = errors.New(`composite key attributes count exceeded`)
+)
+
+// ReservedKeys plain keys, plain key prefixes and composite key object types, used by extensions
+type ReservedKeys struct {
+	// Keys plain keys, matched exactly
+	Keys        []string
+	Prefixes    []string
+	ObjectTypes []string
+	// MaxCompositeKeyAttributes if > 0, CreateCompositeKey rejects keys with more attributes
+	MaxCompositeKeyAttributes int
+}
+
+// DefaultReservedKeys returns keys used by cckit extensions.
+// Extensions packages are not imported, as their tests use testing package
+func DefaultReservedKeys() ReservedKeys {
+	return ReservedKeys{
+		Keys:        []string{`OWNER`},                            // owner.OwnerStateKey, legacy
+		ObjectTypes: []string{state.ExtensionsObjectType, `PING`}, // pinger.PingKeyPrefix
+	}
+}
+
+// WithReservedKeys enables guard of reserved keys: PutState, DelState and CreateCompositeKey return error
+// when application code uses reserved keys. Extensions are allowed to write via state.WithReservedKeys
+func WithReservedKeys(keys ReservedKeys) MockStubOpt {
+	return func(stub *MockStub) {
+		stub.reservedKeys = &keys
+	}
+}
+
+// AllowReservedKeys allows writes to reserved keys until restore func is called
+func (stub *MockStub) AllowReservedKeys() (restore func()) {
+	stub.reservedKeysAllowed++
+	return func() {
+		stub.reservedKeysAllowed--
+	}
+}
+
+// CreateCompositeKey mocked, object type is checked against reserved object types,
+// attributes count is checked against max attributes count
+func (stub *MockStub) CreateCompositeKey(objectType string, attributes []string) (string, error) {
+	if stub.reservedKeys != nil && stub.reservedKeys.MaxCompositeKeyAttributes > 0 &&
+		len(attributes) > stub.reservedKeys.MaxCompositeKeyAttributes {
+		return ``, fmt.Errorf(`%w: composite key "%s" has %d attributes, max %d`, ErrCompositeKeyAttributesExceeded,
+			objectType, len(attributes), stub.reservedKeys.MaxCompositeKeyAttributes)
+	}
+	if err := stub.checkReservedObjectType(objectType); err != nil {
+		return ``, err
+	}
+	return stub.MockStub.CreateCompositeKey(objectType, attributes)
+}
+
+func (stub *MockStub) checkReservedKey(key string) error {
+	if stub.reservedKeys == nil || stub.reservedKeysAllowed > 0 {
+		return nil
+	}
+
+	if strings.HasPrefix(key, compositeKeyNamespace) {
+		objectType, _, err := stub.SplitCompositeKey(key)
+		if err != nil {
+			return err
+		}
+		return stub.checkReservedObjectType(objectType)
+	}
+
+	for _, reserved := range stub.reservedKeys.Keys {
+		if key == reserved {
+			return fmt.Errorf(`%w: key "%s" is reserved by extensions`, ErrReservedKey, key)
+		}
+	}
+	for _, prefix := range stub.reservedKeys.Prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return fmt.Errorf(`%w: key "%s" has prefix "%s", reserved by extensions`, ErrReservedKey, key, prefix)
+		}
+	}
+	return nil
+}
+
+func (stub *MockStub) checkReservedObjectType(objectType string) error {
+	if stub.reservedKeys == nil || stub.reservedKeysAllowed > 0 {
+		return nil
+	}
+
+	for _, reserved := range stub.reservedKeys.ObjectTypes {
+		if objectType == reserved {
+			return fmt.Errorf(`%w: composite key object type "%s" is reserved by extensions`,
+				ErrReservedKey, objectType)
+		}
+	}
+	return nil
+}
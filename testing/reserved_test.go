@@ -0,0 +1,83 @@
+package testing_test
+
+import (
+	"errors"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/extensions/owner"
+	"github.com/s7techlab/cckit/extensions/pinger"
+	"github.com/s7techlab/cckit/router"
+	p "github.com/s7techlab/cckit/router/param"
+	"github.com/s7techlab/cckit/state"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+func NewKeysCC() *router.Chaincode {
+	r := router.New(`keys`)
+
+	r.Init(owner.InvokeSetFromCreator).
+		Invoke(`put`, func(c router.Context) (interface{}, error) {
+			return nil, c.State().Put(c.ParamString(`key`), c.ParamString(`value`))
+		}, p.String(`key`), p.String(`value`)).
+		Invoke(`putComposite`, func(c router.Context) (interface{}, error) {
+			return nil, c.State().Put([]string{c.ParamString(`objectType`), `id`}, `value`)
+		}, p.String(`objectType`)).
+		Invoke(`ping`, pinger.Ping)
+
+	return router.NewChaincode(r)
+}
+
+var _ = Describe(`Reserved keys`, func() {
+
+	It("Disallow application writes to reserved keys, allow extension writes", func() {
+		cc := testcc.NewMockStub(`keys`, NewKeysCC(), testcc.WithFabricDefaults())
+
+		expectcc.ResponseOk(cc.From(Authority).Init())
+		ownerKey, _ := shim.CreateCompositeKey(owner.StateKey()[0], owner.StateKey()[1:])
+		Expect(cc.State).To(HaveKey(ownerKey))
+
+		expectcc.ResponseError(cc.From(Authority).Invoke(`put`, owner.OwnerStateKey, `hijacked`),
+			testcc.ErrReservedKey)
+		expectcc.ResponseError(cc.From(Authority).Invoke(`putComposite`, state.ExtensionsObjectType),
+			testcc.ErrReservedKey)
+		expectcc.ResponseError(cc.From(Authority).Invoke(`putComposite`, pinger.PingKeyPrefix),
+			testcc.ErrReservedKey)
+
+		expectcc.ResponseOk(cc.From(Authority).Invoke(`ping`))
+		expectcc.ResponseOk(cc.From(Authority).Invoke(`put`, `OTHER`, `value`))
+		expectcc.ResponseOk(cc.From(Authority).Invoke(`put`, owner.OwnerStateKey+`SHIP_1`, `value`))
+		expectcc.ResponseOk(cc.From(Authority).Invoke(`putComposite`, `OTHER`))
+	})
+
+	It("Disallow application writes to keys with reserved prefix", func() {
+		cc := testcc.NewMockStub(`keys`, NewKeysCC(), testcc.WithReservedKeys(testcc.ReservedKeys{
+			Prefixes: []string{`SYS_`},
+		}))
+
+		expectcc.ResponseError(cc.From(Authority).Invoke(`put`, `SYS_config`, `value`), testcc.ErrReservedKey)
+		expectcc.ResponseOk(cc.From(Authority).Invoke(`put`, `SYSTEM`, `value`))
+	})
+
+	It("Disallow to create composite key with more attributes than allowed", func() {
+		cc := testcc.NewMockStub(`keys`, NewKeysCC(), testcc.WithReservedKeys(testcc.ReservedKeys{
+			MaxCompositeKeyAttributes: 2,
+		}))
+
+		_, err := cc.CreateCompositeKey(`ORDER`, []string{`a`, `b`, `c`})
+		Expect(err).To(MatchError(ContainSubstring(`composite key "ORDER" has 3 attributes, max 2`)))
+		Expect(errors.Is(err, testcc.ErrCompositeKeyAttributesExceeded)).To(BeTrue())
+
+		_, err = cc.CreateCompositeKey(`ORDER`, []string{`a`, `b`})
+		Expect(err).NotTo(HaveOccurred())
+		expectcc.ResponseOk(cc.From(Authority).Invoke(`putComposite`, `ORDER`))
+	})
+
+	It("Allow application writes to reserved keys by default", func() {
+		cc := testcc.NewMockStub(`keys`, NewKeysCC())
+		expectcc.ResponseOk(cc.From(Authority).Invoke(`put`, owner.OwnerStateKey, `value`))
+	})
+})
@@ -0,0 +1,127 @@
+package testing
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sort"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+const (
+	ResourceIterator     = `iterator`
+	ResourceSubscription = `subscription`
+)
+
+type (
+	// OpenResource iterator or events subscription, created by stub and not closed yet
+	OpenResource struct {
+		Kind        string
+		Description string
+		// Stack of goroutine, created resource
+		Stack string
+		id    uint64
+	}
+
+	// CleanupReporter subset of testing.TB, used for reporting resources, left open after test
+	CleanupReporter interface {
+		ErrorReporter
+		Cleanup(func())
+	}
+
+	// releaser unregisters open resource on close
+	releaser struct {
+		onRelease func()
+	}
+
+	// trackedIterator releases resource of wrapped iterator on close
+	trackedIterator struct {
+		shim.StateQueryIteratorInterface
+		releaser
+	}
+)
+
+// NewMockStubT creates chaincode imitation, iterators and subscriptions, left open after test, are reported to t.
+// Events dispatcher is stopped on test cleanup
+func NewMockStubT(t CleanupReporter, name string, cc shim.Chaincode, opts ...MockStubOpt) *MockStub {
+	stub := NewMockStub(name, cc, opts...)
+	t.Cleanup(func() {
+		for _, resource := range stub.OpenResources() {
+			t.Errorf(`%s`, resource)
+		}
+		stub.Close()
+	})
+	return stub
+}
+
+func (r *OpenResource) String() string {
+	return fmt.Sprintf("%s %s is not closed, created at:\n%s", r.Kind, r.Description, r.Stack)
+}
+
+// OpenResources returns iterators, created by mocked query methods, and event subscriptions,
+// which are not closed yet, in creation order
+func (stub *MockStub) OpenResources() []*OpenResource {
+	stub.resourcesM.Lock()
+	defer stub.resourcesM.Unlock()
+
+	resources := make([]*OpenResource, 0, len(stub.resources))
+	for _, resource := range stub.resources {
+		resources = append(resources, resource)
+	}
+	sort.Slice(resources, func(i, j int) bool {
+		return resources[i].id < resources[j].id
+	})
+	return resources
+}
+
+// openResource registers resource, it's unregistered by released releaser
+func (stub *MockStub) openResource(kind, description string, r *releaser) {
+	stub.resourcesM.Lock()
+	defer stub.resourcesM.Unlock()
+
+	if stub.resources == nil {
+		stub.resources = make(map[uint64]*OpenResource)
+	}
+	stub.resourcesSeq++
+	id := stub.resourcesSeq
+	stub.resources[id] = &OpenResource{Kind: kind, Description: description, Stack: string(debug.Stack()), id: id}
+
+	r.onRelease = func() {
+		stub.resourcesM.Lock()
+		defer stub.resourcesM.Unlock()
+		delete(stub.resources, id)
+	}
+}
+
+// trackIterator registers iterator as open resource, iterators of other packages are wrapped
+func (stub *MockStub) trackIterator(
+	iter shim.StateQueryIteratorInterface, err error, description string) (shim.StateQueryIteratorInterface, error) {
+	if err != nil {
+		return iter, err
+	}
+
+	switch it := iter.(type) {
+	case *MockStateQueryResultIterator:
+		stub.openResource(ResourceIterator, description, &it.releaser)
+		return it, nil
+	case *PrivateMockStateRangeQueryIterator:
+		stub.openResource(ResourceIterator, description, &it.releaser)
+		return it, nil
+	}
+
+	tracked := &trackedIterator{StateQueryIteratorInterface: iter}
+	stub.openResource(ResourceIterator, description, &tracked.releaser)
+	return tracked, nil
+}
+
+func (r *releaser) release() {
+	if r.onRelease != nil {
+		r.onRelease()
+		r.onRelease = nil
+	}
+}
+
+func (iter *trackedIterator) Close() error {
+	iter.release()
+	return iter.StateQueryIteratorInterface.Close()
+}
@@ -0,0 +1,123 @@
+package testing_test
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/router"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+// cleanupRecorder records cleanup functions and reported errors
+type cleanupRecorder struct {
+	cleanups []func()
+	errors   []string
+}
+
+func (r *cleanupRecorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *cleanupRecorder) Cleanup(fn func()) {
+	r.cleanups = append(r.cleanups, fn)
+}
+
+func (r *cleanupRecorder) cleanup() {
+	for _, fn := range r.cleanups {
+		fn()
+	}
+}
+
+// leakyList forgets to close iterator
+func leakyList(c router.Context) (interface{}, error) {
+	iter, err := c.Stub().GetStateByRange(``, ``)
+	if err != nil {
+		return nil, err
+	}
+	return iter.HasNext(), nil
+}
+
+func closingList(c router.Context) (interface{}, error) {
+	iter, err := c.Stub().GetStateByRange(``, ``)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = iter.Close() }()
+	return iter.HasNext(), nil
+}
+
+func NewResourcesCC() *router.Chaincode {
+	r := router.New(`resources`).
+		Query(`leakyList`, leakyList).
+		Query(`list`, closingList).
+		Query(`pagedQuery`, func(c router.Context) (interface{}, error) {
+			iter, _, err := c.Stub().GetQueryResultWithPagination(`{"selector":{"n":1}}`, 1, ``)
+			if err != nil {
+				return nil, err
+			}
+			return nil, iter.Close()
+		})
+
+	return router.NewChaincode(r)
+}
+
+var _ = Describe(`Open resources`, func() {
+
+	It("Allow to report leaked iterator with creating function", func() {
+		t := &cleanupRecorder{}
+		cc := testcc.NewMockStubT(t, `resources`, NewResourcesCC())
+		expectcc.ResponseOk(cc.Query(`leakyList`))
+
+		resources := cc.OpenResources()
+		Expect(resources).To(HaveLen(1))
+		Expect(resources[0].Kind).To(Equal(testcc.ResourceIterator))
+		Expect(resources[0].Description).To(Equal(`GetStateByRange("", "")`))
+
+		t.cleanup()
+		Expect(t.errors).To(HaveLen(1))
+		Expect(t.errors[0]).To(ContainSubstring(`iterator GetStateByRange("", "") is not closed`))
+		Expect(t.errors[0]).To(ContainSubstring(`testing_test.leakyList`))
+	})
+
+	It("Allow to report zero leaks of closed iterators and subscriptions", func() {
+		t := &cleanupRecorder{}
+		cc := testcc.NewMockStubT(t, `resources`, NewResourcesCC())
+		_, closer := cc.EventSubscription()
+		Expect(cc.OpenResources()).To(HaveLen(1))
+
+		expectcc.ResponseOk(cc.Query(`list`))
+		expectcc.ResponseOk(cc.Query(`pagedQuery`))
+		Expect(closer()).To(Succeed())
+
+		t.cleanup()
+		Expect(t.errors).To(BeEmpty())
+	})
+	It("Allow to stop events dispatcher on test cleanup", func() {
+		t := &cleanupRecorder{}
+		cc := testcc.NewMockStubT(t, `counter`, NewCounterCC())
+		events, closer := cc.EventSubscription()
+
+		// subscription is not read, so events beyond channel buffer are queued to dispatcher
+		for i := 0; i < testcc.EventChannelBufferSize+5; i++ {
+			expectcc.ResponseOk(cc.Invoke(`set`, i))
+			cc.ClearEvents()
+		}
+		t.cleanup()
+		Expect(t.errors).To(HaveLen(1))
+		Expect(t.errors[0]).To(ContainSubstring(`EventSubscription`))
+
+		flushed := make(chan struct{})
+		go func() {
+			cc.FlushEvents()
+			close(flushed)
+		}()
+		Eventually(flushed).Should(BeClosed())
+
+		// queued events are dropped
+		Expect(events).To(HaveLen(testcc.EventChannelBufferSize))
+		Expect(closer()).To(Succeed())
+	})
+})
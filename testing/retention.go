@@ -0,0 +1,228 @@
+package testing
+
+import (
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go/peer"
+
+	"github.com/s7techlab/cckit/router/trace"
+)
+
+// Default retention limits, generous for usual tests but finite for soak tests
+const (
+	DefaultMaxEventsHistory = 10000
+	DefaultMaxInvocationLog = 10000
+	DefaultMaxKeyHistory    = 1000
+
+	// RetentionUnlimited limit value, which disables eviction
+	RetentionUnlimited = -1
+)
+
+type (
+	// RetentionLimits limits number of stored entries, oldest entries are evicted first.
+	// Zero limit means default limit, RetentionUnlimited disables eviction
+	RetentionLimits struct {
+		MaxEventsHistory int
+		MaxInvocationLog int
+		// MaxKeyHistory limit of modifications stored per key
+		MaxKeyHistory int
+	}
+
+	// Invocation entry of invocation log
+	Invocation struct {
+		TxID      string
+		Args      [][]byte
+		Transient map[string][]byte // values are redacted, unless WithIncludeTransient is used
+		Response  peer.Response
+		// PayloadSize size of response payload in bytes
+		PayloadSize int
+		Timestamp   *timestamp.Timestamp
+		Creator     string       // creator fingerprint
+		Writes      []*StateItem // state writes of tx, including not committed due to validation error
+		Deletes     []string
+		Event       *peer.ChaincodeEvent
+		// ValidationError error of tx validation, tx writes and event are not committed
+		ValidationError error
+		// Spans router trace spans of tx, recorded if stub is created WithTraceSink
+		Spans []trace.Span
+		// Manual tx is bracketed with MockTransactionStart and MockTransactionEnd, without chaincode invoke
+		Manual bool
+	}
+
+	// MemoryStats snapshot of MockStub stored entries counts and approximate size
+	MemoryStats struct {
+		StateBufferItems   int
+		EventsHistory      int
+		EventsEvicted      int
+		InvocationLog      int
+		InvocationsEvicted int
+		KeyHistory         int
+		KeyHistoryEvicted  int
+		// ApproxBytes size of keys, values, args and payloads
+		ApproxBytes int
+	}
+)
+
+// DefaultRetentionLimits returns default retention limits
+func DefaultRetentionLimits() RetentionLimits {
+	return RetentionLimits{
+		MaxEventsHistory: DefaultMaxEventsHistory,
+		MaxInvocationLog: DefaultMaxInvocationLog,
+		MaxKeyHistory:    DefaultMaxKeyHistory,
+	}
+}
+
+// WithRetentionLimits sets limits of events history, invocation log and per key history,
+// not set limits are default
+func WithRetentionLimits(limits RetentionLimits) MockStubOpt {
+	return func(stub *MockStub) {
+		defaults := DefaultRetentionLimits()
+		if limits.MaxEventsHistory == 0 {
+			limits.MaxEventsHistory = defaults.MaxEventsHistory
+		}
+		if limits.MaxInvocationLog == 0 {
+			limits.MaxInvocationLog = defaults.MaxInvocationLog
+		}
+		if limits.MaxKeyHistory == 0 {
+			limits.MaxKeyHistory = defaults.MaxKeyHistory
+		}
+		stub.retention = limits
+	}
+}
+
+// InvocationLog returns logged invocations, oldest first
+func (stub *MockStub) InvocationLog() []*Invocation {
+	return append([]*Invocation{}, stub.invocationLog...)
+}
+
+// KeyHistory returns committed modifications of key, oldest first
+func (stub *MockStub) KeyHistory(key string) []*queryresult.KeyModification {
+	return append([]*queryresult.KeyModification{}, stub.keyHistory[key]...)
+}
+
+// MemoryStats returns counts and approximate size of stored entries
+func (stub *MockStub) MemoryStats() MemoryStats {
+	stub.subscriptionsM.Lock()
+	defer stub.subscriptionsM.Unlock()
+
+	stats := MemoryStats{
+		StateBufferItems:   len(stub.StateBuffer),
+		EventsHistory:      len(stub.chaincodeEvents),
+		EventsEvicted:      stub.eventsEvicted,
+		InvocationLog:      len(stub.invocationLog),
+		InvocationsEvicted: stub.invocationsEvicted,
+		KeyHistoryEvicted:  stub.keyHistoryEvicted,
+	}
+
+	for _, item := range stub.StateBuffer {
+		stats.ApproxBytes += len(item.Key) + len(item.Value)
+	}
+
+	for _, e := range stub.chaincodeEvents {
+		stats.ApproxBytes += len(e.EventName) + len(e.Payload)
+	}
+
+	for _, i := range stub.invocationLog {
+		stats.ApproxBytes += len(i.TxID) + len(i.Response.Payload) + len(i.Response.Message)
+		for _, arg := range i.Args {
+			stats.ApproxBytes += len(arg)
+		}
+		for key, value := range i.Transient {
+			stats.ApproxBytes += len(key) + len(value)
+		}
+	}
+
+	for key, history := range stub.keyHistory {
+		stats.KeyHistory += len(history)
+		for _, m := range history {
+			stats.ApproxBytes += len(key) + len(m.TxId) + len(m.Value)
+		}
+	}
+
+	return stats
+}
+
+func (stub *MockStub) logInvocation(uuid string, args [][]byte, response peer.Response) {
+	// invocation can be logged during tx or after tx end
+	tx := stub.endedTx
+	if stub.TxID != `` {
+		tx = stub.txOutcome()
+	}
+
+	stub.invocationLog = append(stub.invocationLog, &Invocation{
+		TxID:            uuid,
+		Args:            args,
+		Transient:       stub.recordedTransient(),
+		Response:        response,
+		PayloadSize:     len(response.Payload),
+		Timestamp:       stub.TxTimestamp,
+		Creator:         tx.creator,
+		Writes:          tx.writes,
+		Deletes:         tx.deletes,
+		Event:           tx.event,
+		ValidationError: stub.LastValidationError,
+		Spans:           stub.traceSpans(uuid),
+	})
+
+	if evict := evictCount(len(stub.invocationLog), stub.retention.MaxInvocationLog); evict > 0 {
+		stub.invocationLog = stub.invocationLog[evict:]
+		stub.invocationsEvicted += evict
+	}
+}
+
+// should be called with locked subscriptionsM
+func (stub *MockStub) addEventToHistory(event *peer.ChaincodeEvent) {
+	stub.chaincodeEvents = append(stub.chaincodeEvents, event)
+
+	if evict := evictCount(len(stub.chaincodeEvents), stub.retention.MaxEventsHistory); evict > 0 {
+		stub.chaincodeEvents = stub.chaincodeEvents[evict:]
+		stub.eventsEvicted += evict
+	}
+}
+
+func (stub *MockStub) addKeyModification(key string, value []byte, isDelete bool) {
+	stub.appendKeyModification(key, &queryresult.KeyModification{
+		TxId:      stub.TxID,
+		Value:     value,
+		Timestamp: stub.TxTimestamp,
+		IsDelete:  isDelete,
+	})
+}
+
+func (stub *MockStub) appendKeyModification(key string, modification *queryresult.KeyModification) {
+	if stub.keyHistory == nil {
+		stub.keyHistory = make(map[string][]*queryresult.KeyModification)
+	}
+
+	stub.keyHistory[key] = append(stub.keyHistory[key], modification)
+
+	if evict := evictCount(len(stub.keyHistory[key]), stub.retention.MaxKeyHistory); evict > 0 {
+		stub.keyHistory[key] = stub.keyHistory[key][evict:]
+		stub.keyHistoryEvicted += evict
+	}
+}
+
+// keyVersion returns id of tx, which last modified existing key, or empty string
+func (stub *MockStub) keyVersion(key string) string {
+	history := stub.keyHistory[key]
+	if _, exists := stub.State[key]; !exists || len(history) == 0 {
+		return ``
+	}
+	return history[len(history)-1].TxId
+}
+
+// evictCount returns number of oldest entries to evict, negative limit is unlimited
+func evictCount(stored, limit int) int {
+	if limit < 0 {
+		return 0
+	}
+	return stored - limit
+}
+
+// traceSpans returns router trace spans of tx, if trace sink is set
+func (stub *MockStub) traceSpans(txID string) []trace.Span {
+	if stub.traceSink == nil {
+		return nil
+	}
+	return stub.traceSink.take(txID)
+}
@@ -0,0 +1,102 @@
+package testing_test
+
+import (
+	"strconv"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/router"
+	p "github.com/s7techlab/cckit/router/param"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+func NewCounterCC() *router.Chaincode {
+	r := router.New(`counter`)
+
+	r.Invoke(`set`, func(c router.Context) (interface{}, error) {
+		value := strconv.Itoa(c.ParamInt(`value`))
+		if err := c.Event().Set(`CounterSet`, value); err != nil {
+			return nil, err
+		}
+		return value, c.Stub().PutState(`counter`, []byte(value))
+	}, p.Int(`value`))
+
+	return router.NewChaincode(r)
+}
+
+var _ = Describe(`Retention limits`, func() {
+
+	It("Allow to keep events history, invocation log and key history within limits", func() {
+		cc := testcc.NewMockStub(`counter`, NewCounterCC(), testcc.WithRetentionLimits(testcc.RetentionLimits{
+			MaxEventsHistory: 100,
+			MaxInvocationLog: 50,
+			MaxKeyHistory:    10,
+		}))
+
+		for i := 0; i < 10000; i++ {
+			expectcc.ResponseOk(cc.Invoke(`set`, i))
+			cc.ClearEvents()
+		}
+
+		stats := cc.MemoryStats()
+		Expect(stats.StateBufferItems).To(Equal(0))
+		Expect(stats.EventsHistory).To(Equal(100))
+		Expect(stats.EventsEvicted).To(Equal(9900))
+		Expect(stats.InvocationLog).To(Equal(50))
+		Expect(stats.InvocationsEvicted).To(Equal(9950))
+		Expect(stats.KeyHistory).To(Equal(10))
+		Expect(stats.KeyHistoryEvicted).To(Equal(9990))
+		Expect(stats.ApproxBytes).To(BeNumerically(`<`, 10000))
+
+		history := cc.KeyHistory(`counter`)
+		Expect(history).To(HaveLen(10))
+		Expect(history[9].Value).To(Equal([]byte(`9999`)))
+
+		log := cc.InvocationLog()
+		Expect(log).To(HaveLen(50))
+		Expect(log[49].Response.Payload).To(Equal([]byte(`9999`)))
+	})
+
+	It("Allow to use default retention limits", func() {
+		cc := testcc.NewMockStub(`counter`, NewCounterCC())
+		expectcc.ResponseOk(cc.Invoke(`set`, 1))
+
+		stats := cc.MemoryStats()
+		Expect(stats.EventsHistory).To(Equal(1))
+		Expect(stats.InvocationLog).To(Equal(1))
+		Expect(stats.KeyHistory).To(Equal(1))
+	})
+
+	It("Allow to set limits partially, not set limits are default", func() {
+		cc := testcc.NewMockStub(`counter`, NewCounterCC(), testcc.WithRetentionLimits(testcc.RetentionLimits{
+			MaxKeyHistory: 2,
+		}))
+
+		for i := 0; i < 5; i++ {
+			expectcc.ResponseOk(cc.Invoke(`set`, i))
+		}
+
+		stats := cc.MemoryStats()
+		Expect(stats.EventsHistory).To(Equal(5))
+		Expect(stats.InvocationLog).To(Equal(5))
+		Expect(stats.KeyHistory).To(Equal(2))
+		Expect(stats.KeyHistoryEvicted).To(Equal(3))
+	})
+
+	It("Allow to disable eviction with unlimited retention", func() {
+		cc := testcc.NewMockStub(`counter`, NewCounterCC(), testcc.WithRetentionLimits(testcc.RetentionLimits{
+			MaxKeyHistory: testcc.RetentionUnlimited,
+		}))
+
+		for i := 0; i < testcc.DefaultMaxKeyHistory+1; i++ {
+			expectcc.ResponseOk(cc.Invoke(`set`, i))
+			cc.ClearEvents()
+		}
+
+		stats := cc.MemoryStats()
+		Expect(stats.KeyHistory).To(Equal(testcc.DefaultMaxKeyHistory + 1))
+		Expect(stats.KeyHistoryEvicted).To(Equal(0))
+	})
+})
@@ -0,0 +1,147 @@
+package testing
+
+import (
+	"crypto/sha256"
+	"sort"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset/kvrwset"
+)
+
+// privateWrite private data write or delete of tx
+type privateWrite struct {
+	Collection string
+	Key        string
+	Value      []byte
+	IsDelete   bool
+}
+
+// LastTxRWSetProto returns write set of last ended tx as Fabric read write set with chaincode namespace:
+// public writes and hashed private writes, grouped by collection. Writes are collapsed by key and sorted,
+// reads and versions are not captured. Collection PvtRwsetHash is hash of collection rwset
+// from LastTxPvtRWSetProto, as computed by peer
+func (stub *MockStub) LastTxRWSetProto() (*rwset.TxReadWriteSet, error) {
+	tx := stub.endedTx
+
+	writes := make(map[string]*kvrwset.KVWrite)
+	for _, key := range tx.deletes {
+		writes[key] = &kvrwset.KVWrite{Key: key, IsDelete: true}
+	}
+	for _, item := range tx.writes {
+		writes[item.Key] = &kvrwset.KVWrite{Key: item.Key, Value: item.Value}
+	}
+
+	publicRWSet, err := proto.Marshal(&kvrwset.KVRWSet{Writes: sortedKVWrites(writes)})
+	if err != nil {
+		return nil, err
+	}
+
+	ns := &rwset.NsReadWriteSet{Namespace: stub.ChaincodeID().Name, Rwset: publicRWSet}
+
+	collections, err := stub.lastTxCollectionRWSets()
+	if err != nil {
+		return nil, err
+	}
+	for _, collection := range collections {
+		var hashedWrites []*kvrwset.KVWriteHash
+		for _, write := range collection.writes {
+			hashed := &kvrwset.KVWriteHash{KeyHash: sha256Hash([]byte(write.Key)), IsDelete: write.IsDelete}
+			if !write.IsDelete {
+				hashed.ValueHash = sha256Hash(write.Value)
+			}
+			hashedWrites = append(hashedWrites, hashed)
+		}
+
+		hashedRWSet, err := proto.Marshal(&kvrwset.HashedRWSet{HashedWrites: hashedWrites})
+		if err != nil {
+			return nil, err
+		}
+
+		ns.CollectionHashedRwset = append(ns.CollectionHashedRwset, &rwset.CollectionHashedReadWriteSet{
+			CollectionName: collection.name,
+			HashedRwset:    hashedRWSet,
+			PvtRwsetHash:   sha256Hash(collection.rwset),
+		})
+	}
+
+	return &rwset.TxReadWriteSet{
+		DataModel: rwset.TxReadWriteSet_KV,
+		NsRwset:   []*rwset.NsReadWriteSet{ns},
+	}, nil
+}
+
+// LastTxPvtRWSetProto returns private writes of last ended tx as private read write set,
+// disseminated by peer to collection members. Nil, if tx has no private writes
+func (stub *MockStub) LastTxPvtRWSetProto() (*rwset.TxPvtReadWriteSet, error) {
+	collections, err := stub.lastTxCollectionRWSets()
+	if err != nil || len(collections) == 0 {
+		return nil, err
+	}
+
+	ns := &rwset.NsPvtReadWriteSet{Namespace: stub.ChaincodeID().Name}
+	for _, collection := range collections {
+		ns.CollectionPvtRwset = append(ns.CollectionPvtRwset, &rwset.CollectionPvtReadWriteSet{
+			CollectionName: collection.name,
+			Rwset:          collection.rwset,
+		})
+	}
+
+	return &rwset.TxPvtReadWriteSet{
+		DataModel:  rwset.TxReadWriteSet_KV,
+		NsPvtRwset: []*rwset.NsPvtReadWriteSet{ns},
+	}, nil
+}
+
+type collectionRWSet struct {
+	name   string
+	writes []*kvrwset.KVWrite
+	// rwset marshaled KVRWSet with private writes
+	rwset []byte
+}
+
+// lastTxCollectionRWSets returns private writes of last ended tx, collapsed by key, sorted by collection and key
+func (stub *MockStub) lastTxCollectionRWSets() ([]*collectionRWSet, error) {
+	writes := make(map[string]map[string]*kvrwset.KVWrite)
+	for _, write := range stub.endedTx.privateWrites {
+		if _, ok := writes[write.Collection]; !ok {
+			writes[write.Collection] = make(map[string]*kvrwset.KVWrite)
+		}
+		writes[write.Collection][write.Key] = &kvrwset.KVWrite{
+			Key: write.Key, Value: write.Value, IsDelete: write.IsDelete}
+	}
+
+	names := make([]string, 0, len(writes))
+	for name := range writes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	collections := make([]*collectionRWSet, 0, len(names))
+	for _, name := range names {
+		collection := &collectionRWSet{name: name, writes: sortedKVWrites(writes[name])}
+
+		var err error
+		if collection.rwset, err = proto.Marshal(&kvrwset.KVRWSet{Writes: collection.writes}); err != nil {
+			return nil, err
+		}
+		collections = append(collections, collection)
+	}
+	return collections, nil
+}
+
+func sortedKVWrites(writes map[string]*kvrwset.KVWrite) []*kvrwset.KVWrite {
+	sorted := make([]*kvrwset.KVWrite, 0, len(writes))
+	for _, write := range writes {
+		sorted = append(sorted, write)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Key < sorted[j].Key
+	})
+	return sorted
+}
+
+func sha256Hash(bb []byte) []byte {
+	h := sha256.Sum256(bb)
+	return h[:]
+}
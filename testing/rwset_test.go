@@ -0,0 +1,107 @@
+package testing_test
+
+import (
+	"crypto/sha256"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset/kvrwset"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/router"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+// NewRWSetCC writes public and private keys
+func NewRWSetCC() *router.Chaincode {
+	r := router.New(`rwset`)
+
+	r.Invoke(`write`, func(c router.Context) (interface{}, error) {
+		stub := c.Stub()
+		if err := stub.PutState(`b`, []byte(`public b`)); err != nil {
+			return nil, err
+		}
+		if err := stub.PutState(`a`, []byte(`public a`)); err != nil {
+			return nil, err
+		}
+		if err := stub.DelState(`old`); err != nil {
+			return nil, err
+		}
+		if err := stub.PutPrivateData(`secrets`, `key`, []byte(`draft`)); err != nil {
+			return nil, err
+		}
+		if err := stub.PutPrivateData(`secrets`, `key`, []byte(`private value`)); err != nil {
+			return nil, err
+		}
+		return nil, stub.PutPrivateData(`other`, `key`, []byte(`other value`))
+	})
+
+	return router.NewChaincode(r)
+}
+
+var _ = Describe(`Read write set export`, func() {
+
+	sha := func(bb []byte) []byte {
+		h := sha256.Sum256(bb)
+		return h[:]
+	}
+
+	It("Allow to round trip tx writes via read write set protos", func() {
+		cc := testcc.NewMockStub(`rwset`, NewRWSetCC())
+		Expect(cc.SeedState(map[string][]byte{`old`: []byte(`old`)})).To(Succeed())
+		expectcc.ResponseOk(cc.Invoke(`write`))
+
+		txRWSet, err := cc.LastTxRWSetProto()
+		Expect(err).NotTo(HaveOccurred())
+		bb, err := proto.Marshal(txRWSet)
+		Expect(err).NotTo(HaveOccurred())
+
+		decoded := &rwset.TxReadWriteSet{}
+		Expect(proto.Unmarshal(bb, decoded)).To(Succeed())
+		Expect(decoded.DataModel).To(Equal(rwset.TxReadWriteSet_KV))
+		Expect(decoded.NsRwset).To(HaveLen(1))
+		ns := decoded.NsRwset[0]
+		Expect(ns.Namespace).To(Equal(`rwset`))
+
+		public := &kvrwset.KVRWSet{}
+		Expect(proto.Unmarshal(ns.Rwset, public)).To(Succeed())
+		Expect(public.Writes).To(HaveLen(3))
+		Expect(public.Writes[0].Key).To(Equal(`a`))
+		Expect(public.Writes[0].Value).To(Equal([]byte(`public a`)))
+		Expect(public.Writes[2].Key).To(Equal(`old`))
+		Expect(public.Writes[2].IsDelete).To(BeTrue())
+
+		Expect(ns.CollectionHashedRwset).To(HaveLen(2))
+		Expect(ns.CollectionHashedRwset[1].CollectionName).To(Equal(`secrets`))
+		hashed := &kvrwset.HashedRWSet{}
+		Expect(proto.Unmarshal(ns.CollectionHashedRwset[1].HashedRwset, hashed)).To(Succeed())
+		Expect(hashed.HashedWrites).To(HaveLen(1))
+		Expect(hashed.HashedWrites[0].KeyHash).To(Equal(sha([]byte(`key`))))
+		Expect(hashed.HashedWrites[0].ValueHash).To(Equal(sha([]byte(`private value`))))
+
+		pvtRWSet, err := cc.LastTxPvtRWSetProto()
+		Expect(err).NotTo(HaveOccurred())
+		pvt := pvtRWSet.NsPvtRwset[0].CollectionPvtRwset[1]
+		Expect(pvt.CollectionName).To(Equal(`secrets`))
+		Expect(ns.CollectionHashedRwset[1].PvtRwsetHash).To(Equal(sha(pvt.Rwset)))
+
+		private := &kvrwset.KVRWSet{}
+		Expect(proto.Unmarshal(pvt.Rwset, private)).To(Succeed())
+		Expect(private.Writes[0].Value).To(Equal([]byte(`private value`)))
+	})
+
+	It("Allow to export tx without private writes", func() {
+		cc := testcc.NewMockStub(`counter`, NewCounterCC())
+		expectcc.ResponseOk(cc.Invoke(`set`, 1))
+
+		txRWSet, err := cc.LastTxRWSetProto()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(txRWSet.NsRwset[0].CollectionHashedRwset).To(BeEmpty())
+
+		pvtRWSet, err := cc.LastTxPvtRWSetProto()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pvtRWSet).To(BeNil())
+	})
+})
@@ -0,0 +1,288 @@
+package testing
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/pkg/errors"
+	"github.com/s7techlab/cckit/convert"
+)
+
+var (
+	// ErrMVCCReadConflict occurs when key, read by simulated tx, is written by tx, committed before it
+	ErrMVCCReadConflict = errors.New(`mvcc read conflict`)
+
+	// ErrSimulationNotCommittable occurs when simulation has error response or is already committed
+	ErrSimulationNotCommittable = errors.New(`simulation is not committable`)
+)
+
+type (
+	// Simulation endorsed, but not committed invoke, created with BeginSimulation
+	Simulation struct {
+		TxID     string
+		Args     [][]byte
+		Response peer.Response
+		Event    *peer.ChaincodeEvent
+		// Reads public state keys, read by simulation
+		Reads []string
+		// Ranges public state ranges, read by simulation
+		Ranges []KeyRange
+
+		timestamp *timestamp.Timestamp
+		creator   []byte
+		observed  map[string][]byte
+		// versions of read keys, id of tx, which last modified key
+		versions      map[string]string
+		writes        []*StateItem
+		privateWrites map[string][]*StateItem
+		committed     bool
+	}
+
+	// CommitResult outcome of simulation commit
+	CommitResult struct {
+		TxID      string
+		Committed bool
+		// Err validation error, tx writes and event are not applied
+		Err error
+	}
+
+	// CommitReport outcomes of simulations commit in commit order
+	CommitReport struct {
+		// Seed of commit order shuffle, zero if commits are not shuffled
+		Seed int64
+		// Order indexes of simulations in commit order
+		Order   []int
+		Results []CommitResult
+	}
+
+	// CommitOpts options of simulations commit
+	CommitOpts struct {
+		// Order indexes of simulations in commit order, submission order by default
+		Order []int
+		// Rand shuffles commit order
+		Rand *Rand
+	}
+
+	// CommitOpt option of simulations commit
+	CommitOpt func(*CommitOpts)
+)
+
+// WithCommitOrder sets commit order of simulations as permutation of simulation indexes
+func WithCommitOrder(order ...int) CommitOpt {
+	return func(opts *CommitOpts) {
+		opts.Order = order
+	}
+}
+
+// WithShuffledCommits shuffles commit order with random source, seed is reported in CommitReport.
+// NewRandTB can be used to log seed for reproduction
+func WithShuffledCommits(r *Rand) CommitOpt {
+	return func(opts *CommitOpts) {
+		opts.Rand = r
+	}
+}
+
+// BeginSimulation invokes chaincode function against current state without commit, like endorsing peer does.
+// Reads, writes and event are kept in simulation and applied with CommitSimulations, event is delivered on commit
+func (stub *MockStub) BeginSimulation(funcName string, iargs ...interface{}) *Simulation {
+	sim := &Simulation{TxID: stub.generateTxUID()}
+	fargs, err := convert.ArgsToBytes(iargs...)
+	if err != nil {
+		sim.Response = shim.Error(err.Error())
+		return sim
+	}
+	sim.Args = append([][]byte{[]byte(funcName)}, fargs...)
+
+	stub.checkReentrant(`BeginSimulation`)
+	stub.m.Lock()
+	defer stub.m.Unlock()
+	if err = stub.checkInjectedInvoker(); err != nil {
+		sim.Response = shim.Error(err.Error())
+		return sim
+	}
+	defer stub.clearDeclaredAccess()
+	defer stub.enterPhase(PhaseInvoke)()
+
+	snap := stub.snapshot()
+	sim.timestamp = stub.clockTimestamp()
+	out := stub.runTx(sim.TxID, sim.Args, sim.timestamp, false, snap)
+
+	sim.Response = out.response
+	sim.Event = out.event
+	sim.Reads = append([]string(nil), stub.txAccess.Reads...)
+	sim.Ranges = append([]KeyRange(nil), stub.txAccess.Ranges...)
+	sim.creator = snap.creator
+	sim.observed = snap.state
+	sim.writes = sortedStateItems(diffBytesMaps(``, snap.state, stub.State))
+	sim.privateWrites = make(map[string][]*StateItem)
+	for _, collection := range collections(snap.pvtState, stub.PvtState) {
+		if writes := diffBytesMaps(``, snap.pvtState[collection], stub.PvtState[collection]); len(writes) > 0 {
+			sim.privateWrites[collection] = sortedStateItems(writes)
+		}
+	}
+
+	stub.restore(snap)
+	sim.versions = make(map[string]string)
+	for _, key := range sim.readKeys(snap.state, nil) {
+		sim.versions[key] = stub.keyVersion(key)
+	}
+	if stub.ClearCreatorAfterInvoke {
+		stub.mockCreator = nil
+		stub.transient = nil
+	}
+	return sim
+}
+
+// CommitSimulations commits simulations in submission, explicit or shuffled order, like orderer and committing
+// peer do. Simulation, which public state reads are changed by previously committed tx, fails with
+// ErrMVCCReadConflict: read key is written, even with the same value, or key in read range is written,
+// added or deleted. Rich query results are not re-validated, like in Fabric.
+// Simulations with error response are not committed. Both committed and invalid txs are logged
+func (stub *MockStub) CommitSimulations(sims []*Simulation, opts ...CommitOpt) *CommitReport {
+	o := &CommitOpts{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	report := &CommitReport{Order: append([]int(nil), o.Order...)}
+	if len(report.Order) == 0 {
+		for i := range sims {
+			report.Order = append(report.Order, i)
+		}
+	}
+	if o.Rand != nil {
+		report.Seed = o.Rand.Seed
+		o.Rand.ShuffleSlice(report.Order)
+	}
+
+	stub.checkReentrant(`CommitSimulations`)
+	stub.m.Lock()
+	defer stub.m.Unlock()
+
+	for _, i := range report.Order {
+		err := stub.commitSimulation(sims[i])
+		report.Results = append(report.Results, CommitResult{TxID: sims[i].TxID, Committed: err == nil, Err: err})
+	}
+	return report
+}
+
+// Failed returns number of not committed simulations
+func (r *CommitReport) Failed() int {
+	failed := 0
+	for _, result := range r.Results {
+		if !result.Committed {
+			failed++
+		}
+	}
+	return failed
+}
+
+func (r *CommitReport) String() string {
+	str := fmt.Sprintf(`commit order %v, seed %d`, r.Order, r.Seed)
+	for _, result := range r.Results {
+		if result.Err != nil {
+			str += fmt.Sprintf("\n  %s: %s", result.TxID, result.Err)
+		}
+	}
+	return str
+}
+
+func (stub *MockStub) commitSimulation(sim *Simulation) error {
+	if sim.committed || sim.Response.Status >= shim.ERRORTHRESHOLD {
+		return fmt.Errorf(`tx %s: %w`, sim.TxID, ErrSimulationNotCommittable)
+	}
+	sim.committed = true
+
+	if key, ok := sim.readConflict(stub.State, stub.keyVersion); ok {
+		// invalid tx is recorded in block, but its writes and event are not applied
+		stub.LastValidationError = fmt.Errorf(`key %s: %w`, key, ErrMVCCReadConflict)
+		stub.endedTx = txOutcome{}
+		stub.logInvocation(sim.TxID, sim.Args, sim.Response)
+		stub.countTx(sim.Args, sim.Response)
+		return stub.LastValidationError
+	}
+
+	creator := stub.mockCreator
+	stub.startTx(sim.TxID)
+	stub.TxTimestamp = sim.timestamp
+	stub.mockCreator = sim.creator
+
+	for _, item := range sim.writes {
+		if item.Value == nil {
+			_ = stub.DelState(item.Key)
+		} else {
+			_ = stub.PutState(item.Key, item.Value)
+		}
+	}
+	for collection, writes := range sim.privateWrites {
+		for _, item := range writes {
+			if item.Value == nil {
+				_ = stub.DelPrivateData(collection, item.Key)
+			} else {
+				_ = stub.PutPrivateData(collection, item.Key, item.Value)
+			}
+		}
+	}
+	stub.ChaincodeEvent = sim.Event
+
+	stub.logInvocation(sim.TxID, sim.Args, sim.Response)
+	stub.MockTransactionEnd(sim.TxID)
+	stub.countTx(sim.Args, sim.Response)
+
+	if !stub.ClearCreatorAfterInvoke {
+		stub.mockCreator = creator
+	}
+	return stub.LastValidationError
+}
+
+// readConflict returns first key, read by simulation, which version, existence or value differs
+// from observed one. Version is id of tx, which last modified key
+func (sim *Simulation) readConflict(state map[string][]byte, version func(string) string) (string, bool) {
+	for _, key := range sim.readKeys(sim.observed, state) {
+		observed, existed := sim.observed[key]
+		current, exists := state[key]
+		if existed != exists || !bytes.Equal(observed, current) || sim.versions[key] != version(key) {
+			return key, true
+		}
+	}
+	return ``, false
+}
+
+// readKeys returns sorted keys, read by simulation, and keys of states in ranges, read by simulation.
+// Rich query reads are skipped
+func (sim *Simulation) readKeys(states ...map[string][]byte) []string {
+	keys := make(map[string]struct{})
+	for _, key := range sim.Reads {
+		keys[key] = struct{}{}
+	}
+	for _, r := range sim.Ranges {
+		if r.Query {
+			continue
+		}
+		for _, state := range states {
+			for key := range state {
+				if r.Contains(key) {
+					keys[key] = struct{}{}
+				}
+			}
+		}
+	}
+
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+func sortedStateItems(items []*StateItem) []*StateItem {
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Key < items[j].Key
+	})
+	return items
+}
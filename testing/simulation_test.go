@@ -0,0 +1,182 @@
+package testing_test
+
+import (
+	"sort"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/router"
+	p "github.com/s7techlab/cckit/router/param"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+// NewReportsCC reads state with key, range or rich query and writes number of read entries to report key
+func NewReportsCC() *router.Chaincode {
+	r := router.New(`reports`)
+
+	report := func(c router.Context, iter shim.StateQueryIteratorInterface, err error) (interface{}, error) {
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = iter.Close() }()
+		count := 0
+		for iter.HasNext() {
+			if _, err = iter.Next(); err != nil {
+				return nil, err
+			}
+			count++
+		}
+		return nil, c.Stub().PutState(`report`, []byte{byte('0' + count)})
+	}
+
+	r.Invoke(`set`, func(c router.Context) (interface{}, error) {
+		return nil, c.Stub().PutState(c.ParamString(`key`), []byte(c.ParamString(`value`)))
+	}, p.String(`key`), p.String(`value`)).
+		Invoke(`get`, func(c router.Context) (interface{}, error) {
+			value, err := c.Stub().GetState(c.ParamString(`key`))
+			if err != nil {
+				return nil, err
+			}
+			return nil, c.Stub().PutState(`report`, value)
+		}, p.String(`key`)).
+		Invoke(`range`, func(c router.Context) (interface{}, error) {
+			iter, err := c.Stub().GetStateByRange(c.ParamString(`start`), c.ParamString(`end`))
+			return report(c, iter, err)
+		}, p.String(`start`), p.String(`end`)).
+		Invoke(`query`, func(c router.Context) (interface{}, error) {
+			iter, err := c.Stub().GetQueryResult(`{"selector":{"v":1}}`)
+			return report(c, iter, err)
+		})
+
+	return router.NewChaincode(r)
+}
+
+var _ = Describe(`Simulation commit reordering`, func() {
+
+	var cc *testcc.MockStub
+
+	BeforeEach(func() {
+		cc = testcc.NewMockStub(`accounts`, NewAccountsCC())
+	})
+
+	It("Allow to deliver events of independent txs in shuffled commit order", func() {
+		events, _ := cc.EventSubscription()
+
+		accounts := []string{`alice`, `bob`, `carol`}
+		var sims []*testcc.Simulation
+		for _, account := range accounts {
+			sims = append(sims, cc.BeginSimulation(`deposit`, account, 10))
+		}
+
+		// nothing is committed and delivered until commit
+		Expect(cc.State).To(BeEmpty())
+		Expect(events).To(BeEmpty())
+
+		report := cc.CommitSimulations(sims, testcc.WithShuffledCommits(testcc.NewRandTB(GinkgoT())))
+		Expect(report.Failed()).To(BeZero(), report.String())
+
+		// listener receives events in commit order and restores set of deposited accounts
+		var received []string
+		for range accounts {
+			event := <-events
+			received = append(received, string(event.Payload))
+		}
+
+		var committed []string
+		for _, i := range report.Order {
+			committed = append(committed, accounts[i])
+		}
+		Expect(received).To(Equal(committed))
+
+		sort.Strings(received)
+		Expect(received).To(Equal(accounts))
+	})
+
+	It("Allow to commit simulations in explicit order", func() {
+		sims := []*testcc.Simulation{
+			cc.BeginSimulation(`deposit`, `alice`, 10),
+			cc.BeginSimulation(`deposit`, `alice`, 20),
+		}
+
+		report := cc.CommitSimulations(sims, testcc.WithCommitOrder(1, 0))
+		Expect(report.Failed()).To(BeZero())
+		Expect(report.Results[0].TxID).To(Equal(sims[1].TxID))
+
+		// blind writes don't conflict, last committed wins
+		Expect(cc.State[`alice`]).To(Equal([]byte(`10`)))
+	})
+
+	It("Disallow to commit simulation, which reads are changed by previously committed tx", func() {
+		expectcc.ResponseOk(cc.Invoke(`deposit`, `alice`, 100))
+
+		sims := []*testcc.Simulation{
+			cc.BeginSimulation(`withdraw`, `alice`, 30),
+			cc.BeginSimulation(`withdraw`, `alice`, 50),
+			cc.BeginSimulation(`deposit`, `bob`, 10),
+		}
+
+		report := cc.CommitSimulations(sims, testcc.WithCommitOrder(1, 2, 0))
+		Expect(report.Failed()).To(Equal(1))
+		Expect(report.Results[0].Committed).To(BeTrue())
+		Expect(report.Results[1].Committed).To(BeTrue())
+		Expect(report.Results[2].Err).To(MatchError(ContainSubstring(testcc.ErrMVCCReadConflict.Error())))
+
+		Expect(cc.State[`alice`]).To(Equal([]byte(`50`)))
+		Expect(cc.LastValidationError).To(HaveOccurred())
+
+		txs := cc.Transactions()
+		Expect(txs.Failed()).To(Equal(1))
+		Expect(txs[len(txs)-1].TxID).To(Equal(sims[0].TxID))
+	})
+
+	It("Disallow to commit simulation twice", func() {
+		sim := cc.BeginSimulation(`deposit`, `alice`, 10)
+		Expect(cc.CommitSimulations([]*testcc.Simulation{sim}).Failed()).To(BeZero())
+
+		report := cc.CommitSimulations([]*testcc.Simulation{sim})
+		Expect(report.Results[0].Err).To(MatchError(ContainSubstring(testcc.ErrSimulationNotCommittable.Error())))
+	})
+
+	It("Disallow to commit simulation, which read key is rewritten with the same value", func() {
+		cc := testcc.NewMockStub(`reports`, NewReportsCC())
+		expectcc.ResponseOk(cc.Invoke(`set`, `k`, `{"v":1}`))
+
+		sim := cc.BeginSimulation(`get`, `k`)
+		expectcc.ResponseOk(cc.Invoke(`set`, `k`, `{"v":1}`))
+
+		report := cc.CommitSimulations([]*testcc.Simulation{sim})
+		Expect(report.Results[0].Err).To(MatchError(ContainSubstring(testcc.ErrMVCCReadConflict.Error())))
+	})
+
+	It("Disallow to commit simulation with phantom key in read range", func() {
+		cc := testcc.NewMockStub(`reports`, NewReportsCC())
+		expectcc.ResponseOk(cc.Invoke(`set`, `BOOK_1`, `{"v":1}`))
+
+		sims := []*testcc.Simulation{
+			cc.BeginSimulation(`range`, `BOOK_1`, `BOOK_9`),
+			cc.BeginSimulation(`range`, `BOOK_1`, `BOOK_9`),
+		}
+		// key outside of range doesn't conflict
+		expectcc.ResponseOk(cc.Invoke(`set`, `BOOK_9`, `{"v":1}`))
+		Expect(cc.CommitSimulations(sims[:1]).Failed()).To(BeZero())
+
+		// phantom key inside of range, which doesn't have start key as prefix
+		expectcc.ResponseOk(cc.Invoke(`set`, `BOOK_5`, `{"v":1}`))
+		report := cc.CommitSimulations(sims[1:])
+		Expect(report.Results[0].Err).To(MatchError(ContainSubstring(`key BOOK_5`)))
+	})
+
+	It("Allow to commit simulation with rich query after other tx commit", func() {
+		cc := testcc.NewMockStub(`reports`, NewReportsCC())
+		expectcc.ResponseOk(cc.Invoke(`set`, `a`, `{"v":1}`))
+
+		sim := cc.BeginSimulation(`query`)
+		expectcc.ResponseOk(cc.Invoke(`set`, `b`, `{"v":1}`))
+
+		Expect(cc.CommitSimulations([]*testcc.Simulation{sim}).Failed()).To(BeZero())
+		Expect(cc.State[`report`]).To(Equal([]byte(`1`)))
+	})
+})
@@ -0,0 +1,116 @@
+package testing
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// DefaultStatsWarnings number of most recent warnings in stats
+const DefaultStatsWarnings = 10
+
+type (
+	// Stats counters of mocked chaincode, collected on tx end
+	Stats struct {
+		Chaincode string `json:"chaincode"`
+		// Invokes counts of init and invoke txs by function name
+		Invokes      map[string]int `json:"invokes"`
+		CommittedTxs int            `json:"committed_txs"`
+		StateKeys    int            `json:"state_keys"`
+		// PrivateCollections number of keys by collection
+		PrivateCollections map[string]int `json:"private_collections"`
+		// Events counts of committed events by name
+		Events map[string]int `json:"events"`
+		// Warnings most recent warnings, oldest first
+		Warnings []Warning `json:"warnings"`
+	}
+
+	// txCounters stats counters, updated on each tx
+	txCounters struct {
+		invokes      map[string]int
+		committedTxs int
+		events       map[string]int
+	}
+)
+
+// Stats returns counters of invokes, committed txs and events, state and private collections size
+// and most recent warnings
+func (stub *MockStub) Stats() Stats {
+	stub.checkReentrant(`Stats`)
+	stub.m.Lock()
+	defer stub.m.Unlock()
+
+	stats := Stats{
+		Chaincode:          stub.Name,
+		Invokes:            copyCounters(stub.counters.invokes),
+		CommittedTxs:       stub.counters.committedTxs,
+		StateKeys:          len(stub.State),
+		PrivateCollections: make(map[string]int),
+		Events:             copyCounters(stub.counters.events),
+		Warnings:           stub.Warnings(),
+	}
+
+	for _, collection := range stub.PrivateCollections() {
+		stats.PrivateCollections[collection] = stub.PrivateCollectionSize(collection)
+	}
+
+	if evict := len(stats.Warnings) - DefaultStatsWarnings; evict > 0 {
+		stats.Warnings = stats.Warnings[evict:]
+	}
+	stats.Warnings = append([]Warning{}, stats.Warnings...)
+
+	return stats
+}
+
+// StatsHandler serves stats of stubs as JSON object with chaincode names as keys,
+// intended to be mounted as GET /stats of test HTTP server
+func StatsHandler(stubs ...*MockStub) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set(`Allow`, http.MethodGet)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		stats := make(map[string]Stats, len(stubs))
+		for _, stub := range stubs {
+			stats[stub.Name] = stub.Stats()
+		}
+
+		w.Header().Set(`Content-Type`, `application/json`)
+		_ = json.NewEncoder(w).Encode(stats)
+	})
+}
+
+// countTx updates stats counters, called after tx end
+func (stub *MockStub) countTx(args [][]byte, response peer.Response) {
+	if stub.counters.invokes == nil {
+		stub.counters.invokes = make(map[string]int)
+		stub.counters.events = make(map[string]int)
+	}
+
+	function := ``
+	if len(args) > 0 {
+		function = string(args[0])
+	}
+	stub.counters.invokes[function]++
+
+	if response.Status >= shim.ERRORTHRESHOLD || stub.LastValidationError != nil {
+		return
+	}
+	stub.counters.committedTxs++
+
+	if stub.ChaincodeEvent != nil {
+		stub.counters.events[stub.ChaincodeEvent.EventName]++
+	}
+}
+
+func copyCounters(counters map[string]int) map[string]int {
+	copied := make(map[string]int, len(counters))
+	for key, count := range counters {
+		copied[key] = count
+	}
+	return copied
+}
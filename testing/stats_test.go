@@ -0,0 +1,79 @@
+package testing_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pkg/errors"
+	"github.com/s7techlab/cckit/router"
+	p "github.com/s7techlab/cckit/router/param"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+func NewStatsCC() *router.Chaincode {
+	r := router.New(`stats`)
+
+	r.Invoke(`put`, func(c router.Context) (interface{}, error) {
+		if err := c.Event().Set(`put`, c.ParamString(`key`)); err != nil {
+			return nil, err
+		}
+		return nil, c.Stub().PutState(c.ParamString(`key`), []byte(`value`))
+	}, p.String(`key`)).
+		Invoke(`putPrivate`, func(c router.Context) (interface{}, error) {
+			return nil, c.Stub().PutPrivateData(`collection`, c.ParamString(`key`), []byte(`value`))
+		}, p.String(`key`)).
+		Invoke(`fail`, func(c router.Context) (interface{}, error) {
+			return nil, errors.New(`failed`)
+		})
+
+	return router.NewChaincode(r)
+}
+
+var _ = Describe(`Stats`, func() {
+
+	It("Allow to serve stats of invokes, state and events", func() {
+		cc := testcc.NewMockStub(`stats`, NewStatsCC())
+
+		expectcc.ResponseOk(cc.Invoke(`put`, `a`))
+		expectcc.ResponseOk(cc.Invoke(`put`, `b`))
+		expectcc.ResponseOk(cc.Invoke(`putPrivate`, `c`))
+		expectcc.ResponseError(cc.Invoke(`fail`), `failed`)
+		expectcc.ResponseError(cc.Invoke(`put`, ``), testcc.ErrKeyEmpty)
+
+		server := httptest.NewServer(testcc.StatsHandler(cc))
+		defer server.Close()
+
+		res, err := http.Get(server.URL + `/stats`)
+		Expect(err).NotTo(HaveOccurred())
+		defer func() { _ = res.Body.Close() }()
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+
+		stats := make(map[string]testcc.Stats)
+		Expect(json.NewDecoder(res.Body).Decode(&stats)).To(Succeed())
+
+		Expect(stats[`stats`].Invokes).To(Equal(map[string]int{`put`: 3, `putPrivate`: 1, `fail`: 1}))
+		Expect(stats[`stats`].CommittedTxs).To(Equal(3))
+		// write to empty key is warned and rejected like in Fabric shim, so it's not counted
+		Expect(stats[`stats`].StateKeys).To(Equal(2))
+		Expect(stats[`stats`].PrivateCollections).To(Equal(map[string]int{`collection`: 1}))
+		Expect(stats[`stats`].Events).To(Equal(map[string]int{`put`: 2}))
+		Expect(stats[`stats`].Warnings).To(HaveLen(2))
+		Expect(stats[`stats`].Warnings[0].Code).To(Equal(testcc.WarningEmptyKey))
+		Expect(stats[`stats`].Warnings[1].Code).To(Equal(testcc.WarningEventInFailedTx))
+	})
+
+	It("Disallow to modify stats with non GET request", func() {
+		server := httptest.NewServer(testcc.StatsHandler(testcc.NewMockStub(`stats`, NewStatsCC())))
+		defer server.Close()
+
+		res, err := http.Post(server.URL+`/stats`, `application/json`, nil)
+		Expect(err).NotTo(HaveOccurred())
+		_ = res.Body.Close()
+		Expect(res.StatusCode).To(Equal(http.StatusMethodNotAllowed))
+	})
+})
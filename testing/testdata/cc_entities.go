@@ -0,0 +1,104 @@
+package testdata
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+
+	"github.com/s7techlab/cckit/router"
+	p "github.com/s7techlab/cckit/router/param"
+)
+
+const (
+	EntityKeyPrefix        = `ENTITY`
+	EntityByOwnerKeyPrefix = `ENTITY_BY_OWNER`
+
+	EntityCreatedEvent = `EntityCreated`
+)
+
+var ErrEntityOwnerRequired = errors.New(`entity owner required`)
+
+type Entity struct {
+	Id    string
+	Owner string
+	Value int
+}
+
+func (e Entity) Key() ([]string, error) {
+	return []string{EntityKeyPrefix, e.Id}, nil
+}
+
+// NewEntitiesCC chaincode with entity validation, secondary index and event on create
+func NewEntitiesCC() *router.Chaincode {
+	r := router.New(`entities`)
+
+	r.Init(router.EmptyContextHandler).
+		Invoke(`entityCreate`, entityCreate, p.Struct(`entity`, &Entity{})).
+		Query(`entityGet`, entityGet, p.String(`id`)).
+		Query(`entityList`, entityList).
+		Query(`entityListByOwner`, entityListByOwner, p.String(`owner`))
+
+	return router.NewChaincode(r)
+}
+
+func entityCreate(c router.Context) (interface{}, error) {
+	entity := c.Param(`entity`).(Entity)
+	if entity.Owner == `` {
+		return nil, ErrEntityOwnerRequired
+	}
+
+	if err := c.State().Insert(entity); err != nil {
+		return nil, err
+	}
+
+	idxKey, err := c.Stub().CreateCompositeKey(EntityByOwnerKeyPrefix, []string{entity.Owner, entity.Id})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = c.Stub().PutState(idxKey, []byte(entity.Id)); err != nil {
+		return nil, err
+	}
+
+	return entity, c.Event().Set(EntityCreatedEvent, entity)
+}
+
+func entityGet(c router.Context) (interface{}, error) {
+	return c.State().Get(Entity{Id: c.ParamString(`id`)}, &Entity{})
+}
+
+func entityList(c router.Context) (interface{}, error) {
+	return c.State().List(EntityKeyPrefix, &Entity{})
+}
+
+func entityListByOwner(c router.Context) (interface{}, error) {
+	iter, err := c.Stub().GetStateByPartialCompositeKey(EntityByOwnerKeyPrefix, []string{c.ParamString(`owner`)})
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = iter.Close() }()
+
+	var ids []string
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, string(kv.Value))
+	}
+
+	return ids, nil
+}
+
+// GenerateEntities generates sample entities, owners are picked with rnd
+func GenerateEntities(rnd *rand.Rand, count int, owners ...string) []Entity {
+	entities := make([]Entity, count)
+	for i := range entities {
+		entities[i] = Entity{
+			Id:    fmt.Sprintf(`entity-%d`, i),
+			Owner: owners[rnd.Intn(len(owners))],
+			Value: rnd.Intn(1000),
+		}
+	}
+	return entities
+}
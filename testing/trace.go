@@ -0,0 +1,44 @@
+package testing
+
+import (
+	"sync"
+
+	"github.com/s7techlab/cckit/router/trace"
+)
+
+// TraceSink in-memory sink of router trace spans, spans are attached to invocation records of MockStub,
+// configured with WithTraceSink, by tx id
+type TraceSink struct {
+	m     sync.Mutex
+	spans map[string][]trace.Span
+}
+
+// NewTraceSink creates in-memory trace sink, use it with trace.Middleware and WithTraceSink
+func NewTraceSink() *TraceSink {
+	return &TraceSink{spans: make(map[string][]trace.Span)}
+}
+
+// WithTraceSink attaches spans, recorded to sink during tx, to tx invocation record
+func WithTraceSink(sink *TraceSink) MockStubOpt {
+	return func(stub *MockStub) {
+		stub.traceSink = sink
+	}
+}
+
+// Record stores span until invocation with span tx id is logged
+func (s *TraceSink) Record(span trace.Span) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.spans[span.TxID] = append(s.spans[span.TxID], span)
+}
+
+// take returns and removes spans of tx
+func (s *TraceSink) take(txID string) []trace.Span {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	spans := s.spans[txID]
+	delete(s.spans, txID)
+	return spans
+}
@@ -0,0 +1,68 @@
+package testing_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/router"
+	"github.com/s7techlab/cckit/router/trace"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+var errNotAdmin = errors.New(`only admin allowed`)
+
+// onlyAdmin guard passes invocation with first arg `admin`
+func onlyAdmin(next router.HandlerFunc, _ ...int) router.HandlerFunc {
+	return func(c router.Context) (interface{}, error) {
+		if args := c.GetArgs(); len(args) < 2 || string(args[1]) != `admin` {
+			return nil, errNotAdmin
+		}
+		return next(c)
+	}
+}
+
+func NewTracedCC(sink trace.Sink) *router.Chaincode {
+	r := router.New(`traced`).Use(trace.Middleware(sink))
+
+	r.Invoke(`set`, func(c router.Context) (interface{}, error) {
+		return nil, c.Stub().PutState(`key`, []byte(`value`))
+	}, trace.Guard(`onlyAdmin`, onlyAdmin))
+
+	return router.NewChaincode(r)
+}
+
+var _ = Describe(`Router trace`, func() {
+
+	It("Allow to see middleware rejection reason in tx record", func() {
+		sink := testcc.NewTraceSink()
+		cc := testcc.NewMockStub(`traced`, NewTracedCC(sink), testcc.WithTraceSink(sink))
+
+		expectcc.ResponseError(cc.Invoke(`set`, `user`), errNotAdmin)
+
+		txs := cc.Transactions()
+		Expect(txs).To(HaveLen(1))
+		Expect(txs[0].Spans).To(HaveLen(1))
+
+		span := txs[0].Spans[0]
+		Expect(span.TxID).To(Equal(txs[0].TxID))
+		Expect(span.Method).To(Equal(`set`))
+		Expect(span.Type).To(Equal(router.MethodInvoke))
+		Expect(span.Error).To(Equal(errNotAdmin.Error()))
+		Expect(span.Middleware).To(Equal([]trace.Outcome{
+			{Middleware: `onlyAdmin`, Passed: false, Error: errNotAdmin.Error()}}))
+	})
+
+	It("Allow to see passed middleware in tx record", func() {
+		sink := testcc.NewTraceSink()
+		cc := testcc.NewMockStub(`traced`, NewTracedCC(sink), testcc.WithTraceSink(sink))
+
+		expectcc.ResponseOk(cc.Invoke(`set`, `admin`))
+
+		span := cc.Transactions()[0].Spans[0]
+		Expect(span.Error).To(BeEmpty())
+		Expect(span.Middleware).To(Equal([]trace.Outcome{{Middleware: `onlyAdmin`, Passed: true}}))
+	})
+})
@@ -0,0 +1,167 @@
+package testing
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/pkg/errors"
+
+	"github.com/s7techlab/cckit/router/trace"
+)
+
+type (
+	// TxRecord committed or failed tx of MockStub, built from invocation log
+	TxRecord struct {
+		TxID      string            `json:"txId"`
+		Timestamp time.Time         `json:"timestamp"`
+		Creator   string            `json:"creator,omitempty"`
+		Function  string            `json:"function"`
+		Transient map[string][]byte `json:"transient,omitempty"`
+		Writes    []TxWrite         `json:"writes"`
+		Event     *TxEvent          `json:"event,omitempty"`
+		Status    int32             `json:"status"`
+		Message   string            `json:"message,omitempty"`
+		// PayloadSize size of response payload in bytes
+		PayloadSize int `json:"payloadSize"`
+		// Failed tx response status is error or tx is invalid
+		Failed          bool   `json:"failed"`
+		ValidationError string `json:"validationError,omitempty"`
+		// Spans router trace spans of tx
+		Spans []trace.Span `json:"spans,omitempty"`
+		// Manual tx is started with MockTransactionStart directly, without chaincode invoke
+		Manual bool `json:"manual,omitempty"`
+	}
+
+	// TxWrite state write of tx
+	TxWrite struct {
+		Key      string `json:"key"`
+		Value    []byte `json:"value,omitempty"`
+		IsDelete bool   `json:"isDelete,omitempty"`
+	}
+
+	// TxEvent chaincode event of tx
+	TxEvent struct {
+		Name    string `json:"name"`
+		Payload []byte `json:"payload,omitempty"`
+	}
+
+	// TxRecords transactions report
+	TxRecords []TxRecord
+
+	// txOutcome creator, writes and event of tx, captured on tx end
+	txOutcome struct {
+		creator       string
+		writes        []*StateItem
+		deletes       []string
+		privateWrites []*privateWrite
+		event         *peer.ChaincodeEvent
+	}
+)
+
+// Transactions returns records of logged invocations in commit order, failed txs are flagged.
+// Number of records is limited by RetentionLimits.MaxInvocationLog
+func (stub *MockStub) Transactions() TxRecords {
+	records := make(TxRecords, 0, len(stub.invocationLog))
+	for _, invocation := range stub.invocationLog {
+		records = append(records, txRecord(invocation))
+	}
+	return records
+}
+
+// Failed returns number of failed txs
+func (records TxRecords) Failed() int {
+	failed := 0
+	for _, record := range records {
+		if record.Failed {
+			failed++
+		}
+	}
+	return failed
+}
+
+// WriteJSON writes report with transactions and counts as JSON. Transient values are redacted,
+// unless WithIncludeTransient is used
+func (records TxRecords) WriteJSON(w io.Writer) error {
+	report := struct {
+		Count        int       `json:"count"`
+		Failed       int       `json:"failed"`
+		Transactions TxRecords `json:"transactions"`
+	}{
+		Count:        len(records),
+		Failed:       records.Failed(),
+		Transactions: records,
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent(``, `  `)
+	return errors.Wrap(encoder.Encode(report), `write transactions`)
+}
+
+func txRecord(invocation *Invocation) TxRecord {
+	record := TxRecord{
+		TxID:        invocation.TxID,
+		Creator:     invocation.Creator,
+		Transient:   invocation.Transient,
+		Writes:      []TxWrite{},
+		Status:      invocation.Response.Status,
+		Message:     invocation.Response.Message,
+		PayloadSize: invocation.PayloadSize,
+		Failed:      invocation.Response.Status >= shim.ERRORTHRESHOLD || invocation.ValidationError != nil,
+		Spans:       invocation.Spans,
+		Manual:      invocation.Manual,
+	}
+
+	if invocation.Timestamp != nil {
+		record.Timestamp, _ = ptypes.Timestamp(invocation.Timestamp)
+	}
+	if len(invocation.Args) > 0 {
+		record.Function = string(invocation.Args[0])
+	}
+	if invocation.ValidationError != nil {
+		record.ValidationError = invocation.ValidationError.Error()
+	}
+	for _, key := range invocation.Deletes {
+		record.Writes = append(record.Writes, TxWrite{Key: key, IsDelete: true})
+	}
+	for _, item := range invocation.Writes {
+		record.Writes = append(record.Writes, TxWrite{Key: item.Key, Value: item.Value})
+	}
+	if invocation.Event != nil {
+		record.Event = &TxEvent{Name: invocation.Event.EventName, Payload: invocation.Event.Payload}
+	}
+	return record
+}
+
+// txOutcome captures creator fingerprint, writes and event of current tx
+func (stub *MockStub) txOutcome() txOutcome {
+	outcome := txOutcome{
+		creator:       stub.creatorFingerprint(),
+		deletes:       append([]string(nil), stub.txDeletes...),
+		privateWrites: append([]*privateWrite(nil), stub.txPrivateWrites...),
+		event:         stub.ChaincodeEvent,
+	}
+	for _, item := range stub.StateBuffer {
+		outcome.writes = append(outcome.writes, &StateItem{Key: item.Key, Value: stub.copyValue(item.Value)})
+	}
+	return outcome
+}
+
+// creatorFingerprint returns MSP ID and short hash of tx creator, empty if creator is not set
+func (stub *MockStub) creatorFingerprint() string {
+	if len(stub.mockCreator) == 0 {
+		return ``
+	}
+
+	hash := sha256.Sum256(stub.mockCreator)
+	fingerprint := hex.EncodeToString(hash[:8])
+	if creator, err := stub.creatorIdentity(); err == nil {
+		return creator.Mspid + `:` + fingerprint
+	}
+	return fingerprint
+}
@@ -0,0 +1,66 @@
+package testing_test
+
+import (
+	"bytes"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	idtestdata "github.com/s7techlab/cckit/identity/testdata"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+var _ = Describe(`Transactions report`, func() {
+
+	It("Allow to export committed and failed transactions", func() {
+		cc := testcc.NewMockStub(`stats`, NewStatsCC())
+
+		expectcc.ResponseOk(cc.From(Authority).WithTransient(map[string][]byte{`secret`: []byte(`top-secret`)}).
+			Invoke(`put`, `a`))
+		expectcc.ResponseOk(cc.Invoke(`put`, `b`))
+		expectcc.ResponseOk(cc.Invoke(`putPrivate`, `c`))
+		expectcc.ResponseError(cc.Invoke(`fail`), `failed`)
+		expectcc.ResponseOk(cc.Invoke(`put`, `d`))
+
+		txs := cc.Transactions()
+		Expect(txs).To(HaveLen(5))
+		Expect(txs.Failed()).To(Equal(1))
+
+		buf := &bytes.Buffer{}
+		Expect(txs.WriteJSON(buf)).To(Succeed())
+		Expect(buf.String()).NotTo(ContainSubstring(`top-secret`))
+
+		report := struct {
+			Count        int
+			Failed       int
+			Transactions []testcc.TxRecord
+		}{}
+		Expect(json.Unmarshal(buf.Bytes(), &report)).To(Succeed())
+		Expect(report.Count).To(Equal(5))
+		Expect(report.Failed).To(Equal(1))
+
+		var functions []string
+		for _, tx := range report.Transactions {
+			functions = append(functions, tx.Function)
+			Expect(tx.TxID).NotTo(BeEmpty())
+			Expect(tx.Timestamp.IsZero()).To(BeFalse())
+		}
+		Expect(functions).To(Equal([]string{`put`, `put`, `putPrivate`, `fail`, `put`}))
+
+		first := report.Transactions[0]
+		Expect(first.Creator).To(HavePrefix(idtestdata.DefaultMSP + `:`))
+		Expect(first.Writes).To(Equal([]testcc.TxWrite{{Key: `a`, Value: []byte(`value`)}}))
+		Expect(first.Event).To(Equal(&testcc.TxEvent{Name: `put`, Payload: []byte(`a`)}))
+		Expect(first.Transient).To(HaveKey(`secret`))
+		Expect(first.Failed).To(BeFalse())
+
+		Expect(report.Transactions[2].Writes).To(BeEmpty())
+
+		failed := report.Transactions[3]
+		Expect(failed.Failed).To(BeTrue())
+		Expect(failed.Status).To(BeNumerically(`>=`, 400))
+		Expect(failed.Message).To(Equal(`failed`))
+	})
+})
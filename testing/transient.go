@@ -0,0 +1,52 @@
+package testing
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sort"
+)
+
+// WithIncludeTransient disables redaction of transient values in recorded invocations
+func WithIncludeTransient() MockStubOpt {
+	return func(stub *MockStub) {
+		stub.includeTransient = true
+	}
+}
+
+// TransientKeys returns sorted keys of transient map, next invoke will receive
+func (stub *MockStub) TransientKeys() []string {
+	keys := make([]string, 0, len(stub.transient))
+	for key := range stub.transient {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// TransientLen returns number of entries in transient map, next invoke will receive
+func (stub *MockStub) TransientLen() int {
+	return len(stub.transient)
+}
+
+// RedactTransientValue returns hash and length marker, replacing transient value in recordings
+func RedactTransientValue(value []byte) []byte {
+	hash := sha256.Sum256(value)
+	return []byte(fmt.Sprintf(`[redacted sha256:%x len:%d]`, hash[:8], len(value)))
+}
+
+// recordedTransient returns copy of current transient map for recording, values are redacted at capture time
+func (stub *MockStub) recordedTransient() map[string][]byte {
+	if len(stub.transient) == 0 {
+		return nil
+	}
+
+	recorded := make(map[string][]byte, len(stub.transient))
+	for key, value := range stub.transient {
+		if stub.includeTransient {
+			recorded[key] = append([]byte(nil), value...)
+		} else {
+			recorded[key] = RedactTransientValue(value)
+		}
+	}
+	return recorded
+}
@@ -0,0 +1,95 @@
+package testing_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/router"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+var transientSecret = []byte(`very-secret-transient-value`)
+
+// containsSecret checks serialized output for raw and base64 encoded (as json marshals []byte) secret bytes
+func containsSecret(serialized []byte) bool {
+	return bytes.Contains(serialized, transientSecret) ||
+		bytes.Contains(serialized, []byte(base64.StdEncoding.EncodeToString(transientSecret)))
+}
+
+func NewTransientCC() *router.Chaincode {
+	r := router.New(`transient`)
+
+	r.Invoke(`store`, func(c router.Context) (interface{}, error) {
+		transient, err := c.Stub().GetTransient()
+		if err != nil {
+			return nil, err
+		}
+		return nil, c.Stub().PutState(`hash`, testcc.RedactTransientValue(transient[`secret`]))
+	}).
+		Invoke(`corrupt`, func(c router.Context) (interface{}, error) {
+			transient, err := c.Stub().GetTransient()
+			if err != nil {
+				return nil, err
+			}
+			copy(transient[`secret`], `corrupted`)
+			delete(transient, `secret`)
+			transient[`injected`] = []byte(`value`)
+			return nil, nil
+		})
+
+	return router.NewChaincode(r)
+}
+
+// recorded serializes invocation log, state dump and proposal response of stub
+func recorded(stub *testcc.MockStub) []byte {
+	buf := new(bytes.Buffer)
+
+	log, err := json.Marshal(stub.InvocationLog())
+	Expect(err).NotTo(HaveOccurred())
+	buf.Write(log)
+
+	Expect(testcc.WriteFixture(buf, stub.DumpState())).To(Succeed())
+	buf.Write(testcc.MustProtoMarshal(stub.LastProposalResponse()))
+
+	return buf.Bytes()
+}
+
+var _ = Describe(`Transient`, func() {
+
+	It("Allow to redact transient values in recordings by default", func() {
+		stub := testcc.NewMockStub(`transient`, NewTransientCC())
+		expectcc.ResponseOk(stub.WithTransient(map[string][]byte{`secret`: transientSecret}).Invoke(`store`))
+
+		Expect(containsSecret(recorded(stub))).To(BeFalse())
+		Expect(stub.InvocationLog()[0].Transient[`secret`]).To(
+			Equal(testcc.RedactTransientValue(transientSecret)))
+	})
+
+	It("Allow to include transient values explicitly", func() {
+		stub := testcc.NewMockStub(`transient`, NewTransientCC(), testcc.WithIncludeTransient())
+		expectcc.ResponseOk(stub.WithTransient(map[string][]byte{`secret`: transientSecret}).Invoke(`store`))
+
+		Expect(containsSecret(recorded(stub))).To(BeTrue())
+	})
+
+	It("Disallow chaincode to change transient map of stub", func() {
+		stub := testcc.NewMockStub(`transient`, NewTransientCC())
+		stub.ClearCreatorAfterInvoke = false
+		stub.WithTransient(map[string][]byte{`secret`: append([]byte(nil), transientSecret...)})
+
+		expectcc.ResponseOk(stub.Invoke(`corrupt`))
+		Expect(stub.TransientKeys()).To(Equal([]string{`secret`}))
+		Expect(stub.TransientLen()).To(Equal(1))
+
+		expectcc.ResponseOk(stub.Invoke(`store`))
+		for _, invocation := range stub.InvocationLog() {
+			Expect(invocation.Transient).To(Equal(map[string][]byte{
+				`secret`: testcc.RedactTransientValue(transientSecret)}))
+		}
+	})
+})
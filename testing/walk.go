@@ -0,0 +1,56 @@
+package testing
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/pkg/errors"
+)
+
+// ErrWalkPages occurs when paginated function query fails or returns the same bookmark again
+var ErrWalkPages = errors.New(`walk pages`)
+
+// WalkPages queries paginated function with page size and bookmark of previous page until last page,
+// function should return response.PagedResponse. Returns decoded items of all pages
+func WalkPages(stub *MockStub, funcName string, pageSize int32,
+	decodeItem func([]byte) (interface{}, error)) ([]interface{}, error) {
+
+	var (
+		items    []interface{}
+		bookmark string
+		seen     = make(map[string]bool)
+	)
+
+	for {
+		res := stub.Query(funcName, pageSize, bookmark)
+		if res.Status >= shim.ERRORTHRESHOLD {
+			return nil, fmt.Errorf(`%w: %s, bookmark "%s": %s`, ErrWalkPages, funcName, bookmark, res.Message)
+		}
+
+		page := struct {
+			Items    []json.RawMessage `json:"items"`
+			Bookmark string            `json:"bookmark"`
+		}{}
+		if err := json.Unmarshal(res.Payload, &page); err != nil {
+			return nil, fmt.Errorf(`%w: %s, bookmark "%s": %s`, ErrWalkPages, funcName, bookmark, err)
+		}
+
+		for _, raw := range page.Items {
+			item, err := decodeItem(raw)
+			if err != nil {
+				return nil, fmt.Errorf(`%w: %s, decode item: %s`, ErrWalkPages, funcName, err)
+			}
+			items = append(items, item)
+		}
+
+		if page.Bookmark == `` {
+			return items, nil
+		}
+		if seen[page.Bookmark] {
+			return nil, fmt.Errorf(`%w: %s, bookmark "%s" returned again`, ErrWalkPages, funcName, page.Bookmark)
+		}
+		seen[page.Bookmark] = true
+		bookmark = page.Bookmark
+	}
+}
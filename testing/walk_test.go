@@ -0,0 +1,75 @@
+package testing_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/s7techlab/cckit/response"
+	"github.com/s7techlab/cckit/router"
+	p "github.com/s7techlab/cckit/router/param"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+type Document struct {
+	ID string
+}
+
+func NewDocumentsCC() *router.Chaincode {
+	r := router.New(`documents`)
+
+	r.Invoke(`create`, func(c router.Context) (interface{}, error) {
+		id := c.ParamString(`id`)
+		return nil, c.State().Put([]string{`DOCUMENT`, id}, &Document{ID: id})
+	}, p.String(`id`)).
+		Query(`list`, func(c router.Context) (interface{}, error) {
+			items, metadata, err := c.State().ListPaginated(`DOCUMENT`,
+				int32(c.ParamInt(`pageSize`)), c.ParamString(`bookmark`), &Document{})
+			if err != nil {
+				return nil, err
+			}
+			return response.NewPagedResponse(items, metadata), nil
+		}, p.Int(`pageSize`), p.String(`bookmark`)).
+		Query(`listLoop`, func(c router.Context) (interface{}, error) {
+			return &response.PagedResponse{Items: []Document{}, Bookmark: `same`}, nil
+		}, p.Int(`pageSize`), p.String(`bookmark`))
+
+	return router.NewChaincode(r)
+}
+
+func decodeDocument(bb []byte) (interface{}, error) {
+	document := Document{}
+	err := json.Unmarshal(bb, &document)
+	return document, err
+}
+
+var _ = Describe(`Walk pages`, func() {
+
+	It("Allow to walk all pages of paginated list", func() {
+		cc := testcc.NewMockStub(`documents`, NewDocumentsCC())
+		for i := 0; i < 7; i++ {
+			expectcc.ResponseOk(cc.Invoke(`create`, fmt.Sprintf(`doc-%d`, i)))
+		}
+
+		page := expectcc.PayloadIs(cc.Query(`list`, 3, ``), &response.PagedResponse{}).(response.PagedResponse)
+		Expect(page.Count).To(Equal(int32(3)))
+		Expect(page.Bookmark).NotTo(BeEmpty())
+
+		items, err := testcc.WalkPages(cc, `list`, 3, decodeDocument)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(items).To(HaveLen(7))
+		Expect(items[0]).To(Equal(Document{ID: `doc-0`}))
+		Expect(items[6]).To(Equal(Document{ID: `doc-6`}))
+	})
+
+	It("Disallow to walk pages with repeated bookmark", func() {
+		cc := testcc.NewMockStub(`documents`, NewDocumentsCC())
+
+		_, err := testcc.WalkPages(cc, `listLoop`, 3, decodeDocument)
+		Expect(errors.Is(err, testcc.ErrWalkPages)).To(BeTrue())
+	})
+})
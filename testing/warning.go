@@ -0,0 +1,179 @@
+package testing
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/pkg/errors"
+)
+
+// Warning codes, emitted by MockStub checks
+const (
+	WarningEmptyKey         = `empty_key`
+	WarningEventInFailedTx  = `event_in_failed_tx`
+	WarningOversizedPayload = `oversized_payload`
+	WarningUndeclaredAccess = `undeclared_access`
+	WarningPrivateDataLeak  = `private_data_leak`
+	WarningEventOverwritten = `event_overwritten`
+	WarningLateStubAccess   = `late_stub_access`
+)
+
+// DefaultPayloadWarningSize response payload size, exceeding which emits WarningOversizedPayload
+const DefaultPayloadWarningSize = 1 << 20
+
+// ErrWarningEscalated occurs when tx emits warning with code, escalated with FailOnWarnings
+var ErrWarningEscalated = errors.New(`warning escalated to failure`)
+
+type (
+	// Warning suspicious chaincode behavior, detected by MockStub
+	Warning struct {
+		Code    string
+		TxID    string
+		Message string
+		Key     string
+		// Stack of goroutine, which caused warning, is set for WarningLateStubAccess
+		Stack string
+	}
+
+	// warnings settings and emitted warnings
+	warnings struct {
+		m           sync.Mutex // warnings can be emitted by goroutines, outliving tx
+		items       []Warning
+		logger      TB
+		failOn      map[string]bool
+		failOnAll   bool
+		txFailure   *Warning
+		payloadSize int
+	}
+)
+
+func (w Warning) String() string {
+	if w.Key != `` {
+		return fmt.Sprintf(`[%s] tx %s, key "%s": %s`, w.Code, w.TxID, w.Key, w.Message)
+	}
+	return fmt.Sprintf(`[%s] tx %s: %s`, w.Code, w.TxID, w.Message)
+}
+
+// WithWarningsLogger forwards emitted warnings to tb logger
+func WithWarningsLogger(tb TB) MockStubOpt {
+	return func(stub *MockStub) {
+		stub.warnings.logger = tb
+	}
+}
+
+// WithPayloadWarningSize sets response payload size, exceeding which emits WarningOversizedPayload
+func WithPayloadWarningSize(size int) MockStubOpt {
+	return func(stub *MockStub) {
+		stub.warnings.payloadSize = size
+	}
+}
+
+// FailOnWarnings escalates warnings with codes (all codes, if not set) to tx failure:
+// response is replaced with error and tx writes and event are not committed
+func (stub *MockStub) FailOnWarnings(codes ...string) *MockStub {
+	if len(codes) == 0 {
+		stub.warnings.failOnAll = true
+	}
+	if stub.warnings.failOn == nil {
+		stub.warnings.failOn = make(map[string]bool)
+	}
+	for _, code := range codes {
+		stub.warnings.failOn[code] = true
+	}
+	return stub
+}
+
+// Warnings returns warnings, emitted since stub creation or ClearWarnings
+func (stub *MockStub) Warnings() []Warning {
+	stub.warnings.m.Lock()
+	defer stub.warnings.m.Unlock()
+
+	return append([]Warning(nil), stub.warnings.items...)
+}
+
+// ClearWarnings clears emitted warnings
+func (stub *MockStub) ClearWarnings() {
+	stub.warnings.m.Lock()
+	defer stub.warnings.m.Unlock()
+
+	stub.warnings.items = nil
+}
+
+// Warn emits warning for current tx
+func (stub *MockStub) Warn(code, key, message string) {
+	warning := Warning{Code: code, TxID: stub.TxID, Message: message, Key: key}
+	stub.addWarning(warning)
+
+	if (stub.warnings.failOnAll || stub.warnings.failOn[code]) && stub.warnings.txFailure == nil && stub.TxID != `` {
+		stub.warnings.txFailure = &warning
+	}
+}
+
+// addWarning stores and logs warning
+func (stub *MockStub) addWarning(warning Warning) {
+	stub.warnings.m.Lock()
+	stub.warnings.items = append(stub.warnings.items, warning)
+	stub.warnings.m.Unlock()
+
+	if stub.warnings.logger != nil {
+		stub.warnings.logger.Logf(`mockstub %s warning: %s`, stub.Name, warning)
+	}
+}
+
+// checkResponseWarnings emits warnings about tx response and escalates tx warnings, called before tx end
+func (stub *MockStub) checkResponseWarnings(response peer.Response) peer.Response {
+	if response.Status >= shim.ERRORTHRESHOLD && stub.ChaincodeEvent != nil {
+		stub.Warn(WarningEventInFailedTx, ``,
+			fmt.Sprintf(`event %s is set in failed tx: %s`, stub.ChaincodeEvent.EventName, response.Message))
+	}
+
+	payloadSize := stub.warnings.payloadSize
+	if payloadSize == 0 {
+		payloadSize = DefaultPayloadWarningSize
+	}
+	if len(response.Payload) > payloadSize {
+		stub.Warn(WarningOversizedPayload, ``,
+			fmt.Sprintf(`response payload size %d exceeds %d`, len(response.Payload), payloadSize))
+	}
+
+	stub.checkPrivateDataLeaks(response.Payload)
+	stub.checkEventsOverwritten()
+	if response.Status < shim.ERRORTHRESHOLD {
+		stub.checkEventReferences()
+	}
+
+	failure := stub.warnings.txFailure
+	stub.warnings.txFailure = nil
+	if failure == nil {
+		return response
+	}
+
+	// escalated tx is not committed
+	stub.StateBuffer = nil
+	stub.ChaincodeEvent = nil
+	return shim.Error(fmt.Sprintf(`%s: %s`, ErrWarningEscalated, failure))
+}
+
+// checkEventsOverwritten emits warning, if events with different names are set in tx:
+// peer delivers only last event, events set before are lost
+func (stub *MockStub) checkEventsOverwritten() {
+	if len(stub.txEventNames) < 2 {
+		return
+	}
+
+	last := stub.txEventNames[len(stub.txEventNames)-1]
+	var overwritten []string
+	for _, name := range stub.txEventNames[:len(stub.txEventNames)-1] {
+		if name != last {
+			overwritten = append(overwritten, name)
+		}
+	}
+
+	if len(overwritten) > 0 {
+		stub.Warn(WarningEventOverwritten, ``, fmt.Sprintf(`events %s are overwritten by event %s, set in order: %s`,
+			strings.Join(overwritten, `, `), last, strings.Join(stub.txEventNames, ` -> `)))
+	}
+}
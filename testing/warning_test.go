@@ -0,0 +1,137 @@
+package testing_test
+
+import (
+	"fmt"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pkg/errors"
+	"github.com/s7techlab/cckit/router"
+	p "github.com/s7techlab/cckit/router/param"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+type logsCollector []string
+
+func (c *logsCollector) Logf(format string, args ...interface{}) {
+	*c = append(*c, fmt.Sprintf(format, args...))
+}
+
+func NewWarningsCC() *router.Chaincode {
+	r := router.New(`warnings`)
+
+	r.Invoke(`put`, func(c router.Context) (interface{}, error) {
+		return nil, c.Stub().PutState(c.ParamString(`key`), []byte(`value`))
+	}, p.String(`key`)).
+		Invoke(`putLarge`, func(c router.Context) (interface{}, error) {
+			if err := c.Stub().PutState(`large`, []byte(`value`)); err != nil {
+				return nil, err
+			}
+			return strings.Repeat(`x`, 100), nil
+		}).
+		Invoke(`failWithEvent`, func(c router.Context) (interface{}, error) {
+			if err := c.Event().Set(`event`, `payload`); err != nil {
+				return nil, err
+			}
+			return nil, errors.New(`failed`)
+		})
+
+	return router.NewChaincode(r)
+}
+
+var _ = Describe(`Warnings`, func() {
+
+	It("Allow to collect and log warnings", func() {
+		var logs logsCollector
+		cc := testcc.NewMockStub(`warnings`, NewWarningsCC(),
+			testcc.WithWarningsLogger(&logs), testcc.WithPayloadWarningSize(10))
+
+		expectcc.ResponseError(cc.Invoke(`put`, ``), testcc.ErrKeyEmpty)
+		expectcc.ResponseOk(cc.Invoke(`putLarge`))
+		expectcc.ResponseError(cc.Invoke(`failWithEvent`), `failed`)
+
+		warnings := cc.Warnings()
+		Expect(warnings).To(HaveLen(3))
+		Expect(warnings[0].Code).To(Equal(testcc.WarningEmptyKey))
+		Expect(warnings[1].Code).To(Equal(testcc.WarningOversizedPayload))
+		Expect(warnings[2].Code).To(Equal(testcc.WarningEventInFailedTx))
+		Expect(warnings[2].TxID).NotTo(BeEmpty())
+
+		Expect(logs).To(HaveLen(3))
+		Expect(logs[1]).To(ContainSubstring(testcc.WarningOversizedPayload))
+
+		cc.ClearWarnings()
+		Expect(cc.Warnings()).To(BeEmpty())
+	})
+
+	It("Allow to escalate selected warnings to tx failure", func() {
+		cc := testcc.NewMockStub(`warnings`, NewWarningsCC(), testcc.WithPayloadWarningSize(10)).
+			FailOnWarnings(testcc.WarningOversizedPayload)
+
+		// not escalated
+		expectcc.ResponseError(cc.Invoke(`failWithEvent`), `failed`)
+
+		expectcc.ResponseError(cc.Invoke(`putLarge`), testcc.ErrWarningEscalated)
+		Expect(cc.State).NotTo(HaveKey(`large`))
+		Expect(cc.Warnings()).To(HaveLen(2))
+	})
+})
+
+func NewTransferEventsCC() *router.Chaincode {
+	debit := func(c router.Context) error { return c.Event().Set(`Debited`, c.ParamString(`from`)) }
+	credit := func(c router.Context) error { return c.Event().Set(`Credited`, c.ParamString(`to`)) }
+
+	r := router.New(`transfer`)
+
+	r.Invoke(`transfer`, func(c router.Context) (interface{}, error) {
+		if err := debit(c); err != nil {
+			return nil, err
+		}
+		return nil, credit(c)
+	}, p.String(`from`), p.String(`to`)).
+		Invoke(`transferSingleEvent`, func(c router.Context) (interface{}, error) {
+			if err := c.Event().Set(`Transferred`, c.ParamString(`from`)); err != nil {
+				return nil, err
+			}
+			// event is refined after sub-operations with the same name
+			return nil, c.Event().Set(`Transferred`, c.ParamString(`from`)+`->`+c.ParamString(`to`))
+		}, p.String(`from`), p.String(`to`))
+
+	return router.NewChaincode(r)
+}
+
+var _ = Describe(`Event overwritten warning`, func() {
+
+	It("Allow to detect events with different names set in one tx", func() {
+		cc := testcc.NewMockStub(`transfer`, NewTransferEventsCC())
+
+		expectcc.ResponseOk(cc.Invoke(`transfer`, `a`, `b`))
+
+		warnings := cc.Warnings()
+		Expect(warnings).To(HaveLen(1))
+		Expect(warnings[0].Code).To(Equal(testcc.WarningEventOverwritten))
+		Expect(warnings[0].Message).To(Equal(
+			`events Debited are overwritten by event Credited, set in order: Debited -> Credited`))
+		Expect(cc.ChaincodeEvent.EventName).To(Equal(`Credited`))
+	})
+
+	It("Allow to set single event name several times in one tx", func() {
+		cc := testcc.NewMockStub(`transfer`, NewTransferEventsCC())
+
+		expectcc.ResponseOk(cc.Invoke(`transferSingleEvent`, `a`, `b`))
+		expectcc.ResponseOk(cc.Invoke(`transfer`, `a`, `b`))
+		expectcc.ResponseOk(cc.Invoke(`transferSingleEvent`, `a`, `b`))
+
+		Expect(cc.Warnings()).To(HaveLen(1))
+	})
+
+	It("Allow to escalate overwritten event to failure", func() {
+		cc := testcc.NewMockStub(`transfer`, NewTransferEventsCC()).FailOnWarnings(testcc.WarningEventOverwritten)
+
+		expectcc.ResponseError(cc.Invoke(`transfer`, `a`, `b`), testcc.ErrWarningEscalated)
+		Expect(cc.ChaincodeEvent).To(BeNil())
+	})
+})
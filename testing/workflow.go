@@ -0,0 +1,120 @@
+package testing
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrWorkflowViolation occurs when logged transactions don't satisfy workflow constraint
+var ErrWorkflowViolation = errors.New(`workflow violation`)
+
+type (
+	// Constraint ordering constraint of workflow step, checked for each successful tx of Func
+	// against latest preceding successful txs of referenced functions
+	Constraint struct {
+		// Func constrained workflow step
+		Func string
+		// AfterFunc step, required to precede Func
+		AfterFunc string
+		// SameCreatorAs step, which creator must be Func creator
+		SameCreatorAs string
+		// DifferentCreatorFrom step, which creator must not be Func creator
+		DifferentCreatorFrom string
+		// Within max number of logged transactions between AfterFunc and Func txs, 0 - unlimited
+		Within int
+	}
+
+	// WorkflowViolation describes tx, violating workflow constraint
+	WorkflowViolation struct {
+		Constraint Constraint
+		// TxIDs violating tx and preceding tx, it's checked against, if exists
+		TxIDs  []string
+		Reason string
+	}
+)
+
+func (v *WorkflowViolation) Error() string {
+	return fmt.Sprintf(`%s: %s: %s, txs %s`,
+		ErrWorkflowViolation, v.Constraint.Func, v.Reason, strings.Join(v.TxIDs, `, `))
+}
+
+func (v *WorkflowViolation) Unwrap() error {
+	return ErrWorkflowViolation
+}
+
+// AssertWorkflow checks logged transactions of stub against constraints and reports violations to t
+func AssertWorkflow(t ErrorReporter, stub *MockStub, constraints []Constraint) bool {
+	violations := CheckWorkflow(stub.Transactions(), constraints)
+	for _, violation := range violations {
+		t.Errorf(`%s`, violation)
+	}
+	return len(violations) == 0
+}
+
+// CheckWorkflow returns violations of constraints by transactions. Failed transactions are skipped,
+// but counted for Within distance
+func CheckWorkflow(records TxRecords, constraints []Constraint) []*WorkflowViolation {
+	var violations []*WorkflowViolation
+
+	for _, constraint := range constraints {
+		// index of latest successful tx of function
+		latest := make(map[string]int)
+
+		for i, record := range records {
+			if record.Failed {
+				continue
+			}
+
+			if record.Function == constraint.Func {
+				violations = append(violations, constraint.check(records, latest, i)...)
+			}
+			latest[record.Function] = i
+		}
+	}
+
+	return violations
+}
+
+func (c Constraint) check(records TxRecords, latest map[string]int, i int) []*WorkflowViolation {
+	var violations []*WorkflowViolation
+	record := records[i]
+
+	violation := func(reason string, preceding ...int) {
+		txIDs := []string{record.TxID}
+		for _, p := range preceding {
+			txIDs = append(txIDs, records[p].TxID)
+		}
+		violations = append(violations, &WorkflowViolation{Constraint: c, TxIDs: txIDs, Reason: reason})
+	}
+
+	if c.AfterFunc != `` {
+		after, ok := latest[c.AfterFunc]
+		switch {
+		case !ok:
+			violation(`not preceded by ` + c.AfterFunc)
+		case c.Within > 0 && i-after > c.Within:
+			violation(fmt.Sprintf(`%d txs after %s, allowed within %d`, i-after, c.AfterFunc, c.Within), after)
+		}
+	}
+
+	if c.SameCreatorAs != `` {
+		step, ok := latest[c.SameCreatorAs]
+		switch {
+		case !ok:
+			violation(`no preceding ` + c.SameCreatorAs + ` to compare creator with`)
+		case records[step].Creator != record.Creator:
+			violation(fmt.Sprintf(`creator %s differs from %s creator %s`,
+				record.Creator, c.SameCreatorAs, records[step].Creator), step)
+		}
+	}
+
+	if c.DifferentCreatorFrom != `` {
+		if step, ok := latest[c.DifferentCreatorFrom]; ok && records[step].Creator == record.Creator {
+			violation(fmt.Sprintf(`creator %s is %s creator`, record.Creator, c.DifferentCreatorFrom), step)
+		}
+	}
+
+	return violations
+}
@@ -0,0 +1,75 @@
+package testing_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	idtestdata "github.com/s7techlab/cckit/identity/testdata"
+	"github.com/s7techlab/cckit/router"
+	testcc "github.com/s7techlab/cckit/testing"
+	expectcc "github.com/s7techlab/cckit/testing/expect"
+)
+
+// NewWorkflowCC document workflow without creator checks, workflow is verified by log assertions
+func NewWorkflowCC() *router.Chaincode {
+	r := router.New(`workflow`)
+
+	for _, step := range []string{`submit`, `approve`, `publish`} {
+		step := step
+		r.Invoke(step, func(c router.Context) (interface{}, error) {
+			return nil, c.Stub().PutState(`document`, []byte(step))
+		})
+	}
+
+	return router.NewChaincode(r)
+}
+
+var _ = Describe(`Workflow assertions`, func() {
+
+	author := idtestdata.Certificates[0].MustIdentity(`Org1MSP`)
+	reviewer := idtestdata.Certificates[1].MustIdentity(`Org2MSP`)
+
+	constraints := []testcc.Constraint{
+		{Func: `approve`, AfterFunc: `submit`, DifferentCreatorFrom: `submit`},
+		{Func: `publish`, AfterFunc: `approve`, SameCreatorAs: `submit`, Within: 2},
+	}
+
+	It("Allow compliant workflow", func() {
+		cc := testcc.NewMockStub(`workflow`, NewWorkflowCC())
+		expectcc.ResponseOk(cc.From(author).Invoke(`submit`))
+		expectcc.ResponseOk(cc.From(reviewer).Invoke(`approve`))
+		expectcc.ResponseError(cc.From(author).Invoke(`unknown`))
+		expectcc.ResponseOk(cc.From(author).Invoke(`publish`))
+
+		Expect(testcc.AssertWorkflow(GinkgoT(), cc, constraints)).To(BeTrue())
+	})
+
+	It("Disallow approval from submitter", func() {
+		cc := testcc.NewMockStub(`workflow`, NewWorkflowCC(), testcc.WithSequentialTxIDs(`tx-`))
+		expectcc.ResponseOk(cc.From(author).Invoke(`submit`))
+		expectcc.ResponseOk(cc.From(author).Invoke(`approve`))
+
+		violations := testcc.CheckWorkflow(cc.Transactions(), constraints)
+		Expect(violations).To(HaveLen(1))
+		Expect(errors.Is(violations[0], testcc.ErrWorkflowViolation)).To(BeTrue())
+		Expect(violations[0].TxIDs).To(Equal([]string{`tx-2`, `tx-1`}))
+		Expect(violations[0].Error()).To(ContainSubstring(`approve: creator Org1MSP:`))
+	})
+
+	It("Disallow steps out of order or too late", func() {
+		cc := testcc.NewMockStub(`workflow`, NewWorkflowCC(), testcc.WithSequentialTxIDs(`tx-`))
+		expectcc.ResponseOk(cc.From(reviewer).Invoke(`approve`))
+		expectcc.ResponseOk(cc.From(author).Invoke(`submit`))
+		expectcc.ResponseOk(cc.From(author).Invoke(`submit`))
+		expectcc.ResponseOk(cc.From(author).Invoke(`publish`))
+
+		violations := testcc.CheckWorkflow(cc.Transactions(), constraints)
+		Expect(violations).To(HaveLen(2))
+		Expect(violations[0].TxIDs).To(Equal([]string{`tx-1`}))
+		Expect(violations[0].Reason).To(Equal(`not preceded by submit`))
+		Expect(violations[1].TxIDs).To(Equal([]string{`tx-4`, `tx-1`}))
+		Expect(violations[1].Reason).To(Equal(`3 txs after approve, allowed within 2`))
+	})
+})